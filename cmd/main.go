@@ -4,17 +4,25 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql"
 	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/rest"
 	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/notification"
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/payment"
 	repo "github.com/sean-miningah/sil-backend-assessment/internal/adapters/repositories/postgres"
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/search"
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/storage"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
 	"github.com/sean-miningah/sil-backend-assessment/internal/services"
 	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
 	"github.com/sean-miningah/sil-backend-assessment/pkg/auth/middleware"
 	"github.com/sean-miningah/sil-backend-assessment/pkg/config"
 	"github.com/sean-miningah/sil-backend-assessment/pkg/database"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/requestid"
 	"github.com/sean-miningah/sil-backend-assessment/pkg/telemetry"
 )
 
@@ -57,27 +65,101 @@ func main() {
 
 	// Initialize repository
 	productRepo := repo.NewProductRepository(db)
+	categoryRepo := repo.NewCategoryRepository(db)
+	variantRepo := repo.NewProductVariantRepository(db)
+	imageRepo := repo.NewProductImageRepository(db)
+	priceRepo := repo.NewProductPriceRepository(db)
 	orderRepo := repo.NewOrderRepository(db)
 	customerRepo := repo.NewCustomerRepoisotory(db)
-	notificationRepo := notification.NewNotificationRepo(cfg.ATAPIKey, cfg.NotificationUsername, cfg.GmailAppAPIKey, cfg.ATAPIUrl)
+	apiKeyRepo := repo.NewAPIKeyRepository(db)
+	phoneVerificationRepo := repo.NewPhoneVerificationRepository(db)
+	addressRepo := repo.NewAddressRepository(db)
+	notificationLogRepo := repo.NewNotificationLogRepository(db)
+	privacyRepo := repo.NewPrivacyRepository(db)
+	auditRepo := repo.NewAuditRepository(db)
+	rateRepo := repo.NewExchangeRateRepository(db)
+	cartRepo := repo.NewCartRepository(db)
+	discountRepo := repo.NewDiscountRepository(db)
+	taxRepo := repo.NewTaxRepository(db)
+	shippingRepo := repo.NewShippingRepository(db)
+	paymentRepo := repo.NewPaymentRepository(db)
+	returnRepo := repo.NewReturnRepository(db)
+	productSearch := search.NewPostgresProductSearch(db)
+	blobStore, err := newBlobStore(cfg)
+	if err != nil {
+		log.Fatal("Failed to set up blob storage:", err)
+	}
+	paymentGateway, err := newPaymentGateway(cfg)
+	if err != nil {
+		log.Fatal("Failed to set up payments:", err)
+	}
+	notificationRepo := notification.NewLoggingNotifier(
+		notification.NewNotificationRepo(cfg.ATAPIKey, cfg.NotificationUsername, cfg.GmailAppAPIKey, cfg.ATAPIUrl),
+		notificationLogRepo,
+	)
 
 	// Initialize service
-	productService := services.NewProductService(productRepo, orderRepo)
-	orderService := services.NewOrderService(orderRepo, productRepo, notificationRepo)
-	customerService := services.NewCustomerService(customerRepo)
+	productService := services.NewProductService(productRepo, orderRepo, categoryRepo, auditRepo, rateRepo)
+	paymentService := services.NewPaymentService(paymentRepo, orderRepo, customerRepo, notificationRepo, auditRepo, paymentGateway, time.Duration(cfg.PaymentTimeoutSeconds)*time.Second)
+	orderService := services.NewOrderService(orderRepo, productRepo, priceRepo, notificationRepo, addressRepo, auditRepo, rateRepo, discountRepo, categoryRepo, taxRepo, shippingRepo, customerRepo, paymentService, cfg.TaxMode != "exclusive")
+	customerService := services.NewCustomerService(customerRepo, phoneVerificationRepo, notificationRepo, auditRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	addressService := services.NewAddressService(addressRepo)
+	auditService := services.NewAuditService(auditRepo)
+	searchService := services.NewProductSearchService(productSearch)
+	variantService := services.NewProductVariantService(productRepo, variantRepo, auditRepo)
+	priceService := services.NewProductPriceService(productRepo, priceRepo, auditRepo)
+	imageService := services.NewProductImageService(productRepo, imageRepo, blobStore, auditRepo, cfg.ImageMaxBytes)
+	categoryService := services.NewCategoryService(categoryRepo, productRepo, auditRepo)
+	trashService := services.NewTrashService(productRepo, categoryRepo, auditRepo, time.Duration(cfg.TrashRetentionDays)*24*time.Hour)
+	exchangeRateService := services.NewExchangeRateService(rateRepo, auditRepo)
+	cartService := services.NewCartService(cartRepo, orderService, rateRepo)
+	discountService := services.NewDiscountService(discountRepo, auditRepo)
+	taxService := services.NewTaxService(taxRepo, auditRepo)
+	shippingService := services.NewShippingService(shippingRepo, auditRepo)
+	returnService := services.NewReturnService(returnRepo, orderRepo, orderService, customerRepo, notificationRepo, auditRepo, blobStore, cfg.ImageMaxBytes)
+	privacyService := services.NewPrivacyService(customerRepo, addressRepo, orderRepo, phoneVerificationRepo, notificationLogRepo, privacyRepo, notificationRepo)
 
 	// Initialize handler
-	productHandler := rest.NewProductHandler(productService)
-	orderHandler := rest.NewOrderHandler(orderService)
+	productHandler := rest.NewProductHandler(productService, exchangeRateService)
+	orderHandler := rest.NewOrderHandler(orderService, exchangeRateService)
+	apiKeyHandler := rest.NewAPIKeyHandler(apiKeyService)
+	customerHandler := rest.NewCustomerHandler(customerService)
+	addressHandler := rest.NewAddressHandler(addressService)
+	privacyHandler := rest.NewPrivacyHandler(privacyService)
+	auditHandler := rest.NewAuditHandler(auditService)
+	searchHandler := rest.NewSearchHandler(searchService)
+	variantHandler := rest.NewVariantHandler(variantService)
+	imageHandler := rest.NewImageHandler(imageService, cfg.ImageMaxBytes)
+	priceHandler := rest.NewPriceHandler(priceService)
+	categoryHandler := rest.NewCategoryHandler(categoryService)
+	trashHandler := rest.NewTrashHandler(trashService)
+	exchangeRateHandler := rest.NewExchangeRateHandler(exchangeRateService)
+	cartHandler := rest.NewCartHandler(cartService, exchangeRateService)
+	discountHandler := rest.NewDiscountHandler(discountService)
+	taxHandler := rest.NewTaxHandler(taxService)
+	shippingHandler := rest.NewShippingHandler(shippingService, orderService, cartService)
+	paymentHandler := rest.NewPaymentHandler(paymentService)
+	returnHandler := rest.NewReturnHandler(returnService, cfg.ImageMaxBytes)
 
 	// Initialize GraphQL handler
-	graphqlHandler := graphql.NewHandler(productService, orderService)
+	graphqlHandler := graphql.NewHandler(productService, orderService, customerService, addressService, searchService, variantService, categoryService, cartService, returnService)
 
 	authConfig := auth.NewAuthConfig(cfg)
 	authHandler := rest.NewAuthHandler(authConfig, customerService)
 
+	// Apply scheduled price changes in the background
+	go services.RunPriceScheduler(context.Background(), priceService, time.Minute)
+	// Settle payments whose callback never came
+	go services.RunPaymentReconciler(context.Background(), paymentService, time.Minute)
+
 	// Gin router setup
 	router := gin.Default()
+	router.Use(requestid.Middleware())
+
+	if cfg.StorageDriver == "" || cfg.StorageDriver == "local" {
+		router.Static(localMediaPath, localMediaDir(cfg))
+	}
 
 	router.GET("/auth/login", authHandler.Login)
 	router.GET("/auth/google/callback", authHandler.GoogleCallback)
@@ -85,25 +167,154 @@ func main() {
 
 	// Register routes
 	api := router.Group("/api/v1")
-	api.Use(middleware.AuthMiddleware(authConfig.JWTSecret))
+	api.Use(middleware.AuthMiddleware(authConfig, apiKeyService))
 	{
-		// api.GET("/products", productHandler.List)
-		// api.GET("/products/:id", productHandler.Get)
-		api.POST("/products", productHandler.Create)
-		// api.PUT("/products/:id", productHandler.Update)
-		// api.DELETE("/products/:id", productHandler.Delete)
+		productsRead := middleware.RequireScope(domain.ScopeProductsRead)
+		productsWrite := middleware.RequireScope(domain.ScopeProductsWrite)
+		ordersRead := middleware.RequireScope(domain.ScopeOrdersRead)
+		ordersWrite := middleware.RequireScope(domain.ScopeOrdersWrite)
+
+		api.GET("/products", productsRead, productHandler.List)
+		api.GET("/products/export", productsRead, productHandler.Export)
+		api.GET("/products/search", productsRead, searchHandler.Search)
+		api.GET("/products/search/suggest", productsRead, searchHandler.Suggest)
+		api.GET("/products/:id", productsRead, productHandler.Get)
+		api.POST("/products", productsWrite, productHandler.Create)
+		api.POST("/products/import", productsWrite, productHandler.Import)
+		api.PUT("/products/:id", productsWrite, productHandler.Update)
+		api.DELETE("/products/:id", productsWrite, productHandler.Delete)
+		api.GET("/products/:id/variants", productsRead, variantHandler.List)
+		api.POST("/products/:id/variants", productsWrite, variantHandler.Create)
+		api.GET("/products/:id/variants/:variantId", productsRead, variantHandler.Get)
+		api.PUT("/products/:id/variants/:variantId", productsWrite, variantHandler.Update)
+		api.DELETE("/products/:id/variants/:variantId", productsWrite, variantHandler.Delete)
+		api.GET("/products/:id/images", productsRead, imageHandler.List)
+		api.POST("/products/:id/images", productsWrite, imageHandler.Upload)
+		api.PATCH("/products/:id/images/:imageId", productsWrite, imageHandler.Update)
+		api.DELETE("/products/:id/images/:imageId", productsWrite, imageHandler.Delete)
+		api.GET("/products/:id/price-history", productsRead, priceHandler.History)
+		api.DELETE("/categories/:id", productsWrite, categoryHandler.Delete)
 
-		api.GET("/categories/:categoryId/average-price", productHandler.GetAveragePriceByCategory)
+		api.GET("/categories/:categoryId/average-price", productsRead, productHandler.GetAveragePriceByCategory)
+		api.GET("/categories/:categoryId/attributes", productsRead, categoryHandler.ListAttributes)
+		api.PUT("/categories/:categoryId/attributes", productsWrite, categoryHandler.SetAttributes)
+
+		api.GET("/exchange-rates", productsRead, exchangeRateHandler.List)
+		api.GET("/exchange-rates/:currency/history", productsRead, exchangeRateHandler.History)
 
 		// Order Routes
-		// api.GET("/orders", orderHandler.List)
-		// api.GET("/orders/:id", orderHandler.Get)
-		api.POST("/orders", orderHandler.Create)
-		// api.PUT("/orders/:id", orderHandler.Update)
-		// api.DELETE("/order/:id", orderHandler.Delete)
+		api.GET("/orders", ordersRead, orderHandler.List)
+		api.GET("/orders/:id", ordersRead, orderHandler.Get)
+		api.POST("/orders", ordersWrite, orderHandler.Create)
+		api.GET("/orders/:id/payments", ordersRead, paymentHandler.List)
+		api.POST("/orders/:id/payments", ordersWrite, paymentHandler.Start)
+
+		// Customer profile routes
+		api.GET("/me", customerHandler.Me)
+		api.PATCH("/me", customerHandler.UpdateMe)
+		api.POST("/me/phone/verification", customerHandler.RequestPhoneVerification)
+		api.POST("/me/phone/verify", customerHandler.VerifyPhone)
+
+		// Address book routes
+		api.GET("/me/addresses", addressHandler.List)
+		api.POST("/me/addresses", addressHandler.Create)
+		api.GET("/me/addresses/:id", addressHandler.Get)
+		api.PUT("/me/addresses/:id", addressHandler.Update)
+		api.DELETE("/me/addresses/:id", addressHandler.Delete)
+		api.POST("/me/addresses/:id/default", addressHandler.SetDefault)
+
+		// Returns routes
+		api.GET("/me/returns", returnHandler.ListMine)
+		api.POST("/me/returns", returnHandler.Open)
+		api.GET("/me/returns/:id", returnHandler.GetMine)
+		api.POST("/me/returns/:id/photos", returnHandler.AddPhoto)
+		api.GET("/me/store-credit", returnHandler.StoreCredit)
+
+		// Data protection routes
+		api.GET("/me/export", privacyHandler.Export)
+		api.POST("/me/erasure-request", privacyHandler.RequestErasure)
+
+		api.GET("/customers", middleware.RequireAdmin(), customerHandler.List)
+		api.GET("/audit", middleware.RequireAdmin(), auditHandler.List)
+
+		admin := api.Group("/admin")
+		admin.Use(middleware.RequireAdmin())
+		{
+			admin.POST("/api-keys", apiKeyHandler.Create)
+			admin.GET("/api-keys", apiKeyHandler.List)
+			admin.DELETE("/api-keys/:id", apiKeyHandler.Revoke)
+
+			admin.GET("/erasure-requests", privacyHandler.ListErasureRequests)
+			admin.POST("/erasure-requests/:id/approve", privacyHandler.ApproveErasure)
+			admin.POST("/erasure-requests/:id/reject", privacyHandler.RejectErasure)
+
+			admin.GET("/trash", trashHandler.List)
+			admin.POST("/trash/products/:id/restore", trashHandler.RestoreProduct)
+			admin.POST("/trash/categories/:id/restore", trashHandler.RestoreCategory)
+			admin.POST("/trash/purge", trashHandler.Purge)
+
+			admin.POST("/products/:id/price-changes", priceHandler.Schedule)
+			admin.DELETE("/products/:id/price-changes/:changeId", priceHandler.Cancel)
+
+			admin.POST("/exchange-rates", exchangeRateHandler.Set)
+
+			admin.GET("/discounts", discountHandler.List)
+			admin.POST("/discounts", discountHandler.Create)
+			admin.GET("/discounts/:id", discountHandler.Get)
+			admin.PUT("/discounts/:id", discountHandler.Update)
+			admin.DELETE("/discounts/:id", discountHandler.Delete)
+
+			admin.GET("/tax-classes", taxHandler.ListClasses)
+			admin.POST("/tax-classes", taxHandler.CreateClass)
+			admin.GET("/tax-classes/:id", taxHandler.GetClass)
+			admin.PUT("/tax-classes/:id", taxHandler.UpdateClass)
+			admin.DELETE("/tax-classes/:id", taxHandler.DeleteClass)
+			admin.GET("/tax-classes/:id/rates", taxHandler.RateHistory)
+			admin.POST("/tax-classes/:id/rates", taxHandler.SetRate)
+			admin.PUT("/categories/:id/tax-class", taxHandler.AssignCategory)
+			admin.PUT("/products/:id/tax-class", taxHandler.AssignProduct)
+
+			admin.GET("/shipping-methods", shippingHandler.List)
+			admin.POST("/shipping-methods", shippingHandler.Create)
+			admin.GET("/shipping-methods/:id", shippingHandler.Get)
+			admin.PUT("/shipping-methods/:id", shippingHandler.Update)
+			admin.DELETE("/shipping-methods/:id", shippingHandler.Delete)
+
+			admin.POST("/payments/reconcile", paymentHandler.Reconcile)
+
+			admin.PUT("/orders/:id", orderHandler.Update)
+			admin.POST("/orders/:id/cancel", orderHandler.Cancel)
+			admin.POST("/orders/:id/refunds", orderHandler.Refund)
+			admin.POST("/orders/:id/archive", orderHandler.Archive)
+
+			admin.GET("/returns", returnHandler.List)
+			admin.GET("/returns/:id", returnHandler.Get)
+			admin.POST("/returns/:id/approve", returnHandler.Approve)
+			admin.POST("/returns/:id/reject", returnHandler.Reject)
+			admin.POST("/returns/:id/receive", returnHandler.Receive)
+			admin.POST("/returns/:id/resolve", returnHandler.Resolve)
+		}
+	}
+
+	// Carts work for anonymous shoppers too, who hold a cart token instead of credentials
+	cart := router.Group("/api/v1/cart")
+	cart.Use(middleware.OptionalAuth(authConfig, apiKeyService))
+	{
+		cart.GET("", cartHandler.Get)
+		cart.DELETE("", cartHandler.Clear)
+		cart.POST("/items", cartHandler.AddItem)
+		cart.PATCH("/items/:itemId", cartHandler.UpdateItem)
+		cart.DELETE("/items/:itemId", cartHandler.RemoveItem)
+		cart.POST("/checkout", cartHandler.Checkout)
 	}
 
-	router.POST("/graphql", graphqlHandler.GraphQL())
+	// Quotes are for anonymous shoppers too, like carts
+	router.POST("/api/v1/shipping/quotes", middleware.OptionalAuth(authConfig, apiKeyService), shippingHandler.Quote)
+
+	// Payment providers call back without credentials, the callback token vouches for them
+	router.POST("/api/v1/payments/callback", paymentHandler.Callback)
+
+	router.POST("/graphql", middleware.OptionalAuth(authConfig, apiKeyService), graphqlHandler.GraphQL())
 	if cfg.Environment == "development" {
 		router.GET("/playground", graphqlHandler.Playground())
 	}
@@ -113,3 +324,79 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// localMediaPath is where the server hands out files kept by the local blob store.
+const localMediaPath = "/media"
+
+func localMediaDir(cfg *config.Config) string {
+	if cfg.StorageLocalDir == "" {
+		return "uploads"
+	}
+	return cfg.StorageLocalDir
+}
+
+// newBlobStore picks the storage for uploaded files from STORAGE_DRIVER.
+func newBlobStore(cfg *config.Config) (ports.BlobStore, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		publicURL := cfg.StoragePublicURL
+		if publicURL == "" {
+			publicURL = localMediaPath
+		}
+		return storage.NewLocalBlobStore(localMediaDir(cfg), publicURL), nil
+	case "s3":
+		return storage.NewS3BlobStore(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.StoragePublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", cfg.StorageDriver)
+	}
+}
+
+// fakeCallbackDelay is how long the fake gateway takes to call back, about
+// as long as a customer takes to enter their M-Pesa PIN.
+const fakeCallbackDelay = 5 * time.Second
+
+// newPaymentGateway picks the payment provider from PAYMENT_GATEWAY. The fake
+// one pays whatever it is asked to, so it only runs in development.
+func newPaymentGateway(cfg *config.Config) (ports.PaymentGateway, error) {
+	switch cfg.PaymentGateway {
+	case "":
+		return nil, fmt.Errorf("PAYMENT_GATEWAY is not set")
+	case "fake":
+		if cfg.Environment != "development" {
+			return nil, fmt.Errorf("the fake payment gateway only runs with ENVIRONMENT=development")
+		}
+		if cfg.PaymentCallbackToken == "" {
+			return nil, fmt.Errorf("fake payment callback token is not set")
+		}
+		callbackURL := cfg.PaymentCallbackURL
+		if callbackURL == "" && strings.HasPrefix(cfg.Address, ":") {
+			callbackURL = "http://localhost" + cfg.Address + "/api/v1/payments/callback"
+		}
+		return payment.NewFakeGateway(callbackURL, cfg.PaymentCallbackToken, fakeCallbackDelay), nil
+	case "mpesa":
+		return payment.NewMpesaGateway(payment.MpesaConfig{
+			BaseURL:         cfg.MpesaBaseURL,
+			ConsumerKey:     cfg.MpesaConsumerKey,
+			ConsumerSecret:  cfg.MpesaConsumerSecret,
+			ShortCode:       cfg.MpesaShortCode,
+			Passkey:         cfg.MpesaPasskey,
+			TransactionType: cfg.MpesaTransactionType,
+			CallbackURL:     cfg.PaymentCallbackURL,
+			CallbackToken:   cfg.PaymentCallbackToken,
+
+			InitiatorName:      cfg.MpesaInitiatorName,
+			SecurityCredential: cfg.MpesaSecurityCredential,
+			B2CShortCode:       cfg.MpesaB2CShortCode,
+		})
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_GATEWAY %q", cfg.PaymentGateway)
+	}
+}
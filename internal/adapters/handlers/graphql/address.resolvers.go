@@ -0,0 +1,121 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"go.opentelemetry.io/otel"
+)
+
+// CreateAddress is the resolver for the createAddress field.
+func (r *mutationResolver) CreateAddress(ctx context.Context, input model.AddressInput) (*model.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.CreateAddress")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	address := fromAddressInput(input)
+	if err := r.addressService.CreateAddress(ctx, customerID, address); err != nil {
+		return nil, err
+	}
+
+	return toAddressModel(address), nil
+}
+
+// UpdateAddress is the resolver for the updateAddress field.
+func (r *mutationResolver) UpdateAddress(ctx context.Context, id string, input model.AddressInput) (*model.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.UpdateAddress")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	addressID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	address := fromAddressInput(input)
+	address.ID = addressID
+	if err := r.addressService.UpdateAddress(ctx, customerID, address); err != nil {
+		return nil, err
+	}
+
+	return toAddressModel(address), nil
+}
+
+// DeleteAddress is the resolver for the deleteAddress field.
+func (r *mutationResolver) DeleteAddress(ctx context.Context, id string) (bool, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.DeleteAddress")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	addressID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.addressService.DeleteAddress(ctx, customerID, addressID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SetDefaultAddress is the resolver for the setDefaultAddress field.
+func (r *mutationResolver) SetDefaultAddress(ctx context.Context, id string) (bool, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.SetDefaultAddress")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	addressID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.addressService.SetDefaultAddress(ctx, customerID, addressID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MyAddresses is the resolver for the myAddresses field.
+func (r *queryResolver) MyAddresses(ctx context.Context) ([]*model.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.MyAddresses")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := r.addressService.ListAddresses(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Address, 0, len(addresses))
+	for i := range addresses {
+		result = append(result, toAddressModel(&addresses[i]))
+	}
+
+	return result, nil
+}
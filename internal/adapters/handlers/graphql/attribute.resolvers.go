@@ -0,0 +1,65 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+// SetCategoryAttributes is the resolver for the setCategoryAttributes field.
+func (r *mutationResolver) SetCategoryAttributes(ctx context.Context, categoryID string, input []*model.CategoryAttributeInput) ([]*model.CategoryAttribute, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.SetCategoryAttributes")
+	defer span.End()
+
+	if err := requireScope(ctx, domain.ScopeProductsWrite); err != nil {
+		return nil, err
+	}
+	id, err := parseID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make([]domain.CategoryAttribute, len(input))
+	for i, attribute := range input {
+		attributes[i] = fromCategoryAttributeInput(attribute)
+	}
+
+	saved, err := r.categoryService.SetCategoryAttributes(ctx, id, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.CategoryAttribute, len(saved))
+	for i := range saved {
+		result[i] = toCategoryAttributeModel(&saved[i])
+	}
+	return result, nil
+}
+
+// CategoryAttributes is the resolver for the categoryAttributes field.
+func (r *queryResolver) CategoryAttributes(ctx context.Context, categoryID string) ([]*model.CategoryAttribute, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.CategoryAttributes")
+	defer span.End()
+
+	id, err := parseID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := r.categoryService.ListCategoryAttributes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.CategoryAttribute, len(attributes))
+	for i := range attributes {
+		result[i] = toCategoryAttributeModel(&attributes[i])
+	}
+	return result, nil
+}
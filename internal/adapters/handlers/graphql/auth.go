@@ -0,0 +1,58 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+)
+
+var (
+	errLoginRequired         = errors.New("login required")
+	errCustomerLoginRequired = errors.New("customer login required")
+	errAdminRequired         = errors.New("admin access required")
+)
+
+// currentCustomerID returns the customer making the request. API keys do not
+// act on behalf of a customer and are rejected.
+func currentCustomerID(ctx context.Context) (uint, error) {
+	actor, ok := auth.ActorFromContext(ctx)
+	if !ok || actor.Type != auth.ActorCustomer || actor.ID == 0 {
+		return 0, errCustomerLoginRequired
+	}
+	return actor.ID, nil
+}
+
+func isAdmin(ctx context.Context) bool {
+	actor, ok := auth.ActorFromContext(ctx)
+	return ok && actor.Admin
+}
+
+func requireAdmin(ctx context.Context) error {
+	if !isAdmin(ctx) {
+		return errAdminRequired
+	}
+	return nil
+}
+
+// requireScope lets through callers that may use the scope, as
+// middleware.RequireScope does on the REST API.
+func requireScope(ctx context.Context, scope string) error {
+	actor, ok := auth.ActorFromContext(ctx)
+	if !ok {
+		return errLoginRequired
+	}
+	if !actor.HasScope(scope) {
+		return fmt.Errorf("admin access or the %s scope required", scope)
+	}
+	return nil
+}
+
+// cartOwner is the signed-in customer, if any, and the anonymous cart token
+// passed in.
+func cartOwner(ctx context.Context, token *string) domain.CartOwner {
+	customerID, _ := currentCustomerID(ctx)
+	return domain.CartOwner{CustomerID: customerID, Token: stringValue(token)}
+}
@@ -0,0 +1,154 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"go.opentelemetry.io/otel"
+)
+
+// AddToCart is the resolver for the addToCart field.
+func (r *mutationResolver) AddToCart(ctx context.Context, input model.CartItemInput, cartToken *string) (*model.Cart, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.AddToCart")
+	defer span.End()
+
+	productID, err := parseID(input.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	variantID, err := parseOptionalID(input.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := domain.CartItem{ProductID: productID, VariantID: variantID}
+	if input.Quantity != nil {
+		item.Quantity = int(*input.Quantity)
+	}
+	cart, err := r.cartService.AddItem(ctx, cartOwner(ctx, cartToken), item)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCartModel(cart), nil
+}
+
+// UpdateCartItem is the resolver for the updateCartItem field.
+func (r *mutationResolver) UpdateCartItem(ctx context.Context, id string, quantity int32, cartToken *string) (*model.Cart, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.UpdateCartItem")
+	defer span.End()
+
+	itemID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.cartService.UpdateItem(ctx, cartOwner(ctx, cartToken), itemID, int(quantity))
+	if err != nil {
+		return nil, err
+	}
+
+	return toCartModel(cart), nil
+}
+
+// RemoveCartItem is the resolver for the removeCartItem field.
+func (r *mutationResolver) RemoveCartItem(ctx context.Context, id string, cartToken *string) (*model.Cart, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.RemoveCartItem")
+	defer span.End()
+
+	itemID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.cartService.RemoveItem(ctx, cartOwner(ctx, cartToken), itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCartModel(cart), nil
+}
+
+// ClearCart is the resolver for the clearCart field.
+func (r *mutationResolver) ClearCart(ctx context.Context, cartToken *string) (*model.Cart, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.ClearCart")
+	defer span.End()
+
+	cart, err := r.cartService.ClearCart(ctx, cartOwner(ctx, cartToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return toCartModel(cart), nil
+}
+
+// Checkout is the resolver for the checkout field.
+func (r *mutationResolver) Checkout(ctx context.Context, input *model.CheckoutInput, cartToken *string) (*model.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.Checkout")
+	defer span.End()
+
+	if _, err := currentCustomerID(ctx); err != nil {
+		return nil, err
+	}
+
+	checkout, err := fromCheckoutInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// The order confirmation goes to the customer's email
+	actor, _ := auth.ActorFromContext(ctx)
+	ctx = context.WithValue(ctx, "email", actor.Email)
+
+	order, err := r.cartService.Checkout(ctx, cartOwner(ctx, cartToken), checkout)
+	if err != nil {
+		return nil, err
+	}
+
+	return toOrderModel(order), nil
+}
+
+// Cart is the resolver for the cart field.
+func (r *queryResolver) Cart(ctx context.Context, cartToken *string) (*model.Cart, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.Cart")
+	defer span.End()
+
+	cart, err := r.cartService.GetCart(ctx, cartOwner(ctx, cartToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return toCartModel(cart), nil
+}
+
+// ShippingQuotes is the resolver for the shippingQuotes field.
+func (r *queryResolver) ShippingQuotes(ctx context.Context, input *model.CheckoutInput, county *string, cartToken *string) ([]*model.ShippingQuote, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.ShippingQuotes")
+	defer span.End()
+
+	checkout, err := fromCheckoutInput(input)
+	if err != nil {
+		return nil, err
+	}
+	var address *domain.ShippingAddress
+	if county := stringValue(county); county != "" {
+		address = &domain.ShippingAddress{County: county}
+	}
+
+	quotes, err := r.cartService.QuoteShipping(ctx, cartOwner(ctx, cartToken), checkout, address)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.ShippingQuote, 0, len(quotes))
+	for _, quote := range quotes {
+		result = append(result, toShippingQuoteModel(quote))
+	}
+	return result, nil
+}
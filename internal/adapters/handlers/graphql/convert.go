@@ -0,0 +1,484 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func parseID(id string) (uint, error) {
+	value, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(value), nil
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func toCustomerModel(customer *domain.Customer) *model.Customer {
+	return &model.Customer{
+		ID:            formatID(customer.ID),
+		Name:          customer.Name,
+		Email:         customer.Email,
+		VerifiedEmail: customer.VerifiedEmail,
+		Phone:         optionalString(customer.Phone),
+		PhoneVerified: customer.PhoneVerified,
+		Picture:       optionalString(customer.Picture),
+		Preferences: &model.CustomerPreferences{
+			EmailNotifications: customer.Preferences.EmailNotifications,
+			SmsNotifications:   customer.Preferences.SmsNotifications,
+			MarketingOptIn:     customer.Preferences.MarketingOptIn,
+		},
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func optionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return optionalString(formatTime(*t))
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func toAddressModel(address *domain.Address) *model.Address {
+	return &model.Address{
+		ID:            formatID(address.ID),
+		Label:         optionalString(address.Label),
+		RecipientName: address.RecipientName,
+		Phone:         optionalString(address.Phone),
+		Line1:         address.Line1,
+		Line2:         optionalString(address.Line2),
+		City:          address.City,
+		County:        optionalString(address.County),
+		PostalCode:    optionalString(address.PostalCode),
+		Country:       address.Country,
+		IsDefault:     address.IsDefault,
+		CreatedAt:     formatTime(address.CreatedAt),
+		UpdatedAt:     formatTime(address.UpdatedAt),
+	}
+}
+
+func fromAddressInput(input model.AddressInput) *domain.Address {
+	address := &domain.Address{
+		Label:         stringValue(input.Label),
+		RecipientName: input.RecipientName,
+		Phone:         stringValue(input.Phone),
+		Line1:         input.Line1,
+		Line2:         stringValue(input.Line2),
+		City:          input.City,
+		County:        stringValue(input.County),
+		PostalCode:    stringValue(input.PostalCode),
+		Country:       stringValue(input.Country),
+	}
+	if input.IsDefault != nil {
+		address.IsDefault = *input.IsDefault
+	}
+	return address
+}
+
+func toShippingAddressModel(address domain.ShippingAddress) *model.ShippingAddress {
+	return &model.ShippingAddress{
+		RecipientName: address.RecipientName,
+		Phone:         optionalString(address.Phone),
+		Line1:         address.Line1,
+		Line2:         optionalString(address.Line2),
+		City:          address.City,
+		County:        optionalString(address.County),
+		PostalCode:    optionalString(address.PostalCode),
+		Country:       address.Country,
+	}
+}
+
+func toOrderModel(order *domain.Order) *model.Order {
+	items := make([]*model.OrderItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, &model.OrderItem{
+			ID:        formatID(item.ID),
+			ProductID: formatID(item.ProductID),
+			Quantity:  int32(item.Quantity),
+			Price:     item.Price,
+			Discount:  item.Discount,
+			Tax:       item.Tax,
+			TaxRate:   string(item.TaxRate),
+
+			RefundedQuantity: int32(item.RefundedQuantity),
+		})
+	}
+	adjustments := make([]*model.OrderAdjustment, 0, len(order.Adjustments))
+	for _, adjustment := range order.Adjustments {
+		var code *string
+		if adjustment.Code != "" {
+			code = &adjustment.Code
+		}
+		adjustments = append(adjustments, &model.OrderAdjustment{
+			Type:        adjustment.Type,
+			Code:        code,
+			Description: adjustment.Description,
+			Amount:      adjustment.Amount,
+		})
+	}
+
+	return &model.Order{
+		ID:                 formatID(order.ID),
+		CustomerID:         formatID(order.CustomerID),
+		Items:              items,
+		Subtotal:           order.Subtotal,
+		DiscountTotal:      order.DiscountTotal,
+		Adjustments:        adjustments,
+		TaxTotal:           order.TaxTotal,
+		PricesIncludeTax:   order.PricesIncludeTax,
+		ShippingMethodName: optionalString(order.ShippingMethodName),
+		ShippingCost:       order.ShippingCost,
+		TotalPrice:         order.TotalPrice,
+		Status:             string(order.Status),
+		CancelledAt:        optionalTime(order.CancelledAt),
+		PaymentStatus:      string(order.PaymentStatus),
+		PaidAt:             optionalTime(order.PaidAt),
+		RefundedTotal:      order.RefundedTotal,
+		Currency:           order.Currency,
+		ExchangeRate:       string(order.ExchangeRate),
+		ShippingAddress:    toShippingAddressModel(order.ShippingAddress),
+		CreatedAt:          formatTime(order.CreatedAt),
+	}
+}
+
+func fromCheckoutInput(input *model.CheckoutInput) (domain.CartCheckout, error) {
+	var checkout domain.CartCheckout
+	if input == nil {
+		return checkout, nil
+	}
+	addressID, err := parseOptionalID(input.AddressID)
+	if err != nil {
+		return checkout, err
+	}
+	methodID, err := parseOptionalID(input.ShippingMethodID)
+	if err != nil {
+		return checkout, err
+	}
+	checkout.AddressID = addressID
+	checkout.Currency = stringValue(input.Currency)
+	checkout.DiscountCodes = input.DiscountCodes
+	checkout.ShippingMethodID = methodID
+	return checkout, nil
+}
+
+func toShippingQuoteModel(quote domain.ShippingQuote) *model.ShippingQuote {
+	return &model.ShippingQuote{
+		MethodID:    formatID(quote.MethodID),
+		Name:        quote.Name,
+		Description: optionalString(quote.Description),
+		Type:        string(quote.Type),
+		Cost:        quote.Cost,
+		Free:        quote.Free,
+		Zone:        optionalString(quote.Zone),
+	}
+}
+
+var productSorts = map[model.ProductSort]domain.ProductSort{
+	model.ProductSortPriceAsc:    {Field: domain.ProductSortPrice},
+	model.ProductSortPriceDesc:   {Field: domain.ProductSortPrice, Descending: true},
+	model.ProductSortNameAsc:     {Field: domain.ProductSortName},
+	model.ProductSortNameDesc:    {Field: domain.ProductSortName, Descending: true},
+	model.ProductSortCreatedAsc:  {Field: domain.ProductSortCreated},
+	model.ProductSortCreatedDesc: {Field: domain.ProductSortCreated, Descending: true},
+}
+
+func toProductModel(product *domain.Product) *model.Product {
+	category := &model.Category{ID: formatID(product.CategoryID)}
+	if product.Category.ID == product.CategoryID {
+		category = toCategoryModel(&product.Category)
+	}
+	attributes := map[string]any(product.Attributes)
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	images := make([]*model.ProductImage, len(product.Images))
+	for i := range product.Images {
+		images[i] = toProductImageModel(&product.Images[i])
+	}
+
+	return &model.Product{
+		ID:          formatID(product.ID),
+		Sku:         optionalString(product.SKU),
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Category:    category,
+		Attributes:  attributes,
+		Images:      images,
+		CreatedAt:   formatTime(product.CreatedAt),
+		UpdatedAt:   formatTime(product.UpdatedAt),
+	}
+}
+
+func toCategoryModel(category *domain.Category) *model.Category {
+	return &model.Category{
+		ID:          formatID(category.ID),
+		Name:        category.Name,
+		Description: category.Description,
+		CreatedAt:   formatTime(category.CreatedAt),
+		UpdatedAt:   formatTime(category.UpdatedAt),
+	}
+}
+
+func toProductImageModel(image *domain.ProductImage) *model.ProductImage {
+	return &model.ProductImage{
+		ID:           formatID(image.ID),
+		URL:          image.URL,
+		ThumbnailURL: image.ThumbnailURL,
+		ContentType:  image.ContentType,
+		Size:         int32(image.Size),
+		Width:        int32(image.Width),
+		Height:       int32(image.Height),
+		Position:     int32(image.Position),
+		IsPrimary:    image.IsPrimary,
+	}
+}
+
+func toCategoryAttributeModel(attribute *domain.CategoryAttribute) *model.CategoryAttribute {
+	allowed := []string(attribute.AllowedValues)
+	if allowed == nil {
+		allowed = []string{}
+	}
+	return &model.CategoryAttribute{
+		ID:            formatID(attribute.ID),
+		CategoryID:    formatID(attribute.CategoryID),
+		Name:          attribute.Name,
+		Type:          model.AttributeType(strings.ToUpper(string(attribute.Type))),
+		Required:      attribute.Required,
+		AllowedValues: allowed,
+	}
+}
+
+func fromCategoryAttributeInput(input *model.CategoryAttributeInput) domain.CategoryAttribute {
+	return domain.CategoryAttribute{
+		Name:          input.Name,
+		Type:          domain.AttributeType(strings.ToLower(string(input.Type))),
+		Required:      input.Required != nil && *input.Required,
+		AllowedValues: input.AllowedValues,
+	}
+}
+
+// toAttributes reads a Map argument as attribute values. Numbers in variables
+// arrive as json.Number and literals as int64, a JSON round trip turns both
+// into the float64 the rest of the code expects.
+func toAttributes(values map[string]any) (domain.Attributes, error) {
+	if values == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	var attributes domain.Attributes
+	if err := json.Unmarshal(raw, &attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+var attributeOperators = map[model.AttributeOperator]domain.AttributeOperator{
+	model.AttributeOperatorEq:  domain.AttributeEq,
+	model.AttributeOperatorNe:  domain.AttributeNe,
+	model.AttributeOperatorGt:  domain.AttributeGt,
+	model.AttributeOperatorGte: domain.AttributeGte,
+	model.AttributeOperatorLt:  domain.AttributeLt,
+	model.AttributeOperatorLte: domain.AttributeLte,
+}
+
+func fromAttributeFilterInputs(inputs []*model.AttributeFilterInput) []domain.AttributeFilter {
+	filters := make([]domain.AttributeFilter, 0, len(inputs))
+	for _, input := range inputs {
+		filters = append(filters, domain.AttributeFilter{
+			Name:     input.Name,
+			Operator: attributeOperators[input.Op],
+			Value:    input.Value,
+		})
+	}
+	return filters
+}
+
+func toVariantModel(variant *domain.ProductVariant) *model.ProductVariant {
+	options := make([]*model.VariantOption, len(variant.Options))
+	for i, option := range variant.Options {
+		options[i] = &model.VariantOption{Name: option.Name, Value: option.Value}
+	}
+
+	return &model.ProductVariant{
+		ID:        formatID(variant.ID),
+		ProductID: formatID(variant.ProductID),
+		Sku:       optionalString(variant.SKU),
+		Price:     variant.Price,
+		Stock:     int32(variant.Stock),
+		Options:   options,
+		CreatedAt: formatTime(variant.CreatedAt),
+		UpdatedAt: formatTime(variant.UpdatedAt),
+	}
+}
+
+func toProductOptionModel(option *domain.ProductOption) *model.ProductOption {
+	values := make([]*model.ProductOptionValue, len(option.Values))
+	for i, value := range option.Values {
+		values[i] = &model.ProductOptionValue{ID: formatID(value.ID), Value: value.Value}
+	}
+	return &model.ProductOption{ID: formatID(option.ID), Name: option.Name, Values: values}
+}
+
+func fromVariantInput(input model.ProductVariantInput) *domain.ProductVariant {
+	options := make([]domain.VariantOption, len(input.Options))
+	for i, option := range input.Options {
+		options[i] = domain.VariantOption{Name: option.Name, Value: option.Value}
+	}
+
+	return &domain.ProductVariant{
+		SKU:     stringValue(input.Sku),
+		Price:   input.Price,
+		Stock:   int(input.Stock),
+		Options: options,
+	}
+}
+
+func parseOptionalID(id *string) (*uint, error) {
+	if id == nil {
+		return nil, nil
+	}
+	value, err := parseID(*id)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func formatOptionalID(id *uint) *string {
+	if id == nil {
+		return nil
+	}
+	value := formatID(*id)
+	return &value
+}
+
+func toCartModel(cart *domain.CartView) *model.Cart {
+	items := make([]*model.CartLine, 0, len(cart.Items))
+	for _, line := range cart.Items {
+		items = append(items, &model.CartLine{
+			ID:        formatID(line.ID),
+			ProductID: formatID(line.ProductID),
+			VariantID: formatOptionalID(line.VariantID),
+			Name:      line.Name,
+			Quantity:  int32(line.Quantity),
+			UnitPrice: line.UnitPrice,
+			Price:     line.Price,
+			Available: line.Available,
+			Problem:   optionalString(line.Problem),
+		})
+	}
+
+	return &model.Cart{
+		Token:         optionalString(cart.Token),
+		Items:         items,
+		Quantity:      int32(cart.Quantity),
+		Subtotal:      cart.Subtotal,
+		CheckoutReady: cart.CheckoutReady,
+	}
+}
+
+func toReturnModel(ret *domain.OrderReturn) *model.Return {
+	lines := make([]*model.ReturnLine, 0, len(ret.Lines))
+	for _, line := range ret.Lines {
+		lines = append(lines, &model.ReturnLine{
+			ID:          formatID(line.ID),
+			OrderItemID: formatID(line.OrderItemID),
+			Quantity:    int32(line.Quantity),
+			Reason:      line.Reason,
+		})
+	}
+	photos := make([]*model.ReturnPhoto, 0, len(ret.Photos))
+	for _, photo := range ret.Photos {
+		photos = append(photos, &model.ReturnPhoto{
+			ID:          formatID(photo.ID),
+			URL:         photo.URL,
+			ContentType: photo.ContentType,
+			Size:        int32(photo.Size),
+		})
+	}
+	var amount *domain.Money
+	if ret.RefundID != nil {
+		amount = &ret.Amount
+	}
+
+	return &model.Return{
+		ID:         formatID(ret.ID),
+		OrderID:    formatID(ret.OrderID),
+		CustomerID: formatID(ret.CustomerID),
+		Status:     string(ret.Status),
+		Lines:      lines,
+		Photos:     photos,
+		Comment:    optionalString(ret.Comment),
+		Note:       optionalString(ret.Note),
+		Restocked:  ret.Restocked,
+		Resolution: optionalString(string(ret.Resolution)),
+		Amount:     amount,
+		CreatedAt:  formatTime(ret.CreatedAt),
+		ApprovedAt: optionalTime(ret.ApprovedAt),
+		RejectedAt: optionalTime(ret.RejectedAt),
+		ReceivedAt: optionalTime(ret.ReceivedAt),
+		ResolvedAt: optionalTime(ret.ResolvedAt),
+	}
+}
+
+func toReturnModels(returns []domain.OrderReturn) []*model.Return {
+	models := make([]*model.Return, 0, len(returns))
+	for i := range returns {
+		models = append(models, toReturnModel(&returns[i]))
+	}
+	return models
+}
+
+func toStoreCreditAccountModel(account *domain.StoreCreditAccount) *model.StoreCreditAccount {
+	balance := make([]*domain.Money, 0, len(account.Balance))
+	for i := range account.Balance {
+		balance = append(balance, &account.Balance[i])
+	}
+	entries := make([]*model.StoreCredit, 0, len(account.Entries))
+	for _, entry := range account.Entries {
+		entries = append(entries, &model.StoreCredit{
+			ID:        formatID(entry.ID),
+			Amount:    entry.Amount,
+			OrderID:   formatID(entry.OrderID),
+			Reason:    entry.Reason,
+			CreatedAt: formatTime(entry.CreatedAt),
+		})
+	}
+
+	return &model.StoreCreditAccount{
+		Balance: balance,
+		Entries: entries,
+	}
+}
@@ -0,0 +1,30 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"go.opentelemetry.io/otel"
+)
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*model.Customer, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.Me")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := r.customerService.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCustomerModel(customer), nil
+}
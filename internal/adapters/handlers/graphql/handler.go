@@ -12,9 +12,9 @@ type Handler struct {
 	resolver *Resolver
 }
 
-func NewHandler(ps ports.ProductService, os ports.OrderService) *Handler {
+func NewHandler(ps ports.ProductService, os ports.OrderService, cs ports.CustomerService, as ports.AddressService, ss ports.ProductSearchService, vs ports.ProductVariantService, cg ports.CategoryService, ct ports.CartService, rs ports.ReturnService) *Handler {
 	return &Handler{
-		resolver: NewResolver(ps, os),
+		resolver: NewResolver(ps, os, cs, as, ss, vs, cg, ct, rs),
 	}
 }
 
@@ -2,43 +2,546 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+type Address struct {
+	ID            string  `json:"id"`
+	Label         *string `json:"label,omitempty"`
+	RecipientName string  `json:"recipientName"`
+	Phone         *string `json:"phone,omitempty"`
+	Line1         string  `json:"line1"`
+	Line2         *string `json:"line2,omitempty"`
+	City          string  `json:"city"`
+	County        *string `json:"county,omitempty"`
+	PostalCode    *string `json:"postalCode,omitempty"`
+	Country       string  `json:"country"`
+	IsDefault     bool    `json:"isDefault"`
+	CreatedAt     string  `json:"createdAt"`
+	UpdatedAt     string  `json:"updatedAt"`
+}
+
+type AddressInput struct {
+	Label         *string `json:"label,omitempty"`
+	RecipientName string  `json:"recipientName"`
+	Phone         *string `json:"phone,omitempty"`
+	Line1         string  `json:"line1"`
+	Line2         *string `json:"line2,omitempty"`
+	City          string  `json:"city"`
+	County        *string `json:"county,omitempty"`
+	PostalCode    *string `json:"postalCode,omitempty"`
+	Country       *string `json:"country,omitempty"`
+	IsDefault     *bool   `json:"isDefault,omitempty"`
+}
+
+type AttributeFilterInput struct {
+	Name  string            `json:"name"`
+	Op    AttributeOperator `json:"op"`
+	Value string            `json:"value"`
+}
+
+type Cart struct {
+	// Token of an anonymous cart, pass it back as cartToken
+	Token         *string       `json:"token,omitempty"`
+	Items         []*CartLine   `json:"items"`
+	Quantity      int32         `json:"quantity"`
+	Subtotal      *domain.Money `json:"subtotal,omitempty"`
+	CheckoutReady bool          `json:"checkoutReady"`
+}
+
+type CartItemInput struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  *int32  `json:"quantity,omitempty"`
+}
+
+type CartLine struct {
+	ID        string        `json:"id"`
+	ProductID string        `json:"productId"`
+	VariantID *string       `json:"variantId,omitempty"`
+	Name      string        `json:"name"`
+	Quantity  int32         `json:"quantity"`
+	UnitPrice *domain.Money `json:"unitPrice,omitempty"`
+	Price     *domain.Money `json:"price,omitempty"`
+	// False when the line can't be ordered any more, problem says why
+	Available bool    `json:"available"`
+	Problem   *string `json:"problem,omitempty"`
+}
+
 type Category struct {
-	ID        string      `json:"id"`
-	Name      string      `json:"name"`
-	Parent    *Category   `json:"parent,omitempty"`
-	Children  []*Category `json:"children,omitempty"`
-	Products  []*Product  `json:"products,omitempty"`
-	CreatedAt string      `json:"createdAt"`
-	UpdatedAt string      `json:"updatedAt"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parent      *Category   `json:"parent,omitempty"`
+	Children    []*Category `json:"children,omitempty"`
+	Products    []*Product  `json:"products,omitempty"`
+	CreatedAt   string      `json:"createdAt"`
+	UpdatedAt   string      `json:"updatedAt"`
+}
+
+type CategoryAttribute struct {
+	ID            string        `json:"id"`
+	CategoryID    string        `json:"categoryId"`
+	Name          string        `json:"name"`
+	Type          AttributeType `json:"type"`
+	Required      bool          `json:"required"`
+	AllowedValues []string      `json:"allowedValues"`
+}
+
+type CategoryAttributeInput struct {
+	Name          string        `json:"name"`
+	Type          AttributeType `json:"type"`
+	Required      *bool         `json:"required,omitempty"`
+	AllowedValues []string      `json:"allowedValues,omitempty"`
+}
+
+type CheckoutInput struct {
+	AddressID *string `json:"addressId,omitempty"`
+	Currency  *string `json:"currency,omitempty"`
+	// Applied in the order given, after any automatic discounts
+	DiscountCodes []string `json:"discountCodes,omitempty"`
+	// Required once any shipping method is active, see shippingQuotes
+	ShippingMethodID *string `json:"shippingMethodId,omitempty"`
 }
 
 type CreateProductInput struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	CategoryID  string  `json:"categoryId"`
+	Sku         *string        `json:"sku,omitempty"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Price       domain.Money   `json:"price"`
+	CategoryID  string         `json:"categoryId"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
+}
+
+type Customer struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	Email         string               `json:"email"`
+	VerifiedEmail bool                 `json:"verifiedEmail"`
+	Phone         *string              `json:"phone,omitempty"`
+	PhoneVerified bool                 `json:"phoneVerified"`
+	Picture       *string              `json:"picture,omitempty"`
+	Preferences   *CustomerPreferences `json:"preferences"`
+}
+
+type CustomerPreferences struct {
+	EmailNotifications bool `json:"emailNotifications"`
+	SmsNotifications   bool `json:"smsNotifications"`
+	MarketingOptIn     bool `json:"marketingOptIn"`
 }
 
 type Mutation struct {
 }
 
+type OpenReturnInput struct {
+	OrderID string             `json:"orderId"`
+	Lines   []*ReturnLineInput `json:"lines"`
+	Comment *string            `json:"comment,omitempty"`
+}
+
+type Order struct {
+	ID         string       `json:"id"`
+	CustomerID string       `json:"customerId"`
+	Items      []*OrderItem `json:"items"`
+	// The items before discounts
+	Subtotal      domain.Money       `json:"subtotal"`
+	DiscountTotal domain.Money       `json:"discountTotal"`
+	Adjustments   []*OrderAdjustment `json:"adjustments"`
+	TaxTotal      domain.Money       `json:"taxTotal"`
+	// Whether taxTotal is already part of the prices, otherwise it is added to totalPrice
+	PricesIncludeTax   bool    `json:"pricesIncludeTax"`
+	ShippingMethodName *string `json:"shippingMethodName,omitempty"`
+	// Part of totalPrice
+	ShippingCost domain.Money `json:"shippingCost"`
+	TotalPrice   domain.Money `json:"totalPrice"`
+	// placed, or cancelled
+	Status      string  `json:"status"`
+	CancelledAt *string `json:"cancelledAt,omitempty"`
+	// unpaid, paid once a payment for the order succeeded, then partially_refunded or refunded
+	PaymentStatus string  `json:"paymentStatus"`
+	PaidAt        *string `json:"paidAt,omitempty"`
+	// What has been refunded of totalPrice
+	RefundedTotal domain.Money `json:"refundedTotal"`
+	// Currency the order was charged in
+	Currency string `json:"currency"`
+	// What one unit of currency was worth in KES when the order was placed
+	ExchangeRate    string           `json:"exchangeRate"`
+	ShippingAddress *ShippingAddress `json:"shippingAddress"`
+	CreatedAt       string           `json:"createdAt"`
+}
+
+// An amount taken off, or added to, the order's subtotal
+type OrderAdjustment struct {
+	Type        string  `json:"type"`
+	Code        *string `json:"code,omitempty"`
+	Description string  `json:"description"`
+	// Negative for discounts
+	Amount domain.Money `json:"amount"`
+}
+
+type OrderItem struct {
+	ID        string       `json:"id"`
+	ProductID string       `json:"productId"`
+	Quantity  int32        `json:"quantity"`
+	Price     domain.Money `json:"price"`
+	// The line's share of the order's discounts, not taken off price
+	Discount domain.Money `json:"discount"`
+	// Tax on price less discount at taxRate percent
+	Tax     domain.Money `json:"tax"`
+	TaxRate string       `json:"taxRate"`
+	// How many of the units have been refunded
+	RefundedQuantity int32 `json:"refundedQuantity"`
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
 type Product struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Category    *Category `json:"category"`
-	CreatedAt   string    `json:"createdAt"`
-	UpdatedAt   string    `json:"updatedAt"`
+	ID          string          `json:"id"`
+	Sku         *string         `json:"sku,omitempty"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Price       domain.Money    `json:"price"`
+	Category    *Category       `json:"category"`
+	Attributes  map[string]any  `json:"attributes"`
+	Images      []*ProductImage `json:"images"`
+	CreatedAt   string          `json:"createdAt"`
+	UpdatedAt   string          `json:"updatedAt"`
+}
+
+type ProductConnection struct {
+	Edges    []*ProductEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type ProductEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Product `json:"node"`
+}
+
+type ProductFilter struct {
+	CategoryID         *string                 `json:"categoryId,omitempty"`
+	IncludeDescendants *bool                   `json:"includeDescendants,omitempty"`
+	MinPrice           *domain.Money           `json:"minPrice,omitempty"`
+	MaxPrice           *domain.Money           `json:"maxPrice,omitempty"`
+	Name               *string                 `json:"name,omitempty"`
+	Attributes         []*AttributeFilterInput `json:"attributes,omitempty"`
+}
+
+type ProductImage struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	ContentType  string `json:"contentType"`
+	Size         int32  `json:"size"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+	Position     int32  `json:"position"`
+	IsPrimary    bool   `json:"isPrimary"`
+}
+
+type ProductOption struct {
+	ID     string                `json:"id"`
+	Name   string                `json:"name"`
+	Values []*ProductOptionValue `json:"values"`
+}
+
+type ProductOptionValue struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+type ProductSearchHit struct {
+	Product *Product `json:"product"`
+	Rank    float64  `json:"rank"`
+}
+
+type ProductSearchResult struct {
+	Hits     []*ProductSearchHit `json:"hits"`
+	Page     int32               `json:"page"`
+	PageSize int32               `json:"pageSize"`
+	Total    int32               `json:"total"`
+}
+
+type ProductSuggestion struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ProductVariant struct {
+	ID        string           `json:"id"`
+	ProductID string           `json:"productId"`
+	Sku       *string          `json:"sku,omitempty"`
+	Price     *domain.Money    `json:"price,omitempty"`
+	Stock     int32            `json:"stock"`
+	Options   []*VariantOption `json:"options"`
+	CreatedAt string           `json:"createdAt"`
+	UpdatedAt string           `json:"updatedAt"`
+}
+
+type ProductVariantInput struct {
+	Sku     *string               `json:"sku,omitempty"`
+	Price   *domain.Money         `json:"price,omitempty"`
+	Stock   int32                 `json:"stock"`
+	Options []*VariantOptionInput `json:"options"`
+}
+
+type ProductVariants struct {
+	Options  []*ProductOption  `json:"options"`
+	Variants []*ProductVariant `json:"variants"`
 }
 
 type Query struct {
 }
 
+type Return struct {
+	ID         string `json:"id"`
+	OrderID    string `json:"orderId"`
+	CustomerID string `json:"customerId"`
+	// requested, approved or rejected, then received and finally refunded or credited
+	Status string        `json:"status"`
+	Lines  []*ReturnLine `json:"lines"`
+	// Photos are uploaded with POST /api/v1/me/returns/{id}/photos
+	Photos  []*ReturnPhoto `json:"photos"`
+	Comment *string        `json:"comment,omitempty"`
+	// What staff told the customer on approving or rejecting the return
+	Note *string `json:"note,omitempty"`
+	// Whether the units went back into stock when received
+	Restocked bool `json:"restocked"`
+	// refund or store_credit, once resolved
+	Resolution *string `json:"resolution,omitempty"`
+	// What the customer got back, once resolved
+	Amount     *domain.Money `json:"amount,omitempty"`
+	CreatedAt  string        `json:"createdAt"`
+	ApprovedAt *string       `json:"approvedAt,omitempty"`
+	RejectedAt *string       `json:"rejectedAt,omitempty"`
+	ReceivedAt *string       `json:"receivedAt,omitempty"`
+	ResolvedAt *string       `json:"resolvedAt,omitempty"`
+}
+
+type ReturnLine struct {
+	ID          string `json:"id"`
+	OrderItemID string `json:"orderItemId"`
+	Quantity    int32  `json:"quantity"`
+	Reason      string `json:"reason"`
+}
+
+type ReturnLineInput struct {
+	OrderItemID string `json:"orderItemId"`
+	Quantity    int32  `json:"quantity"`
+	Reason      string `json:"reason"`
+}
+
+type ReturnPhoto struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	ContentType string `json:"contentType"`
+	Size        int32  `json:"size"`
+}
+
+type ShippingAddress struct {
+	RecipientName string  `json:"recipientName"`
+	Phone         *string `json:"phone,omitempty"`
+	Line1         string  `json:"line1"`
+	Line2         *string `json:"line2,omitempty"`
+	City          string  `json:"city"`
+	County        *string `json:"county,omitempty"`
+	PostalCode    *string `json:"postalCode,omitempty"`
+	Country       string  `json:"country"`
+}
+
+// What a shipping method charges to deliver the cart
+type ShippingQuote struct {
+	MethodID    string  `json:"methodId"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	// flat_rate, weight_based or zone_based
+	Type string `json:"type"`
+	// In the currency the order would be charged in
+	Cost domain.Money `json:"cost"`
+	// Set when the cart is worth enough for the method to be free
+	Free bool    `json:"free"`
+	Zone *string `json:"zone,omitempty"`
+}
+
+type StoreCredit struct {
+	ID        string       `json:"id"`
+	Amount    domain.Money `json:"amount"`
+	OrderID   string       `json:"orderId"`
+	Reason    string       `json:"reason"`
+	CreatedAt string       `json:"createdAt"`
+}
+
+type StoreCreditAccount struct {
+	// One amount per currency credited
+	Balance []*domain.Money `json:"balance"`
+	Entries []*StoreCredit  `json:"entries"`
+}
+
 type UpdateProductInput struct {
-	ID          string   `json:"id"`
-	Name        *string  `json:"name,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Price       *float64 `json:"price,omitempty"`
-	CategoryID  *string  `json:"categoryId,omitempty"`
+	ID          string         `json:"id"`
+	Sku         *string        `json:"sku,omitempty"`
+	Name        *string        `json:"name,omitempty"`
+	Description *string        `json:"description,omitempty"`
+	Price       *domain.Money  `json:"price,omitempty"`
+	CategoryID  *string        `json:"categoryId,omitempty"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
+}
+
+type VariantOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type VariantOptionInput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type AttributeOperator string
+
+const (
+	AttributeOperatorEq  AttributeOperator = "EQ"
+	AttributeOperatorNe  AttributeOperator = "NE"
+	AttributeOperatorGt  AttributeOperator = "GT"
+	AttributeOperatorGte AttributeOperator = "GTE"
+	AttributeOperatorLt  AttributeOperator = "LT"
+	AttributeOperatorLte AttributeOperator = "LTE"
+)
+
+var AllAttributeOperator = []AttributeOperator{
+	AttributeOperatorEq,
+	AttributeOperatorNe,
+	AttributeOperatorGt,
+	AttributeOperatorGte,
+	AttributeOperatorLt,
+	AttributeOperatorLte,
+}
+
+func (e AttributeOperator) IsValid() bool {
+	switch e {
+	case AttributeOperatorEq, AttributeOperatorNe, AttributeOperatorGt, AttributeOperatorGte, AttributeOperatorLt, AttributeOperatorLte:
+		return true
+	}
+	return false
+}
+
+func (e AttributeOperator) String() string {
+	return string(e)
+}
+
+func (e *AttributeOperator) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AttributeOperator(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AttributeOperator", str)
+	}
+	return nil
+}
+
+func (e AttributeOperator) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AttributeType string
+
+const (
+	AttributeTypeString  AttributeType = "STRING"
+	AttributeTypeNumber  AttributeType = "NUMBER"
+	AttributeTypeBoolean AttributeType = "BOOLEAN"
+)
+
+var AllAttributeType = []AttributeType{
+	AttributeTypeString,
+	AttributeTypeNumber,
+	AttributeTypeBoolean,
+}
+
+func (e AttributeType) IsValid() bool {
+	switch e {
+	case AttributeTypeString, AttributeTypeNumber, AttributeTypeBoolean:
+		return true
+	}
+	return false
+}
+
+func (e AttributeType) String() string {
+	return string(e)
+}
+
+func (e *AttributeType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AttributeType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AttributeType", str)
+	}
+	return nil
+}
+
+func (e AttributeType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ProductSort string
+
+const (
+	ProductSortPriceAsc    ProductSort = "PRICE_ASC"
+	ProductSortPriceDesc   ProductSort = "PRICE_DESC"
+	ProductSortNameAsc     ProductSort = "NAME_ASC"
+	ProductSortNameDesc    ProductSort = "NAME_DESC"
+	ProductSortCreatedAsc  ProductSort = "CREATED_ASC"
+	ProductSortCreatedDesc ProductSort = "CREATED_DESC"
+)
+
+var AllProductSort = []ProductSort{
+	ProductSortPriceAsc,
+	ProductSortPriceDesc,
+	ProductSortNameAsc,
+	ProductSortNameDesc,
+	ProductSortCreatedAsc,
+	ProductSortCreatedDesc,
+}
+
+func (e ProductSort) IsValid() bool {
+	switch e {
+	case ProductSortPriceAsc, ProductSortPriceDesc, ProductSortNameAsc, ProductSortNameDesc, ProductSortCreatedAsc, ProductSortCreatedDesc:
+		return true
+	}
+	return false
+}
+
+func (e ProductSort) String() string {
+	return string(e)
+}
+
+func (e *ProductSort) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductSort(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductSort", str)
+	}
+	return nil
+}
+
+func (e ProductSort) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+// MarshalMoney writes the Money scalar as {"amount": 1050, "currency": "KES"},
+// the amount in minor units as in REST responses.
+func MarshalMoney(m domain.Money) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		raw, _ := json.Marshal(m)
+		_, _ = w.Write(raw)
+	})
+}
+
+// UnmarshalMoney reads {amount: 1050, currency: "KES"}. The amount must be a
+// whole number of minor units, the currency may be left out.
+func UnmarshalMoney(v any) (domain.Money, error) {
+	fields, ok := v.(map[string]any)
+	if !ok {
+		return domain.Money{}, fmt.Errorf("money must be an object like {amount: 1050, currency: \"KES\"}")
+	}
+	for name := range fields {
+		if name != "amount" && name != "currency" {
+			return domain.Money{}, fmt.Errorf("money has no field %q", name)
+		}
+	}
+
+	var money domain.Money
+	switch amount := fields["amount"].(type) {
+	case int:
+		money.Amount = int64(amount)
+	case int32:
+		money.Amount = int64(amount)
+	case int64:
+		money.Amount = amount
+	case json.Number:
+		parsed, err := strconv.ParseInt(string(amount), 10, 64)
+		if err != nil {
+			return domain.Money{}, fmt.Errorf("money amount must be a whole number of minor units")
+		}
+		money.Amount = parsed
+	case float64:
+		if amount != math.Trunc(amount) || math.Abs(amount) > 1<<53 {
+			return domain.Money{}, fmt.Errorf("money amount must be a whole number of minor units")
+		}
+		money.Amount = int64(amount)
+	default:
+		return domain.Money{}, fmt.Errorf("money amount must be a whole number of minor units")
+	}
+
+	if currency, ok := fields["currency"]; ok && currency != nil {
+		code, ok := currency.(string)
+		if !ok {
+			return domain.Money{}, fmt.Errorf("money currency must be a string")
+		}
+		money.Currency = code
+	}
+	return money, nil
+}
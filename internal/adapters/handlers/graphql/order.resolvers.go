@@ -0,0 +1,42 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"go.opentelemetry.io/otel"
+)
+
+// Order is the resolver for the order field.
+func (r *queryResolver) Order(ctx context.Context, id string) (*model.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.Order")
+	defer span.End()
+
+	orderID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := r.orderService.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Customers only see their own orders, admins see all of them
+	if !isAdmin(ctx) {
+		customerID, err := currentCustomerID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if order.CustomerID != customerID {
+			return nil, fmt.Errorf("order not found")
+		}
+	}
+
+	return toOrderModel(order), nil
+}
@@ -11,18 +11,27 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	productService ports.ProductService
-	orderService   ports.OrderService
-	// categoryService ports.CategoryService
+	productService  ports.ProductService
+	orderService    ports.OrderService
+	customerService ports.CustomerService
+	addressService  ports.AddressService
+	searchService   ports.ProductSearchService
+	variantService  ports.ProductVariantService
+	categoryService ports.CategoryService
+	cartService     ports.CartService
+	returnService   ports.ReturnService
 }
 
-func NewResolver(ps ports.ProductService, os ports.OrderService,
-
-// cs ports.CategoryService
-) *Resolver {
+func NewResolver(ps ports.ProductService, os ports.OrderService, cs ports.CustomerService, as ports.AddressService, ss ports.ProductSearchService, vs ports.ProductVariantService, cg ports.CategoryService, ct ports.CartService, rs ports.ReturnService) *Resolver {
 	return &Resolver{
-		productService: ps,
-		orderService:   os,
-		// categoryService: cs,
+		productService:  ps,
+		orderService:    os,
+		customerService: cs,
+		addressService:  as,
+		searchService:   ss,
+		variantService:  vs,
+		categoryService: cg,
+		cartService:     ct,
+		returnService:   rs,
 	}
 }
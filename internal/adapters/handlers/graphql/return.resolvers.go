@@ -0,0 +1,212 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+// OpenReturn is the resolver for the openReturn field.
+func (r *mutationResolver) OpenReturn(ctx context.Context, input model.OpenReturnInput) (*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.OpenReturn")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, err := parseID(input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	request := domain.OpenReturnRequest{OrderID: orderID, Comment: stringValue(input.Comment)}
+	for _, line := range input.Lines {
+		itemID, err := parseID(line.OrderItemID)
+		if err != nil {
+			return nil, err
+		}
+		request.Lines = append(request.Lines, domain.ReturnLineRequest{OrderItemID: itemID, Quantity: int(line.Quantity), Reason: line.Reason})
+	}
+
+	ret, err := r.returnService.OpenReturn(ctx, customerID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModel(ret), nil
+}
+
+// ApproveReturn is the resolver for the approveReturn field.
+func (r *mutationResolver) ApproveReturn(ctx context.Context, id string, note *string) (*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.ApproveReturn")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	returnID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := r.returnService.ApproveReturn(ctx, returnID, stringValue(note))
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModel(ret), nil
+}
+
+// RejectReturn is the resolver for the rejectReturn field.
+func (r *mutationResolver) RejectReturn(ctx context.Context, id string, note string) (*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.RejectReturn")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	returnID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := r.returnService.RejectReturn(ctx, returnID, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModel(ret), nil
+}
+
+// ReceiveReturn is the resolver for the receiveReturn field.
+func (r *mutationResolver) ReceiveReturn(ctx context.Context, id string, restock *bool) (*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.ReceiveReturn")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	returnID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := r.returnService.ReceiveReturn(ctx, returnID, restock == nil || *restock)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModel(ret), nil
+}
+
+// ResolveReturn is the resolver for the resolveReturn field.
+func (r *mutationResolver) ResolveReturn(ctx context.Context, id string, resolution string) (*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.ResolveReturn")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	returnID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := r.returnService.ResolveReturn(ctx, returnID, domain.ReturnResolution(resolution))
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModel(ret), nil
+}
+
+// MyReturns is the resolver for the myReturns field.
+func (r *queryResolver) MyReturns(ctx context.Context) ([]*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.MyReturns")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	returns, err := r.returnService.ListCustomerReturns(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModels(returns), nil
+}
+
+// MyStoreCredit is the resolver for the myStoreCredit field.
+func (r *queryResolver) MyStoreCredit(ctx context.Context) (*model.StoreCreditAccount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.MyStoreCredit")
+	defer span.End()
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := r.returnService.GetStoreCredit(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStoreCreditAccountModel(account), nil
+}
+
+// Return is the resolver for the return field.
+func (r *queryResolver) Return(ctx context.Context, id string) (*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.Return")
+	defer span.End()
+
+	returnID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Customers only see their own returns, admins see all of them
+	if isAdmin(ctx) {
+		ret, err := r.returnService.GetReturn(ctx, returnID)
+		if err != nil {
+			return nil, err
+		}
+		return toReturnModel(ret), nil
+	}
+
+	customerID, err := currentCustomerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := r.returnService.GetCustomerReturn(ctx, customerID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModel(ret), nil
+}
+
+// Returns is the resolver for the returns field.
+func (r *queryResolver) Returns(ctx context.Context, status *string) ([]*model.Return, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.Returns")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	returns, err := r.returnService.ListReturns(ctx, domain.ReturnStatus(stringValue(status)))
+	if err != nil {
+		return nil, err
+	}
+
+	return toReturnModels(returns), nil
+}
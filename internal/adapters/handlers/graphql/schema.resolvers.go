@@ -20,31 +20,30 @@ func (r *mutationResolver) CreateProduct(ctx context.Context, input model.Create
 	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.CreateProduct")
 	defer span.End()
 
+	if err := requireScope(ctx, domain.ScopeProductsWrite); err != nil {
+		return nil, err
+	}
 	categoryID, err := strconv.ParseUint(input.CategoryID, 10, 32)
 	if err != nil {
 		return nil, err
 	}
 
 	product := &domain.Product{
-		Name:       input.Name,
-		Price:      input.Price,
-		CategoryID: uint(categoryID),
+		SKU:         stringValue(input.Sku),
+		Name:        input.Name,
+		Description: input.Description,
+		Price:       input.Price,
+		CategoryID:  uint(categoryID),
 	}
-
-	if err := r.productService.CreateProduct(ctx, product); err != nil {
+	if product.Attributes, err = toAttributes(input.Attributes); err != nil {
 		return nil, err
 	}
 
-	category := &model.Category{
-		ID: strconv.FormatUint(uint64(product.CategoryID), 10),
+	if err := r.productService.CreateProduct(ctx, product); err != nil {
+		return nil, err
 	}
 
-	return &model.Product{
-		ID:       strconv.FormatUint(uint64(product.ID), 10),
-		Name:     product.Name,
-		Price:    product.Price,
-		Category: category,
-	}, nil
+	return toProductModel(product), nil
 }
 
 // UpdateProduct is the resolver for the updateProduct field.
@@ -52,6 +51,9 @@ func (r *mutationResolver) UpdateProduct(ctx context.Context, input model.Update
 	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.UpdateProduct")
 	defer span.End()
 
+	if err := requireScope(ctx, domain.ScopeProductsWrite); err != nil {
+		return nil, err
+	}
 	productID, err := strconv.ParseUint(input.ID, 10, 32)
 	if err != nil {
 		return nil, err
@@ -62,9 +64,15 @@ func (r *mutationResolver) UpdateProduct(ctx context.Context, input model.Update
 		return nil, err
 	}
 
+	if input.Sku != nil {
+		product.SKU = *input.Sku
+	}
 	if input.Name != nil {
 		product.Name = *input.Name
 	}
+	if input.Description != nil {
+		product.Description = *input.Description
+	}
 	if input.Price != nil {
 		product.Price = *input.Price
 	}
@@ -75,21 +83,17 @@ func (r *mutationResolver) UpdateProduct(ctx context.Context, input model.Update
 		}
 		product.CategoryID = uint(categoryID)
 	}
+	if input.Attributes != nil {
+		if product.Attributes, err = toAttributes(input.Attributes); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := r.productService.UpdateProduct(ctx, product); err != nil {
 		return nil, err
 	}
 
-	category := &model.Category{
-		ID: strconv.FormatUint(uint64(product.CategoryID), 10),
-	}
-
-	return &model.Product{
-		ID:       strconv.FormatUint(uint64(product.ID), 10),
-		Name:     product.Name,
-		Price:    product.Price,
-		Category: category,
-	}, nil
+	return toProductModel(product), nil
 }
 
 // DeleteProduct is the resolver for the deleteProduct field.
@@ -97,6 +101,9 @@ func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (bool,
 	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.DeleteProduct")
 	defer span.End()
 
+	if err := requireScope(ctx, domain.ScopeProductsWrite); err != nil {
+		return false, err
+	}
 	productID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
 		return false, err
@@ -110,30 +117,57 @@ func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (bool,
 }
 
 // Products is the resolver for the products field.
-func (r *queryResolver) Products(ctx context.Context) ([]*model.Product, error) {
+func (r *queryResolver) Products(ctx context.Context, first *int32, after *string, sort *model.ProductSort, filter *model.ProductFilter) (*model.ProductConnection, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.Products")
 	defer span.End()
 
-	products, err := r.productService.ListProducts(ctx)
+	query := domain.ProductQuery{Cursor: stringValue(after)}
+	if first != nil {
+		query.Limit = int(*first)
+	}
+	if sort != nil {
+		query.Sort = productSorts[*sort].String()
+	}
+	if filter != nil {
+		if filter.CategoryID != nil {
+			categoryID, err := parseID(*filter.CategoryID)
+			if err != nil {
+				return nil, err
+			}
+			query.CategoryID = categoryID
+		}
+		query.IncludeDescendants = filter.IncludeDescendants != nil && *filter.IncludeDescendants
+		query.MinPrice = filter.MinPrice
+		query.MaxPrice = filter.MaxPrice
+		query.Name = stringValue(filter.Name)
+		query.Attributes = fromAttributeFilterInputs(filter.Attributes)
+	}
+
+	page, err := r.productService.ListProducts(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert domain products to GraphQL model
-	var result []*model.Product
-	for _, p := range products {
-		category := &model.Category{
-			ID: strconv.FormatUint(uint64(p.CategoryID), 10),
+	// The service only hands out the cursor of the last row, edges need one each
+	productSort, err := domain.ParseProductSort(query.Sort)
+	if err != nil {
+		return nil, err
+	}
+	connection := &model.ProductConnection{
+		Edges:    make([]*model.ProductEdge, len(page.Products)),
+		PageInfo: &model.PageInfo{HasNextPage: page.NextCursor != ""},
+	}
+	for i := range page.Products {
+		connection.Edges[i] = &model.ProductEdge{
+			Cursor: domain.NewProductCursor(productSort, &page.Products[i]).Encode(),
+			Node:   toProductModel(&page.Products[i]),
 		}
-		result = append(result, &model.Product{
-			ID:       strconv.FormatUint(uint64(p.ID), 10),
-			Name:     p.Name,
-			Price:    p.Price,
-			Category: category,
-		})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
 	}
 
-	return result, nil
+	return connection, nil
 }
 
 // Product is the resolver for the product field.
@@ -150,16 +184,7 @@ func (r *queryResolver) Product(ctx context.Context, id string) (*model.Product,
 	if err != nil {
 		return nil, err
 	}
-	category := &model.Category{
-		ID: strconv.FormatUint(uint64(product.CategoryID), 10),
-	}
-
-	return &model.Product{
-		ID:       strconv.FormatUint(uint64(product.ID), 10),
-		Name:     product.Name,
-		Price:    product.Price,
-		Category: category,
-	}, nil
+	return toProductModel(product), nil
 }
 
 // Categories is the resolver for the categories field.
@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductService struct {
+	mock.Mock
+}
+
+func (m *MockProductService) CreateProduct(ctx context.Context, product *domain.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductService) GetProduct(ctx context.Context, id uint) (*domain.Product, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Product), args.Error(1)
+}
+
+func (m *MockProductService) ListProducts(ctx context.Context, query domain.ProductQuery) (*domain.ProductPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductPage), args.Error(1)
+}
+
+func (m *MockProductService) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductService) DeleteProduct(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProductService) GetAverageCategoryPrice(ctx context.Context, id uint, byVariant bool, currency string) (domain.Money, error) {
+	args := m.Called(ctx, id, byVariant, currency)
+	return args.Get(0).(domain.Money), args.Error(1)
+}
+
+func (m *MockProductService) ImportProducts(ctx context.Context, r io.Reader, format domain.ImportFormat, dryRun bool) (*domain.ProductImportReport, error) {
+	args := m.Called(ctx, r, format, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductImportReport), args.Error(1)
+}
+
+func (m *MockProductService) ExportProducts(ctx context.Context, w io.Writer, format domain.ImportFormat, filter domain.ProductExportFilter) error {
+	args := m.Called(ctx, w, format, filter)
+	return args.Error(0)
+}
+
+func TestMutationResolver_ProductWrites(t *testing.T) {
+	callers := []struct {
+		name  string
+		actor *auth.Actor
+	}{
+		{name: "anonymous"},
+		{name: "customer", actor: &auth.Actor{Type: auth.ActorCustomer, ID: 1}},
+		{name: "key without the scope", actor: &auth.Actor{Type: auth.ActorAPIKey, ID: 2, Scopes: []string{domain.ScopeProductsRead}}},
+	}
+
+	for _, caller := range callers {
+		t.Run(caller.name, func(t *testing.T) {
+			products := new(MockProductService)
+			resolver := &mutationResolver{&Resolver{productService: products}}
+			ctx := context.Background()
+			if caller.actor != nil {
+				ctx = auth.WithActor(ctx, caller.actor)
+			}
+
+			_, err := resolver.CreateProduct(ctx, model.CreateProductInput{Name: "Phone", Price: domain.NewMoney(1000, "KES"), CategoryID: "1"})
+			assert.Error(t, err)
+			_, err = resolver.UpdateProduct(ctx, model.UpdateProductInput{ID: "1"})
+			assert.Error(t, err)
+			_, err = resolver.DeleteProduct(ctx, "1")
+			assert.Error(t, err)
+
+			// The catalogue was never touched
+			products.AssertNotCalled(t, "CreateProduct", mock.Anything, mock.Anything)
+			products.AssertNotCalled(t, "GetProduct", mock.Anything, mock.Anything)
+			products.AssertNotCalled(t, "DeleteProduct", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("admin", func(t *testing.T) {
+		products := new(MockProductService)
+		products.On("CreateProduct", mock.Anything, mock.Anything).Return(nil)
+		products.On("DeleteProduct", mock.Anything, uint(1)).Return(nil)
+		resolver := &mutationResolver{&Resolver{productService: products}}
+		ctx := auth.WithActor(context.Background(), &auth.Actor{Type: auth.ActorCustomer, ID: 1, Admin: true})
+
+		_, err := resolver.CreateProduct(ctx, model.CreateProductInput{Name: "Phone", Price: domain.NewMoney(1000, "KES"), CategoryID: "1"})
+		assert.NoError(t, err)
+		deleted, err := resolver.DeleteProduct(ctx, "1")
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+	})
+
+	t.Run("key with the scope", func(t *testing.T) {
+		products := new(MockProductService)
+		products.On("DeleteProduct", mock.Anything, uint(1)).Return(nil)
+		resolver := &mutationResolver{&Resolver{productService: products}}
+		ctx := auth.WithActor(context.Background(), &auth.Actor{Type: auth.ActorAPIKey, ID: 2, Scopes: []string{domain.ScopeProductsWrite}})
+
+		_, err := resolver.DeleteProduct(ctx, "1")
+		assert.NoError(t, err)
+	})
+}
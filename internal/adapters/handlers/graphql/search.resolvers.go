@@ -0,0 +1,72 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+// SearchProducts is the resolver for the searchProducts field.
+func (r *queryResolver) SearchProducts(ctx context.Context, query string, page *int32, pageSize *int32) (*model.ProductSearchResult, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.SearchProducts")
+	defer span.End()
+
+	searchQuery := domain.ProductSearchQuery{Query: query}
+	if page != nil {
+		searchQuery.Page = int(*page)
+	}
+	if pageSize != nil {
+		searchQuery.PageSize = int(*pageSize)
+	}
+
+	results, err := r.searchService.SearchProducts(ctx, searchQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]*model.ProductSearchHit, len(results.Hits))
+	for i := range results.Hits {
+		hits[i] = &model.ProductSearchHit{
+			Product: toProductModel(&results.Hits[i].Product),
+			Rank:    results.Hits[i].Rank,
+		}
+	}
+
+	return &model.ProductSearchResult{
+		Hits:     hits,
+		Page:     int32(results.Page),
+		PageSize: int32(results.PageSize),
+		Total:    int32(results.Total),
+	}, nil
+}
+
+// ProductSuggestions is the resolver for the productSuggestions field.
+func (r *queryResolver) ProductSuggestions(ctx context.Context, prefix string, limit *int32) ([]*model.ProductSuggestion, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.ProductSuggestions")
+	defer span.End()
+
+	suggestLimit := 0
+	if limit != nil {
+		suggestLimit = int(*limit)
+	}
+
+	suggestions, err := r.searchService.SuggestProducts(ctx, prefix, suggestLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.ProductSuggestion, len(suggestions))
+	for i, suggestion := range suggestions {
+		result[i] = &model.ProductSuggestion{
+			ID:   formatID(suggestion.ID),
+			Name: suggestion.Name,
+		}
+	}
+	return result, nil
+}
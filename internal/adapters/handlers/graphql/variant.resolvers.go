@@ -0,0 +1,112 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.64
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/handlers/graphql/model"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+// CreateProductVariant is the resolver for the createProductVariant field.
+func (r *mutationResolver) CreateProductVariant(ctx context.Context, productID string, input model.ProductVariantInput) (*model.ProductVariant, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.CreateProductVariant")
+	defer span.End()
+
+	if err := requireScope(ctx, domain.ScopeProductsWrite); err != nil {
+		return nil, err
+	}
+	parsedProductID, err := parseID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := fromVariantInput(input)
+	if err := r.variantService.CreateVariant(ctx, parsedProductID, variant); err != nil {
+		return nil, err
+	}
+
+	return toVariantModel(variant), nil
+}
+
+// UpdateProductVariant is the resolver for the updateProductVariant field.
+func (r *mutationResolver) UpdateProductVariant(ctx context.Context, productID string, id string, input model.ProductVariantInput) (*model.ProductVariant, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.UpdateProductVariant")
+	defer span.End()
+
+	if err := requireScope(ctx, domain.ScopeProductsWrite); err != nil {
+		return nil, err
+	}
+	parsedProductID, err := parseID(productID)
+	if err != nil {
+		return nil, err
+	}
+	variantID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := fromVariantInput(input)
+	variant.ID = variantID
+	if err := r.variantService.UpdateVariant(ctx, parsedProductID, variant); err != nil {
+		return nil, err
+	}
+
+	return toVariantModel(variant), nil
+}
+
+// DeleteProductVariant is the resolver for the deleteProductVariant field.
+func (r *mutationResolver) DeleteProductVariant(ctx context.Context, productID string, id string) (bool, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.DeleteProductVariant")
+	defer span.End()
+
+	if err := requireScope(ctx, domain.ScopeProductsWrite); err != nil {
+		return false, err
+	}
+	parsedProductID, err := parseID(productID)
+	if err != nil {
+		return false, err
+	}
+	variantID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.variantService.DeleteVariant(ctx, parsedProductID, variantID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ProductVariants is the resolver for the productVariants field.
+func (r *queryResolver) ProductVariants(ctx context.Context, productID string) (*model.ProductVariants, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "GraphQL.ProductVariants")
+	defer span.End()
+
+	parsedProductID, err := parseID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := r.variantService.ListVariants(ctx, parsedProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ProductVariants{
+		Options:  make([]*model.ProductOption, len(list.Options)),
+		Variants: make([]*model.ProductVariant, len(list.Variants)),
+	}
+	for i := range list.Options {
+		result.Options[i] = toProductOptionModel(&list.Options[i])
+	}
+	for i := range list.Variants {
+		result.Variants[i] = toVariantModel(&list.Variants[i])
+	}
+	return result, nil
+}
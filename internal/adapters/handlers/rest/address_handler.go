@@ -0,0 +1,257 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type AddressHandler struct {
+	addressService ports.AddressService
+}
+
+type AddressRequest struct {
+	Label         string `json:"label" binding:"max=64"`
+	RecipientName string `json:"recipient_name" binding:"required,max=255"`
+	Phone         string `json:"phone"`
+	Line1         string `json:"line1" binding:"required,max=255"`
+	Line2         string `json:"line2" binding:"max=255"`
+	City          string `json:"city" binding:"required,max=128"`
+	County        string `json:"county" binding:"max=128"`
+	PostalCode    string `json:"postal_code" binding:"max=32"`
+	Country       string `json:"country" binding:"omitempty,len=2"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+func (r AddressRequest) toDomain() *domain.Address {
+	return &domain.Address{
+		Label:         r.Label,
+		RecipientName: r.RecipientName,
+		Phone:         r.Phone,
+		Line1:         r.Line1,
+		Line2:         r.Line2,
+		City:          r.City,
+		County:        r.County,
+		PostalCode:    r.PostalCode,
+		Country:       r.Country,
+		IsDefault:     r.IsDefault,
+	}
+}
+
+func NewAddressHandler(as ports.AddressService) *AddressHandler {
+	return &AddressHandler{
+		addressService: as,
+	}
+}
+
+// List godoc
+// @Summary List the current customer's addresses
+// @Tags addresses
+// @Produce json
+// @Success 200 {array} domain.Address
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/addresses [get]
+func (h *AddressHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "AddressHandler.List")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	addresses, err := h.addressService.ListAddresses(ctx, customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch addresses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// Create godoc
+// @Summary Add an address to the current customer's address book
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param address body AddressRequest true "Address"
+// @Success 201 {object} domain.Address
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/addresses [post]
+func (h *AddressHandler) Create(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "AddressHandler.Create")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	address := req.toDomain()
+	if err := h.addressService.CreateAddress(ctx, customerID, address); err != nil {
+		respondError(c, err, "Failed to create address")
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// Get godoc
+// @Summary Get one of the current customer's addresses
+// @Tags addresses
+// @Produce json
+// @Param id path int true "Address ID"
+// @Success 200 {object} domain.Address
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /me/addresses/{id} [get]
+func (h *AddressHandler) Get(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "AddressHandler.Get")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address ID"})
+		return
+	}
+
+	address, err := h.addressService.GetAddress(ctx, customerID, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Address not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// Update godoc
+// @Summary Replace one of the current customer's addresses
+// @Description Orders already placed keep the address they were shipped to
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param id path int true "Address ID"
+// @Param address body AddressRequest true "Address"
+// @Success 200 {object} domain.Address
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/addresses/{id} [put]
+func (h *AddressHandler) Update(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "AddressHandler.Update")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address ID"})
+		return
+	}
+
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	address := req.toDomain()
+	address.ID = uint(id)
+	if err := h.addressService.UpdateAddress(ctx, customerID, address); err != nil {
+		respondError(c, err, "Failed to update address")
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// Delete godoc
+// @Summary Delete one of the current customer's addresses
+// @Tags addresses
+// @Param id path int true "Address ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/addresses/{id} [delete]
+func (h *AddressHandler) Delete(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "AddressHandler.Delete")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address ID"})
+		return
+	}
+
+	if err := h.addressService.DeleteAddress(ctx, customerID, uint(id)); err != nil {
+		respondError(c, err, "Failed to delete address")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetDefault godoc
+// @Summary Make an address the current customer's default
+// @Tags addresses
+// @Param id path int true "Address ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/addresses/{id}/default [post]
+func (h *AddressHandler) SetDefault(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "AddressHandler.SetDefault")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address ID"})
+		return
+	}
+
+	if err := h.addressService.SetDefaultAddress(ctx, customerID, uint(id)); err != nil {
+		respondError(c, err, "Failed to set default address")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"go.opentelemetry.io/otel"
+)
+
+type APIKeyHandler struct {
+	apiKeyService ports.APIKeyService
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=255"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+// CreateAPIKeyResponse is the only place the raw key is ever returned.
+type CreateAPIKeyResponse struct {
+	APIKey *domain.APIKey `json:"api_key"`
+	Key    string         `json:"key"`
+}
+
+func NewAPIKeyHandler(aks ports.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: aks,
+	}
+}
+
+// Create godoc
+// @Summary Create an API key
+// @Description Create a scoped API key for machine-to-machine access. The key is only shown once.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param api_key body CreateAPIKeyRequest true "API key details"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "APIKeyHandler.Create")
+	defer span.End()
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var createdBy uint
+	if actor, ok := auth.ActorFromContext(ctx); ok && actor.Type == auth.ActorCustomer {
+		createdBy = actor.ID
+	}
+
+	key, rawKey, err := h.apiKeyService.CreateAPIKey(ctx, req.Name, req.Scopes, createdBy)
+	if err != nil {
+		respondError(c, err, "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKey: key, Key: rawKey})
+}
+
+// List godoc
+// @Summary List API keys
+// @Description List all API keys, including revoked ones. Secrets are never returned.
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} domain.APIKey
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "APIKeyHandler.List")
+	defer span.End()
+
+	keys, err := h.apiKeyService.ListAPIKeys(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// Revoke godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key so it can no longer authenticate
+// @Tags api-keys
+// @Param id path int true "API key ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "APIKeyHandler.Revoke")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(ctx, uint(id)); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke API key"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type AuditHandler struct {
+	auditService ports.AuditService
+}
+
+func NewAuditHandler(as ports.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: as,
+	}
+}
+
+// List godoc
+// @Summary List audit entries
+// @Description Query the audit log, newest first. Times are RFC3339.
+// @Tags audit
+// @Produce json
+// @Param entity query string false "Entity type, e.g. product, order or customer"
+// @Param entity_id query int false "Entity ID, used together with entity"
+// @Param actor query string false "Actor type, optionally with an ID, e.g. api_key or api_key:3"
+// @Param from query string false "Only entries at or after this time"
+// @Param to query string false "Only entries before this time"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size, at most 200"
+// @Success 200 {object} domain.AuditPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /audit [get]
+func (h *AuditHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "AuditHandler.List")
+	defer span.End()
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	entries, err := h.auditService.ListAuditEntries(ctx, filter)
+	if err != nil {
+		respondError(c, err, "Failed to fetch audit entries")
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func parseAuditFilter(c *gin.Context) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{EntityType: c.Query("entity")}
+	filter.Page, _ = strconv.Atoi(c.Query("page"))
+	filter.PageSize, _ = strconv.Atoi(c.Query("page_size"))
+
+	if raw := c.Query("entity_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return filter, domain.NewValidationError("invalid entity_id")
+		}
+		filter.EntityID = uint(id)
+	}
+
+	if raw := c.Query("actor"); raw != "" {
+		actorType, actorID, hasID := strings.Cut(raw, ":")
+		filter.ActorType = actorType
+		if hasID {
+			id, err := strconv.ParseUint(actorID, 10, 32)
+			if err != nil {
+				return filter, domain.NewValidationError("invalid actor, expected type or type:id")
+			}
+			filter.ActorID = uint(id)
+		}
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, domain.NewValidationError(param + " must be an RFC3339 time")
+		}
+		*target = &t
+	}
+
+	return filter, nil
+}
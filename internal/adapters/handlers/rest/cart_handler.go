@@ -0,0 +1,287 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+// cartTokenHeader carries the token of an anonymous cart.
+const cartTokenHeader = "X-Cart-Token"
+
+type CartHandler struct {
+	cartService ports.CartService
+	rateService ports.ExchangeRateService
+}
+
+type AddCartItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	// VariantID is required for products that come in variants
+	VariantID *uint `json:"variant_id"`
+	// Quantity defaults to 1
+	Quantity int `json:"quantity" binding:"gte=0"`
+}
+
+type UpdateCartItemRequest struct {
+	// Quantity 0 removes the line
+	Quantity *int `json:"quantity" binding:"required,gte=0"`
+}
+
+type CheckoutRequest struct {
+	// AddressID picks the shipping address from the address book, the default address is used when omitted
+	AddressID *uint `json:"address_id"`
+	// Currency to charge the order in, converted at today's rate. The products' currency is used when omitted.
+	Currency string `json:"currency" binding:"omitempty,len=3"`
+	// DiscountCodes are applied in the order given, after any automatic discounts
+	DiscountCodes []string `json:"discount_codes"`
+	// ShippingMethodID is required once any shipping method is active, see POST /shipping/quotes
+	ShippingMethodID *uint `json:"shipping_method_id"`
+}
+
+func NewCartHandler(cs ports.CartService, rs ports.ExchangeRateService) *CartHandler {
+	return &CartHandler{
+		cartService: cs,
+		rateService: rs,
+	}
+}
+
+// cartOwner is the signed-in customer and the anonymous cart token, either
+// of which may be missing.
+func cartOwner(c *gin.Context) domain.CartOwner {
+	customerID, _ := currentCustomerID(c)
+	return domain.CartOwner{CustomerID: customerID, Token: c.GetHeader(cartTokenHeader)}
+}
+
+// Get godoc
+// @Summary View the cart
+// @Description The cart priced at today's prices. Lines that can't be ordered any more are flagged with available false and a problem, and left out of the subtotal. Signed-in customers who send X-Cart-Token get the anonymous cart merged into theirs.
+// @Tags cart
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param currency query string false "Also show prices converted to this currency"
+// @Success 200 {object} domain.CartView
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart [get]
+func (h *CartHandler) Get(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CartHandler.Get")
+	defer span.End()
+
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
+	cart, err := h.cartService.GetCart(ctx, cartOwner(c))
+	if err != nil {
+		respondError(c, err, "Failed to fetch cart")
+		return
+	}
+
+	displayCart(rates, currency, cart)
+	c.JSON(http.StatusOK, cart)
+}
+
+// AddItem godoc
+// @Summary Add a product to the cart
+// @Description Adds to the quantity when the cart already holds the product and variant. Anonymous shoppers get a cart token back with their first item, send it as X-Cart-Token from then on.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param item body AddCartItemRequest true "Product and quantity"
+// @Success 200 {object} domain.CartView
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/items [post]
+func (h *CartHandler) AddItem(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CartHandler.AddItem")
+	defer span.End()
+
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
+	var req AddCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	item := domain.CartItem{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Quantity:  req.Quantity,
+	}
+	cart, err := h.cartService.AddItem(ctx, cartOwner(c), item)
+	if err != nil {
+		respondError(c, err, "Failed to add to cart")
+		return
+	}
+
+	displayCart(rates, currency, cart)
+	c.JSON(http.StatusOK, cart)
+}
+
+// UpdateItem godoc
+// @Summary Change the quantity of a cart line
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param itemId path int true "Cart line ID"
+// @Param item body UpdateCartItemRequest true "New quantity"
+// @Success 200 {object} domain.CartView
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/items/{itemId} [patch]
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CartHandler.UpdateItem")
+	defer span.End()
+
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid cart item ID"})
+		return
+	}
+
+	var req UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	cart, err := h.cartService.UpdateItem(ctx, cartOwner(c), uint(itemID), *req.Quantity)
+	if err != nil {
+		respondError(c, err, "Failed to update cart")
+		return
+	}
+
+	displayCart(rates, currency, cart)
+	c.JSON(http.StatusOK, cart)
+}
+
+// RemoveItem godoc
+// @Summary Remove a line from the cart
+// @Tags cart
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param itemId path int true "Cart line ID"
+// @Success 200 {object} domain.CartView
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/items/{itemId} [delete]
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CartHandler.RemoveItem")
+	defer span.End()
+
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid cart item ID"})
+		return
+	}
+
+	cart, err := h.cartService.RemoveItem(ctx, cartOwner(c), uint(itemID))
+	if err != nil {
+		respondError(c, err, "Failed to update cart")
+		return
+	}
+
+	displayCart(rates, currency, cart)
+	c.JSON(http.StatusOK, cart)
+}
+
+// Clear godoc
+// @Summary Empty the cart
+// @Tags cart
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Success 200 {object} domain.CartView
+// @Failure 500 {object} ErrorResponse
+// @Router /cart [delete]
+func (h *CartHandler) Clear(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CartHandler.Clear")
+	defer span.End()
+
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
+	cart, err := h.cartService.ClearCart(ctx, cartOwner(c))
+	if err != nil {
+		respondError(c, err, "Failed to clear cart")
+		return
+	}
+
+	displayCart(rates, currency, cart)
+	c.JSON(http.StatusOK, cart)
+}
+
+// Checkout godoc
+// @Summary Order the cart
+// @Description Prices the cart again and places an order for it, then empties the cart. Fails without placing an order when a line is unavailable.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart to merge first"
+// @Param checkout body CheckoutRequest false "Shipping address and method, currency and discount codes"
+// @Success 201 {object} domain.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/checkout [post]
+func (h *CartHandler) Checkout(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CartHandler.Checkout")
+	defer span.End()
+
+	email, ok := c.Get("email")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+	if _, ok := currentCustomerID(c); !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	var req CheckoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	ctx = context.WithValue(ctx, "email", email)
+	order, err := h.cartService.Checkout(ctx, cartOwner(c), domain.CartCheckout{
+		AddressID:        req.AddressID,
+		Currency:         req.Currency,
+		DiscountCodes:    req.DiscountCodes,
+		ShippingMethodID: req.ShippingMethodID,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to check out")
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type CategoryHandler struct {
+	categoryService ports.CategoryService
+}
+
+func NewCategoryHandler(cs ports.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: cs,
+	}
+}
+
+// Delete godoc
+// @Summary Delete a category
+// @Description Move an empty category to the trash. Categories with products or subcategories are refused.
+// @Tags categories
+// @Param id path int true "Category ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CategoryHandler.Delete")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(ctx, uint(id)); err != nil {
+		respondError(c, err, "Failed to delete category")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAttributes godoc
+// @Summary List a category's attributes
+// @Description The attributes products in the category can carry, including those inherited from parent categories
+// @Tags categories
+// @Produce json
+// @Param categoryId path int true "Category ID"
+// @Success 200 {array} domain.CategoryAttribute
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{categoryId}/attributes [get]
+func (h *CategoryHandler) ListAttributes(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CategoryHandler.ListAttributes")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("categoryId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		return
+	}
+
+	attributes, err := h.categoryService.ListCategoryAttributes(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch attributes")
+		return
+	}
+
+	c.JSON(http.StatusOK, attributes)
+}
+
+// SetAttributes godoc
+// @Summary Replace a category's attributes
+// @Description Replace the attributes defined on the category itself. Types are string, number and boolean.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param categoryId path int true "Category ID"
+// @Param attributes body []domain.CategoryAttribute true "Attribute definitions"
+// @Success 200 {array} domain.CategoryAttribute
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{categoryId}/attributes [put]
+func (h *CategoryHandler) SetAttributes(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CategoryHandler.SetAttributes")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("categoryId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		return
+	}
+
+	var attributes []domain.CategoryAttribute
+	if err := c.ShouldBindJSON(&attributes); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	attributes, err = h.categoryService.SetCategoryAttributes(ctx, uint(id), attributes)
+	if err != nil {
+		respondError(c, err, "Failed to save attributes")
+		return
+	}
+
+	c.JSON(http.StatusOK, attributes)
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+)
+
+// requestedCurrency is the currency the client wants prices shown in, from
+// ?currency= or else the first entry of the Accept-Currency header. It is
+// empty when the client asked for none.
+func requestedCurrency(c *gin.Context) string {
+	currency := c.Query("currency")
+	if currency == "" {
+		currency, _, _ = strings.Cut(c.GetHeader("Accept-Currency"), ",")
+		currency, _, _ = strings.Cut(currency, ";")
+	}
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// displayRates loads the current rates when the client asked for prices in
+// another currency. It writes a 400 and returns false when that currency has
+// no rate, and returns nil rates when the client asked for none.
+func displayRates(c *gin.Context, rateService ports.ExchangeRateService) (domain.ExchangeRates, string, bool) {
+	currency := requestedCurrency(c)
+	if currency == "" {
+		return nil, "", true
+	}
+
+	rates, err := rateService.CurrentRates(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to load exchange rates")
+		return nil, "", false
+	}
+	if _, err := rates.Rate(currency); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return nil, "", false
+	}
+	return rates, currency, true
+}
+
+// displayMoney converts m for display, nil when it can't be converted.
+func displayMoney(rates domain.ExchangeRates, m domain.Money, currency string) *domain.Money {
+	converted, err := rates.Convert(m, currency)
+	if err != nil {
+		return nil
+	}
+	return &converted
+}
+
+func displayProducts(rates domain.ExchangeRates, currency string, products []domain.Product) {
+	if rates == nil {
+		return
+	}
+	for i := range products {
+		products[i].DisplayPrice = displayMoney(rates, products[i].Price, currency)
+	}
+}
+
+func displayOrders(rates domain.ExchangeRates, currency string, orders []domain.Order) {
+	for i := range orders {
+		displayOrder(rates, currency, &orders[i])
+	}
+}
+
+func displayOrder(rates domain.ExchangeRates, currency string, order *domain.Order) {
+	if rates == nil {
+		return
+	}
+	order.DisplayTotalPrice = displayMoney(rates, order.TotalPrice, currency)
+	for i := range order.Items {
+		order.Items[i].DisplayPrice = displayMoney(rates, order.Items[i].Price, currency)
+	}
+}
+
+func displayCart(rates domain.ExchangeRates, currency string, cart *domain.CartView) {
+	if rates == nil {
+		return
+	}
+	for i := range cart.Items {
+		if cart.Items[i].Price != nil {
+			cart.Items[i].DisplayPrice = displayMoney(rates, *cart.Items[i].Price, currency)
+		}
+	}
+	if cart.Subtotal != nil {
+		cart.DisplaySubtotal = displayMoney(rates, *cart.Subtotal, currency)
+	}
+}
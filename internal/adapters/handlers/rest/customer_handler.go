@@ -0,0 +1,202 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"go.opentelemetry.io/otel"
+)
+
+type CustomerHandler struct {
+	customerService ports.CustomerService
+}
+
+type UpdateProfileRequest struct {
+	Name        *string                     `json:"name" binding:"omitempty,min=1,max=255"`
+	Phone       *string                     `json:"phone"`
+	Preferences *domain.CustomerPreferences `json:"preferences"`
+}
+
+type VerifyPhoneRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func NewCustomerHandler(cs ports.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: cs,
+	}
+}
+
+// Me godoc
+// @Summary Get the current customer's profile
+// @Tags customers
+// @Produce json
+// @Success 200 {object} domain.Customer
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /me [get]
+func (h *CustomerHandler) Me(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CustomerHandler.Me")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	customer, err := h.customerService.GetCustomer(ctx, customerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Customer not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// UpdateMe godoc
+// @Summary Update the current customer's profile
+// @Description Update name, phone and notification preferences. A new phone number is sent a verification code by SMS.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param profile body UpdateProfileRequest true "Profile changes"
+// @Success 200 {object} domain.Customer
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me [patch]
+func (h *CustomerHandler) UpdateMe(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CustomerHandler.UpdateMe")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	customer, err := h.customerService.UpdateProfile(ctx, customerID, domain.CustomerProfileUpdate{
+		Name:        req.Name,
+		Phone:       req.Phone,
+		Preferences: req.Preferences,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// RequestPhoneVerification godoc
+// @Summary Send a phone verification code
+// @Description Send a one-time code by SMS to the current customer's unverified phone number
+// @Tags customers
+// @Success 202 "Accepted"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /me/phone/verification [post]
+func (h *CustomerHandler) RequestPhoneVerification(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CustomerHandler.RequestPhoneVerification")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	if err := h.customerService.RequestPhoneVerification(ctx, customerID); err != nil {
+		respondError(c, err, "Failed to send verification code")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyPhone godoc
+// @Summary Verify the current customer's phone number
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param code body VerifyPhoneRequest true "Verification code"
+// @Success 200 {object} domain.Customer
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /me/phone/verify [post]
+func (h *CustomerHandler) VerifyPhone(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CustomerHandler.VerifyPhone")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	var req VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	customer, err := h.customerService.VerifyPhone(ctx, customerID, req.Code)
+	if err != nil {
+		respondError(c, err, "Failed to verify phone")
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// List godoc
+// @Summary List customers
+// @Description Search customers by name, email or phone, one page at a time
+// @Tags customers
+// @Produce json
+// @Param q query string false "Search term"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size, at most 100"
+// @Success 200 {object} domain.CustomerPage
+// @Failure 500 {object} ErrorResponse
+// @Router /customers [get]
+func (h *CustomerHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "CustomerHandler.List")
+	defer span.End()
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	customers, err := h.customerService.ListCustomers(ctx, domain.CustomerFilter{
+		Search:   c.Query("q"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch customers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, customers)
+}
+
+// currentCustomerID returns the logged in customer. API keys act on behalf of
+// no customer and are rejected by customer-only routes.
+func currentCustomerID(c *gin.Context) (uint, bool) {
+	actor, ok := auth.ActorFromContext(c.Request.Context())
+	if !ok || actor.Type != auth.ActorCustomer || actor.ID == 0 {
+		return 0, false
+	}
+	return actor.ID, true
+}
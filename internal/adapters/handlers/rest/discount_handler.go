@@ -0,0 +1,214 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type DiscountHandler struct {
+	discountService ports.DiscountService
+}
+
+type DiscountRequest struct {
+	// Code is what customers enter at checkout. Without a code the discount applies to every order it matches.
+	Code string `json:"code" binding:"omitempty,max=64"`
+	Name string `json:"name" binding:"required,max=255"`
+	// Type is percentage, fixed_amount or buy_x_get_y
+	Type domain.DiscountType `json:"type" binding:"required"`
+	// PercentOff is the percentage taken off, for percentage and buy_x_get_y discounts. 100 makes the "get" units free.
+	PercentOff int `json:"percent_off"`
+	// AmountOff is in minor units, for fixed_amount discounts. The currency defaults to KES.
+	AmountOff   domain.Money `json:"amount_off"`
+	BuyQuantity int          `json:"buy_quantity"`
+	GetQuantity int          `json:"get_quantity"`
+	// ProductIDs and CategoryIDs limit the discount, categories include their subcategories
+	ProductIDs  []uint `json:"product_ids"`
+	CategoryIDs []uint `json:"category_ids"`
+	// MinOrderValue is the subtotal the order needs before discounts, in minor units
+	MinOrderValue    domain.Money `json:"min_order_value"`
+	UsageLimit       *int         `json:"usage_limit"`
+	PerCustomerLimit *int         `json:"per_customer_limit"`
+	StartsAt         *time.Time   `json:"starts_at"`
+	EndsAt           *time.Time   `json:"ends_at"`
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+func NewDiscountHandler(ds ports.DiscountService) *DiscountHandler {
+	return &DiscountHandler{
+		discountService: ds,
+	}
+}
+
+func (r DiscountRequest) discount() *domain.Discount {
+	active := r.Active == nil || *r.Active
+	return &domain.Discount{
+		Code:             r.Code,
+		Name:             r.Name,
+		Type:             r.Type,
+		PercentOff:       r.PercentOff,
+		AmountOff:        r.AmountOff,
+		BuyQuantity:      r.BuyQuantity,
+		GetQuantity:      r.GetQuantity,
+		ProductIDs:       r.ProductIDs,
+		CategoryIDs:      r.CategoryIDs,
+		MinOrderValue:    r.MinOrderValue,
+		UsageLimit:       r.UsageLimit,
+		PerCustomerLimit: r.PerCustomerLimit,
+		StartsAt:         r.StartsAt,
+		EndsAt:           r.EndsAt,
+		Active:           active,
+	}
+}
+
+// List godoc
+// @Summary List discounts
+// @Tags discounts
+// @Produce json
+// @Success 200 {array} domain.Discount
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/discounts [get]
+func (h *DiscountHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "DiscountHandler.List")
+	defer span.End()
+
+	discounts, err := h.discountService.ListDiscounts(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to fetch discounts")
+		return
+	}
+
+	c.JSON(http.StatusOK, discounts)
+}
+
+// Get godoc
+// @Summary Get a discount
+// @Tags discounts
+// @Produce json
+// @Param id path int true "Discount ID"
+// @Success 200 {object} domain.Discount
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/discounts/{id} [get]
+func (h *DiscountHandler) Get(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "DiscountHandler.Get")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid discount ID"})
+		return
+	}
+
+	discount, err := h.discountService.GetDiscount(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch discount")
+		return
+	}
+
+	c.JSON(http.StatusOK, discount)
+}
+
+// Create godoc
+// @Summary Create a discount
+// @Description Discounts with a code apply when the customer enters it with the order, those without one apply to every order they match. Usage limits count orders.
+// @Tags discounts
+// @Accept json
+// @Produce json
+// @Param discount body DiscountRequest true "Discount rule"
+// @Success 201 {object} domain.Discount
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/discounts [post]
+func (h *DiscountHandler) Create(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "DiscountHandler.Create")
+	defer span.End()
+
+	var req DiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	discount := req.discount()
+	if err := h.discountService.CreateDiscount(ctx, discount); err != nil {
+		respondError(c, err, "Failed to create discount")
+		return
+	}
+
+	c.JSON(http.StatusCreated, discount)
+}
+
+// Update godoc
+// @Summary Replace a discount
+// @Description Replaces the whole rule. Orders already placed keep the discount they were given.
+// @Tags discounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Discount ID"
+// @Param discount body DiscountRequest true "Discount rule"
+// @Success 200 {object} domain.Discount
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/discounts/{id} [put]
+func (h *DiscountHandler) Update(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "DiscountHandler.Update")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid discount ID"})
+		return
+	}
+
+	var req DiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	discount := req.discount()
+	discount.ID = uint(id)
+	if err := h.discountService.UpdateDiscount(ctx, discount); err != nil {
+		respondError(c, err, "Failed to update discount")
+		return
+	}
+
+	c.JSON(http.StatusOK, discount)
+}
+
+// Delete godoc
+// @Summary Delete a discount
+// @Description Only discounts no order has used can be deleted, deactivate the others.
+// @Tags discounts
+// @Param id path int true "Discount ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/discounts/{id} [delete]
+func (h *DiscountHandler) Delete(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "DiscountHandler.Delete")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid discount ID"})
+		return
+	}
+
+	if err := h.discountService.DeleteDiscount(ctx, uint(id)); err != nil {
+		respondError(c, err, "Failed to delete discount")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
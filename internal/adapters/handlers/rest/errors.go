@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+// errorStatus maps service errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case domain.IsValidationError(err):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes err for client errors and the fallback message for
+// server errors, so internal details are not leaked to callers.
+func respondError(c *gin.Context, err error, fallback string) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, ErrorResponse{Error: fallback})
+		return
+	}
+	c.JSON(status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type ExchangeRateHandler struct {
+	rateService ports.ExchangeRateService
+}
+
+type SetExchangeRateRequest struct {
+	Currency string `json:"currency" binding:"required,len=3"`
+	// Rate is what one unit of the currency is worth in KES, as a decimal string like "129.45"
+	Rate string `json:"rate" binding:"required"`
+	// EffectiveFrom defaults to now and can't be in the past
+	EffectiveFrom time.Time `json:"effective_from"`
+	Note          string    `json:"note"`
+}
+
+func NewExchangeRateHandler(rs ports.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		rateService: rs,
+	}
+}
+
+// List godoc
+// @Summary Current exchange rates
+// @Description The rate in force now for each currency, as what one unit of it is worth in KES
+// @Tags exchange-rates
+// @Produce json
+// @Success 200 {array} domain.ExchangeRate
+// @Failure 500 {object} ErrorResponse
+// @Router /exchange-rates [get]
+func (h *ExchangeRateHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ExchangeRateHandler.List")
+	defer span.End()
+
+	rates, err := h.rateService.ListRates(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to fetch exchange rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// History godoc
+// @Summary Exchange rate history
+// @Description Every rate set for the currency, oldest first, including ones that are not in force yet
+// @Tags exchange-rates
+// @Produce json
+// @Param currency path string true "Currency code, e.g. USD"
+// @Success 200 {array} domain.ExchangeRate
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /exchange-rates/{currency}/history [get]
+func (h *ExchangeRateHandler) History(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ExchangeRateHandler.History")
+	defer span.End()
+
+	history, err := h.rateService.RateHistory(ctx, c.Param("currency"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch exchange rate history")
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// Set godoc
+// @Summary Set an exchange rate
+// @Description Adds a rate for the currency from effective_from on. Earlier rates are kept as history, orders placed at them keep the rate they were charged at.
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param rate body SetExchangeRateRequest true "Exchange rate"
+// @Success 201 {object} domain.ExchangeRate
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/exchange-rates [post]
+func (h *ExchangeRateHandler) Set(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ExchangeRateHandler.Set")
+	defer span.End()
+
+	var req SetExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rate := &domain.ExchangeRate{
+		Currency:      req.Currency,
+		Rate:          domain.Rate(req.Rate),
+		EffectiveFrom: req.EffectiveFrom,
+		Note:          req.Note,
+	}
+	if err := h.rateService.SetRate(ctx, rate); err != nil {
+		respondError(c, err, "Failed to set exchange rate")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
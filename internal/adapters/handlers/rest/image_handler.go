@@ -0,0 +1,208 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+// multipartOverhead leaves room for the form's boundaries and headers on top
+// of the image itself.
+const multipartOverhead = 64 << 10
+
+type ImageHandler struct {
+	imageService ports.ProductImageService
+	maxSize      int64
+}
+
+// NewImageHandler refuses uploads over maxSize bytes with 413,
+// domain.DefaultMaxImageSize when it is not positive.
+func NewImageHandler(is ports.ProductImageService, maxSize int64) *ImageHandler {
+	if maxSize <= 0 {
+		maxSize = domain.DefaultMaxImageSize
+	}
+	return &ImageHandler{
+		imageService: is,
+		maxSize:      maxSize,
+	}
+}
+
+// List godoc
+// @Summary List a product's images
+// @Tags images
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} domain.ProductImage
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images [get]
+func (h *ImageHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ImageHandler.List")
+	defer span.End()
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	images, err := h.imageService.ListImages(ctx, uint(productID))
+	if err != nil {
+		respondError(c, err, "Failed to fetch images")
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// Upload godoc
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG or GIF as the "image" field of a multipart form. The type is read from the file's content. A thumbnail is made and the image is added after the product's other images. The first image of a product is its primary image.
+// @Tags images
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param image formData file true "Image file"
+// @Param primary formData bool false "Make this the product's primary image"
+// @Success 201 {object} domain.ProductImage
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images [post]
+func (h *ImageHandler) Upload(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ImageHandler.Upload")
+	defer span.End()
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("image")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || err == nil && header.Size > h.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Image is too large"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Multipart form needs an image field"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	primary, _ := strconv.ParseBool(c.PostForm("primary"))
+
+	image, err := h.imageService.UploadImage(ctx, uint(productID), domain.ProductImageUpload{
+		Filename: header.Filename,
+		Data:     data,
+		Primary:  primary,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to upload image")
+		return
+	}
+
+	c.JSON(http.StatusCreated, image)
+}
+
+// Update godoc
+// @Summary Reorder an image or make it primary
+// @Description Moving an image to a position shifts the others along. Making an image primary takes the flag from the current primary image.
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Param image body domain.ProductImageUpdate true "Changes"
+// @Success 200 {object} domain.ProductImage
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images/{imageId} [patch]
+func (h *ImageHandler) Update(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ImageHandler.Update")
+	defer span.End()
+
+	productID, imageID, ok := imageIDs(c)
+	if !ok {
+		return
+	}
+
+	var update domain.ProductImageUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	image, err := h.imageService.UpdateImage(ctx, productID, imageID, update)
+	if err != nil {
+		respondError(c, err, "Failed to update image")
+		return
+	}
+
+	c.JSON(http.StatusOK, image)
+}
+
+// Delete godoc
+// @Summary Delete a product image
+// @Description The image and its thumbnail are removed from storage. When it was the primary image, the next one becomes primary.
+// @Tags images
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images/{imageId} [delete]
+func (h *ImageHandler) Delete(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ImageHandler.Delete")
+	defer span.End()
+
+	productID, imageID, ok := imageIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.imageService.DeleteImage(ctx, productID, imageID); err != nil {
+		respondError(c, err, "Failed to delete image")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// imageIDs reads the product and image IDs from the path, answering 400
+// when either is not a number.
+func imageIDs(c *gin.Context) (uint, uint, bool) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return 0, 0, false
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid image ID"})
+		return 0, 0, false
+	}
+	return uint(productID), uint(imageID), true
+}
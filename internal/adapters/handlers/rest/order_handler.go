@@ -2,7 +2,6 @@ package rest
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,28 +9,62 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
 	"go.opentelemetry.io/otel"
 )
 
 type OrderHandler struct {
 	orderService ports.OrderService
+	rateService  ports.ExchangeRateService
 }
 
 type OrderItem struct {
 	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	// VariantID is required for products that come in variants
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity"`
 }
 type OrderRequest struct {
 	Items []OrderItem `json:"items"`
+	// AddressID picks the shipping address from the address book, the default address is used when omitted
+	AddressID *uint `json:"address_id"`
+	// Currency to charge the order in, converted at today's rate. The products' currency is used when omitted.
+	Currency string `json:"currency" binding:"omitempty,len=3"`
+	// DiscountCodes are applied in the order given, after any automatic discounts
+	DiscountCodes []string `json:"discount_codes"`
+	// ShippingMethodID is required once any shipping method is active, see POST /shipping/quotes
+	ShippingMethodID *uint `json:"shipping_method_id"`
 }
 
+// UpdateOrderRequest is the lines the order should have, lines left out are removed.
 type UpdateOrderRequest struct {
-	Items []OrderItem `json:"items"`
+	Items  []OrderItem `json:"items" binding:"required"`
+	Reason string      `json:"reason"`
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type RefundLine struct {
+	OrderItemID uint `json:"order_item_id" binding:"required"`
+	Quantity    int  `json:"quantity" binding:"required,gt=0"`
+}
+
+type RefundOrderRequest struct {
+	// Lines to refund, everything not refunded yet and the shipping when omitted
+	Lines  []RefundLine `json:"lines" binding:"dive"`
+	Reason string       `json:"reason" binding:"required"`
+	// Restock puts the refunded units back into stock, true when omitted
+	Restock *bool `json:"restock"`
+	// StoreCredit gives the refund as store credit instead of paying it back
+	StoreCredit bool `json:"store_credit"`
 }
 
-func NewOrderHandler(os ports.OrderService) *OrderHandler {
+func NewOrderHandler(os ports.OrderService, rs ports.ExchangeRateService) *OrderHandler {
 	return &OrderHandler{
 		orderService: os,
+		rateService:  rs,
 	}
 }
 
@@ -44,6 +77,11 @@ func (h *OrderHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
 		return
 	}
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
 
 	ctx = context.WithValue(ctx, "email", email)
 	var req OrderRequest
@@ -52,40 +90,24 @@ func (h *OrderHandler) Create(c *gin.Context) {
 		return
 	}
 
-	var orderItems []domain.OrderItem
-	var totalOrderPrice float64
-	for _, item := range req.Items {
-		// Fetch product to get its price
-		product, err := h.orderService.GetOrderProduct(ctx, uint(item.ProductID))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error: fmt.Sprintf("Invalid product ID: %d", item.ProductID),
-			})
-			return
-		}
-
-		// Calculate item total price based on quantity
-		itemPrice := product.Price * float64(item.Quantity)
-
-		orderItems = append(orderItems, domain.OrderItem{
-			ProductID: uint(item.ProductID),
-			Quantity:  item.Quantity,
-			Price:     itemPrice, // Store the total price for this item
-		})
-
-		totalOrderPrice += itemPrice
+	orderItems, err := priceOrderItems(ctx, h.orderService, req.Items)
+	if err != nil {
+		respondError(c, err, "Failed to create order")
+		return
 	}
 
 	order := &domain.Order{
-		CustomerID: 1,
-		Items:      orderItems,
-		CreatedAt:  time.Now(),
-		TotalPrice: totalOrderPrice,
+		CustomerID:        customerID,
+		Items:             orderItems,
+		CreatedAt:         time.Now(),
+		ShippingAddressID: req.AddressID,
+		Currency:          req.Currency,
+		DiscountCodes:     req.DiscountCodes,
+		ShippingMethodID:  req.ShippingMethodID,
 	}
 
-	err := h.orderService.CreateOrder(ctx, order)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create order"})
+	if err := h.orderService.CreateOrder(ctx, order); err != nil {
+		respondError(c, err, "Failed to create order")
 		return
 	}
 
@@ -96,16 +118,62 @@ func (h *OrderHandler) Create(c *gin.Context) {
 	})
 }
 
+// priceOrderItems prices the requested items at the prices in force now, a
+// variant's own price winning over its product's.
+func priceOrderItems(ctx context.Context, orderService ports.OrderService, items []OrderItem) ([]domain.OrderItem, error) {
+	var orderItems []domain.OrderItem
+	for _, item := range items {
+		if item.Quantity < 1 {
+			return nil, domain.NewValidationError("Invalid quantity for product ID: %d", item.ProductID)
+		}
+
+		// Fetch product to get its price
+		product, err := orderService.GetOrderProduct(ctx, uint(item.ProductID))
+		if err != nil {
+			return nil, domain.NewValidationError("Invalid product ID: %d", item.ProductID)
+		}
+
+		unitPrice := product.Price
+		variant, err := orderService.GetOrderVariant(ctx, product.ID, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if variant != nil {
+			unitPrice = variant.EffectivePrice(product.Price)
+		}
+
+		orderItems = append(orderItems, domain.OrderItem{
+			ProductID: uint(item.ProductID),
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			Price:     unitPrice.Mul(int64(item.Quantity)), // Store the total price for this item
+		})
+	}
+	return orderItems, nil
+}
+
 func (h *OrderHandler) List(c *gin.Context) {
 	ctx, span := otel.Tracer("").Start(c.Request.Context(), "OrderHandler.List")
 	defer span.End()
 
-	orders, err := h.orderService.ListOrders(ctx)
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
+	var orders []domain.Order
+	var err error
+	if customerID, own := orderViewer(c); own {
+		orders, err = h.orderService.ListCustomerOrders(ctx, customerID)
+	} else {
+		orders, err = h.orderService.ListOrders(ctx)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch orders"})
 		return
 	}
 
+	displayOrders(rates, currency, orders)
 	c.JSON(http.StatusOK, orders)
 }
 
@@ -119,15 +187,27 @@ func (h *OrderHandler) Get(c *gin.Context) {
 		return
 	}
 
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
 	order, err := h.orderService.GetOrder(ctx, uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
 		return
 	}
+	// Customers only see their own orders
+	if customerID, own := orderViewer(c); own && order.CustomerID != customerID {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		return
+	}
 
+	displayOrder(rates, currency, order)
 	c.JSON(http.StatusOK, order)
 }
 
+// Update amends the order's lines while it is unpaid and still placed.
 func (h *OrderHandler) Update(c *gin.Context) {
 	ctx, span := otel.Tracer("").Start(c.Request.Context(), "OrderHandler.Update")
 	defer span.End()
@@ -144,22 +224,66 @@ func (h *OrderHandler) Update(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.GetOrder(ctx, uint(id))
+	request := domain.AmendRequest{Reason: req.Reason}
+	for _, item := range req.Items {
+		request.Lines = append(request.Lines, domain.AmendLineRequest{ProductID: uint(item.ProductID), VariantID: item.VariantID, Quantity: item.Quantity})
+	}
+	order, err := h.orderService.AmendOrder(ctx, uint(id), request)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		respondError(c, err, "Failed to update order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Archive takes the order off the order list. Orders are never deleted,
+// accounting needs them.
+func (h *OrderHandler) Archive(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "OrderHandler.Archive")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	if err := h.orderService.ArchiveOrder(ctx, uint(id)); err != nil {
+		respondError(c, err, "Failed to archive order")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrderHandler) Cancel(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "OrderHandler.Cancel")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
 		return
 	}
 
-	if err := h.orderService.UpdateOrder(ctx, order); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update order"})
+	var req CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	order, err := h.orderService.CancelOrder(ctx, uint(id), req.Reason)
+	if err != nil {
+		respondError(c, err, "Failed to cancel order")
 		return
 	}
 
 	c.JSON(http.StatusOK, order)
 }
 
-func (h *OrderHandler) Delete(c *gin.Context) {
-	ctx, span := otel.Tracer("").Start(c.Request.Context(), "OrderHandler.Delete")
+func (h *OrderHandler) Refund(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "OrderHandler.Refund")
 	defer span.End()
 
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -168,15 +292,35 @@ func (h *OrderHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderService.DeleteOrder(ctx, uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete order"})
+	var req RefundOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	if err := h.orderService.DeleteOrder(ctx, uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete order"})
+	request := domain.RefundRequest{Reason: req.Reason, Restock: req.Restock == nil || *req.Restock, StoreCredit: req.StoreCredit}
+	for _, line := range req.Lines {
+		request.Lines = append(request.Lines, domain.RefundLineRequest{OrderItemID: line.OrderItemID, Quantity: line.Quantity})
+	}
+	refund, err := h.orderService.RefundOrder(ctx, uint(id), request)
+	if err != nil {
+		respondError(c, err, "Failed to refund order")
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusCreated, refund)
+}
+
+// orderViewer reports whether the caller may only see their own orders, and
+// whose those are. Admins see every order, and so do API keys, which reach
+// the order routes only with the orders:read scope.
+func orderViewer(c *gin.Context) (uint, bool) {
+	actor, ok := auth.ActorFromContext(c.Request.Context())
+	if !ok {
+		return 0, true
+	}
+	if actor.Admin || actor.Type == auth.ActorAPIKey {
+		return 0, false
+	}
+	return actor.ID, true
 }
@@ -0,0 +1,174 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+// maxCallbackBytes caps the callback bodies read, provider callbacks are a few hundred bytes.
+const maxCallbackBytes = 64 << 10
+
+type PaymentHandler struct {
+	paymentService ports.PaymentService
+}
+
+type StartPaymentRequest struct {
+	// Phone to ask for the payment, in international format. The customer's own phone is used when omitted.
+	Phone string `json:"phone"`
+}
+
+// CallbackAck is the acknowledgement M-Pesa expects for a callback it posted.
+type CallbackAck struct {
+	ResultCode int    `json:"ResultCode"`
+	ResultDesc string `json:"ResultDesc"`
+}
+
+func NewPaymentHandler(ps ports.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: ps,
+	}
+}
+
+// Start godoc
+// @Summary Pay for an order
+// @Description Asks the customer to pay the order's total, with M-Pesa a prompt for their PIN on the phone. The payment is pending until the provider reports back, poll GET /orders/{id}/payments for the outcome.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param payment body StartPaymentRequest false "Phone to pay from"
+// @Success 201 {object} domain.PaymentIntent
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/payments [post]
+func (h *PaymentHandler) Start(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PaymentHandler.Start")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	var req StartPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	intent, err := h.paymentService.StartPayment(ctx, customerID, uint(id), req.Phone)
+	if err != nil {
+		respondError(c, err, "Failed to start payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, intent)
+}
+
+// List godoc
+// @Summary List an order's payments
+// @Description The customer's attempts at paying the order, oldest first
+// @Tags payments
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} domain.PaymentIntent
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/payments [get]
+func (h *PaymentHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PaymentHandler.List")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid order ID"})
+		return
+	}
+
+	intents, err := h.paymentService.ListOrderPayments(ctx, customerID, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch payments")
+		return
+	}
+
+	c.JSON(http.StatusOK, intents)
+}
+
+// Callback godoc
+// @Summary Payment provider callback
+// @Description Where the payment provider posts the outcome of a payment request. The token the callback URL was given is checked, and a result delivered twice is applied once.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param token query string true "Callback secret"
+// @Success 200 {object} CallbackAck
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /payments/callback [post]
+func (h *PaymentHandler) Callback(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PaymentHandler.Callback")
+	defer span.End()
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCallbackBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to read callback"})
+		return
+	}
+
+	if err := h.paymentService.HandleCallback(ctx, c.Query("token"), body); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Unknown payment request"})
+			return
+		}
+		respondError(c, err, "Failed to process callback")
+		return
+	}
+
+	c.JSON(http.StatusOK, CallbackAck{ResultCode: 0, ResultDesc: "Accepted"})
+}
+
+// Reconcile godoc
+// @Summary Reconcile stuck payments
+// @Description Asks the provider about payments whose callback hasn't come and times out the expired ones, as the background job does every minute
+// @Tags payments
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/payments/reconcile [post]
+func (h *PaymentHandler) Reconcile(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PaymentHandler.Reconcile")
+	defer span.End()
+
+	settled, err := h.paymentService.ReconcilePayments(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to reconcile payments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settled": settled})
+}
@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type PriceHandler struct {
+	priceService ports.ProductPriceService
+}
+
+func NewPriceHandler(ps ports.ProductPriceService) *PriceHandler {
+	return &PriceHandler{
+		priceService: ps,
+	}
+}
+
+// History godoc
+// @Summary Get a product's price history
+// @Description The product's prices over time, oldest first. Each period runs from effective_from until effective_to, the current one has no effective_to. Scheduled changes are included and marked upcoming.
+// @Tags prices
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} domain.ProductPrice
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/price-history [get]
+func (h *PriceHandler) History(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PriceHandler.History")
+	defer span.End()
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	history, err := h.priceService.PriceHistory(ctx, uint(productID))
+	if err != nil {
+		respondError(c, err, "Failed to fetch price history")
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// Schedule godoc
+// @Summary Schedule a price change
+// @Description Set a new price from a future time on. With effective_to the change is temporary, like a promotion, and the price in force before it comes back at effective_to. Returns the updated price history.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param change body domain.PriceChange true "Price change"
+// @Success 201 {array} domain.ProductPrice
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/products/{id}/price-changes [post]
+func (h *PriceHandler) Schedule(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PriceHandler.Schedule")
+	defer span.End()
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	var change domain.PriceChange
+	if err := c.ShouldBindJSON(&change); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	history, err := h.priceService.SchedulePriceChange(ctx, uint(productID), change)
+	if err != nil {
+		respondError(c, err, "Failed to schedule price change")
+		return
+	}
+
+	c.JSON(http.StatusCreated, history)
+}
+
+// Cancel godoc
+// @Summary Cancel a scheduled price change
+// @Description Only changes that have not started yet can be cancelled. The price before the change stays in force instead.
+// @Tags prices
+// @Param id path int true "Product ID"
+// @Param changeId path int true "Price period ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/products/{id}/price-changes/{changeId} [delete]
+func (h *PriceHandler) Cancel(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PriceHandler.Cancel")
+	defer span.End()
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+	changeID, err := strconv.ParseUint(c.Param("changeId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid price change ID"})
+		return
+	}
+
+	if err := h.priceService.CancelPriceChange(ctx, uint(productID), uint(changeID)); err != nil {
+		respondError(c, err, "Failed to cancel price change")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
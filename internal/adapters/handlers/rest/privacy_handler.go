@@ -0,0 +1,193 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"go.opentelemetry.io/otel"
+)
+
+type PrivacyHandler struct {
+	privacyService ports.PrivacyService
+}
+
+type ErasureRequestBody struct {
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+type RejectErasureRequest struct {
+	Note string `json:"note" binding:"required,max=1000"`
+}
+
+func NewPrivacyHandler(ps ports.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: ps,
+	}
+}
+
+// Export godoc
+// @Summary Export the current customer's data
+// @Description Download a JSON archive of the profile, addresses, orders and notifications sent
+// @Tags privacy
+// @Produce json
+// @Success 200 {object} domain.CustomerDataExport
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/export [get]
+func (h *PrivacyHandler) Export(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PrivacyHandler.Export")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	export, err := h.privacyService.ExportCustomerData(ctx, customerID)
+	if err != nil {
+		respondError(c, err, "Failed to export customer data")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="customer-%d-export.json"`, customerID))
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+// RequestErasure godoc
+// @Summary Ask for the current customer's data to be erased
+// @Description The request waits for an admin to approve it
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param request body ErasureRequestBody false "Reason for the request"
+// @Success 202 {object} domain.ErasureRequest
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /me/erasure-request [post]
+func (h *PrivacyHandler) RequestErasure(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PrivacyHandler.RequestErasure")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	var req ErasureRequestBody
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	request, err := h.privacyService.RequestErasure(ctx, customerID, req.Reason)
+	if err != nil {
+		respondError(c, err, "Failed to request erasure")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, request)
+}
+
+// ListErasureRequests godoc
+// @Summary List erasure requests
+// @Tags privacy
+// @Produce json
+// @Param status query string false "pending, rejected or completed"
+// @Success 200 {array} domain.ErasureRequest
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/erasure-requests [get]
+func (h *PrivacyHandler) ListErasureRequests(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PrivacyHandler.ListErasureRequests")
+	defer span.End()
+
+	requests, err := h.privacyService.ListErasureRequests(ctx, domain.ErasureStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch erasure requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// ApproveErasure godoc
+// @Summary Approve an erasure request
+// @Description Anonymizes the customer's personal data. Order amounts are kept.
+// @Tags privacy
+// @Produce json
+// @Param id path int true "Erasure request ID"
+// @Success 200 {object} domain.ErasureRequest
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/erasure-requests/{id}/approve [post]
+func (h *PrivacyHandler) ApproveErasure(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PrivacyHandler.ApproveErasure")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid erasure request ID"})
+		return
+	}
+
+	request, err := h.privacyService.ApproveErasure(ctx, uint(id), reviewerName(c))
+	if err != nil {
+		respondError(c, err, "Failed to approve erasure request")
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// RejectErasure godoc
+// @Summary Reject an erasure request
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param id path int true "Erasure request ID"
+// @Param request body RejectErasureRequest true "Reason shown to the customer"
+// @Success 200 {object} domain.ErasureRequest
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/erasure-requests/{id}/reject [post]
+func (h *PrivacyHandler) RejectErasure(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "PrivacyHandler.RejectErasure")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid erasure request ID"})
+		return
+	}
+
+	var req RejectErasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	request, err := h.privacyService.RejectErasure(ctx, uint(id), reviewerName(c), req.Note)
+	if err != nil {
+		respondError(c, err, "Failed to reject erasure request")
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+func reviewerName(c *gin.Context) string {
+	if actor, ok := auth.ActorFromContext(c.Request.Context()); ok {
+		return actor.String()
+	}
+	return ""
+}
@@ -2,7 +2,10 @@ package rest
 
 import (
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
@@ -12,29 +15,48 @@ import (
 
 type ProductHandler struct {
 	productService ports.ProductService
+	rateService    ports.ExchangeRateService
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required,min=3,max=255"`
-	Description string  `json:"description" binding:"required"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	CategoryID  uint    `json:"category_id" binding:"required"`
+	SKU         string `json:"sku" binding:"omitempty,max=64"`
+	Name        string `json:"name" binding:"required,min=3,max=255"`
+	Description string `json:"description" binding:"required"`
+	// Price is in minor units, e.g. {"amount": 1050, "currency": "KES"} for KES 10.50.
+	// The currency defaults to KES.
+	Price      domain.Money `json:"price" binding:"required"`
+	CategoryID uint         `json:"category_id" binding:"required"`
+	// Attributes are checked against the category's attribute definitions
+	Attributes domain.Attributes `json:"attributes"`
+	// WeightGrams and the dimensions in millimetres price weight based shipping
+	WeightGrams int `json:"weight_grams"`
+	LengthMM    int `json:"length_mm"`
+	WidthMM     int `json:"width_mm"`
+	HeightMM    int `json:"height_mm"`
 }
 
 type UpdateProductRequest struct {
-	Name        string  `json:"name" binding:"omitempty,min=3,max=255"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"omitempty,gt=0"`
-	CategoryID  uint    `json:"category_id"`
+	SKU         string        `json:"sku" binding:"omitempty,max=64"`
+	Name        string        `json:"name" binding:"omitempty,min=3,max=255"`
+	Description string        `json:"description"`
+	Price       *domain.Money `json:"price"`
+	CategoryID  uint          `json:"category_id"`
+	// Attributes replace all of the product's attributes when given
+	Attributes  domain.Attributes `json:"attributes"`
+	WeightGrams *int              `json:"weight_grams"`
+	LengthMM    *int              `json:"length_mm"`
+	WidthMM     *int              `json:"width_mm"`
+	HeightMM    *int              `json:"height_mm"`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
-func NewProductHandler(ps ports.ProductService) *ProductHandler {
+func NewProductHandler(ps ports.ProductService, rs ports.ExchangeRateService) *ProductHandler {
 	return &ProductHandler{
 		productService: ps,
+		rateService:    rs,
 	}
 }
 
@@ -60,13 +82,20 @@ func (h *ProductHandler) Create(c *gin.Context) {
 	}
 
 	product := &domain.Product{
-		Name:       req.Name,
-		Price:      req.Price,
-		CategoryID: req.CategoryID,
+		SKU:         req.SKU,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		CategoryID:  req.CategoryID,
+		Attributes:  req.Attributes,
+		WeightGrams: req.WeightGrams,
+		LengthMM:    req.LengthMM,
+		WidthMM:     req.WidthMM,
+		HeightMM:    req.HeightMM,
 	}
 
 	if err := h.productService.CreateProduct(ctx, product); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create product"})
+		respondError(c, err, "Failed to create product")
 		return
 	}
 
@@ -80,7 +109,10 @@ func (h *ProductHandler) Create(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param currency query string false "Also show the price converted to this currency, as display_price"
+// @Param Accept-Currency header string false "Same as currency, the query parameter wins"
 // @Success 200 {object} domain.Product
+// @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [get]
@@ -94,37 +126,142 @@ func (h *ProductHandler) Get(c *gin.Context) {
 		return
 	}
 
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
 	product, err := h.productService.GetProduct(ctx, uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Product not found"})
 		return
 	}
 
+	if rates != nil {
+		product.DisplayPrice = displayMoney(rates, product.Price, currency)
+	}
 	c.JSON(http.StatusOK, product)
 }
 
 // List godoc
-// @Summary List all products
-// @Description Get a list of all products
+// @Summary List products
+// @Description Get a page of products. Pass next_cursor from the response as cursor to get the following page.
 // @Tags products
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Product
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size, at most 100"
+// @Param sort query string false "price, name or created_at, prefixed with - for descending order (default -created_at)"
+// @Param category_id query int false "Only products in this category"
+// @Param include_descendants query bool false "Also include products in subcategories of category_id"
+// @Param min_price query string false "Minimum price in major units of currency, e.g. 10.50"
+// @Param max_price query string false "Maximum price in major units of currency, e.g. 10.50"
+// @Param currency query string false "Show prices converted to this currency as display_price, min_price and max_price are in it too (default KES)"
+// @Param Accept-Currency header string false "Same as currency, the query parameter wins"
+// @Param q query string false "Name contains"
+// @Param attr.name query string false "Attribute filter such as attr.ram_gb>=16 or attr.fabric=cotton, operators = != > >= < <="
+// @Success 200 {object} domain.ProductPage
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products [get]
 func (h *ProductHandler) List(c *gin.Context) {
 	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ProductHandler.List")
 	defer span.End()
 
-	products, err := h.productService.ListProducts(ctx)
+	rates, currency, ok := displayRates(c, h.rateService)
+	if !ok {
+		return
+	}
+
+	query, err := parseProductQuery(c, rates, currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	products, err := h.productService.ListProducts(ctx, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch products"})
+		respondError(c, err, "Failed to fetch products")
 		return
 	}
 
+	displayProducts(rates, currency, products.Products)
 	c.JSON(http.StatusOK, products)
 }
 
+// parseProductQuery reads the listing parameters. Price bounds given in a
+// display currency are converted to the catalog's currency to filter on.
+func parseProductQuery(c *gin.Context, rates domain.ExchangeRates, currency string) (domain.ProductQuery, error) {
+	query := domain.ProductQuery{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+		Name:   c.Query("q"),
+	}
+	query.Limit, _ = strconv.Atoi(c.Query("limit"))
+	query.IncludeDescendants, _ = strconv.ParseBool(c.Query("include_descendants"))
+
+	if raw := c.Query("category_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return query, domain.NewValidationError("invalid category_id")
+		}
+		query.CategoryID = uint(id)
+	}
+
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	for param, target := range map[string]**domain.Money{"min_price": &query.MinPrice, "max_price": &query.MaxPrice} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := domain.ParseMoney(raw, currency)
+		if err != nil {
+			return query, domain.NewValidationError(param + ": " + err.Error())
+		}
+		if rates != nil {
+			if value, err = rates.Convert(value, domain.DefaultCurrency); err != nil {
+				return query, err
+			}
+		}
+		*target = &value
+	}
+
+	filters, err := parseAttributeFilters(c.Request.URL.RawQuery)
+	if err != nil {
+		return query, err
+	}
+	query.Attributes = filters
+
+	return query, nil
+}
+
+var attributeFilterPattern = regexp.MustCompile(`^attr\.([^=!<>]+)(!=|>=|<=|=|>|<)(.*)$`)
+
+// parseAttributeFilters reads filters like attr.ram_gb>=16 from the raw query
+// string. They can't be read as ordinary parameters, since the = that
+// separates a parameter from its value is part of the operator.
+func parseAttributeFilters(rawQuery string) ([]domain.AttributeFilter, error) {
+	var filters []domain.AttributeFilter
+	for _, part := range strings.Split(rawQuery, "&") {
+		part, err := url.QueryUnescape(part)
+		if err != nil || !strings.HasPrefix(part, "attr.") {
+			continue
+		}
+		match := attributeFilterPattern.FindStringSubmatch(part)
+		if match == nil {
+			return nil, domain.NewValidationError("invalid attribute filter %q, expected e.g. attr.ram_gb>=16", part)
+		}
+		filters = append(filters, domain.AttributeFilter{
+			Name:     match[1],
+			Operator: domain.AttributeOperator(match[2]),
+			Value:    match[3],
+		})
+	}
+	return filters, nil
+}
+
 // Update godoc
 // @Summary Update a product
 // @Description Update a product's details
@@ -161,18 +298,39 @@ func (h *ProductHandler) Update(c *gin.Context) {
 	}
 
 	// Update only provided fields
+	if req.SKU != "" {
+		product.SKU = req.SKU
+	}
 	if req.Name != "" {
 		product.Name = req.Name
 	}
-	if req.Price > 0 {
-		product.Price = req.Price
+	if req.Description != "" {
+		product.Description = req.Description
+	}
+	if req.Price != nil {
+		product.Price = *req.Price
 	}
 	if req.CategoryID > 0 {
 		product.CategoryID = req.CategoryID
 	}
+	if req.Attributes != nil {
+		product.Attributes = req.Attributes
+	}
+	if req.WeightGrams != nil {
+		product.WeightGrams = *req.WeightGrams
+	}
+	if req.LengthMM != nil {
+		product.LengthMM = *req.LengthMM
+	}
+	if req.WidthMM != nil {
+		product.WidthMM = *req.WidthMM
+	}
+	if req.HeightMM != nil {
+		product.HeightMM = *req.HeightMM
+	}
 
 	if err := h.productService.UpdateProduct(ctx, product); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update product"})
+		respondError(c, err, "Failed to update product")
 		return
 	}
 
@@ -181,13 +339,14 @@ func (h *ProductHandler) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete a product
-// @Description Delete a product by ID
+// @Description Move a product to the trash. It stops being listed and ordered, but past orders still show it.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [delete]
 func (h *ProductHandler) Delete(c *gin.Context) {
@@ -201,13 +360,26 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.productService.DeleteProduct(ctx, uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete product"})
+		respondError(c, err, "Failed to delete product")
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// GetAveragePriceByCategory godoc
+// @Summary Average price in a category
+// @Description With by=variant every variant counts at its own price, products without variants at theirs
+// @Tags products
+// @Produce json
+// @Param categoryId path int true "Category ID"
+// @Param by query string false "product (default) or variant"
+// @Param currency query string false "Currency to give the average in, prices in other currencies are converted at today's rates (default KES)"
+// @Param Accept-Currency header string false "Same as currency, the query parameter wins"
+// @Success 200 {object} domain.Money
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /categories/{categoryId}/average-price [get]
 func (h *ProductHandler) GetAveragePriceByCategory(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("categoryId"), 10, 32)
 
@@ -216,9 +388,19 @@ func (h *ProductHandler) GetAveragePriceByCategory(c *gin.Context) {
 		return
 	}
 
-	avgPrice, err := h.productService.GetAverageCategoryPrice(c.Request.Context(), uint(id))
+	var byVariant bool
+	switch c.DefaultQuery("by", "product") {
+	case "product":
+	case "variant":
+		byVariant = true
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "by must be product or variant"})
+		return
+	}
+
+	avgPrice, err := h.productService.GetAverageCategoryPrice(c.Request.Context(), uint(id), byVariant, requestedCurrency(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request"})
+		respondError(c, err, "Failed to compute average price")
 		return
 	}
 
@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+const maxImportSize = 100 << 20
+
+// Import godoc
+// @Summary Import products
+// @Description Upsert products by SKU from a CSV or NDJSON file, sent either as the request body or as the "file" field of a multipart form.
+// @Description CSV files need a header with sku, name, price and category. Categories are name paths such as "Electronics > Phones" and are created when missing.
+// @Tags products
+// @Accept text/csv,application/x-ndjson,multipart/form-data
+// @Produce json
+// @Param format query string false "csv or ndjson, detected from the content type or file name when left out"
+// @Param dry_run query bool false "Validate and report without saving anything"
+// @Success 200 {object} domain.ProductImportReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/import [post]
+func (h *ProductHandler) Import(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ProductHandler.Import")
+	defer span.End()
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	format := domain.ImportFormat(strings.ToLower(c.Query("format")))
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	body, filename, err := importBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if format == "" {
+		format = detectImportFormat(c.ContentType(), filename)
+	}
+
+	report, err := h.productService.ImportProducts(ctx, body, format, dryRun)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Import file is too large"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to import products")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// importBody returns the uploaded file without buffering it, either the raw
+// request body or the "file" part of a multipart form.
+func importBody(c *gin.Context) (io.Reader, string, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, "", nil
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", errors.New("multipart form has no file field")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() == "file" {
+			return part, part.FileName(), nil
+		}
+	}
+}
+
+func detectImportFormat(contentType, filename string) domain.ImportFormat {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return domain.ImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return domain.ImportFormatNDJSON
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return domain.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return domain.ImportFormatNDJSON
+	}
+	return ""
+}
+
+// Export godoc
+// @Summary Export products
+// @Description Stream the catalog as CSV or NDJSON with the same columns the importer reads, so the file can be edited and imported again.
+// @Tags products
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "csv (default) or ndjson"
+// @Param category_id query int false "Only export this category and its subcategories"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/export [get]
+func (h *ProductHandler) Export(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ProductHandler.Export")
+	defer span.End()
+
+	format := domain.ImportFormat(strings.ToLower(c.DefaultQuery("format", string(domain.ImportFormatCSV))))
+
+	var filter domain.ProductExportFilter
+	if raw := c.Query("category_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+			return
+		}
+		filter.CategoryID = uint(id)
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == domain.ImportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="products.`+string(format)+`"`)
+
+	err := h.productService.ExportProducts(ctx, c.Writer, format, filter)
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	if c.Writer.Written() {
+		// The status line is already out, all that can be done is to cut the stream short
+		log.Printf("product export failed part way: %v", err)
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	respondError(c, err, "Failed to export products")
+}
@@ -0,0 +1,462 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type ReturnHandler struct {
+	returnService ports.ReturnService
+	maxPhotoSize  int64
+}
+
+type ReturnLine struct {
+	OrderItemID uint   `json:"order_item_id" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,gt=0"`
+	Reason      string `json:"reason" binding:"required"`
+}
+
+type OpenReturnRequest struct {
+	OrderID uint         `json:"order_id" binding:"required"`
+	Lines   []ReturnLine `json:"lines" binding:"required,min=1,dive"`
+	Comment string       `json:"comment"`
+}
+
+type ApproveReturnRequest struct {
+	// Note tells the customer how to send the goods back
+	Note string `json:"note"`
+}
+
+type RejectReturnRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+type ReceiveReturnRequest struct {
+	// Restock puts the returned units back into stock, true when omitted
+	Restock *bool `json:"restock"`
+}
+
+type ResolveReturnRequest struct {
+	Resolution domain.ReturnResolution `json:"resolution" binding:"required,oneof=refund store_credit"`
+}
+
+// NewReturnHandler refuses photos over maxPhotoSize bytes with 413,
+// domain.DefaultMaxImageSize when it is not positive.
+func NewReturnHandler(rs ports.ReturnService, maxPhotoSize int64) *ReturnHandler {
+	if maxPhotoSize <= 0 {
+		maxPhotoSize = domain.DefaultMaxImageSize
+	}
+	return &ReturnHandler{
+		returnService: rs,
+		maxPhotoSize:  maxPhotoSize,
+	}
+}
+
+// Open godoc
+// @Summary Ask to return items
+// @Description Opens a return for units of one of the customer's paid orders, each line with a reason. Units already refunded or on another open return can't be returned. Staff review the return and the customer is told of every step.
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param return body OpenReturnRequest true "What to return"
+// @Success 201 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/returns [post]
+func (h *ReturnHandler) Open(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.Open")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	var req OpenReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	request := domain.OpenReturnRequest{OrderID: req.OrderID, Comment: req.Comment}
+	for _, line := range req.Lines {
+		request.Lines = append(request.Lines, domain.ReturnLineRequest{OrderItemID: line.OrderItemID, Quantity: line.Quantity, Reason: line.Reason})
+	}
+	ret, err := h.returnService.OpenReturn(ctx, customerID, request)
+	if err != nil {
+		respondError(c, err, "Failed to open return")
+		return
+	}
+
+	c.JSON(http.StatusCreated, ret)
+}
+
+// ListMine godoc
+// @Summary List my returns
+// @Tags returns
+// @Produce json
+// @Success 200 {array} domain.OrderReturn
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/returns [get]
+func (h *ReturnHandler) ListMine(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.ListMine")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	returns, err := h.returnService.ListCustomerReturns(ctx, customerID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch returns")
+		return
+	}
+
+	c.JSON(http.StatusOK, returns)
+}
+
+// GetMine godoc
+// @Summary Get one of my returns
+// @Tags returns
+// @Produce json
+// @Param id path int true "Return ID"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/returns/{id} [get]
+func (h *ReturnHandler) GetMine(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.GetMine")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid return ID"})
+		return
+	}
+
+	ret, err := h.returnService.GetCustomerReturn(ctx, customerID, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch return")
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// AddPhoto godoc
+// @Summary Add a photo to my return
+// @Description Upload a JPEG, PNG or GIF of the goods as the "photo" field of a multipart form, while the return hasn't been received. A return has at most 5 photos.
+// @Tags returns
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param photo formData file true "Photo file"
+// @Success 201 {object} domain.ReturnPhoto
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/returns/{id}/photos [post]
+func (h *ReturnHandler) AddPhoto(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.AddPhoto")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid return ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxPhotoSize+multipartOverhead)
+	header, err := c.FormFile("photo")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || err == nil && header.Size > h.maxPhotoSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Photo is too large"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Multipart form needs a photo field"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	photo, err := h.returnService.AddReturnPhoto(ctx, customerID, uint(id), domain.ReturnPhotoUpload{
+		Filename: header.Filename,
+		Data:     data,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to add photo")
+		return
+	}
+
+	c.JSON(http.StatusCreated, photo)
+}
+
+// StoreCredit godoc
+// @Summary Get my store credit
+// @Description The balance in each currency credited and the entries adding up to it, oldest first
+// @Tags returns
+// @Produce json
+// @Success 200 {object} domain.StoreCreditAccount
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /me/store-credit [get]
+func (h *ReturnHandler) StoreCredit(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.StoreCredit")
+	defer span.End()
+
+	customerID, ok := currentCustomerID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required"})
+		return
+	}
+
+	account, err := h.returnService.GetStoreCredit(ctx, customerID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch store credit")
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// List godoc
+// @Summary List returns
+// @Tags returns
+// @Produce json
+// @Param status query string false "requested, approved, rejected, received, refunded or credited"
+// @Success 200 {array} domain.OrderReturn
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/returns [get]
+func (h *ReturnHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.List")
+	defer span.End()
+
+	returns, err := h.returnService.ListReturns(ctx, domain.ReturnStatus(c.Query("status")))
+	if err != nil {
+		respondError(c, err, "Failed to fetch returns")
+		return
+	}
+
+	c.JSON(http.StatusOK, returns)
+}
+
+// Get godoc
+// @Summary Get a return
+// @Tags returns
+// @Produce json
+// @Param id path int true "Return ID"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/returns/{id} [get]
+func (h *ReturnHandler) Get(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.Get")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid return ID"})
+		return
+	}
+
+	ret, err := h.returnService.GetReturn(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch return")
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// Approve godoc
+// @Summary Approve a return
+// @Description Accepts a requested return and tells the customer to send the goods back, with the note when given
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param approval body ApproveReturnRequest false "Note for the customer"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/returns/{id}/approve [post]
+func (h *ReturnHandler) Approve(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.Approve")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid return ID"})
+		return
+	}
+
+	var req ApproveReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ret, err := h.returnService.ApproveReturn(ctx, uint(id), req.Note)
+	if err != nil {
+		respondError(c, err, "Failed to approve return")
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// Reject godoc
+// @Summary Reject a return
+// @Description Turns down a return that hasn't been received, the note telling the customer why
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param rejection body RejectReturnRequest true "Why the return is rejected"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/returns/{id}/reject [post]
+func (h *ReturnHandler) Reject(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.Reject")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid return ID"})
+		return
+	}
+
+	var req RejectReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ret, err := h.returnService.RejectReturn(ctx, uint(id), req.Note)
+	if err != nil {
+		respondError(c, err, "Failed to reject return")
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// Receive godoc
+// @Summary Receive a return's goods
+// @Description Records that the goods of an approved return came back, putting them back into stock unless restock is false
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param receipt body ReceiveReturnRequest false "Whether to restock"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/returns/{id}/receive [post]
+func (h *ReturnHandler) Receive(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.Receive")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid return ID"})
+		return
+	}
+
+	var req ReceiveReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ret, err := h.returnService.ReceiveReturn(ctx, uint(id), req.Restock == nil || *req.Restock)
+	if err != nil {
+		respondError(c, err, "Failed to receive return")
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
+
+// Resolve godoc
+// @Summary Refund or credit a return
+// @Description Pays the customer back for a received return, what they paid for the units after discounts and with tax. A refund goes back through the payment provider, store credit is added to the customer's balance.
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param id path int true "Return ID"
+// @Param resolution body ResolveReturnRequest true "refund or store_credit"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/returns/{id}/resolve [post]
+func (h *ReturnHandler) Resolve(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ReturnHandler.Resolve")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid return ID"})
+		return
+	}
+
+	var req ResolveReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ret, err := h.returnService.ResolveReturn(ctx, uint(id), req.Resolution)
+	if err != nil {
+		respondError(c, err, "Failed to resolve return")
+		return
+	}
+
+	c.JSON(http.StatusOK, ret)
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type SearchHandler struct {
+	searchService ports.ProductSearchService
+}
+
+func NewSearchHandler(ss ports.ProductSearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: ss,
+	}
+}
+
+// Search godoc
+// @Summary Search products
+// @Description Full-text search over product names and category names, best match first. Small typos are tolerated.
+// @Tags products
+// @Produce json
+// @Param q query string true "Search text, supports quoted phrases and -excluded words"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size, at most 100"
+// @Success 200 {object} domain.ProductSearchPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "SearchHandler.Search")
+	defer span.End()
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	results, err := h.searchService.SearchProducts(ctx, domain.ProductSearchQuery{
+		Query:    c.Query("q"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to search products")
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// Suggest godoc
+// @Summary Suggest products while typing
+// @Description Complete a partially typed search, matching the last word as a prefix
+// @Tags products
+// @Produce json
+// @Param q query string true "What has been typed so far"
+// @Param limit query int false "Number of suggestions, at most 20"
+// @Success 200 {array} domain.ProductSuggestion
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/search/suggest [get]
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "SearchHandler.Suggest")
+	defer span.End()
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	suggestions, err := h.searchService.SuggestProducts(ctx, c.Query("q"), limit)
+	if err != nil {
+		respondError(c, err, "Failed to suggest products")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,279 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type ShippingHandler struct {
+	shippingService ports.ShippingService
+	orderService    ports.OrderService
+	cartService     ports.CartService
+}
+
+type ShippingMethodRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description"`
+	// Type is flat_rate, weight_based or zone_based
+	Type domain.ShippingMethodType `json:"type" binding:"required"`
+	// Fee is in minor units, the flat rate or the base fee of a weight based method. The currency defaults to KES.
+	Fee domain.Money `json:"fee"`
+	// PerKg is charged for every started kilogram above included_weight_grams
+	PerKg          domain.Money `json:"per_kg"`
+	IncludedWeight int          `json:"included_weight_grams"`
+	// MaxWeight is the heaviest order a weight based method takes, 0 for no limit
+	MaxWeight int `json:"max_weight_grams"`
+	// Zones of a zone based method, the first zone that covers the address's county sets the fee
+	Zones []domain.ShippingZone `json:"zones"`
+	// FreeOver makes shipping free for orders whose items are worth this much after discounts
+	FreeOver domain.Money `json:"free_over"`
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+type ShippingQuoteRequest struct {
+	// Items quote an order draft, the caller's cart is quoted when they are left out
+	Items []OrderItem `json:"items"`
+	// AddressID picks an address from the address book, the default address is used when neither it nor address is given
+	AddressID *uint `json:"address_id"`
+	// Address quotes delivery to any address, only its county matters to zone based methods
+	Address *domain.ShippingAddress `json:"address"`
+	// Currency to quote in, the products' currency when omitted
+	Currency      string   `json:"currency" binding:"omitempty,len=3"`
+	DiscountCodes []string `json:"discount_codes"`
+}
+
+func NewShippingHandler(ss ports.ShippingService, os ports.OrderService, cs ports.CartService) *ShippingHandler {
+	return &ShippingHandler{
+		shippingService: ss,
+		orderService:    os,
+		cartService:     cs,
+	}
+}
+
+func (r ShippingMethodRequest) method() *domain.ShippingMethod {
+	active := r.Active == nil || *r.Active
+	return &domain.ShippingMethod{
+		Name:           r.Name,
+		Description:    r.Description,
+		Type:           r.Type,
+		Fee:            r.Fee,
+		PerKg:          r.PerKg,
+		IncludedWeight: r.IncludedWeight,
+		MaxWeight:      r.MaxWeight,
+		Zones:          r.Zones,
+		FreeOver:       r.FreeOver,
+		Active:         active,
+	}
+}
+
+// Quote godoc
+// @Summary Quote shipping
+// @Description What each active shipping method charges to deliver the items, or the caller's cart, to the address, cheapest first. Methods that can't deliver there are left out. Works without credentials, send X-Cart-Token to quote an anonymous cart.
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Token of an anonymous cart"
+// @Param quote body ShippingQuoteRequest true "Order draft and address"
+// @Success 200 {array} domain.ShippingQuote
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /shipping/quotes [post]
+func (h *ShippingHandler) Quote(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ShippingHandler.Quote")
+	defer span.End()
+
+	var req ShippingQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	owner := cartOwner(c)
+	if req.AddressID != nil && owner.CustomerID == 0 {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Customer login required to use address_id"})
+		return
+	}
+
+	var quotes []domain.ShippingQuote
+	var err error
+	if len(req.Items) == 0 {
+		quotes, err = h.cartService.QuoteShipping(ctx, owner, domain.CartCheckout{
+			AddressID:     req.AddressID,
+			Currency:      req.Currency,
+			DiscountCodes: req.DiscountCodes,
+		}, req.Address)
+	} else {
+		var items []domain.OrderItem
+		if items, err = priceOrderItems(ctx, h.orderService, req.Items); err == nil {
+			order := &domain.Order{
+				CustomerID:        owner.CustomerID,
+				Items:             items,
+				ShippingAddressID: req.AddressID,
+				Currency:          req.Currency,
+				DiscountCodes:     req.DiscountCodes,
+			}
+			if req.Address != nil {
+				order.ShippingAddress = *req.Address
+			}
+			quotes, err = h.orderService.QuoteShipping(ctx, order)
+		}
+	}
+	if err != nil {
+		respondError(c, err, "Failed to quote shipping")
+		return
+	}
+
+	c.JSON(http.StatusOK, quotes)
+}
+
+// List godoc
+// @Summary List shipping methods
+// @Tags shipping
+// @Produce json
+// @Success 200 {array} domain.ShippingMethod
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/shipping-methods [get]
+func (h *ShippingHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ShippingHandler.List")
+	defer span.End()
+
+	methods, err := h.shippingService.ListShippingMethods(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to fetch shipping methods")
+		return
+	}
+
+	c.JSON(http.StatusOK, methods)
+}
+
+// Get godoc
+// @Summary Get a shipping method
+// @Tags shipping
+// @Produce json
+// @Param id path int true "Shipping method ID"
+// @Success 200 {object} domain.ShippingMethod
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/shipping-methods/{id} [get]
+func (h *ShippingHandler) Get(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ShippingHandler.Get")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid shipping method ID"})
+		return
+	}
+
+	method, err := h.shippingService.GetShippingMethod(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch shipping method")
+		return
+	}
+
+	c.JSON(http.StatusOK, method)
+}
+
+// Create godoc
+// @Summary Create a shipping method
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param method body ShippingMethodRequest true "Shipping method"
+// @Success 201 {object} domain.ShippingMethod
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/shipping-methods [post]
+func (h *ShippingHandler) Create(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ShippingHandler.Create")
+	defer span.End()
+
+	var req ShippingMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	method := req.method()
+	if err := h.shippingService.CreateShippingMethod(ctx, method); err != nil {
+		respondError(c, err, "Failed to create shipping method")
+		return
+	}
+
+	c.JSON(http.StatusCreated, method)
+}
+
+// Update godoc
+// @Summary Replace a shipping method
+// @Description Orders already placed keep the method's name and cost at the time
+// @Tags shipping
+// @Accept json
+// @Produce json
+// @Param id path int true "Shipping method ID"
+// @Param method body ShippingMethodRequest true "Shipping method"
+// @Success 200 {object} domain.ShippingMethod
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/shipping-methods/{id} [put]
+func (h *ShippingHandler) Update(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ShippingHandler.Update")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid shipping method ID"})
+		return
+	}
+
+	var req ShippingMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	method := req.method()
+	method.ID = uint(id)
+	if err := h.shippingService.UpdateShippingMethod(ctx, method); err != nil {
+		respondError(c, err, "Failed to update shipping method")
+		return
+	}
+
+	c.JSON(http.StatusOK, method)
+}
+
+// Delete godoc
+// @Summary Delete a shipping method
+// @Description Orders already placed keep the method's name and cost
+// @Tags shipping
+// @Param id path int true "Shipping method ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/shipping-methods/{id} [delete]
+func (h *ShippingHandler) Delete(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "ShippingHandler.Delete")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid shipping method ID"})
+		return
+	}
+
+	if err := h.shippingService.DeleteShippingMethod(ctx, uint(id)); err != nil {
+		respondError(c, err, "Failed to delete shipping method")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,332 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type TaxHandler struct {
+	taxService ports.TaxService
+}
+
+type TaxClassRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description"`
+	// IsDefault makes this the class of products that neither they nor their categories give one
+	IsDefault bool `json:"is_default"`
+}
+
+type SetTaxRateRequest struct {
+	// Percent is a decimal string like "16", "0" for zero rated goods
+	Percent string `json:"percent" binding:"required"`
+	// EffectiveFrom defaults to now and can't be in the past
+	EffectiveFrom time.Time `json:"effective_from"`
+	Note          string    `json:"note"`
+}
+
+type AssignTaxClassRequest struct {
+	// TaxClassID null removes the assignment, so the class is inherited again
+	TaxClassID *uint `json:"tax_class_id"`
+}
+
+func NewTaxHandler(ts ports.TaxService) *TaxHandler {
+	return &TaxHandler{
+		taxService: ts,
+	}
+}
+
+// ListClasses godoc
+// @Summary List tax classes
+// @Description Each class with the rate in force now
+// @Tags tax
+// @Produce json
+// @Success 200 {array} domain.TaxClass
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/tax-classes [get]
+func (h *TaxHandler) ListClasses(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.ListClasses")
+	defer span.End()
+
+	classes, err := h.taxService.ListTaxClasses(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to fetch tax classes")
+		return
+	}
+
+	c.JSON(http.StatusOK, classes)
+}
+
+// GetClass godoc
+// @Summary Get a tax class
+// @Tags tax
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Success 200 {object} domain.TaxClass
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/tax-classes/{id} [get]
+func (h *TaxHandler) GetClass(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.GetClass")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid tax class ID"})
+		return
+	}
+
+	class, err := h.taxService.GetTaxClass(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch tax class")
+		return
+	}
+
+	c.JSON(http.StatusOK, class)
+}
+
+// CreateClass godoc
+// @Summary Create a tax class
+// @Description A class has no rate, and taxes nothing, until one is set
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param class body TaxClassRequest true "Tax class"
+// @Success 201 {object} domain.TaxClass
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/tax-classes [post]
+func (h *TaxHandler) CreateClass(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.CreateClass")
+	defer span.End()
+
+	var req TaxClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	class := &domain.TaxClass{Name: req.Name, Description: req.Description, IsDefault: req.IsDefault}
+	if err := h.taxService.CreateTaxClass(ctx, class); err != nil {
+		respondError(c, err, "Failed to create tax class")
+		return
+	}
+
+	c.JSON(http.StatusCreated, class)
+}
+
+// UpdateClass godoc
+// @Summary Update a tax class
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Param class body TaxClassRequest true "Tax class"
+// @Success 200 {object} domain.TaxClass
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/tax-classes/{id} [put]
+func (h *TaxHandler) UpdateClass(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.UpdateClass")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid tax class ID"})
+		return
+	}
+
+	var req TaxClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	class := &domain.TaxClass{ID: uint(id), Name: req.Name, Description: req.Description, IsDefault: req.IsDefault}
+	if err := h.taxService.UpdateTaxClass(ctx, class); err != nil {
+		respondError(c, err, "Failed to update tax class")
+		return
+	}
+
+	c.JSON(http.StatusOK, class)
+}
+
+// DeleteClass godoc
+// @Summary Delete a tax class
+// @Description Removes the class and its rates. Products and categories that had it inherit their class again, orders keep the tax they were charged.
+// @Tags tax
+// @Param id path int true "Tax class ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/tax-classes/{id} [delete]
+func (h *TaxHandler) DeleteClass(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.DeleteClass")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid tax class ID"})
+		return
+	}
+
+	if err := h.taxService.DeleteTaxClass(ctx, uint(id)); err != nil {
+		respondError(c, err, "Failed to delete tax class")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RateHistory godoc
+// @Summary Tax rate history
+// @Description Every rate set for the class, oldest first, including ones that are not in force yet
+// @Tags tax
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Success 200 {array} domain.TaxRate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/tax-classes/{id}/rates [get]
+func (h *TaxHandler) RateHistory(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.RateHistory")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid tax class ID"})
+		return
+	}
+
+	rates, err := h.taxService.TaxRateHistory(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to fetch tax rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetRate godoc
+// @Summary Set a tax rate
+// @Description Adds a rate for the class from effective_from on. Earlier rates are kept as history.
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax class ID"
+// @Param rate body SetTaxRateRequest true "Tax rate"
+// @Success 201 {object} domain.TaxRate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/tax-classes/{id}/rates [post]
+func (h *TaxHandler) SetRate(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.SetRate")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid tax class ID"})
+		return
+	}
+
+	var req SetTaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rate := &domain.TaxRate{
+		TaxClassID:    uint(id),
+		Percent:       domain.Rate(req.Percent),
+		EffectiveFrom: req.EffectiveFrom,
+		Note:          req.Note,
+	}
+	if err := h.taxService.SetTaxRate(ctx, rate); err != nil {
+		respondError(c, err, "Failed to set tax rate")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// AssignCategory godoc
+// @Summary Set a category's tax class
+// @Description Subcategories and products without a class of their own inherit it
+// @Tags tax
+// @Accept json
+// @Param id path int true "Category ID"
+// @Param class body AssignTaxClassRequest true "Tax class"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/categories/{id}/tax-class [put]
+func (h *TaxHandler) AssignCategory(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.AssignCategory")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		return
+	}
+
+	var req AssignTaxClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.taxService.AssignCategoryTaxClass(ctx, uint(id), req.TaxClassID); err != nil {
+		respondError(c, err, "Failed to set tax class")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AssignProduct godoc
+// @Summary Set a product's tax class
+// @Description Overrides the class the product would inherit from its category
+// @Tags tax
+// @Accept json
+// @Param id path int true "Product ID"
+// @Param class body AssignTaxClassRequest true "Tax class"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/products/{id}/tax-class [put]
+func (h *TaxHandler) AssignProduct(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TaxHandler.AssignProduct")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	var req AssignTaxClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.taxService.AssignProductTaxClass(ctx, uint(id), req.TaxClassID); err != nil {
+		respondError(c, err, "Failed to set tax class")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
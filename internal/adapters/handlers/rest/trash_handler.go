@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type TrashHandler struct {
+	trashService ports.TrashService
+}
+
+func NewTrashHandler(ts ports.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: ts,
+	}
+}
+
+// List godoc
+// @Summary List the trash
+// @Description Deleted products and categories, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Success 200 {object} domain.Trash
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/trash [get]
+func (h *TrashHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TrashHandler.List")
+	defer span.End()
+
+	trash, err := h.trashService.ListTrash(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to fetch trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
+
+// RestoreProduct godoc
+// @Summary Restore a deleted product
+// @Description The product's category must not be deleted
+// @Tags trash
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} domain.Product
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/trash/products/{id}/restore [post]
+func (h *TrashHandler) RestoreProduct(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TrashHandler.RestoreProduct")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	product, err := h.trashService.RestoreProduct(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to restore product")
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// RestoreCategory godoc
+// @Summary Restore a deleted category
+// @Description The parent category must not be deleted
+// @Tags trash
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} domain.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/trash/categories/{id}/restore [post]
+func (h *TrashHandler) RestoreCategory(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TrashHandler.RestoreCategory")
+	defer span.End()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		return
+	}
+
+	category, err := h.trashService.RestoreCategory(ctx, uint(id))
+	if err != nil {
+		respondError(c, err, "Failed to restore category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// Purge godoc
+// @Summary Purge the trash
+// @Description Permanently remove items deleted longer ago than the retention period. Products on past orders are kept.
+// @Tags trash
+// @Produce json
+// @Success 200 {object} domain.TrashPurgeSummary
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/trash/purge [post]
+func (h *TrashHandler) Purge(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "TrashHandler.Purge")
+	defer span.End()
+
+	summary, err := h.trashService.PurgeTrash(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to purge trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
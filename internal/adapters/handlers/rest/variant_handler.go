@@ -0,0 +1,218 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type VariantHandler struct {
+	variantService ports.ProductVariantService
+}
+
+type ProductVariantRequest struct {
+	SKU string `json:"sku" binding:"omitempty,max=64"`
+	// Price overrides the product's price, the product's price is used when omitted.
+	// The currency defaults to the product's.
+	Price   *domain.Money          `json:"price"`
+	Stock   int                    `json:"stock" binding:"gte=0"`
+	Options []domain.VariantOption `json:"options" binding:"required,min=1"`
+}
+
+func NewVariantHandler(vs ports.ProductVariantService) *VariantHandler {
+	return &VariantHandler{
+		variantService: vs,
+	}
+}
+
+func (r ProductVariantRequest) toVariant() *domain.ProductVariant {
+	return &domain.ProductVariant{
+		SKU:     r.SKU,
+		Price:   r.Price,
+		Stock:   r.Stock,
+		Options: r.Options,
+	}
+}
+
+// List godoc
+// @Summary List a product's variants
+// @Description The options the product varies by, with their values, and its variants
+// @Tags variants
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} domain.ProductVariants
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants [get]
+func (h *VariantHandler) List(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "VariantHandler.List")
+	defer span.End()
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	variants, err := h.variantService.ListVariants(ctx, uint(productID))
+	if err != nil {
+		respondError(c, err, "Failed to fetch variants")
+		return
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+// Get godoc
+// @Summary Get a variant
+// @Tags variants
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Success 200 {object} domain.ProductVariant
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantId} [get]
+func (h *VariantHandler) Get(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "VariantHandler.Get")
+	defer span.End()
+
+	productID, variantID, ok := variantIDs(c)
+	if !ok {
+		return
+	}
+
+	variant, err := h.variantService.GetVariant(ctx, productID, variantID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch variant")
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// Create godoc
+// @Summary Add a variant to a product
+// @Description Options the product does not have yet are added. Once a product has options, every variant picks one value for each of them.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variant body ProductVariantRequest true "Variant"
+// @Success 201 {object} domain.ProductVariant
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants [post]
+func (h *VariantHandler) Create(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "VariantHandler.Create")
+	defer span.End()
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return
+	}
+
+	var req ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	variant := req.toVariant()
+	if err := h.variantService.CreateVariant(ctx, uint(productID), variant); err != nil {
+		respondError(c, err, "Failed to create variant")
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+// Update godoc
+// @Summary Replace a variant
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Param variant body ProductVariantRequest true "Variant"
+// @Success 200 {object} domain.ProductVariant
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantId} [put]
+func (h *VariantHandler) Update(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "VariantHandler.Update")
+	defer span.End()
+
+	productID, variantID, ok := variantIDs(c)
+	if !ok {
+		return
+	}
+
+	var req ProductVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	variant := req.toVariant()
+	variant.ID = variantID
+	if err := h.variantService.UpdateVariant(ctx, productID, variant); err != nil {
+		respondError(c, err, "Failed to update variant")
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// Delete godoc
+// @Summary Delete a variant
+// @Description The variant can no longer be ordered, past orders still show it
+// @Tags variants
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantId} [delete]
+func (h *VariantHandler) Delete(c *gin.Context) {
+	ctx, span := otel.Tracer("").Start(c.Request.Context(), "VariantHandler.Delete")
+	defer span.End()
+
+	productID, variantID, ok := variantIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.variantService.DeleteVariant(ctx, productID, variantID); err != nil {
+		respondError(c, err, "Failed to delete variant")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// variantIDs reads the product and variant IDs from the path, answering 400
+// when either is not a number.
+func variantIDs(c *gin.Context) (uint, uint, bool) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid product ID"})
+		return 0, 0, false
+	}
+	variantID, err := strconv.ParseUint(c.Param("variantId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid variant ID"})
+		return 0, 0, false
+	}
+	return uint(productID), uint(variantID), true
+}
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LoggingNotifier wraps a notifier and records every message it sends, so
+// customers can be shown what was sent to them.
+type LoggingNotifier struct {
+	next    ports.NotificationRepository
+	logRepo ports.NotificationLogRepository
+}
+
+func NewLoggingNotifier(next ports.NotificationRepository, logRepo ports.NotificationLogRepository) *LoggingNotifier {
+	return &LoggingNotifier{
+		next:    next,
+		logRepo: logRepo,
+	}
+}
+
+func (n *LoggingNotifier) SendSms(ctx context.Context, phoneNumber []string, senderID, message string) error {
+	err := n.next.SendSms(ctx, phoneNumber, senderID, message)
+	for _, phone := range phoneNumber {
+		n.record(ctx, domain.NotificationChannelSms, phone, "", message, err)
+	}
+	return err
+}
+
+func (n *LoggingNotifier) SendEmail(ctx context.Context, email, subject, to string) error {
+	err := n.next.SendEmail(ctx, email, subject, to)
+	n.record(ctx, domain.NotificationChannelEmail, to, subject, email, err)
+	return err
+}
+
+// record never fails the send, a missing log entry is only reported on the span.
+func (n *LoggingNotifier) record(ctx context.Context, channel, recipient, subject, message string, sendErr error) {
+	log := &domain.NotificationLog{
+		Channel:   channel,
+		Recipient: recipient,
+		Subject:   subject,
+		Message:   message,
+		Status:    domain.NotificationStatusSent,
+	}
+	if sendErr != nil {
+		log.Status = domain.NotificationStatusFailed
+		log.Error = sendErr.Error()
+	}
+	if err := n.logRepo.Create(ctx, log); err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+	}
+}
@@ -0,0 +1,201 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+const fakeProvider = "fake"
+
+// FakeGateway settles payments in process, for development and tests. How a
+// payment ends is picked by the last digits of the phone it is asked from,
+// the way M-Pesa's result codes would have it:
+//
+//	...1032  the customer cancels
+//	...1037  the customer never answers and the provider times out
+//	...0001  the payment fails for lack of funds
+//	...0000  nothing ever comes back, for the reconciler to time out
+//	anything else pays in full
+type FakeGateway struct {
+	callbackURL string
+	token       string
+	delay       time.Duration
+	client      *http.Client
+}
+
+// NewFakeGateway posts each result to callbackURL, with token added, delay
+// after the payment was requested, the way a provider calls back. Without a
+// callbackURL results are only known by QueryPayment.
+func NewFakeGateway(callbackURL, token string, delay time.Duration) *FakeGateway {
+	if callbackURL != "" {
+		if u, err := url.Parse(callbackURL); err == nil {
+			query := u.Query()
+			query.Set("token", token)
+			u.RawQuery = query.Encode()
+			callbackURL = u.String()
+		}
+	}
+	return &FakeGateway{
+		callbackURL: callbackURL,
+		token:       token,
+		delay:       delay,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *FakeGateway) Provider() string {
+	return fakeProvider
+}
+
+// fakeCallback is the body the fake gateway posts.
+type fakeCallback struct {
+	Reference string               `json:"reference"`
+	Status    domain.PaymentStatus `json:"status"`
+	Amount    domain.Money         `json:"amount"`
+	Receipt   string               `json:"receipt"`
+	Reason    string               `json:"reason"`
+}
+
+func (g *FakeGateway) RequestPayment(ctx context.Context, intent *domain.PaymentIntent) (string, error) {
+	_, span := otel.Tracer("").Start(ctx, "FakeGateway.RequestPayment")
+	defer span.End()
+
+	if intent.Phone == "" {
+		return "", domain.NewValidationError("a phone number is required")
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	ref := "fake_" + hex.EncodeToString(b)
+
+	if g.callbackURL != "" {
+		result := fakeResult(ref, intent)
+		if result.Status != domain.PaymentPending {
+			go g.callBack(result)
+		}
+	}
+	return ref, nil
+}
+
+func (g *FakeGateway) ParseCallback(ctx context.Context, token string, body []byte) (*domain.PaymentResult, error) {
+	_, span := otel.Tracer("").Start(ctx, "FakeGateway.ParseCallback")
+	defer span.End()
+
+	// Without a token configured anyone could post a result
+	if g.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) != 1 {
+		return nil, domain.NewValidationError("invalid callback token")
+	}
+	var callback fakeCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, domain.NewValidationError("invalid callback: %v", err)
+	}
+	if callback.Reference == "" || !callback.Status.Final() {
+		return nil, domain.NewValidationError("invalid callback: no reference or outcome")
+	}
+	return &domain.PaymentResult{
+		ProviderRef: callback.Reference,
+		Status:      callback.Status,
+		Amount:      callback.Amount,
+		Receipt:     callback.Receipt,
+		Reason:      callback.Reason,
+	}, nil
+}
+
+func (g *FakeGateway) QueryPayment(ctx context.Context, intent *domain.PaymentIntent) (*domain.PaymentResult, error) {
+	_, span := otel.Tracer("").Start(ctx, "FakeGateway.QueryPayment")
+	defer span.End()
+
+	if intent.ProviderRef == nil {
+		return nil, domain.NewValidationError("payment intent %d was never requested", intent.ID)
+	}
+	return fakeResult(*intent.ProviderRef, intent), nil
+}
+
+// Refund pays back at once, the fake provider has nothing to wait for.
+func (g *FakeGateway) Refund(ctx context.Context, intent *domain.PaymentIntent, amount domain.Money, reason string) (*domain.PaymentResult, error) {
+	_, span := otel.Tracer("").Start(ctx, "FakeGateway.Refund")
+	defer span.End()
+
+	if intent.Status != domain.PaymentSucceeded {
+		return nil, domain.NewValidationError("payment intent %d was never paid", intent.ID)
+	}
+	if amount.Amount <= 0 || amount.Amount > intent.Amount.Amount {
+		return nil, domain.NewValidationError("cannot refund %s of a %s payment", amount, intent.Amount)
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &domain.PaymentResult{
+		ProviderRef: "fake_refund_" + hex.EncodeToString(b),
+		Refund:      true,
+		Status:      domain.PaymentSucceeded,
+		Amount:      amount,
+	}, nil
+}
+
+// fakeResult is how the payment asked for from intent.Phone ends.
+func fakeResult(ref string, intent *domain.PaymentIntent) *domain.PaymentResult {
+	result := &domain.PaymentResult{ProviderRef: ref}
+	switch {
+	case strings.HasSuffix(intent.Phone, "1032"):
+		result.Status = domain.PaymentCancelled
+		result.Reason = "Request cancelled by user"
+	case strings.HasSuffix(intent.Phone, "1037"):
+		result.Status = domain.PaymentTimedOut
+		result.Reason = "DS timeout user cannot be reached"
+	case strings.HasSuffix(intent.Phone, "0001"):
+		result.Status = domain.PaymentFailed
+		result.Reason = "The balance is insufficient for the transaction"
+	case strings.HasSuffix(intent.Phone, "0000"):
+		result.Status = domain.PaymentPending
+	default:
+		result.Status = domain.PaymentSucceeded
+		result.Amount = intent.Amount
+		// Shaped like an M-Pesa transaction code
+		result.Receipt = strings.ToUpper(strings.TrimPrefix(ref, "fake_"))
+		if len(result.Receipt) > 10 {
+			result.Receipt = result.Receipt[:10]
+		}
+	}
+	return result
+}
+
+// callBack posts the result to the callback URL once the delay is up.
+func (g *FakeGateway) callBack(result *domain.PaymentResult) {
+	time.Sleep(g.delay)
+
+	body, err := json.Marshal(fakeCallback{
+		Reference: result.ProviderRef,
+		Status:    result.Status,
+		Amount:    result.Amount,
+		Receipt:   result.Receipt,
+		Reason:    result.Reason,
+	})
+	if err != nil {
+		log.Printf("payments: fake gateway failed to encode callback: %v", err)
+		return
+	}
+	resp, err := g.client.Post(g.callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("payments: fake gateway failed to call back: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("payments: fake gateway callback for %s answered %s", result.ProviderRef, resp.Status)
+	}
+}
@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type AddressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) *AddressRepository {
+	return &AddressRepository{
+		db: db,
+	}
+}
+
+func (r *AddressRepository) Create(ctx context.Context, address *domain.Address) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.CustomerID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(address).Error
+	})
+}
+
+func (r *AddressRepository) Get(ctx context.Context, id uint) (*domain.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressRepository.Get")
+	defer span.End()
+
+	var address domain.Address
+	err := r.db.WithContext(ctx).First(&address, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *AddressRepository) GetDefault(ctx context.Context, customerID uint) (*domain.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressRepository.GetDefault")
+	defer span.End()
+
+	var address domain.Address
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ? AND is_default = ?", customerID, true).
+		First(&address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *AddressRepository) ListByCustomer(ctx context.Context, customerID uint) ([]domain.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressRepository.ListByCustomer")
+	defer span.End()
+
+	var addresses []domain.Address
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Order("is_default DESC, id").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *AddressRepository) Update(ctx context.Context, address *domain.Address) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressRepository.Update")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.CustomerID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(address).Error
+	})
+}
+
+func (r *AddressRepository) Delete(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressRepository.Delete")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Delete(&domain.Address{}, id).Error
+}
+
+func (r *AddressRepository) SetDefault(ctx context.Context, customerID, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressRepository.SetDefault")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultAddress(tx, customerID); err != nil {
+			return err
+		}
+		result := tx.Model(&domain.Address{}).
+			Where("id = ? AND customer_id = ?", id, customerID).
+			Update("is_default", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// clearDefaultAddress unsets the current default so a customer never has two.
+func clearDefaultAddress(tx *gorm.DB, customerID uint) error {
+	return tx.Model(&domain.Address{}).
+		Where("customer_id = ? AND is_default = ?", customerID, true).
+		Update("is_default", false).Error
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *APIKeyRepository) Get(ctx context.Context, id uint) (*domain.APIKey, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyRepository.Get")
+	defer span.End()
+
+	var key domain.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyRepository.GetByPrefix")
+	defer span.End()
+
+	var key domain.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyRepository.List")
+	defer span.End()
+
+	var keys []domain.APIKey
+	err := r.db.WithContext(ctx).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyRepository.Revoke")
+	defer span.End()
+
+	result := r.db.WithContext(ctx).
+		Model(&domain.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyRepository.TouchLastUsed")
+	defer span.End()
+
+	return r.db.WithContext(ctx).
+		Model(&domain.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", time.Now().UTC()).Error
+}
@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *domain.AuditEntry) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AuditRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "AuditRepository.List")
+	defer span.End()
+
+	query := r.db.WithContext(ctx).Model(&domain.AuditEntry{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorType != "" {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	page := &domain.AuditPage{Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&page.Entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
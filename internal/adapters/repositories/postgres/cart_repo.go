@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type CartRepository struct {
+	db *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) *CartRepository {
+	return &CartRepository{
+		db: db,
+	}
+}
+
+func (r *CartRepository) GetByCustomer(ctx context.Context, customerID uint) (*domain.Cart, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartRepository.GetByCustomer")
+	defer span.End()
+
+	return r.get(r.db.WithContext(ctx).Where("customer_id = ?", customerID))
+}
+
+func (r *CartRepository) GetByToken(ctx context.Context, token string) (*domain.Cart, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartRepository.GetByToken")
+	defer span.End()
+
+	if token == "" {
+		return nil, domain.ErrNotFound
+	}
+	return r.get(r.db.WithContext(ctx).Where("token = ?", token))
+}
+
+func (r *CartRepository) get(query *gorm.DB) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *CartRepository) Create(ctx context.Context, cart *domain.Cart) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CartRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Omit("Items").Create(cart).Error
+}
+
+func (r *CartRepository) SaveItem(ctx context.Context, item *domain.CartItem) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CartRepository.SaveItem")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		return touchCart(tx, item.CartID)
+	})
+}
+
+func (r *CartRepository) DeleteItem(ctx context.Context, cartID, itemID uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CartRepository.DeleteItem")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("cart_id = ?", cartID).Delete(&domain.CartItem{}, itemID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrNotFound
+		}
+		return touchCart(tx, cartID)
+	})
+}
+
+func (r *CartRepository) ClearItems(ctx context.Context, cartID uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CartRepository.ClearItems")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cart_id = ?", cartID).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		return touchCart(tx, cartID)
+	})
+}
+
+func (r *CartRepository) Merge(ctx context.Context, into *domain.Cart, fromID uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CartRepository.Merge")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cart_id = ?", fromID).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&domain.Cart{}, fromID).Error; err != nil {
+			return err
+		}
+		for i := range into.Items {
+			if err := tx.Save(&into.Items[i]).Error; err != nil {
+				return err
+			}
+		}
+		return touchCart(tx, into.ID)
+	})
+}
+
+// touchCart bumps the cart's updated_at, so it tells when the cart was last used.
+func touchCart(tx *gorm.DB, cartID uint) error {
+	return tx.Model(&domain.Cart{}).Where("id = ?", cartID).Update("updated_at", gorm.Expr("now()")).Error
+}
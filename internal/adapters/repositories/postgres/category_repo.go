@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *CategoryRepository) Get(ctx context.Context, id uint) (*domain.Category, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.Get")
+	defer span.End()
+
+	var category domain.Category
+	err := r.db.WithContext(ctx).First(&category, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	return &category, err
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.List")
+	defer span.End()
+
+	var categories []domain.Category
+	err := r.db.WithContext(ctx).Order("id").Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.Update")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.Delete")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Delete(&domain.Category{}, id).Error
+}
+
+func (r *CategoryRepository) ListAttributes(ctx context.Context, categoryIDs []uint) ([]domain.CategoryAttribute, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.ListAttributes")
+	defer span.End()
+
+	var attributes []domain.CategoryAttribute
+	err := r.db.WithContext(ctx).
+		Where("category_id IN ?", categoryIDs).
+		Order("name").
+		Find(&attributes).Error
+	return attributes, err
+}
+
+func (r *CategoryRepository) ReplaceAttributes(ctx context.Context, categoryID uint, attributes []domain.CategoryAttribute) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.ReplaceAttributes")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("category_id = ?", categoryID).Delete(&domain.CategoryAttribute{}).Error; err != nil {
+			return err
+		}
+		if len(attributes) == 0 {
+			return nil
+		}
+		for i := range attributes {
+			attributes[i].ID = 0
+			attributes[i].CategoryID = categoryID
+		}
+		return tx.Create(&attributes).Error
+	})
+}
+
+func (r *CategoryRepository) GetDeleted(ctx context.Context, id uint) (*domain.Category, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.GetDeleted")
+	defer span.End()
+
+	var category domain.Category
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&category, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	return &category, err
+}
+
+func (r *CategoryRepository) ListDeleted(ctx context.Context) ([]domain.Category, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.ListDeleted")
+	defer span.End()
+
+	var categories []domain.Category
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC, id").
+		Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepository) Restore(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.Restore")
+	defer span.End()
+
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.Category{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CategoryRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryRepository.PurgeDeleted")
+	defer span.End()
+
+	// A category is only removed once nothing points at it. Purging a leaf
+	// can free its parent, so keep going until a pass removes nothing.
+	var purged int64
+	for {
+		result := r.db.WithContext(ctx).Unscoped().
+			Where("deleted_at < ?", deletedBefore).
+			Where("NOT EXISTS (SELECT 1 FROM products WHERE products.category_id = categories.id)").
+			Where("NOT EXISTS (SELECT 1 FROM categories AS children WHERE children.parent_category_id = categories.id)").
+			Delete(&domain.Category{})
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return purged, nil
+		}
+		purged += result.RowsAffected
+	}
+}
@@ -23,8 +23,9 @@ func (r *CustomerRepository) UpsertCustomer(ctx context.Context, customer *domai
 	ctx, span := otel.Tracer("").Start(ctx, "CustomerRepository.Upsert")
 	defer span.End()
 
+	// Customers signing in through google are matched on their email, they carry no ID yet
 	var existingCustomer domain.Customer
-	if err := r.db.WithContext(ctx).Where("id = ?", customer.ID).First(&existingCustomer).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("email = ?", customer.Email).First(&existingCustomer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Customer doesn't exist, create a new one
 			if err := r.db.WithContext(ctx).Create(customer).Error; err != nil {
@@ -38,9 +39,11 @@ func (r *CustomerRepository) UpsertCustomer(ctx context.Context, customer *domai
 
 	// Update only the fields that are provided, excluding ID
 	if err := r.db.WithContext(ctx).Model(&existingCustomer).Updates(map[string]interface{}{
-		"name":  customer.Name,
-		"email": customer.Email,
-		// Add other fields to update here
+		"name":           customer.Name,
+		"email":          customer.Email,
+		"verified_email": customer.VerifiedEmail,
+		"picture":        customer.Picture,
+		// Phone and preferences are managed by the customer and must survive a login
 	}).Error; err != nil {
 		return nil, err
 	}
@@ -77,5 +80,54 @@ func (r *CustomerRepository) GetCustomer(ctx context.Context, id uint) (*domain.
 
 	var customer domain.Customer
 	err := r.db.WithContext(ctx).First(&customer, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	return &customer, err
+}
+
+func (r *CustomerRepository) GetCustomerByEmail(ctx context.Context, email string) (*domain.Customer, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CustomerRepository.GetByEmail")
+	defer span.End()
+
+	var customer domain.Customer
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&customer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
 	return &customer, err
 }
+
+func (r *CustomerRepository) UpdateCustomer(ctx context.Context, customer *domain.Customer) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CustomerRepository.Update")
+	defer span.End()
+
+	// Save writes zero values too, so preferences can be switched off
+	return r.db.WithContext(ctx).Save(customer).Error
+}
+
+func (r *CustomerRepository) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CustomerRepository.List")
+	defer span.End()
+
+	query := r.db.WithContext(ctx).Model(&domain.Customer{})
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ? OR phone ILIKE ?", pattern, pattern, pattern)
+	}
+
+	page := &domain.CustomerPage{Page: filter.Page, PageSize: filter.PageSize}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	err := query.
+		Order("id").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&page.Customers).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
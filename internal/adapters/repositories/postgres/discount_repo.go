@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type DiscountRepository struct {
+	db *gorm.DB
+}
+
+func NewDiscountRepository(db *gorm.DB) *DiscountRepository {
+	return &DiscountRepository{
+		db: db,
+	}
+}
+
+func (r *DiscountRepository) Create(ctx context.Context, discount *domain.Discount) error {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(discount).Error
+}
+
+func (r *DiscountRepository) Get(ctx context.Context, id uint) (*domain.Discount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.Get")
+	defer span.End()
+
+	return r.first(r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r *DiscountRepository) GetByCode(ctx context.Context, code string) (*domain.Discount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.GetByCode")
+	defer span.End()
+
+	if code == "" {
+		return nil, domain.ErrNotFound
+	}
+	return r.first(r.db.WithContext(ctx).Where("code = ?", code))
+}
+
+func (r *DiscountRepository) first(query *gorm.DB) (*domain.Discount, error) {
+	var discount domain.Discount
+	err := query.First(&discount).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &discount, nil
+}
+
+func (r *DiscountRepository) List(ctx context.Context) ([]domain.Discount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.List")
+	defer span.End()
+
+	var discounts []domain.Discount
+	err := r.db.WithContext(ctx).Order("id").Find(&discounts).Error
+	return discounts, err
+}
+
+func (r *DiscountRepository) Update(ctx context.Context, discount *domain.Discount) error {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.Update")
+	defer span.End()
+
+	// times_used is only ever counted up by orders
+	return r.db.WithContext(ctx).Omit("TimesUsed", "CreatedAt").Save(discount).Error
+}
+
+func (r *DiscountRepository) Delete(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.Delete")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Delete(&domain.Discount{}, id).Error
+}
+
+func (r *DiscountRepository) ListAutomatic(ctx context.Context, at time.Time) ([]domain.Discount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.ListAutomatic")
+	defer span.End()
+
+	var discounts []domain.Discount
+	err := r.db.WithContext(ctx).
+		Where("active AND (code IS NULL OR code = '')").
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at > ?", at).
+		Order("id").
+		Find(&discounts).Error
+	return discounts, err
+}
+
+func (r *DiscountRepository) CountCustomerUses(ctx context.Context, discountID, customerID uint) (int64, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountRepository.CountCustomerUses")
+	defer span.End()
+
+	return countCustomerUses(r.db.WithContext(ctx), discountID, customerID)
+}
+
+func countCustomerUses(db *gorm.DB, discountID, customerID uint) (int64, error) {
+	var count int64
+	err := db.Model(&domain.Order{}).
+		Where("customer_id = ?", customerID).
+		Where("EXISTS (SELECT 1 FROM order_adjustments WHERE order_adjustments.order_id = orders.id AND order_adjustments.discount_id = ?)", discountID).
+		Count(&count).Error
+	return count, err
+}
+
+// redeemDiscounts counts the order's use of each of its discounts. Bumping
+// times_used locks the discount's row, so concurrent orders are checked
+// against the limits one at a time.
+func redeemDiscounts(tx *gorm.DB, order *domain.Order) error {
+	for _, adjustment := range order.Adjustments {
+		if adjustment.DiscountID == nil {
+			continue
+		}
+		result := tx.Model(&domain.Discount{}).
+			Where("id = ? AND (usage_limit IS NULL OR times_used < usage_limit)", *adjustment.DiscountID).
+			Update("times_used", gorm.Expr("times_used + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrDiscountUsedUp
+		}
+
+		var discount domain.Discount
+		if err := tx.Select("per_customer_limit").First(&discount, *adjustment.DiscountID).Error; err != nil {
+			return err
+		}
+		if discount.PerCustomerLimit == nil {
+			continue
+		}
+		uses, err := countCustomerUses(tx, *adjustment.DiscountID, order.CustomerID)
+		if err != nil {
+			return err
+		}
+		if uses >= int64(*discount.PerCustomerLimit) {
+			return domain.ErrDiscountUsedUp
+		}
+	}
+	return nil
+}
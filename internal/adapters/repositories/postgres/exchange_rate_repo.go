@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type ExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db: db,
+	}
+}
+
+func (r *ExchangeRateRepository) Create(ctx context.Context, rate *domain.ExchangeRate) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ExchangeRateRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(rate).Error
+}
+
+func (r *ExchangeRateRepository) ListAt(ctx context.Context, at time.Time) ([]domain.ExchangeRate, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ExchangeRateRepository.ListAt")
+	defer span.End()
+
+	var rates []domain.ExchangeRate
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (currency) * FROM exchange_rates
+		WHERE effective_from <= ?
+		ORDER BY currency, effective_from DESC`, at).
+		Scan(&rates).Error
+	return rates, err
+}
+
+func (r *ExchangeRateRepository) History(ctx context.Context, currency string) ([]domain.ExchangeRate, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ExchangeRateRepository.History")
+	defer span.End()
+
+	var rates []domain.ExchangeRate
+	err := r.db.WithContext(ctx).
+		Where("currency = ?", currency).
+		Order("effective_from").
+		Find(&rates).Error
+	return rates, err
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type ProductImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) *ProductImageRepository {
+	return &ProductImageRepository{
+		db: db,
+	}
+}
+
+func (r *ProductImageRepository) Create(ctx context.Context, image *domain.ProductImage) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(image).Error
+}
+
+func (r *ProductImageRepository) Get(ctx context.Context, id uint) (*domain.ProductImage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageRepository.Get")
+	defer span.End()
+
+	var image domain.ProductImage
+	err := r.db.WithContext(ctx).First(&image, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *ProductImageRepository) ListByProduct(ctx context.Context, productID uint) ([]domain.ProductImage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageRepository.ListByProduct")
+	defer span.End()
+
+	var images []domain.ProductImage
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Scopes(orderImages).
+		Find(&images).Error
+	return images, err
+}
+
+func (r *ProductImageRepository) SaveOrder(ctx context.Context, images []domain.ProductImage) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageRepository.SaveOrder")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, image := range images {
+			err := tx.Model(&domain.ProductImage{}).
+				Where("id = ?", image.ID).
+				Updates(map[string]interface{}{"position": image.Position, "is_primary": image.IsPrimary}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ProductImageRepository) Delete(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageRepository.Delete")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Delete(&domain.ProductImage{}, id).Error
+}
+
+// orderImages puts images in display order, for queries and preloads.
+func orderImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type NotificationLogRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationLogRepository(db *gorm.DB) *NotificationLogRepository {
+	return &NotificationLogRepository{
+		db: db,
+	}
+}
+
+func (r *NotificationLogRepository) Create(ctx context.Context, log *domain.NotificationLog) error {
+	ctx, span := otel.Tracer("").Start(ctx, "NotificationLogRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+func (r *NotificationLogRepository) ListByRecipients(ctx context.Context, recipients []string) ([]domain.NotificationLog, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "NotificationLogRepository.ListByRecipients")
+	defer span.End()
+
+	var logs []domain.NotificationLog
+	if len(recipients) == 0 {
+		return logs, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("recipient IN ?", recipients).
+		Order("created_at").
+		Find(&logs).Error
+	return logs, err
+}
@@ -2,10 +2,13 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
 	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository struct {
@@ -22,7 +25,29 @@ func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) (*dom
 	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.Create")
 	defer span.End()
 
-	err := r.db.WithContext(ctx).Create(order).Error
+	// Stock and discount uses are taken in the same transaction, so an order
+	// never sells more of a variant than there is, nor uses a discount past
+	// its limits
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range order.Items {
+			if item.VariantID == nil {
+				continue
+			}
+			result := tx.Model(&domain.ProductVariant{}).
+				Where("id = ? AND stock >= ?", *item.VariantID, item.Quantity).
+				Update("stock", gorm.Expr("stock - ?", item.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return domain.ErrInsufficientStock
+			}
+		}
+		if err := redeemDiscounts(tx, order); err != nil {
+			return err
+		}
+		return tx.Create(order).Error
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +59,7 @@ func (r *OrderRepository) List(ctx context.Context) ([]domain.Order, error) {
 	defer span.End()
 
 	var orders []domain.Order
-	err := r.db.WithContext(ctx).Find(&orders).Error
+	err := r.db.WithContext(ctx).Where("archived_at IS NULL").Find(&orders).Error
 	return orders, err
 }
 
@@ -43,22 +68,150 @@ func (r *OrderRepository) Get(ctx context.Context, id uint) (*domain.Order, erro
 	defer span.End()
 
 	var order domain.Order
-	err := r.db.WithContext(ctx).First(&order, id).Error
-	return &order, err
+	err := preloadOrderItems(r.db.WithContext(ctx)).First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	fillOrderVariantOptions(&order)
+	return &order, nil
+}
+
+func (r *OrderRepository) Amend(ctx context.Context, order *domain.Order, amendment *domain.OrderAmendment, stock map[uint]int) error {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.Amend")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for variantID, quantity := range stock {
+			if quantity == 0 {
+				continue
+			}
+			result := tx.Model(&domain.ProductVariant{}).
+				Where("id = ? AND stock >= ?", variantID, quantity).
+				Update("stock", gorm.Expr("stock - ?", quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return domain.ErrInsufficientStock
+			}
+		}
+
+		kept := []uint{0}
+		for _, item := range order.Items {
+			if item.ID != 0 {
+				kept = append(kept, item.ID)
+			}
+		}
+		err := tx.Where("order_id = ? AND id NOT IN ?", order.ID, kept).Delete(&domain.OrderItem{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range order.Items {
+			order.Items[i].OrderID = order.ID
+			if err := tx.Omit(clause.Associations).Save(&order.Items[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("order_id = ?", order.ID).Delete(&domain.OrderAdjustment{}).Error; err != nil {
+			return err
+		}
+		for i := range order.Adjustments {
+			order.Adjustments[i].ID = 0
+			order.Adjustments[i].OrderID = order.ID
+		}
+		if len(order.Adjustments) > 0 {
+			if err := tx.Create(&order.Adjustments).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit(clause.Associations).Save(order).Error; err != nil {
+			return err
+		}
+
+		// Lines the amendment added only have an ID now
+		for i := range amendment.Lines {
+			line := &amendment.Lines[i]
+			if line.OrderItemID != 0 {
+				continue
+			}
+			for _, item := range order.Items {
+				if item.Matches(line.ProductID, line.VariantID) {
+					line.OrderItemID = item.ID
+				}
+			}
+		}
+		amendment.OrderID = order.ID
+		return tx.Create(amendment).Error
+	})
 }
 
-func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error {
-	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.Update")
+func (r *OrderRepository) SaveRefund(ctx context.Context, order *domain.Order, refund *domain.OrderRefund, restock map[uint]int) error {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.SaveRefund")
 	defer span.End()
 
-	return r.db.WithContext(ctx).Save(order).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if refund != nil {
+			if err := tx.Create(refund).Error; err != nil {
+				return err
+			}
+			if refund.Provider == domain.StoreCreditProvider && refund.Amount.Amount > 0 {
+				credit := &domain.StoreCredit{
+					CustomerID: order.CustomerID,
+					Amount:     refund.Amount,
+					OrderID:    order.ID,
+					RefundID:   &refund.ID,
+					Reason:     refund.Reason,
+				}
+				if err := tx.Create(credit).Error; err != nil {
+					return err
+				}
+			}
+			for _, line := range refund.Lines {
+				item := orderItem(order, line.OrderItemID)
+				if item == nil {
+					continue
+				}
+				err := tx.Model(&domain.OrderItem{}).
+					Where("id = ?", item.ID).
+					Update("refunded_quantity", item.RefundedQuantity).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		for variantID, quantity := range restock {
+			err := tx.Model(&domain.ProductVariant{}).
+				Where("id = ?", variantID).
+				Update("stock", gorm.Expr("stock + ?", quantity)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&domain.Order{}).
+			Where("id = ?", order.ID).
+			Updates(map[string]interface{}{
+				"status":                  order.Status,
+				"cancelled_at":            order.CancelledAt,
+				"cancel_reason":           order.CancelReason,
+				"payment_status":          order.PaymentStatus,
+				"refunded_total_amount":   order.RefundedTotal.Amount,
+				"refunded_total_currency": order.RefundedTotal.Currency,
+			}).Error
+	})
 }
 
-func (r *OrderRepository) Delete(ctx context.Context, id uint) error {
-	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.Delete")
+func (r *OrderRepository) Archive(ctx context.Context, id uint, at time.Time) error {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.Archive")
 	defer span.End()
 
-	return r.db.WithContext(ctx).Delete(&domain.Order{}, id).Error
+	return r.db.WithContext(ctx).
+		Model(&domain.Order{}).
+		Where("id = ?", id).
+		Update("archived_at", at).Error
 }
 
 func (r *OrderRepository) DeleteOrderItems(ctx context.Context, orderID uint) error {
@@ -72,11 +225,94 @@ func (r *OrderRepository) GetOrderProduct(ctx context.Context, productID uint) (
 	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.GetOrderProduct")
 	defer span.End()
 
+	// Deleted products are left out, so they cannot be ordered any more
 	var product domain.Product
 	err := r.db.WithContext(ctx).
 		First(&product, productID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
+
+func (r *OrderRepository) ListByCustomer(ctx context.Context, customerID uint) ([]domain.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.ListByCustomer")
+	defer span.End()
+
+	var orders []domain.Order
+	err := preloadOrderItems(r.db.WithContext(ctx)).
+		Where("customer_id = ?", customerID).
+		Order("created_at").
+		Find(&orders).Error
+	for i := range orders {
+		fillOrderVariantOptions(&orders[i])
+	}
+	return orders, err
+}
+
+func (r *OrderRepository) GetOrderVariant(ctx context.Context, variantID uint) (*domain.ProductVariant, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.GetOrderVariant")
+	defer span.End()
+
+	var variant domain.ProductVariant
+	err := r.db.WithContext(ctx).First(&variant, variantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *OrderRepository) HasVariants(ctx context.Context, productID uint) (bool, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderRepository.HasVariants")
+	defer span.End()
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.ProductVariant{}).
+		Where("product_id = ?", productID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// preloadOrderItems loads the items with the product and variant they were
+// ordered as, including ones that have since been deleted.
+func preloadOrderItems(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items").
+		Preload("Adjustments", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Refunds.Lines").
+		Preload("Amendments", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Amendments.Lines").
+		Preload("Items.Product", unscoped).
+		Preload("Items.Variant", unscoped).
+		Preload("Items.Variant.OptionValues.Option")
+}
+
+func orderItem(order *domain.Order, id uint) *domain.OrderItem {
+	for i := range order.Items {
+		if order.Items[i].ID == id {
+			return &order.Items[i]
+		}
+	}
+	return nil
+}
+
+func fillOrderVariantOptions(order *domain.Order) {
+	for i := range order.Items {
+		if order.Items[i].Variant != nil {
+			fillVariantOptions(order.Items[i].Variant)
+		}
+	}
+}
+
+// unscoped lets past orders load products that have since been deleted.
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type PaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
+	return &PaymentRepository{
+		db: db,
+	}
+}
+
+func (r *PaymentRepository) CreateIntent(ctx context.Context, intent *domain.PaymentIntent) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentRepository.CreateIntent")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(intent).Error
+}
+
+func (r *PaymentRepository) GetIntentByRef(ctx context.Context, provider, ref string) (*domain.PaymentIntent, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentRepository.GetIntentByRef")
+	defer span.End()
+
+	var intent domain.PaymentIntent
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND provider_ref = ?", provider, ref).
+		First(&intent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (r *PaymentRepository) ListByOrder(ctx context.Context, orderID uint) ([]domain.PaymentIntent, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentRepository.ListByOrder")
+	defer span.End()
+
+	var intents []domain.PaymentIntent
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at, id").
+		Find(&intents).Error
+	return intents, err
+}
+
+func (r *PaymentRepository) ListPending(ctx context.Context, createdBefore time.Time, limit int) ([]domain.PaymentIntent, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentRepository.ListPending")
+	defer span.End()
+
+	var intents []domain.PaymentIntent
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", domain.PaymentPending, createdBefore).
+		Order("created_at, id").
+		Limit(limit).
+		Find(&intents).Error
+	return intents, err
+}
+
+func (r *PaymentRepository) UpdateIntent(ctx context.Context, intent *domain.PaymentIntent) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentRepository.UpdateIntent")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Save(intent).Error
+}
+
+func (r *PaymentRepository) Settle(ctx context.Context, intent *domain.PaymentIntent) (bool, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentRepository.Settle")
+	defer span.End()
+
+	// The callback and the reconciler can settle the same intent at once, the
+	// status condition lets only the first success through
+	settled := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.PaymentIntent{}).
+			Where("id = ? AND status <> ?", intent.ID, domain.PaymentSucceeded).
+			Updates(map[string]interface{}{
+				"status":         intent.Status,
+				"receipt":        intent.Receipt,
+				"failure_reason": intent.FailureReason,
+				"completed_at":   intent.CompletedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		settled = true
+
+		// A payment that lands after the order was cancelled doesn't revive
+		// it, the money is refunded instead
+		if intent.Status != domain.PaymentSucceeded {
+			return nil
+		}
+		return tx.Model(&domain.Order{}).
+			Where("id = ? AND payment_status = ? AND status <> ?", intent.OrderID, domain.OrderUnpaid, domain.OrderCancelled).
+			Updates(map[string]interface{}{
+				"payment_status": domain.OrderPaid,
+				"paid_at":        intent.CompletedAt,
+			}).Error
+	})
+	return settled, err
+}
+
+func (r *PaymentRepository) SettleRefund(ctx context.Context, provider, ref string, status domain.RefundStatus, reason string) (bool, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentRepository.SettleRefund")
+	defer span.End()
+
+	db := r.db.WithContext(ctx)
+	result := db.Model(&domain.OrderRefund{}).
+		Where("provider = ? AND provider_ref = ? AND status = ?", provider, ref, domain.RefundPending).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"failure_reason": reason,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	var count int64
+	if err := db.Model(&domain.OrderRefund{}).
+		Where("provider = ? AND provider_ref = ?", provider, ref).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, domain.ErrNotFound
+	}
+	return false, nil
+}
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type PhoneVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewPhoneVerificationRepository(db *gorm.DB) *PhoneVerificationRepository {
+	return &PhoneVerificationRepository{
+		db: db,
+	}
+}
+
+func (r *PhoneVerificationRepository) Create(ctx context.Context, verification *domain.PhoneVerification) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PhoneVerificationRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(verification).Error
+}
+
+func (r *PhoneVerificationRepository) GetLatest(ctx context.Context, customerID uint) (*domain.PhoneVerification, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PhoneVerificationRepository.GetLatest")
+	defer span.End()
+
+	var verification domain.PhoneVerification
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		First(&verification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *PhoneVerificationRepository) ListPhones(ctx context.Context, customerID uint) ([]string, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PhoneVerificationRepository.ListPhones")
+	defer span.End()
+
+	var phones []string
+	err := r.db.WithContext(ctx).
+		Model(&domain.PhoneVerification{}).
+		Where("customer_id = ?", customerID).
+		Distinct().
+		Pluck("phone", &phones).Error
+	return phones, err
+}
+
+func (r *PhoneVerificationRepository) Update(ctx context.Context, verification *domain.PhoneVerification) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PhoneVerificationRepository.Update")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Save(verification).Error
+}
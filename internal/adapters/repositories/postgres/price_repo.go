@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewProductPriceRepository(db *gorm.DB) *ProductPriceRepository {
+	return &ProductPriceRepository{
+		db: db,
+	}
+}
+
+func (r *ProductPriceRepository) ListByProduct(ctx context.Context, productID uint) ([]domain.ProductPrice, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceRepository.ListByProduct")
+	defer span.End()
+
+	var prices []domain.ProductPrice
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("effective_from").
+		Find(&prices).Error
+	return prices, err
+}
+
+func (r *ProductPriceRepository) PriceAt(ctx context.Context, productID uint, at time.Time) (*domain.ProductPrice, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceRepository.PriceAt")
+	defer span.End()
+
+	var price domain.ProductPrice
+	err := r.db.WithContext(ctx).
+		Scopes(coveringPrice(productID, at)).
+		First(&price).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (r *ProductPriceRepository) Schedule(ctx context.Context, productID uint, change domain.PriceChange) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceRepository.Schedule")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The price in force when a temporary change ends is the one that comes back
+		var restore *domain.ProductPrice
+		if change.EffectiveTo != nil {
+			var current domain.ProductPrice
+			err := tx.Scopes(coveringPrice(productID, *change.EffectiveTo)).First(&current).Error
+			if err == nil {
+				restore = &current
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		if err := setPrice(tx, productID, change.Price, change.EffectiveFrom, change.Note); err != nil {
+			return err
+		}
+		if restore != nil {
+			return setPrice(tx, productID, restore.Price, *change.EffectiveTo, restore.Note)
+		}
+		return nil
+	})
+}
+
+func (r *ProductPriceRepository) Cancel(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceRepository.Cancel")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var period domain.ProductPrice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&period, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&period).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.ProductPrice{}).
+			Where("product_id = ? AND effective_to = ?", period.ProductID, period.EffectiveFrom).
+			Update("effective_to", period.EffectiveTo).Error
+	})
+}
+
+func (r *ProductPriceRepository) ApplyDue(ctx context.Context, at time.Time) (int64, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceRepository.ApplyDue")
+	defer span.End()
+
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE products SET price_amount = product_prices.price_amount, price_currency = product_prices.price_currency, updated_at = ?
+		FROM product_prices
+		WHERE product_prices.product_id = products.id
+			AND product_prices.effective_from <= ?
+			AND (product_prices.effective_to IS NULL OR product_prices.effective_to > ?)
+			AND (products.price_amount, products.price_currency) IS DISTINCT FROM (product_prices.price_amount, product_prices.price_currency)`,
+		at, at, at)
+	return result.RowsAffected, result.Error
+}
+
+// coveringPrice selects the product's period in force at the given time.
+func coveringPrice(productID uint, at time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("product_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", productID, at, at)
+	}
+}
+
+// setPrice makes price the product's price from the given time until the
+// next period starts, splitting the period in force at that time in two.
+func setPrice(tx *gorm.DB, productID uint, price domain.Money, from time.Time, note string) error {
+	var current domain.ProductPrice
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(coveringPrice(productID, from)).
+		First(&current).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	period := domain.ProductPrice{ProductID: productID, Price: price, EffectiveFrom: from, Note: note}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Before the first period, or the product has none yet
+		var next domain.ProductPrice
+		err := tx.Where("product_id = ? AND effective_from > ?", productID, from).Order("effective_from").First(&next).Error
+		if err == nil {
+			period.EffectiveTo = &next.EffectiveFrom
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	case current.EffectiveFrom.Equal(from):
+		return tx.Model(&current).Updates(map[string]interface{}{"price_amount": price.Amount, "price_currency": price.Currency, "note": note}).Error
+	default:
+		period.EffectiveTo = current.EffectiveTo
+		if err := tx.Model(&current).Update("effective_to", from).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(&period).Error
+}
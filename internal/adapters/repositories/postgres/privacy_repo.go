@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+const erasedValue = "[erased]"
+
+type PrivacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) *PrivacyRepository {
+	return &PrivacyRepository{
+		db: db,
+	}
+}
+
+func (r *PrivacyRepository) CreateErasureRequest(ctx context.Context, request *domain.ErasureRequest) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyRepository.CreateErasureRequest")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(request).Error
+}
+
+func (r *PrivacyRepository) GetErasureRequest(ctx context.Context, id uint) (*domain.ErasureRequest, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyRepository.GetErasureRequest")
+	defer span.End()
+
+	var request domain.ErasureRequest
+	err := r.db.WithContext(ctx).First(&request, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *PrivacyRepository) ListErasureRequests(ctx context.Context, status domain.ErasureStatus) ([]domain.ErasureRequest, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyRepository.ListErasureRequests")
+	defer span.End()
+
+	query := r.db.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []domain.ErasureRequest
+	err := query.Order("created_at").Find(&requests).Error
+	return requests, err
+}
+
+func (r *PrivacyRepository) ListCustomerErasureRequests(ctx context.Context, customerID uint) ([]domain.ErasureRequest, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyRepository.ListCustomerErasureRequests")
+	defer span.End()
+
+	var requests []domain.ErasureRequest
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at").
+		Find(&requests).Error
+	return requests, err
+}
+
+func (r *PrivacyRepository) UpdateErasureRequest(ctx context.Context, request *domain.ErasureRequest) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyRepository.UpdateErasureRequest")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Save(request).Error
+}
+
+func (r *PrivacyRepository) AnonymizeCustomer(ctx context.Context, customerID uint) (*domain.ErasureSummary, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyRepository.AnonymizeCustomer")
+	defer span.End()
+
+	summary := &domain.ErasureSummary{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var customer domain.Customer
+		if err := tx.First(&customer, customerID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrNotFound
+			}
+			return err
+		}
+
+		// Notifications are matched on contact details, including phones the
+		// customer used before, so collect them before anything is cleared
+		var recipients []string
+		err := tx.Model(&domain.PhoneVerification{}).
+			Where("customer_id = ?", customerID).
+			Distinct().
+			Pluck("phone", &recipients).Error
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, customer.Email)
+		if customer.Phone != "" {
+			recipients = append(recipients, customer.Phone)
+		}
+
+		result := tx.Model(&domain.NotificationLog{}).
+			Where("recipient IN ?", recipients).
+			Updates(map[string]interface{}{
+				"recipient": erasedValue,
+				"subject":   "",
+				"message":   "",
+				"error":     "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.NotificationsScrubbed = result.RowsAffected
+
+		result = tx.Where("customer_id = ?", customerID).Delete(&domain.Address{})
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.AddressesDeleted = result.RowsAffected
+
+		if err := tx.Where("customer_id = ?", customerID).Delete(&domain.PhoneVerification{}).Error; err != nil {
+			return err
+		}
+
+		// Cart items go with their cart
+		if err := tx.Where("customer_id = ?", customerID).Delete(&domain.Cart{}).Error; err != nil {
+			return err
+		}
+
+		// Orders keep their amounts and the region they shipped to for accounting,
+		// everything that identifies the person is cleared
+		result = tx.Model(&domain.Order{}).
+			Where("customer_id = ?", customerID).
+			Updates(map[string]interface{}{
+				"shipping_recipient_name": erasedValue,
+				"shipping_phone":          "",
+				"shipping_line1":          erasedValue,
+				"shipping_line2":          "",
+				"shipping_postal_code":    "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.OrdersAnonymized = result.RowsAffected
+
+		// Payments keep their amounts and receipts, not the number they were paid from
+		customerOrders := tx.Model(&domain.Order{}).Select("id").Where("customer_id = ?", customerID)
+		err = tx.Model(&domain.PaymentIntent{}).
+			Where("order_id IN (?)", customerOrders).
+			Update("phone", "").Error
+		if err != nil {
+			return err
+		}
+
+		// Returns keep their lines and reasons, not what the customer wrote
+		err = tx.Model(&domain.OrderReturn{}).
+			Where("customer_id = ?", customerID).
+			Update("comment", "").Error
+		if err != nil {
+			return err
+		}
+
+		// Audit entries keep who did what and when, but the diffs of the
+		// customer, their orders, payments and returns carry personal details
+		err = tx.Model(&domain.AuditEntry{}).
+			Where("(entity_type = ? AND entity_id = ?) OR (entity_type = ? AND entity_id IN (?)) OR (entity_type = ? AND entity_id IN (?)) OR (entity_type = ? AND entity_id IN (?))",
+				domain.AuditEntityCustomer, customerID,
+				domain.AuditEntityOrder, customerOrders,
+				domain.AuditEntityPayment, tx.Model(&domain.PaymentIntent{}).Select("id").Where("order_id IN (?)", customerOrders),
+				domain.AuditEntityReturn, tx.Model(&domain.OrderReturn{}).Select("id").Where("customer_id = ?", customerID)).
+			Update("changes", nil).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&domain.AuditEntry{}).
+			Where("actor_type = ? AND actor_id = ?", "customer", customerID).
+			Update("actor_label", fmt.Sprintf("customer:%d", customerID)).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&customer).Updates(map[string]interface{}{
+			"name":                     erasedValue,
+			"email":                    fmt.Sprintf("erased-%d@invalid", customer.ID),
+			"verified_email":           false,
+			"phone":                    "",
+			"phone_verified":           false,
+			"picture":                  "",
+			"pref_email_notifications": false,
+			"pref_sms_notifications":   false,
+			"pref_marketing_opt_in":    false,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
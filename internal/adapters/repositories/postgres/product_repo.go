@@ -2,12 +2,17 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
 	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
@@ -24,7 +29,12 @@ func (r *ProductRepository) Create(ctx context.Context, product *domain.Product)
 	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.Create")
 	defer span.End()
 
-	return r.db.WithContext(ctx).Create(product).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Images").Create(product).Error; err != nil {
+			return err
+		}
+		return setPrice(tx, product.ID, product.Price, product.CreatedAt, "")
+	})
 }
 
 func (r *ProductRepository) Get(ctx context.Context, id uint) (*domain.Product, error) {
@@ -34,29 +44,107 @@ func (r *ProductRepository) Get(ctx context.Context, id uint) (*domain.Product,
 	var product domain.Product
 	err := r.db.WithContext(ctx).
 		Preload("Category").
+		Preload("Images", orderImages).
 		First(&product, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *ProductRepository) List(ctx context.Context) ([]domain.Product, error) {
+func (r *ProductRepository) List(ctx context.Context, q domain.ProductListQuery) ([]domain.Product, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.List")
 	defer span.End()
 
+	query := r.db.WithContext(ctx).Preload("Category").Preload("Images", orderImages)
+	if q.CategoryIDs != nil {
+		query = query.Where("category_id IN ?", q.CategoryIDs)
+	}
+	if q.MinPrice != nil {
+		query = query.Where("price_currency = ? AND price_amount >= ?", q.MinPrice.Currency, q.MinPrice.Amount)
+	}
+	if q.MaxPrice != nil {
+		query = query.Where("price_currency = ? AND price_amount <= ?", q.MaxPrice.Currency, q.MaxPrice.Amount)
+	}
+	if q.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+likeEscaper.Replace(q.Name)+"%")
+	}
+	for _, condition := range q.Attributes {
+		query = query.Where("attributes @@ ?::jsonpath", attributePredicate(condition))
+	}
+
+	// Keyset pagination: continue after the cursor's sort key, with the ID
+	// breaking ties so rows sharing a price or name are neither skipped nor repeated
+	direction, comparison := "ASC", ">"
+	if q.Sort.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	switch q.Sort.Field {
+	case domain.ProductSortPrice:
+		if q.After != nil {
+			query = query.Where("(price_amount, id) "+comparison+" (?, ?)", q.After.Price, q.After.ID)
+		}
+		query = query.Order("price_amount " + direction)
+	case domain.ProductSortName:
+		if q.After != nil {
+			query = query.Where("(name, id) "+comparison+" (?, ?)", q.After.Name, q.After.ID)
+		}
+		query = query.Order("name " + direction)
+	default:
+		if q.After != nil {
+			query = query.Where("(created_at, id) "+comparison+" (?, ?)", *q.After.CreatedAt, q.After.ID)
+		}
+		query = query.Order("created_at " + direction)
+	}
+
 	var products []domain.Product
-	err := r.db.WithContext(ctx).
-		Preload("Category").
-		Find(&products).Error
+	err := query.Order("id " + direction).Limit(q.Limit).Find(&products).Error
 	return products, err
 }
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+var jsonPathOperators = map[domain.AttributeOperator]string{
+	domain.AttributeEq:  "==",
+	domain.AttributeNe:  "!=",
+	domain.AttributeGt:  ">",
+	domain.AttributeGte: ">=",
+	domain.AttributeLt:  "<",
+	domain.AttributeLte: "<=",
+}
+
+// attributePredicate writes the condition as a jsonpath predicate such as
+// $."ram_gb" >= 16, which the GIN index on attributes can serve. Names are
+// checked against domain.AttributeNamePattern before they get here, and
+// values are written as JSON literals so they can't change the expression.
+func attributePredicate(condition domain.AttributeCondition) string {
+	value, _ := json.Marshal(condition.Value)
+	return fmt.Sprintf(`$.%q %s %s`, condition.Name, jsonPathOperators[condition.Operator], value)
+}
+
 func (r *ProductRepository) Update(ctx context.Context, product *domain.Product) error {
 	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.Update")
 	defer span.End()
 
-	return r.db.WithContext(ctx).Save(product).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("price_amount", "price_currency").First(&current, product.ID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// Images are managed through ProductImageRepository
+		if err := tx.Omit("Images").Save(product).Error; err != nil {
+			return err
+		}
+		if err == nil && current.Price == product.Price {
+			return nil
+		}
+		return setPrice(tx, product.ID, product.Price, product.UpdatedAt, "")
+	})
 }
 
 func (r *ProductRepository) Delete(ctx context.Context, id uint) error {
@@ -66,45 +154,301 @@ func (r *ProductRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&domain.Product{}, id).Error
 }
 
-func (r *ProductRepository) GetAverageCategoryPrice(ctx context.Context, productID uint) (float64, error) {
-	// var categoryID uint
-	var products []domain.Product
-
-	fmt.Printf("ID: %d\n", productID)
+func (r *ProductRepository) GetCategoryPriceTotals(ctx context.Context, categoryID uint, byVariant bool) ([]domain.PriceTotal, error) {
+	if byVariant {
+		return r.variantPriceTotals(ctx, categoryID)
+	}
 
-	// err := r.db.WithContext(ctx).
-	// 	Model(&domain.Product{}).
-	// 	Where("category_id = ?", categoryID).
-	// 	Select("COALESCE(AVG(price), 0)").
-	// 	Scan(&avgPrice).Error
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.GetCategoryPriceTotals")
+	defer span.End()
 
+	var totals []domain.PriceTotal
 	err := r.db.WithContext(ctx).
-		Where("category_id = ?", productID).
-		Find(&products).Error
+		Model(&domain.Product{}).
+		Select("price_currency AS currency, SUM(price_amount) AS sum, COUNT(*) AS count").
+		Where("category_id = ?", categoryID).
+		Group("price_currency").
+		Scan(&totals).Error
+	return totals, err
+}
 
-	fmt.Println(products)
-	if err != nil {
-		return 0, err
+// variantPriceTotals counts every variant at its own price, falling back on
+// the product's price, and products without variants at the product's price.
+func (r *ProductRepository) variantPriceTotals(ctx context.Context, categoryID uint) ([]domain.PriceTotal, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.variantPriceTotals")
+	defer span.End()
+
+	var totals []domain.PriceTotal
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT currency, SUM(amount) AS sum, COUNT(*) AS count FROM (
+			SELECT
+				CASE WHEN v.price_currency IS NULL THEN p.price_amount ELSE v.price_amount END AS amount,
+				COALESCE(v.price_currency, p.price_currency) AS currency
+			FROM products p
+			JOIN product_variants v ON v.product_id = p.id AND v.deleted_at IS NULL
+			WHERE p.category_id = ? AND p.deleted_at IS NULL
+			UNION ALL
+			SELECT p.price_amount, p.price_currency
+			FROM products p
+			WHERE p.category_id = ? AND p.deleted_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+		) AS prices
+		GROUP BY currency`, categoryID, categoryID).Scan(&totals).Error
+	return totals, err
+}
+
+var errImportDryRun = errors.New("dry run")
+
+func (r *ProductRepository) ImportBatch(ctx context.Context, rows []domain.ProductImportRow, dryRun bool, check ports.AttributeCheck) ([]domain.ProductImportResult, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.ImportBatch")
+	defer span.End()
+
+	results := make([]domain.ProductImportResult, len(rows))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		skus := make([]string, len(rows))
+		for i, row := range rows {
+			skus[i] = row.SKU
+		}
+
+		// Deleted products keep their SKU until they are purged, so they are
+		// looked up too and the rows that would reuse them are rejected
+		var existing []domain.Product
+		if err := tx.Unscoped().Where("sku IN ?", skus).Find(&existing).Error; err != nil {
+			return err
+		}
+		bySKU := make(map[string]*domain.Product, len(existing))
+		for i := range existing {
+			bySKU[existing[i].SKU] = &existing[i]
+		}
+
+		categories := make(map[string]uint)
+		defined := make(map[uint][]domain.CategoryAttribute)
+		for i, row := range rows {
+			results[i] = domain.ProductImportResult{Line: row.Line, SKU: row.SKU}
+
+			// Each row gets savepoints so one bad row does not abort the batch
+			var lineage []uint
+			var categoryID uint
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				var err error
+				if lineage, err = resolveCategoryPath(tx, categories, row.Category); err != nil {
+					return err
+				}
+				categoryID = lineage[0]
+				if _, ok := defined[categoryID]; ok {
+					return nil
+				}
+				var attributes []domain.CategoryAttribute
+				if err := tx.Where("category_id IN ?", lineage).Find(&attributes).Error; err != nil {
+					return err
+				}
+				defined[categoryID] = attributes
+				return nil
+			})
+			if err == nil {
+				err = tx.Transaction(func(tx *gorm.DB) error {
+					current, found := bySKU[row.SKU]
+					if found && current.DeletedAt.Valid {
+						return fmt.Errorf("sku %q belongs to a deleted product, restore it first", row.SKU)
+					}
+					if !found {
+						product := &domain.Product{
+							SKU:         row.SKU,
+							Name:        row.Name,
+							Description: row.Description,
+							Price:       row.Price,
+							CategoryID:  categoryID,
+							Attributes:  domain.Attributes{},
+						}
+						// Imports carry no attributes, so a category requiring some rejects the row
+						if err := check(product.Attributes, lineage, defined[categoryID]); err != nil {
+							return err
+						}
+						if err := tx.Create(product).Error; err != nil {
+							return err
+						}
+						if err := setPrice(tx, product.ID, product.Price, product.CreatedAt, ""); err != nil {
+							return err
+						}
+						bySKU[row.SKU] = product
+						results[i].Status = domain.ImportRowCreated
+						results[i].ProductID = product.ID
+						created := *product
+						results[i].After = &created
+						return nil
+					}
+
+					results[i].ProductID = current.ID
+					if current.Name == row.Name && current.Description == row.Description &&
+						current.Price == row.Price && current.CategoryID == categoryID {
+						results[i].Status = domain.ImportRowUnchanged
+						return nil
+					}
+
+					if err := check(current.Attributes, lineage, defined[categoryID]); err != nil {
+						return err
+					}
+
+					before := *current
+					current.Name = row.Name
+					current.Description = row.Description
+					current.Price = row.Price
+					current.CategoryID = categoryID
+					if err := tx.Select("name", "description", "price_amount", "price_currency", "category_id", "updated_at").Updates(current).Error; err != nil {
+						*current = before
+						return err
+					}
+					if current.Price != before.Price {
+						if err := setPrice(tx, current.ID, current.Price, time.Now(), ""); err != nil {
+							*current = before
+							return err
+						}
+					}
+					results[i].Status = domain.ImportRowUpdated
+					results[i].Before = &before
+					after := *current
+					results[i].After = &after
+					return nil
+				})
+			}
+			if err != nil {
+				span.RecordError(err)
+				results[i].Status = domain.ImportRowRejected
+				results[i].ProductID = 0
+				results[i].Errors = []string{"could not save row: " + err.Error()}
+			}
+		}
+
+		if dryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		return nil, err
+	}
+	return results, nil
+}
+
+// resolveCategoryPath walks "Electronics > Phones" from the root, matching
+// names case-insensitively and creating the levels that do not exist yet.
+// Resolved paths are cached for the rest of the batch, but only once the
+// whole path resolved, since a failure rolls back the levels it created. It
+// returns the category's lineage, the category first and the root last.
+func resolveCategoryPath(tx *gorm.DB, cache map[string]uint, path string) ([]uint, error) {
+	names := domain.SplitCategoryPath(path)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("invalid category path %q", path)
 	}
 
-	if len(products) == 0 {
-		return 0, errors.New("no products found in this category")
+	var parentID *uint
+	key := ""
+	lineage := make([]uint, len(names))
+	resolved := make(map[string]uint, len(names))
+	for i, name := range names {
+		key += strings.ToLower(name) + domain.CategoryPathSeparator
+		if id, ok := cache[key]; ok {
+			lineage[len(names)-1-i] = id
+			parentID = &id
+			continue
+		}
+
+		var category domain.Category
+		query := tx.Where("LOWER(name) = LOWER(?)", name)
+		if parentID == nil {
+			query = query.Where("parent_category_id IS NULL")
+		} else {
+			query = query.Where("parent_category_id = ?", *parentID)
+		}
+		err := query.Order("id").First(&category).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			category = domain.Category{Name: name, ParentCategoryID: parentID}
+			err = tx.Create(&category).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resolved[key] = category.ID
+		lineage[len(names)-1-i] = category.ID
+		id := category.ID
+		parentID = &id
 	}
 
-	var totalPrice float64
-	var count int
+	for key, id := range resolved {
+		cache[key] = id
+	}
+	return lineage, nil
+}
+
+func (r *ProductRepository) ListAfter(ctx context.Context, afterID uint, limit int, categoryIDs []uint) ([]domain.Product, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.ListAfter")
+	defer span.End()
+
+	query := r.db.WithContext(ctx).Where("id > ?", afterID)
+	if categoryIDs != nil {
+		query = query.Where("category_id IN ?", categoryIDs)
+	}
+
+	var products []domain.Product
+	err := query.Order("id").Limit(limit).Find(&products).Error
+	return products, err
+}
+
+func (r *ProductRepository) GetDeleted(ctx context.Context, id uint) (*domain.Product, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.GetDeleted")
+	defer span.End()
 
-	for _, product := range products {
-		totalPrice += product.Price
-		count++
+	var product domain.Product
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&product, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
+	return &product, nil
+}
 
-	// Avoid division by zero (though this is checked earlier)
-	if count == 0 {
-		return 0, errors.New("no valid products to calculate average")
+func (r *ProductRepository) ListDeleted(ctx context.Context) ([]domain.Product, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.ListDeleted")
+	defer span.End()
+
+	var products []domain.Product
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC, id").
+		Find(&products).Error
+	return products, err
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.Restore")
+	defer span.End()
+
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
 	}
+	return nil
+}
 
-	averagePrice := totalPrice / float64(count)
-	return averagePrice, nil
+func (r *ProductRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductRepository.PurgeDeleted")
+	defer span.End()
 
+	// Products on past orders are kept so the orders can still show them
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at < ?", deletedBefore).
+		Where("NOT EXISTS (SELECT 1 FROM order_items WHERE order_items.product_id = products.id)").
+		Delete(&domain.Product{})
+	return result.RowsAffected, result.Error
 }
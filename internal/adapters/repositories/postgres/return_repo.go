@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type ReturnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) *ReturnRepository {
+	return &ReturnRepository{
+		db: db,
+	}
+}
+
+func (r *ReturnRepository) Create(ctx context.Context, ret *domain.OrderReturn) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(ret).Error
+}
+
+func (r *ReturnRepository) Get(ctx context.Context, id uint) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.Get")
+	defer span.End()
+
+	var ret domain.OrderReturn
+	err := r.withDetails(ctx).First(&ret, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (r *ReturnRepository) List(ctx context.Context, status domain.ReturnStatus) ([]domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.List")
+	defer span.End()
+
+	query := r.withDetails(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var returns []domain.OrderReturn
+	err := query.Order("created_at, id").Find(&returns).Error
+	return returns, err
+}
+
+func (r *ReturnRepository) ListByCustomer(ctx context.Context, customerID uint) ([]domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.ListByCustomer")
+	defer span.End()
+
+	var returns []domain.OrderReturn
+	err := r.withDetails(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at, id").
+		Find(&returns).Error
+	return returns, err
+}
+
+func (r *ReturnRepository) ListByOrder(ctx context.Context, orderID uint) ([]domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.ListByOrder")
+	defer span.End()
+
+	var returns []domain.OrderReturn
+	err := r.db.WithContext(ctx).
+		Preload("Lines").
+		Where("order_id = ?", orderID).
+		Order("created_at, id").
+		Find(&returns).Error
+	return returns, err
+}
+
+func (r *ReturnRepository) AddPhoto(ctx context.Context, photo *domain.ReturnPhoto) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.AddPhoto")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(photo).Error
+}
+
+func (r *ReturnRepository) Transition(ctx context.Context, ret *domain.OrderReturn, from domain.ReturnStatus, restock map[uint]int) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.Transition")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.OrderReturn{}).
+			Where("id = ? AND status = ?", ret.ID, from).
+			Updates(map[string]interface{}{
+				"status":          ret.Status,
+				"note":            ret.Note,
+				"restocked":       ret.Restocked,
+				"resolution":      ret.Resolution,
+				"refund_id":       ret.RefundID,
+				"amount_amount":   ret.Amount.Amount,
+				"amount_currency": ret.Amount.Currency,
+				"approved_at":     ret.ApprovedAt,
+				"rejected_at":     ret.RejectedAt,
+				"received_at":     ret.ReceivedAt,
+				"resolved_at":     ret.ResolvedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrReturnChanged
+		}
+
+		for variantID, quantity := range restock {
+			err := tx.Model(&domain.ProductVariant{}).
+				Where("id = ?", variantID).
+				Update("stock", gorm.Expr("stock + ?", quantity)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ReturnRepository) ListStoreCredit(ctx context.Context, customerID uint) ([]domain.StoreCredit, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnRepository.ListStoreCredit")
+	defer span.End()
+
+	var credits []domain.StoreCredit
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Order("created_at, id").
+		Find(&credits).Error
+	return credits, err
+}
+
+func (r *ReturnRepository) withDetails(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Preload("Lines").
+		Preload("Photos", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		})
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type ShippingRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingRepository(db *gorm.DB) *ShippingRepository {
+	return &ShippingRepository{
+		db: db,
+	}
+}
+
+func (r *ShippingRepository) CreateMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingRepository.CreateMethod")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(method).Error
+}
+
+func (r *ShippingRepository) GetMethod(ctx context.Context, id uint) (*domain.ShippingMethod, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingRepository.GetMethod")
+	defer span.End()
+
+	var method domain.ShippingMethod
+	err := r.db.WithContext(ctx).First(&method, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+func (r *ShippingRepository) ListMethods(ctx context.Context, activeOnly bool) ([]domain.ShippingMethod, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingRepository.ListMethods")
+	defer span.End()
+
+	query := r.db.WithContext(ctx).Order("name, id")
+	if activeOnly {
+		query = query.Where("active")
+	}
+	var methods []domain.ShippingMethod
+	err := query.Find(&methods).Error
+	return methods, err
+}
+
+func (r *ShippingRepository) UpdateMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingRepository.UpdateMethod")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Omit("CreatedAt").Save(method).Error
+}
+
+func (r *ShippingRepository) DeleteMethod(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingRepository.DeleteMethod")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Delete(&domain.ShippingMethod{}, id).Error
+}
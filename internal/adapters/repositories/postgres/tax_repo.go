@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type TaxRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRepository(db *gorm.DB) *TaxRepository {
+	return &TaxRepository{
+		db: db,
+	}
+}
+
+func (r *TaxRepository) CreateClass(ctx context.Context, class *domain.TaxClass) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.CreateClass")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(class).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaults(tx, class)
+	})
+}
+
+func (r *TaxRepository) GetClass(ctx context.Context, id uint) (*domain.TaxClass, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.GetClass")
+	defer span.End()
+
+	var class domain.TaxClass
+	err := r.db.WithContext(ctx).First(&class, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &class, nil
+}
+
+func (r *TaxRepository) ListClasses(ctx context.Context) ([]domain.TaxClass, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.ListClasses")
+	defer span.End()
+
+	var classes []domain.TaxClass
+	err := r.db.WithContext(ctx).Order("name").Find(&classes).Error
+	return classes, err
+}
+
+func (r *TaxRepository) UpdateClass(ctx context.Context, class *domain.TaxClass) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.UpdateClass")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("CreatedAt").Save(class).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaults(tx, class)
+	})
+}
+
+// clearOtherDefaults keeps the class the only default.
+func clearOtherDefaults(tx *gorm.DB, class *domain.TaxClass) error {
+	if !class.IsDefault {
+		return nil
+	}
+	return tx.Model(&domain.TaxClass{}).
+		Where("id <> ? AND is_default", class.ID).
+		Update("is_default", false).Error
+}
+
+func (r *TaxRepository) DeleteClass(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.DeleteClass")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Product{}).Unscoped().Where("tax_class_id = ?", id).Update("tax_class_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Category{}).Unscoped().Where("tax_class_id = ?", id).Update("tax_class_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tax_class_id = ?", id).Delete(&domain.TaxRate{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.TaxClass{}, id).Error
+	})
+}
+
+func (r *TaxRepository) CreateRate(ctx context.Context, rate *domain.TaxRate) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.CreateRate")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Create(rate).Error
+}
+
+func (r *TaxRepository) RatesAt(ctx context.Context, at time.Time) ([]domain.TaxRate, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.RatesAt")
+	defer span.End()
+
+	var rates []domain.TaxRate
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (tax_class_id) * FROM tax_rates
+		WHERE effective_from <= ?
+		ORDER BY tax_class_id, effective_from DESC`, at).
+		Scan(&rates).Error
+	return rates, err
+}
+
+func (r *TaxRepository) RateHistory(ctx context.Context, classID uint) ([]domain.TaxRate, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.RateHistory")
+	defer span.End()
+
+	var rates []domain.TaxRate
+	err := r.db.WithContext(ctx).
+		Where("tax_class_id = ?", classID).
+		Order("effective_from").
+		Find(&rates).Error
+	return rates, err
+}
+
+func (r *TaxRepository) SetCategoryClass(ctx context.Context, categoryID uint, classID *uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.SetCategoryClass")
+	defer span.End()
+
+	return setTaxClass(r.db.WithContext(ctx).Model(&domain.Category{}), categoryID, classID)
+}
+
+func (r *TaxRepository) SetProductClass(ctx context.Context, productID uint, classID *uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxRepository.SetProductClass")
+	defer span.End()
+
+	return setTaxClass(r.db.WithContext(ctx).Model(&domain.Product{}), productID, classID)
+}
+
+func setTaxClass(query *gorm.DB, id uint, classID *uint) error {
+	result := query.Where("id = ?", id).Update("tax_class_id", classID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+type ProductVariantRepository struct {
+	db *gorm.DB
+}
+
+func NewProductVariantRepository(db *gorm.DB) *ProductVariantRepository {
+	return &ProductVariantRepository{
+		db: db,
+	}
+}
+
+func (r *ProductVariantRepository) Create(ctx context.Context, variant *domain.ProductVariant) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantRepository.Create")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		values, err := resolveVariantOptions(tx, variant.ProductID, variant.Options)
+		if err != nil {
+			return err
+		}
+		variant.OptionValues = values
+		return tx.Create(variant).Error
+	})
+}
+
+func (r *ProductVariantRepository) Get(ctx context.Context, id uint) (*domain.ProductVariant, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantRepository.Get")
+	defer span.End()
+
+	var variant domain.ProductVariant
+	err := r.db.WithContext(ctx).
+		Preload("OptionValues.Option").
+		First(&variant, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	fillVariantOptions(&variant)
+	return &variant, nil
+}
+
+func (r *ProductVariantRepository) ListByProduct(ctx context.Context, productID uint) ([]domain.ProductVariant, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantRepository.ListByProduct")
+	defer span.End()
+
+	var variants []domain.ProductVariant
+	err := r.db.WithContext(ctx).
+		Preload("OptionValues.Option").
+		Where("product_id = ?", productID).
+		Order("id").
+		Find(&variants).Error
+	for i := range variants {
+		fillVariantOptions(&variants[i])
+	}
+	return variants, err
+}
+
+func (r *ProductVariantRepository) ListOptions(ctx context.Context, productID uint) ([]domain.ProductOption, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantRepository.ListOptions")
+	defer span.End()
+
+	var options []domain.ProductOption
+	err := r.db.WithContext(ctx).
+		Preload("Values", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position, id")
+		}).
+		Where("product_id = ?", productID).
+		Order("position, id").
+		Find(&options).Error
+	return options, err
+}
+
+func (r *ProductVariantRepository) Update(ctx context.Context, variant *domain.ProductVariant) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantRepository.Update")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		values, err := resolveVariantOptions(tx, variant.ProductID, variant.Options)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(variant).Select("sku", "price_amount", "price_currency", "stock", "updated_at").Updates(variant).Error; err != nil {
+			return err
+		}
+		variant.OptionValues = values
+		return tx.Model(variant).Association("OptionValues").Replace(values)
+	})
+}
+
+func (r *ProductVariantRepository) Delete(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantRepository.Delete")
+	defer span.End()
+
+	return r.db.WithContext(ctx).Delete(&domain.ProductVariant{}, id).Error
+}
+
+// resolveVariantOptions finds the stored value for each option the variant
+// picks, adding options and values the product does not have yet. New ones go
+// after the existing ones so the order they were first used in is kept.
+func resolveVariantOptions(tx *gorm.DB, productID uint, options []domain.VariantOption) ([]domain.ProductOptionValue, error) {
+	values := make([]domain.ProductOptionValue, 0, len(options))
+	for _, selected := range options {
+		var option domain.ProductOption
+		err := tx.Where("product_id = ? AND name = ?", productID, selected.Name).First(&option).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var count int64
+			if err := tx.Model(&domain.ProductOption{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			option = domain.ProductOption{ProductID: productID, Name: selected.Name, Position: int(count)}
+			err = tx.Create(&option).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var value domain.ProductOptionValue
+		err = tx.Where("option_id = ? AND value = ?", option.ID, selected.Value).First(&value).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var count int64
+			if err := tx.Model(&domain.ProductOptionValue{}).Where("option_id = ?", option.ID).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			value = domain.ProductOptionValue{OptionID: option.ID, Value: selected.Value, Position: int(count)}
+			err = tx.Create(&value).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// fillVariantOptions turns the preloaded option values into name and value
+// pairs, in the product's option order.
+func fillVariantOptions(variant *domain.ProductVariant) {
+	values := variant.OptionValues
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].Option == nil || values[j].Option == nil {
+			return false
+		}
+		return values[i].Option.Position < values[j].Option.Position
+	})
+
+	variant.Options = make([]domain.VariantOption, 0, len(values))
+	for _, value := range values {
+		if value.Option != nil {
+			variant.Options = append(variant.Options, domain.VariantOption{Name: value.Option.Name, Value: value.Value})
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// PostgresProductSearch searches the generated search_vector column with
+// Postgres full-text search, and falls back on pg_trgm word similarity on the
+// name so misspelt queries still find something.
+type PostgresProductSearch struct {
+	db *gorm.DB
+}
+
+func NewPostgresProductSearch(db *gorm.DB) *PostgresProductSearch {
+	return &PostgresProductSearch{
+		db: db,
+	}
+}
+
+type searchMatch struct {
+	ID   uint
+	Name string
+	Rank float64
+}
+
+func (s *PostgresProductSearch) Search(ctx context.Context, query domain.ProductSearchQuery) (*domain.ProductSearchPage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PostgresProductSearch.Search")
+	defer span.End()
+
+	page := &domain.ProductSearchPage{Page: query.Page, PageSize: query.PageSize, Hits: []domain.ProductSearchHit{}}
+	matches := s.db.WithContext(ctx).
+		Table("products").
+		Where("deleted_at IS NULL").
+		Where("search_vector @@ websearch_to_tsquery('english', ?) OR ? <% name", query.Query, query.Query)
+
+	if err := matches.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int64("search.total", page.Total))
+	if page.Total == 0 {
+		return page, nil
+	}
+
+	var ranked []searchMatch
+	err := matches.
+		Select("id, name, ts_rank_cd(search_vector, websearch_to_tsquery('english', ?)) + word_similarity(?, name) AS rank", query.Query, query.Query).
+		Order("rank DESC, id").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Scan(&ranked).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ranked) == 0 {
+		return page, nil
+	}
+
+	ids := make([]uint, len(ranked))
+	for i, match := range ranked {
+		ids[i] = match.ID
+	}
+	var products []domain.Product
+	if err := s.db.WithContext(ctx).Preload("Category").Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]domain.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	for _, match := range ranked {
+		if product, ok := byID[match.ID]; ok {
+			page.Hits = append(page.Hits, domain.ProductSearchHit{Product: product, Rank: match.Rank})
+		}
+	}
+	return page, nil
+}
+
+func (s *PostgresProductSearch) Suggest(ctx context.Context, prefix string, limit int) ([]domain.ProductSuggestion, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PostgresProductSearch.Suggest")
+	defer span.End()
+
+	tsquery := prefixQuery(prefix)
+	if tsquery == "" {
+		return []domain.ProductSuggestion{}, nil
+	}
+
+	var matches []searchMatch
+	err := s.db.WithContext(ctx).
+		Table("products").
+		Select("id, name, ts_rank_cd(search_vector, to_tsquery('english', ?)) + word_similarity(?, name) AS rank", tsquery, prefix).
+		Where("deleted_at IS NULL").
+		Where("search_vector @@ to_tsquery('english', ?) OR ? <% name", tsquery, prefix).
+		Order("rank DESC, name").
+		Limit(limit).
+		Scan(&matches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]domain.ProductSuggestion, len(matches))
+	for i, match := range matches {
+		suggestions[i] = domain.ProductSuggestion{ID: match.ID, Name: match.Name}
+	}
+	return suggestions, nil
+}
+
+// prefixQuery turns "red sneak" into "red & sneak:*" so the word being typed
+// matches as a prefix. Anything but letters and digits is dropped, which
+// keeps user input from being read as tsquery syntax.
+func prefixQuery(text string) string {
+	terms := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) == 0 {
+		return ""
+	}
+	terms[len(terms)-1] += ":*"
+	return strings.Join(terms, " & ")
+}
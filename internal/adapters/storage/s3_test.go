@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var s3TestNow = time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+// fakeS3 keeps objects in memory by host and escaped path, and remembers the
+// last request it got.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	last    *http.Request
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last = r
+
+	object := r.Host + r.URL.EscapedPath()
+	if strings.HasSuffix(object, "/broken") {
+		http.Error(w, "<Error><Code>InternalError</Code></Error>", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[object] = body
+	case http.MethodGet:
+		body, ok := f.objects[object]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		if _, ok := f.objects[object]; !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		delete(f.objects, object)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newS3TestStore returns a store whose requests all reach a fake S3, whatever
+// host they are addressed to.
+func newS3TestStore(t *testing.T, cfg S3Config) (*S3BlobStore, *fakeS3) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3BlobStore(cfg)
+	assert.NoError(t, err)
+	store.client = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}}
+	store.now = func() time.Time { return s3TestNow }
+	return store, fake
+}
+
+func TestS3BlobStore_PutGetDelete(t *testing.T) {
+	tests := []struct {
+		name      string
+		pathStyle bool
+		object    string
+	}{
+		{name: "path style", pathStyle: true, object: "s3.test/photos/returns/1/photo%20one.jpg"},
+		{name: "virtual host", pathStyle: false, object: "photos.s3.test/returns/1/photo%20one.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, fake := newS3TestStore(t, S3Config{Endpoint: "http://s3.test", Bucket: "photos", AccessKey: "minio", SecretKey: "secret", PathStyle: tt.pathStyle})
+			ctx := context.Background()
+			key := "returns/1/photo one.jpg"
+
+			err := store.Put(ctx, key, bytes.NewReader([]byte("jpeg")), 4, "image/jpeg")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("jpeg"), fake.objects[tt.object])
+			assert.Equal(t, "image/jpeg", fake.last.Header.Get("Content-Type"))
+			assert.Equal(t, int64(4), fake.last.ContentLength)
+
+			body, err := store.Get(ctx, key)
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(body)
+			body.Close()
+			assert.Equal(t, "jpeg", string(data))
+
+			assert.NoError(t, store.Delete(ctx, key))
+			assert.Empty(t, fake.objects)
+
+			_, err = store.Get(ctx, key)
+			assert.ErrorIs(t, err, domain.ErrNotFound)
+			// Deleting what is already gone is not an error
+			assert.NoError(t, store.Delete(ctx, key))
+		})
+	}
+}
+
+func TestS3BlobStore_Errors(t *testing.T) {
+	store, _ := newS3TestStore(t, S3Config{Endpoint: "http://s3.test", Bucket: "photos", PathStyle: true})
+
+	err := store.Put(context.Background(), "broken", strings.NewReader("jpeg"), 4, "image/jpeg")
+	assert.EqualError(t, err, "s3 PUT /photos/broken: 500 Internal Server Error: <Error><Code>InternalError</Code></Error>")
+	assert.NotErrorIs(t, err, domain.ErrNotFound)
+
+	err = store.Delete(context.Background(), "broken")
+	assert.Error(t, err)
+}
+
+func TestS3BlobStore_URL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  S3Config
+		want string
+	}{
+		{
+			name: "path style",
+			cfg:  S3Config{Endpoint: "http://localhost:9000/", Bucket: "photos", PathStyle: true},
+			want: "http://localhost:9000/photos/returns/1/photo%20one%2B.jpg",
+		},
+		{
+			name: "virtual host",
+			cfg:  S3Config{Endpoint: "http://localhost:9000", Bucket: "photos"},
+			want: "http://photos.localhost:9000/returns/1/photo%20one%2B.jpg",
+		},
+		{
+			name: "endpoint with a path",
+			cfg:  S3Config{Endpoint: "http://localhost:9000/s3", Bucket: "photos", PathStyle: true},
+			want: "http://localhost:9000/s3/photos/returns/1/photo%20one%2B.jpg",
+		},
+		{
+			name: "aws",
+			cfg:  S3Config{Region: "eu-west-1", Bucket: "photos"},
+			want: "https://photos.s3.eu-west-1.amazonaws.com/returns/1/photo%20one%2B.jpg",
+		},
+		{
+			name: "public url",
+			cfg:  S3Config{Bucket: "photos", PublicURL: "https://cdn.example.com/"},
+			want: "https://cdn.example.com/returns/1/photo%20one%2B.jpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewS3BlobStore(tt.cfg)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, store.URL("returns/1/photo one+.jpg"))
+		})
+	}
+
+	_, err := NewS3BlobStore(S3Config{})
+	assert.Error(t, err)
+	_, err = NewS3BlobStore(S3Config{Bucket: "photos", Endpoint: "localhost"})
+	assert.Error(t, err)
+}
+
+func TestS3BlobStore_Authorization(t *testing.T) {
+	store, fake := newS3TestStore(t, S3Config{Endpoint: "http://s3.test", Region: "eu-west-1", Bucket: "photos", AccessKey: "minio", SecretKey: "secret", PathStyle: true})
+
+	_, err := store.Get(context.Background(), "returns/1/photo.jpg")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	assert.Equal(t, "20261019T083000Z", fake.last.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "UNSIGNED-PAYLOAD", fake.last.Header.Get("X-Amz-Content-Sha256"))
+	authorization := fake.last.Header.Get("Authorization")
+	assert.Regexp(t, regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=minio/20261019/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`), authorization)
+
+	// The same request signed again gets the same signature, and another
+	// secret a different one
+	_, _ = store.Get(context.Background(), "returns/1/photo.jpg")
+	assert.Equal(t, authorization, fake.last.Header.Get("Authorization"))
+	store.cfg.SecretKey = "other"
+	_, _ = store.Get(context.Background(), "returns/1/photo.jpg")
+	assert.NotEqual(t, authorization, fake.last.Header.Get("Authorization"))
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+type Address struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CustomerID    uint      `json:"customer_id" gorm:"index"`
+	Label         string    `json:"label"`
+	RecipientName string    `json:"recipient_name"`
+	Phone         string    `json:"phone"`
+	Line1         string    `json:"line1"`
+	Line2         string    `json:"line2"`
+	City          string    `json:"city"`
+	County        string    `json:"county"`
+	PostalCode    string    `json:"postal_code"`
+	Country       string    `json:"country"`
+	IsDefault     bool      `json:"is_default"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ShippingAddress is the copy of an address stored on an order. It is
+// detached from the address book so later edits do not rewrite history.
+type ShippingAddress struct {
+	RecipientName string `json:"recipient_name"`
+	Phone         string `json:"phone"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2"`
+	City          string `json:"city"`
+	County        string `json:"county"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+}
+
+func (a *Address) Snapshot() ShippingAddress {
+	return ShippingAddress{
+		RecipientName: a.RecipientName,
+		Phone:         a.Phone,
+		Line1:         a.Line1,
+		Line2:         a.Line2,
+		City:          a.City,
+		County:        a.County,
+		PostalCode:    a.PostalCode,
+		Country:       a.Country,
+	}
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+var ErrOrderNotAmendable = NewValidationError("only unpaid orders that are still placed can be amended, refund lines of a paid order instead")
+
+// Amendable reports whether an order in the status can still have its lines
+// changed. Only placed orders can, nothing has been done with them yet.
+func (s OrderStatus) Amendable() bool {
+	return s == OrderPlaced
+}
+
+// Matches reports whether the line is for the same product and variant.
+func (i *OrderItem) Matches(productID uint, variantID *uint) bool {
+	if i.ProductID != productID {
+		return false
+	}
+	if i.VariantID == nil || variantID == nil {
+		return i.VariantID == nil && variantID == nil
+	}
+	return *i.VariantID == *variantID
+}
+
+// OrderAmendment is a change made to an order's lines after it was placed,
+// kept so the order's history shows what it was before.
+type OrderAmendment struct {
+	ID      uint                 `json:"id" gorm:"primaryKey"`
+	OrderID uint                 `json:"order_id" gorm:"index"`
+	Lines   []OrderAmendmentLine `json:"lines" gorm:"foreignKey:AmendmentID"`
+	Reason  string               `json:"reason"`
+	// PreviousTotal and NewTotal are the order's TotalPrice before and after.
+	PreviousTotal Money     `json:"previous_total" gorm:"embedded;embeddedPrefix:previous_total_"`
+	NewTotal      Money     `json:"new_total" gorm:"embedded;embeddedPrefix:new_total_"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// OrderAmendmentLine is one line the amendment added, changed or removed. An
+// added line has no PreviousQuantity and a removed one no Quantity, Price
+// being the line's price before its discount.
+type OrderAmendmentLine struct {
+	ID               uint  `json:"id" gorm:"primaryKey"`
+	AmendmentID      uint  `json:"amendment_id" gorm:"index"`
+	OrderItemID      uint  `json:"order_item_id"`
+	ProductID        uint  `json:"product_id"`
+	VariantID        *uint `json:"variant_id"`
+	PreviousQuantity int   `json:"previous_quantity"`
+	Quantity         int   `json:"quantity"`
+	PreviousPrice    Money `json:"previous_price" gorm:"embedded;embeddedPrefix:previous_price_"`
+	Price            Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+}
+
+// AmendRequest is the lines an order should have after an amendment. Lines
+// of the order that aren't listed are removed.
+type AmendRequest struct {
+	Lines  []AmendLineRequest
+	Reason string
+}
+
+// AmendLineRequest is matched to the order's line for the same product and
+// variant, or added when there is none.
+type AmendLineRequest struct {
+	ProductID uint
+	VariantID *uint
+	Quantity  int
+}
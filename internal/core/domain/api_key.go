@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeOrdersRead    = "orders:read"
+	ScopeOrdersWrite   = "orders:write"
+	ScopeAdmin         = "admin"
+)
+
+// KnownScopes lists every scope an API key may be granted.
+var KnownScopes = []string{
+	ScopeProductsRead,
+	ScopeProductsWrite,
+	ScopeOrdersRead,
+	ScopeOrdersWrite,
+	ScopeAdmin,
+}
+
+// Scopes is stored as a comma separated text column.
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+func (s *Scopes) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Scopes", value)
+	}
+
+	*s = nil
+	for _, scope := range strings.Split(raw, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			*s = append(*s, scope)
+		}
+	}
+	return nil
+}
+
+func (s Scopes) Has(scope string) bool {
+	for _, granted := range s {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex"`
+	KeyHash    string     `json:"-"`
+	Scopes     Scopes     `json:"scopes" gorm:"type:text"`
+	CreatedBy  uint       `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+
+	AuditEntityProduct  = "product"
+	AuditEntityCategory = "category"
+	AuditEntityVariant  = "product_variant"
+	AuditEntityImage    = "product_image"
+	AuditEntityPrice    = "product_price"
+	AuditEntityRate     = "exchange_rate"
+	AuditEntityDiscount = "discount"
+	AuditEntityTaxClass = "tax_class"
+	AuditEntityTaxRate  = "tax_rate"
+	AuditEntityShipping = "shipping_method"
+	AuditEntityOrder    = "order"
+	AuditEntityPayment  = "payment_intent"
+	AuditEntityReturn   = "order_return"
+	AuditEntityCustomer = "customer"
+)
+
+// JSON is a raw JSON document stored in a jsonb column.
+type JSON []byte
+
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSON", value)
+	}
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
+
+// AuditEntry records one change made through a service. Changes maps each
+// field that changed to its before and after values.
+type AuditEntry struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorType  string    `json:"actor_type" gorm:"index:idx_audit_actor"`
+	ActorID    uint      `json:"actor_id" gorm:"index:idx_audit_actor"`
+	ActorLabel string    `json:"actor_label"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type" gorm:"index:idx_audit_entity"`
+	EntityID   uint      `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Changes    JSON      `json:"changes" gorm:"type:jsonb"`
+	RequestID  string    `json:"request_id"`
+	TraceID    string    `json:"trace_id"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// AuditChange is the before and after value of one field.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   uint
+	ActorType  string
+	ActorID    uint
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PageSize   int
+}
+
+type AuditPage struct {
+	Entries  []AuditEntry `json:"data"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+	Total    int64        `json:"total"`
+}
@@ -0,0 +1,88 @@
+package domain
+
+import "time"
+
+// MaxCartQuantity caps the quantity of one cart line.
+const MaxCartQuantity = 999
+
+var ErrCartEmpty = NewValidationError("the cart is empty")
+
+// Cart is what a shopper means to order. A signed-in customer has one cart,
+// an anonymous shopper's cart is found by its token until they sign in and
+// it is merged into the customer's cart.
+type Cart struct {
+	ID         uint       `json:"id"`
+	CustomerID *uint      `json:"customer_id" gorm:"uniqueIndex"`
+	Token      string     `json:"-" gorm:"index:idx_carts_token,unique,where:token <> ''"`
+	Items      []CartItem `json:"items" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CartItem is a product, or one of its variants, and how many of it. Prices
+// are not kept, the cart is priced afresh every time it is viewed.
+type CartItem struct {
+	ID        uint      `json:"id"`
+	CartID    uint      `json:"cart_id" gorm:"not null;index"`
+	ProductID uint      `json:"product_id" gorm:"not null"`
+	VariantID *uint     `json:"variant_id"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the item is for the same product and variant.
+func (i *CartItem) Matches(productID uint, variantID *uint) bool {
+	if i.ProductID != productID {
+		return false
+	}
+	if i.VariantID == nil || variantID == nil {
+		return i.VariantID == nil && variantID == nil
+	}
+	return *i.VariantID == *variantID
+}
+
+// CartOwner is who a cart belongs to: a signed-in customer, an anonymous
+// shopper holding a cart token, or a customer who still holds the token of
+// the cart they filled before signing in.
+type CartOwner struct {
+	CustomerID uint
+	Token      string
+}
+
+// CartView is a cart priced at the prices in force now. Lines that can't be
+// ordered any more are kept but flagged, and left out of the subtotal.
+type CartView struct {
+	// Token identifies an anonymous cart, send it back as X-Cart-Token
+	Token    string     `json:"token,omitempty"`
+	Items    []CartLine `json:"items"`
+	Quantity int        `json:"quantity"`
+	// Subtotal is in the currency of the first line, nil for an empty cart
+	Subtotal        *Money `json:"subtotal"`
+	DisplaySubtotal *Money `json:"display_subtotal,omitempty"`
+	// CheckoutReady is false when the cart is empty or a line is unavailable
+	CheckoutReady bool `json:"checkout_ready"`
+}
+
+type CartLine struct {
+	ID        uint   `json:"id"`
+	ProductID uint   `json:"product_id"`
+	VariantID *uint  `json:"variant_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice *Money `json:"unit_price"`
+	Price     *Money `json:"price"`
+	// DisplayPrice is Price converted to the currency the client asked for.
+	DisplayPrice *Money `json:"display_price,omitempty"`
+	Available    bool   `json:"available"`
+	// Problem says why an unavailable line can't be ordered
+	Problem string `json:"problem,omitempty"`
+}
+
+// CartCheckout is what checkout needs besides the cart.
+type CartCheckout struct {
+	AddressID        *uint
+	Currency         string
+	DiscountCodes    []string
+	ShippingMethodID *uint
+}
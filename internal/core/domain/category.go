@@ -1,8 +1,20 @@
 package domain
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Category struct {
 	ID               uint       `json:"id"`
 	Name             string     `json:"name"`
+	Description      string     `json:"description" gorm:"not null;default:''"`
 	ParentCategoryID *uint      `json:"parent_category_id"`
 	Category         []Category `json:"category" gorm:"foreignKey:ParentCategoryID"`
+	// TaxClassID applies to the category's products and to subcategories that don't name their own.
+	TaxClassID *uint          `json:"tax_class_id" gorm:"index"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
@@ -0,0 +1,94 @@
+package domain
+
+import "strings"
+
+// Regions are Kenya's former provinces, which shipping zones can name
+// instead of listing their counties one by one.
+var Regions = []string{"Nairobi", "Central", "Coast", "Eastern", "North Eastern", "Nyanza", "Rift Valley", "Western"}
+
+// countyRegions maps each of the 47 counties, by countyKey, to its region.
+var countyRegions = map[string]string{
+	"nairobi": "Nairobi",
+
+	"kiambu":    "Central",
+	"kirinyaga": "Central",
+	"muranga":   "Central",
+	"nyandarua": "Central",
+	"nyeri":     "Central",
+
+	"mombasa":     "Coast",
+	"kwale":       "Coast",
+	"kilifi":      "Coast",
+	"tanariver":   "Coast",
+	"lamu":        "Coast",
+	"taitataveta": "Coast",
+
+	"marsabit":     "Eastern",
+	"isiolo":       "Eastern",
+	"meru":         "Eastern",
+	"tharakanithi": "Eastern",
+	"embu":         "Eastern",
+	"kitui":        "Eastern",
+	"machakos":     "Eastern",
+	"makueni":      "Eastern",
+
+	"garissa": "North Eastern",
+	"wajir":   "North Eastern",
+	"mandera": "North Eastern",
+
+	"siaya":   "Nyanza",
+	"kisumu":  "Nyanza",
+	"homabay": "Nyanza",
+	"migori":  "Nyanza",
+	"kisii":   "Nyanza",
+	"nyamira": "Nyanza",
+
+	"turkana":        "Rift Valley",
+	"westpokot":      "Rift Valley",
+	"samburu":        "Rift Valley",
+	"transnzoia":     "Rift Valley",
+	"uasingishu":     "Rift Valley",
+	"elgeyomarakwet": "Rift Valley",
+	"nandi":          "Rift Valley",
+	"baringo":        "Rift Valley",
+	"laikipia":       "Rift Valley",
+	"nakuru":         "Rift Valley",
+	"narok":          "Rift Valley",
+	"kajiado":        "Rift Valley",
+	"kericho":        "Rift Valley",
+	"bomet":          "Rift Valley",
+
+	"kakamega": "Western",
+	"vihiga":   "Western",
+	"bungoma":  "Western",
+	"busia":    "Western",
+}
+
+// CountyRegion returns the region of a Kenyan county. Case, spacing,
+// punctuation and a trailing "County" don't matter, so "Murang'a" and
+// "muranga county" are the same county.
+func CountyRegion(county string) (string, bool) {
+	region, ok := countyRegions[countyKey(county)]
+	return region, ok
+}
+
+func isRegion(name string) bool {
+	for _, region := range Regions {
+		if strings.EqualFold(strings.TrimSpace(name), region) {
+			return true
+		}
+	}
+	return false
+}
+
+func countyKey(county string) string {
+	county = strings.ToLower(strings.TrimSpace(county))
+	county = strings.TrimSuffix(county, "county")
+	var key strings.Builder
+	for _, r := range county {
+		if r >= 'a' && r <= 'z' {
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
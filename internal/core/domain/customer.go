@@ -1,10 +1,54 @@
 package domain
 
+import "time"
+
 type Customer struct {
-	ID            uint   `json:"id" gorm:"primaryKey"`
-	Name          string `json:"name"`
-	Email         string `json:"email" gorm:"unique"`
-	VerifiedEmail bool   `json:"verified_email"`
-	Phone         string `json:"phone"`
-	Picture       string `json:"picture"`
+	ID            uint                `json:"id" gorm:"primaryKey"`
+	Name          string              `json:"name"`
+	Email         string              `json:"email" gorm:"unique"`
+	VerifiedEmail bool                `json:"verified_email"`
+	Phone         string              `json:"phone"`
+	PhoneVerified bool                `json:"phone_verified"`
+	Picture       string              `json:"picture"`
+	Preferences   CustomerPreferences `json:"preferences" gorm:"embedded;embeddedPrefix:pref_"`
+}
+
+// CustomerPreferences controls which notifications a customer receives.
+type CustomerPreferences struct {
+	EmailNotifications bool `json:"email_notifications" gorm:"default:true"`
+	SmsNotifications   bool `json:"sms_notifications" gorm:"default:false"`
+	MarketingOptIn     bool `json:"marketing_opt_in" gorm:"default:false"`
+}
+
+// PhoneVerification holds a one-time code sent by SMS to confirm a customer's phone number.
+type PhoneVerification struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	CustomerID uint       `json:"customer_id" gorm:"index"`
+	Phone      string     `json:"phone"`
+	CodeHash   string     `json:"-"`
+	Attempts   int        `json:"attempts"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CustomerFilter struct {
+	Search   string
+	Page     int
+	PageSize int
+}
+
+type CustomerPage struct {
+	Customers []Customer `json:"data"`
+	Page      int        `json:"page"`
+	PageSize  int        `json:"page_size"`
+	Total     int64      `json:"total"`
+}
+
+// CustomerProfileUpdate carries the fields a customer may change on their own
+// profile. Nil fields are left untouched.
+type CustomerProfileUpdate struct {
+	Name        *string
+	Phone       *string
+	Preferences *CustomerPreferences
 }
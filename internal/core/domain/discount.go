@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type DiscountType string
+
+const (
+	// DiscountPercentage takes PercentOff percent off the matching lines.
+	DiscountPercentage DiscountType = "percentage"
+	// DiscountFixedAmount takes AmountOff off the matching lines, spread over
+	// them by price.
+	DiscountFixedAmount DiscountType = "fixed_amount"
+	// DiscountBuyXGetY takes PercentOff percent off GetQuantity units for
+	// every BuyQuantity units bought, the cheapest matching units first.
+	DiscountBuyXGetY DiscountType = "buy_x_get_y"
+)
+
+// AdjustmentDiscount marks an order adjustment made by a discount.
+const AdjustmentDiscount = "discount"
+
+var ErrDiscountUsedUp = NewValidationError("a discount on the order has reached its usage limit")
+
+// Discount is a promotion rule. Discounts with a code apply when the customer
+// enters the code, those without one apply to every order they match.
+type Discount struct {
+	ID uint `json:"id"`
+	// Code is stored upper case and matched regardless of case.
+	Code string       `json:"code" gorm:"size:64;index:idx_discounts_code,unique,where:code <> ''"`
+	Name string       `json:"name" gorm:"not null"`
+	Type DiscountType `json:"type" gorm:"size:20;not null"`
+	// PercentOff is the percentage taken off, for percentage and buy_x_get_y
+	// discounts. 100 makes the "get" units of a buy_x_get_y discount free.
+	PercentOff int `json:"percent_off"`
+	// AmountOff is the amount taken off by a fixed_amount discount.
+	AmountOff   Money `json:"amount_off" gorm:"embedded;embeddedPrefix:amount_off_"`
+	BuyQuantity int   `json:"buy_quantity"`
+	GetQuantity int   `json:"get_quantity"`
+	// ProductIDs and CategoryIDs limit the discount to those products and to
+	// products in those categories or their subcategories. A discount limited
+	// to neither applies to every line.
+	ProductIDs  IDList `json:"product_ids" gorm:"type:jsonb"`
+	CategoryIDs IDList `json:"category_ids" gorm:"type:jsonb"`
+	// MinOrderValue is the order subtotal, before discounts, the order needs
+	// to reach. A zero amount means no minimum.
+	MinOrderValue Money `json:"min_order_value" gorm:"embedded;embeddedPrefix:min_order_value_"`
+	// UsageLimit caps the number of orders the discount is applied to in
+	// total, PerCustomerLimit the number per customer. Nil means no limit.
+	UsageLimit       *int `json:"usage_limit"`
+	PerCustomerLimit *int `json:"per_customer_limit"`
+	TimesUsed        int  `json:"times_used" gorm:"not null;default:0"`
+	// StartsAt and EndsAt bound when the discount can be used, either may be open.
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	Active    bool       `json:"active" gorm:"not null;default:false"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// NormalizeDiscountCode is how codes are stored and looked up.
+func NormalizeDiscountCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks the rule makes sense for its type.
+func (d *Discount) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return NewValidationError("name is required")
+	}
+	switch d.Type {
+	case DiscountPercentage:
+		if d.PercentOff < 1 || d.PercentOff > 100 {
+			return NewValidationError("percent_off must be between 1 and 100")
+		}
+	case DiscountFixedAmount:
+		if err := d.AmountOff.Validate(); err != nil {
+			return err
+		}
+		if d.AmountOff.Amount <= 0 {
+			return NewValidationError("amount_off must be greater than 0")
+		}
+	case DiscountBuyXGetY:
+		if d.BuyQuantity < 1 || d.GetQuantity < 1 {
+			return NewValidationError("buy_quantity and get_quantity must be at least 1")
+		}
+		if d.PercentOff < 1 || d.PercentOff > 100 {
+			return NewValidationError("percent_off must be between 1 and 100")
+		}
+	default:
+		return NewValidationError("type must be one of %s, %s or %s", DiscountPercentage, DiscountFixedAmount, DiscountBuyXGetY)
+	}
+	if !d.MinOrderValue.IsZero() {
+		if err := d.MinOrderValue.Validate(); err != nil {
+			return err
+		}
+		if d.MinOrderValue.IsNegative() {
+			return NewValidationError("min_order_value must not be negative")
+		}
+	}
+	if d.UsageLimit != nil && *d.UsageLimit < 1 {
+		return NewValidationError("usage_limit must be at least 1")
+	}
+	if d.PerCustomerLimit != nil && *d.PerCustomerLimit < 1 {
+		return NewValidationError("per_customer_limit must be at least 1")
+	}
+	if d.StartsAt != nil && d.EndsAt != nil && !d.EndsAt.After(*d.StartsAt) {
+		return NewValidationError("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// ValidAt tells whether the discount is active and within its validity window.
+func (d *Discount) ValidAt(at time.Time) bool {
+	if !d.Active {
+		return false
+	}
+	if d.StartsAt != nil && at.Before(*d.StartsAt) {
+		return false
+	}
+	return d.EndsAt == nil || at.Before(*d.EndsAt)
+}
+
+// UsedUp tells whether the discount has reached its total usage limit.
+func (d *Discount) UsedUp() bool {
+	return d.UsageLimit != nil && d.TimesUsed >= *d.UsageLimit
+}
+
+// Label is how the discount is named on an order.
+func (d *Discount) Label() string {
+	if d.Code == "" {
+		return d.Name
+	}
+	return d.Code + ": " + d.Name
+}
+
+// OrderAdjustment is an amount added to or, for discounts, taken off an
+// order's subtotal. The adjustments are kept with the order, so its total can
+// be traced back to the rules that made it.
+type OrderAdjustment struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	OrderID    uint   `json:"order_id" gorm:"index"`
+	Type       string `json:"type" gorm:"size:20;not null"`
+	DiscountID *uint  `json:"discount_id,omitempty" gorm:"index"`
+	// Code and Description are copied from the discount when the order is placed.
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description"`
+	// Amount is negative for discounts.
+	Amount    Money     `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IDList is a list of IDs stored in a jsonb column.
+type IDList []uint
+
+func (l IDList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal([]uint(l))
+	return string(raw), err
+}
+
+func (l *IDList) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into IDList", value)
+	}
+	return json.Unmarshal(raw, (*[]uint)(l))
+}
+
+func (l IDList) Contains(id uint) bool {
+	for _, listed := range l {
+		if listed == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// maxRateDecimals is the scale of the numeric column rates are stored in.
+const maxRateDecimals = 12
+
+// Rate is an exchange rate kept as an exact decimal, like "129.45".
+type Rate string
+
+// ParseRate reads a positive decimal with at most 12 decimal places.
+func ParseRate(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+	whole, fraction, hasPoint := strings.Cut(value, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || (hasPoint && fraction == "") {
+		return "", NewValidationError("rate %q is not a decimal number", value)
+	}
+	if len(fraction) > maxRateDecimals {
+		return "", NewValidationError("rate %q has more than %d decimal places", value, maxRateDecimals)
+	}
+	if len(strings.TrimLeft(whole, "0")) > 12 {
+		return "", NewValidationError("rate %q is too large", value)
+	}
+	rate := normalizeRate(value)
+	if rate == "0" {
+		return "", NewValidationError("rate must be greater than 0")
+	}
+	return rate, nil
+}
+
+// normalizeRate drops leading zeros and trailing decimal zeros, so the
+// numeric column's padding doesn't show.
+func normalizeRate(value string) Rate {
+	whole, fraction, _ := strings.Cut(value, ".")
+	whole = strings.TrimLeft(whole, "0")
+	if whole == "" {
+		whole = "0"
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if fraction == "" {
+		return Rate(whole)
+	}
+	return Rate(whole + "." + fraction)
+}
+
+func (r Rate) Rat() *big.Rat {
+	rat, ok := new(big.Rat).SetString(string(r))
+	if !ok {
+		return new(big.Rat)
+	}
+	return rat
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return string(r), nil
+}
+
+func (r *Rate) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*r = ""
+	case []byte:
+		*r = normalizeRate(string(v))
+	case string:
+		*r = normalizeRate(v)
+	case float64:
+		*r = normalizeRate(big.NewFloat(v).Text('f', maxRateDecimals))
+	default:
+		return fmt.Errorf("cannot scan %T into Rate", value)
+	}
+	return nil
+}
+
+// ExchangeRate is what one unit of Currency is worth in DefaultCurrency from
+// EffectiveFrom on, e.g. USD 129.45 means USD 1 = KES 129.45. Setting a rate
+// adds a row, so older rows are the currency's rate history.
+type ExchangeRate struct {
+	ID            uint      `json:"id"`
+	Currency      string    `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_from"`
+	Rate          Rate      `json:"rate" gorm:"type:numeric(24,12);not null"`
+	EffectiveFrom time.Time `json:"effective_from" gorm:"not null;uniqueIndex:idx_exchange_rates_from"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ExchangeRates are the rates in force at one time, by currency.
+// DefaultCurrency is always there at a rate of 1.
+type ExchangeRates map[string]Rate
+
+func NewExchangeRates(rates []ExchangeRate) ExchangeRates {
+	snapshot := ExchangeRates{DefaultCurrency: "1"}
+	for _, rate := range rates {
+		snapshot[rate.Currency] = rate.Rate
+	}
+	return snapshot
+}
+
+// Rate returns the currency's rate, an error when none has been set.
+func (r ExchangeRates) Rate(currency string) (Rate, error) {
+	if _, ok := CurrencyExponent(currency); !ok {
+		return "", NewValidationError("unsupported currency %q", currency)
+	}
+	rate, ok := r[currency]
+	if !ok {
+		return "", NewValidationError("no exchange rate is set for %s", currency)
+	}
+	return rate, nil
+}
+
+// Convert converts the amount to another currency through DefaultCurrency,
+// rounding once to the nearest minor unit with halves away from zero.
+func (r ExchangeRates) Convert(m Money, to string) (Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+	factor, err := r.factor(m.Currency, to)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: roundRat(factor.Mul(factor, new(big.Rat).SetInt64(m.Amount))), Currency: to}, nil
+}
+
+// Average is the mean price of the totals in the given currency. Each
+// currency's sum is converted exactly and the mean is rounded once.
+func (r ExchangeRates) Average(totals []PriceTotal, to string) (Money, error) {
+	sum := new(big.Rat)
+	var count int64
+	for _, total := range totals {
+		factor, err := r.factor(total.Currency, to)
+		if err != nil {
+			return Money{}, err
+		}
+		sum.Add(sum, factor.Mul(factor, new(big.Rat).SetInt64(total.Sum)))
+		count += total.Count
+	}
+	if count == 0 {
+		return Money{}, ErrNotFound
+	}
+	if _, ok := CurrencyExponent(to); !ok {
+		return Money{}, NewValidationError("unsupported currency %q", to)
+	}
+	return Money{Amount: roundRat(sum.Quo(sum, new(big.Rat).SetInt64(count))), Currency: to}, nil
+}
+
+// factor is what one minor unit of from is worth in minor units of to.
+func (r ExchangeRates) factor(from, to string) (*big.Rat, error) {
+	fromRate, err := r.Rate(from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := r.Rate(to)
+	if err != nil {
+		return nil, err
+	}
+	fromExponent, _ := CurrencyExponent(from)
+	toExponent, _ := CurrencyExponent(to)
+
+	factor := new(big.Rat).Quo(fromRate.Rat(), toRate.Rat())
+	scale := new(big.Rat).SetFrac(pow10(toExponent), pow10(fromExponent))
+	return factor.Mul(factor, scale), nil
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+// roundRat rounds to the nearest integer with halves away from zero.
+func roundRat(value *big.Rat) int64 {
+	return roundDiv(value.Num(), value.Denom())
+}
+
+// PriceTotal adds up the prices in one currency, for averaging.
+type PriceTotal struct {
+	Currency string
+	Sum      int64
+	Count    int64
+}
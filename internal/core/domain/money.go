@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used where a price is given without a currency.
+const DefaultCurrency = "KES"
+
+// currencyExponents is the number of decimal places of each supported
+// ISO-4217 currency, i.e. how many minor units make up one major unit.
+var currencyExponents = map[string]int{
+	"KES": 2,
+	"UGX": 0,
+	"TZS": 2,
+	"RWF": 0,
+	"ETB": 2,
+	"NGN": 2,
+	"ZAR": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of the currency.
+func CurrencyExponent(currency string) (int, bool) {
+	exponent, ok := currencyExponents[currency]
+	return exponent, ok
+}
+
+var ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+// Money is an exact amount of a currency, counted in the currency's minor
+// unit, e.g. {Amount: 1050, Currency: "KES"} is KES 10.50.
+//
+// Rounding rules: adding, subtracting and multiplying by a quantity are exact.
+// Parsing never rounds, an amount with more decimals than the currency has is
+// rejected. Only operations that divide, Scale and Average, round, once, to
+// the nearest minor unit with halves rounded away from zero.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency" gorm:"size:3"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads an amount written in major units, like "10.50", exactly.
+func ParseMoney(value, currency string) (Money, error) {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, NewValidationError("unsupported currency %q", currency)
+	}
+
+	digits := strings.TrimPrefix(value, "-")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || (strings.Contains(digits, ".") && fraction == "") {
+		return Money{}, NewValidationError("%q is not an amount", value)
+	}
+	if len(fraction) > exponent {
+		return Money{}, NewValidationError("%s amounts have at most %d decimal places, got %q", currency, exponent, value)
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, NewValidationError("%q is out of range", value)
+	}
+	if strings.HasPrefix(value, "-") {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks the currency is supported.
+func (m Money) Validate() error {
+	if _, ok := CurrencyExponent(m.Currency); !ok {
+		return NewValidationError("unsupported currency %q", m.Currency)
+	}
+	return nil
+}
+
+// Decimal writes the amount in major units, e.g. "10.50".
+func (m Money) Decimal() string {
+	exponent := currencyExponents[m.Currency]
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.FormatUint(uint64(amount), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Currency + " " + m.Decimal()
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns the sum, ErrCurrencyMismatch when the currencies differ.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns the difference, ErrCurrencyMismatch when the currencies differ.
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul multiplies by a quantity.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Scale multiplies by numerator/denominator, e.g. Scale(16, 100) for 16%,
+// rounding half away from zero.
+func (m Money) Scale(numerator, denominator int64) Money {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(numerator))
+	return Money{Amount: roundDiv(product, big.NewInt(denominator)), Currency: m.Currency}
+}
+
+// Average returns the mean of the amounts, rounding half away from zero.
+// It returns ErrCurrencyMismatch when they are not all in the same currency.
+func Average(amounts []Money) (Money, error) {
+	if len(amounts) == 0 {
+		return Money{}, errors.New("no amounts to average")
+	}
+	sum := new(big.Int)
+	for _, amount := range amounts {
+		if amount.Currency != amounts[0].Currency {
+			return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, amounts[0].Currency, amount.Currency)
+		}
+		sum.Add(sum, big.NewInt(amount.Amount))
+	}
+	return Money{Amount: roundDiv(sum, big.NewInt(int64(len(amounts)))), Currency: amounts[0].Currency}, nil
+}
+
+// roundDiv divides rounding half away from zero.
+func roundDiv(dividend, divisor *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(dividend, divisor, new(big.Int))
+	if new(big.Int).Abs(new(big.Int).Mul(remainder, big.NewInt(2))).Cmp(new(big.Int).Abs(divisor)) >= 0 {
+		if (dividend.Sign() < 0) != (divisor.Sign() < 0) {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient.Int64()
+}
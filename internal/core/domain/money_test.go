@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "two decimals", value: "10.50", currency: "KES", want: 1050},
+		{name: "fewer decimals than the currency", value: "10.5", currency: "KES", want: 1050},
+		{name: "whole amount", value: "10", currency: "KES", want: 1000},
+		{name: "less than one major unit", value: "0.05", currency: "KES", want: 5},
+		{name: "negative", value: "-10.50", currency: "KES", want: -1050},
+		{name: "negative less than one major unit", value: "-0.05", currency: "KES", want: -5},
+		{name: "no minor unit", value: "500", currency: "JPY", want: 500},
+		{name: "three decimals", value: "1.234", currency: "BHD", want: 1234},
+		{name: "three decimals less than one major unit", value: "0.007", currency: "KWD", want: 7},
+		{name: "too many decimals", value: "10.505", currency: "KES", wantErr: true},
+		{name: "decimals on a currency without a minor unit", value: "500.5", currency: "JPY", wantErr: true},
+		{name: "too many decimals for three", value: "1.2345", currency: "BHD", wantErr: true},
+		{name: "empty", value: "", currency: "KES", wantErr: true},
+		{name: "trailing point", value: "10.", currency: "KES", wantErr: true},
+		{name: "leading point", value: ".50", currency: "KES", wantErr: true},
+		{name: "minus only", value: "-", currency: "KES", wantErr: true},
+		{name: "double minus", value: "--1", currency: "KES", wantErr: true},
+		{name: "thousands separator", value: "1,000", currency: "KES", wantErr: true},
+		{name: "exponent", value: "1e3", currency: "KES", wantErr: true},
+		{name: "out of range", value: "999999999999999999", currency: "KES", wantErr: true},
+		{name: "unsupported currency", value: "10.50", currency: "XXX", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, tt.currency)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.True(t, IsValidationError(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, Money{Amount: tt.want, Currency: tt.currency}, got)
+		})
+	}
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: Money{Amount: 1050, Currency: "KES"}, want: "10.50"},
+		{money: Money{Amount: 100, Currency: "KES"}, want: "1.00"},
+		{money: Money{Amount: 50, Currency: "KES"}, want: "0.50"},
+		{money: Money{Amount: 5, Currency: "KES"}, want: "0.05"},
+		{money: Money{Amount: 0, Currency: "KES"}, want: "0.00"},
+		{money: Money{Amount: -5, Currency: "KES"}, want: "-0.05"},
+		{money: Money{Amount: -1050, Currency: "KES"}, want: "-10.50"},
+		{money: Money{Amount: 500, Currency: "JPY"}, want: "500"},
+		{money: Money{Amount: 0, Currency: "UGX"}, want: "0"},
+		{money: Money{Amount: -500, Currency: "JPY"}, want: "-500"},
+		{money: Money{Amount: 1234, Currency: "BHD"}, want: "1.234"},
+		{money: Money{Amount: 7, Currency: "BHD"}, want: "0.007"},
+		{money: Money{Amount: 70, Currency: "KWD"}, want: "0.070"},
+		{money: Money{Amount: -7, Currency: "KWD"}, want: "-0.007"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.money.Currency+" "+tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.money.Decimal())
+
+			// What is written reads back as the same amount
+			parsed, err := ParseMoney(tt.want, tt.money.Currency)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.money, parsed)
+		})
+	}
+
+	assert.Equal(t, "KES 10.50", Money{Amount: 1050, Currency: "KES"}.String())
+}
+
+func TestMoney_Scale(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      int64
+		numerator   int64
+		denominator int64
+		want        int64
+	}{
+		{name: "exact", amount: 1000, numerator: 16, denominator: 100, want: 160},
+		{name: "below half", amount: 1041, numerator: 1, denominator: 10, want: 104},
+		{name: "half", amount: 1045, numerator: 1, denominator: 10, want: 105},
+		{name: "above half", amount: 1049, numerator: 1, denominator: 10, want: 105},
+		{name: "negative below half", amount: -1041, numerator: 1, denominator: 10, want: -104},
+		{name: "negative half", amount: -1045, numerator: 1, denominator: 10, want: -105},
+		{name: "negative above half", amount: -1049, numerator: 1, denominator: 10, want: -105},
+		{name: "negative denominator", amount: 5, numerator: 1, denominator: -2, want: -3},
+		{name: "both negative", amount: -5, numerator: 1, denominator: -2, want: 3},
+		{name: "no overflow in between", amount: 9000000000000000000, numerator: 3, denominator: 4, want: 6750000000000000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Money{Amount: tt.amount, Currency: "KES"}.Scale(tt.numerator, tt.denominator)
+			assert.Equal(t, Money{Amount: tt.want, Currency: "KES"}, got)
+		})
+	}
+}
+
+func TestRoundDiv(t *testing.T) {
+	tests := []struct {
+		dividend int64
+		divisor  int64
+		want     int64
+	}{
+		{dividend: 4, divisor: 2, want: 2},
+		{dividend: 5, divisor: 2, want: 3},
+		{dividend: -5, divisor: 2, want: -3},
+		{dividend: 5, divisor: -2, want: -3},
+		{dividend: -5, divisor: -2, want: 3},
+		{dividend: 4, divisor: 3, want: 1},
+		{dividend: -4, divisor: 3, want: -1},
+		{dividend: 2, divisor: 3, want: 1},
+		{dividend: -2, divisor: 3, want: -1},
+		{dividend: -1, divisor: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		got := roundDiv(big.NewInt(tt.dividend), big.NewInt(tt.divisor))
+		assert.Equal(t, tt.want, got, "%d / %d", tt.dividend, tt.divisor)
+	}
+}
+
+func TestAverage(t *testing.T) {
+	kes := func(amounts ...int64) []Money {
+		money := make([]Money, len(amounts))
+		for i, amount := range amounts {
+			money[i] = Money{Amount: amount, Currency: "KES"}
+		}
+		return money
+	}
+
+	tests := []struct {
+		name    string
+		amounts []Money
+		want    Money
+	}{
+		{name: "one amount", amounts: kes(1050), want: Money{Amount: 1050, Currency: "KES"}},
+		{name: "exact", amounts: kes(100, 200), want: Money{Amount: 150, Currency: "KES"}},
+		{name: "half", amounts: kes(1, 2), want: Money{Amount: 2, Currency: "KES"}},
+		{name: "negative half", amounts: kes(-1, -2), want: Money{Amount: -2, Currency: "KES"}},
+		{name: "rounds down", amounts: kes(1, 1, 2), want: Money{Amount: 1, Currency: "KES"}},
+		{name: "rounds up", amounts: kes(1, 2, 2), want: Money{Amount: 2, Currency: "KES"}},
+		{name: "no minor unit", amounts: []Money{{Amount: 500, Currency: "JPY"}, {Amount: 501, Currency: "JPY"}}, want: Money{Amount: 501, Currency: "JPY"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Average(tt.amounts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Average(nil)
+	assert.Error(t, err)
+
+	_, err = Average([]Money{{Amount: 100, Currency: "KES"}, {Amount: 100, Currency: "USD"}})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+const (
+	NotificationChannelSms   = "sms"
+	NotificationChannelEmail = "email"
+
+	NotificationStatusSent   = "sent"
+	NotificationStatusFailed = "failed"
+)
+
+// NotificationLog records every SMS and email sent, one row per recipient.
+// Logs are matched to customers by the email or phone they were sent to.
+type NotificationLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Channel   string    `json:"channel"`
+	Recipient string    `json:"recipient" gorm:"index"`
+	Subject   string    `json:"subject"`
+	Message   string    `json:"message"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -3,20 +3,76 @@ package domain
 import "time"
 
 type Order struct {
-	ID          uint        `json:"id" gorm:"primaryKey"`
-	CustomerID  uint        `json:"customer_id"`
-	Customer    Customer    `json:"customer" gorm:"foreignKey:CustomerID"`
-	Items       []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
-	TotalAmount float64     `json:"total_amount"`
-	CreatedAt   time.Time   `json:"created_at"`
-	TotalPrice  float64     `json:"total_price"`
+	ID                uint            `json:"id" gorm:"primaryKey"`
+	CustomerID        uint            `json:"customer_id"`
+	Customer          Customer        `json:"customer" gorm:"foreignKey:CustomerID"`
+	Items             []OrderItem     `json:"items" gorm:"foreignKey:OrderID"`
+	TotalAmount       Money           `json:"total_amount" gorm:"embedded;embeddedPrefix:total_amount_"`
+	CreatedAt         time.Time       `json:"created_at"`
+	TotalPrice        Money           `json:"total_price" gorm:"embedded;embeddedPrefix:total_price_"`
+	ShippingAddressID *uint           `json:"shipping_address_id"`
+	ShippingAddress   ShippingAddress `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_"`
+	// Currency is the currency the order is charged in, and ExchangeRate what
+	// one unit of it was worth in DefaultCurrency when the order was placed.
+	Currency     string `json:"currency" gorm:"size:3"`
+	ExchangeRate Rate   `json:"exchange_rate" gorm:"type:numeric(24,12)"`
+	// Subtotal is the sum of the items before discounts, DiscountTotal what
+	// the adjustments take off it and TotalPrice what is left to pay.
+	Subtotal      Money             `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	DiscountTotal Money             `json:"discount_total" gorm:"embedded;embeddedPrefix:discount_total_"`
+	Adjustments   []OrderAdjustment `json:"adjustments" gorm:"foreignKey:OrderID"`
+	// TaxTotal is the tax on the discounted items. With PricesIncludeTax it
+	// is already part of the prices, otherwise it is added to TotalPrice.
+	TaxTotal         Money `json:"tax_total" gorm:"embedded;embeddedPrefix:tax_total_"`
+	PricesIncludeTax bool  `json:"prices_include_tax" gorm:"not null;default:false"`
+	// ShippingMethodID is the method the customer chose, ShippingMethodName
+	// its name when the order was placed and ShippingCost what it charged,
+	// which is part of TotalPrice.
+	ShippingMethodID   *uint  `json:"shipping_method_id"`
+	ShippingMethodName string `json:"shipping_method_name"`
+	ShippingCost       Money  `json:"shipping_cost" gorm:"embedded;embeddedPrefix:shipping_cost_"`
+	// PaymentStatus turns paid, and PaidAt is set, when a payment intent for
+	// the order succeeds, and partially_refunded or refunded with refunds.
+	PaymentStatus OrderPaymentStatus `json:"payment_status" gorm:"size:20;not null;default:unpaid"`
+	PaidAt        *time.Time         `json:"paid_at"`
+	// Status is placed until the order is cancelled, when the stock of what
+	// wasn't refunded yet goes back and a paid order is refunded in full.
+	Status       OrderStatus `json:"status" gorm:"size:20;not null;default:placed;index"`
+	CancelledAt  *time.Time  `json:"cancelled_at"`
+	CancelReason string      `json:"cancel_reason"`
+	// RefundedTotal adds up the Refunds, it never exceeds TotalPrice.
+	RefundedTotal Money         `json:"refunded_total" gorm:"embedded;embeddedPrefix:refunded_total_"`
+	Refunds       []OrderRefund `json:"refunds" gorm:"foreignKey:OrderID"`
+	// Amendments are the changes made to the order's lines since it was placed, oldest first.
+	Amendments []OrderAmendment `json:"amendments" gorm:"foreignKey:OrderID"`
+	// ArchivedAt hides the order from the order list. Archived orders are
+	// kept for accounting.
+	ArchivedAt *time.Time `json:"archived_at" gorm:"index"`
+	// DiscountCodes are the codes the customer entered, the applied ones end up in Adjustments.
+	DiscountCodes []string `json:"-" gorm:"-"`
+	// DisplayTotalPrice is TotalPrice converted to the currency the client asked for.
+	DisplayTotalPrice *Money `json:"display_total_price,omitempty" gorm:"-"`
 }
 
 type OrderItem struct {
-	ID        uint    `json:"id" gorm:"primaryKey"`
-	OrderID   uint    `json:"order_id"`
-	ProductID uint    `json:"product_id"`
-	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	OrderID   uint            `json:"order_id"`
+	ProductID uint            `json:"product_id"`
+	Product   Product         `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID *uint           `json:"variant_id" gorm:"index"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	Quantity  int             `json:"quantity"`
+	Price     Money           `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	// Discount is the line's share of the order's discounts, already part of
+	// DiscountTotal and not taken off Price.
+	Discount Money `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	// Tax is charged on Price less Discount at TaxRate percent, the rate of
+	// the line's tax class when the order was placed.
+	Tax        Money `json:"tax" gorm:"embedded;embeddedPrefix:tax_"`
+	TaxClassID *uint `json:"tax_class_id"`
+	TaxRate    Rate  `json:"tax_rate" gorm:"type:numeric(9,4)"`
+	// RefundedQuantity is how many of the units have been refunded.
+	RefundedQuantity int `json:"refunded_quantity" gorm:"not null;default:0"`
+	// DisplayPrice is Price converted to the currency the client asked for.
+	DisplayPrice *Money `json:"display_price,omitempty" gorm:"-"`
 }
@@ -0,0 +1,77 @@
+package domain
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending"
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed"
+	// PaymentCancelled is a customer turning the request down, on M-Pesa by
+	// dismissing the prompt on their phone.
+	PaymentCancelled PaymentStatus = "cancelled"
+	// PaymentTimedOut is a request the customer never answered.
+	PaymentTimedOut PaymentStatus = "timed_out"
+)
+
+// Final reports whether nothing more is expected of the provider. Only a
+// late success can still change a final intent, the money was taken after all.
+func (s PaymentStatus) Final() bool {
+	return s != PaymentPending
+}
+
+type OrderPaymentStatus string
+
+const (
+	OrderUnpaid            OrderPaymentStatus = "unpaid"
+	OrderPaid              OrderPaymentStatus = "paid"
+	OrderPartiallyRefunded OrderPaymentStatus = "partially_refunded"
+	OrderRefunded          OrderPaymentStatus = "refunded"
+)
+
+var (
+	ErrOrderAlreadyPaid = NewValidationError("the order is already paid")
+	ErrPaymentPending   = NewValidationError("a payment for the order is still waiting for the customer")
+)
+
+// PaymentIntent is one attempt at collecting an order's total through a
+// payment provider. An order can have several, only one of them pending at a
+// time and at most one succeeded.
+type PaymentIntent struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	OrderID  uint   `json:"order_id" gorm:"index"`
+	Provider string `json:"provider" gorm:"size:20;uniqueIndex:idx_payment_intents_provider_ref"`
+	// ProviderRef is how the provider knows the request, M-Pesa's
+	// CheckoutRequestID. Callbacks and status queries carry it, so it is
+	// never shown to customers.
+	ProviderRef *string       `json:"-" gorm:"size:100;uniqueIndex:idx_payment_intents_provider_ref"`
+	Amount      Money         `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Phone       string        `json:"phone"`
+	Status      PaymentStatus `json:"status" gorm:"size:20;index"`
+	// Receipt is the provider's receipt for a successful payment, the M-Pesa
+	// transaction code customers get by SMS.
+	Receipt string `json:"receipt"`
+	// FailureReason is the provider's explanation of why the payment didn't go through.
+	FailureReason string `json:"failure_reason"`
+	// ExpiresAt is when a pending intent is given up on as timed out, unless
+	// the provider says otherwise.
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// PaymentResult is what a provider reports about a payment request, in a
+// callback or when asked, or about a refund.
+type PaymentResult struct {
+	ProviderRef string
+	// Refund marks the results of refunds.
+	Refund bool
+	// Status is PaymentPending while the provider is still waiting for the customer.
+	Status PaymentStatus
+	// Amount is what the customer paid, set when the payment succeeded.
+	Amount  Money
+	Receipt string
+	Reason  string
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+type ErasureStatus string
+
+const (
+	ErasureStatusPending   ErasureStatus = "pending"
+	ErasureStatusRejected  ErasureStatus = "rejected"
+	ErasureStatusCompleted ErasureStatus = "completed"
+)
+
+// ErasureRequest is both the customer's request to be forgotten and the audit
+// record of who approved it and what was removed.
+type ErasureRequest struct {
+	ID                    uint          `json:"id" gorm:"primaryKey"`
+	CustomerID            uint          `json:"customer_id" gorm:"index"`
+	Status                ErasureStatus `json:"status" gorm:"index"`
+	Reason                string        `json:"reason"`
+	ReviewNote            string        `json:"review_note"`
+	ReviewedBy            string        `json:"reviewed_by"`
+	ReviewedAt            *time.Time    `json:"reviewed_at"`
+	CompletedAt           *time.Time    `json:"completed_at"`
+	AddressesDeleted      int64         `json:"addresses_deleted"`
+	OrdersAnonymized      int64         `json:"orders_anonymized"`
+	NotificationsScrubbed int64         `json:"notifications_scrubbed"`
+	CreatedAt             time.Time     `json:"created_at"`
+	UpdatedAt             time.Time     `json:"updated_at"`
+}
+
+// ErasureSummary counts what an erasure touched, it is copied onto the request.
+type ErasureSummary struct {
+	AddressesDeleted      int64
+	OrdersAnonymized      int64
+	NotificationsScrubbed int64
+}
+
+// CustomerDataExport is the archive handed to a customer asking for their data.
+type CustomerDataExport struct {
+	ExportedAt      time.Time         `json:"exported_at"`
+	Customer        *Customer         `json:"customer"`
+	Addresses       []Address         `json:"addresses"`
+	Orders          []Order           `json:"orders"`
+	Notifications   []NotificationLog `json:"notifications"`
+	ErasureRequests []ErasureRequest  `json:"erasure_requests"`
+}
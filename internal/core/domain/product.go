@@ -1,9 +1,32 @@
 package domain
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Product struct {
-	ID         uint     `json:"id"`
-	Name       string   `json:"name"`
-	Price      float64  `json:"price"`
-	CategoryID uint     `json:"category_id"`
-	Category   Category `json:"category" gormm:"foreignKey:CategoryID"`
+	ID          uint   `json:"id"`
+	SKU         string `json:"sku" gorm:"index:idx_products_sku,unique,where:sku <> ''"`
+	Name        string `json:"name" gorm:"index"`
+	Description string `json:"description" gorm:"not null;default:''"`
+	Price       Money  `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	// DisplayPrice is Price converted to the currency the client asked for.
+	DisplayPrice *Money   `json:"display_price,omitempty" gorm:"-"`
+	CategoryID   uint     `json:"category_id"`
+	Category     Category `json:"category" gormm:"foreignKey:CategoryID"`
+	// TaxClassID overrides the tax class the product would take from its category.
+	TaxClassID *uint `json:"tax_class_id" gorm:"index"`
+	// WeightGrams and the dimensions in millimetres price weight based
+	// shipping, see ShippingWeight.
+	WeightGrams int            `json:"weight_grams" gorm:"not null;default:0"`
+	LengthMM    int            `json:"length_mm" gorm:"not null;default:0"`
+	WidthMM     int            `json:"width_mm" gorm:"not null;default:0"`
+	HeightMM    int            `json:"height_mm" gorm:"not null;default:0"`
+	Attributes  Attributes     `json:"attributes" gorm:"type:jsonb;not null;default:'{}'"`
+	Images      []ProductImage `json:"images" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP;index"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
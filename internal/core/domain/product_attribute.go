@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type AttributeType string
+
+const (
+	AttributeString  AttributeType = "string"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+)
+
+// AttributeNamePattern keeps attribute names safe to use as JSON keys in
+// queries and in the attr.<name> filter syntax.
+var AttributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// CategoryAttribute defines a spec products in the category can carry, like
+// ram_gb for laptops. Subcategories inherit their ancestors' attributes.
+type CategoryAttribute struct {
+	ID            uint          `json:"id"`
+	CategoryID    uint          `json:"category_id" gorm:"not null;uniqueIndex:idx_category_attributes_name"`
+	Name          string        `json:"name" gorm:"not null;uniqueIndex:idx_category_attributes_name"`
+	Type          AttributeType `json:"type" gorm:"not null"`
+	Required      bool          `json:"required"`
+	AllowedValues StringList    `json:"allowed_values,omitempty" gorm:"type:jsonb"`
+}
+
+// StringList is a list of strings stored in a jsonb column.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal([]string(l))
+	return string(raw), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+	return json.Unmarshal(raw, (*[]string)(l))
+}
+
+// Attributes holds a product's attribute values, stored in a jsonb column.
+type Attributes map[string]interface{}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(map[string]interface{}(a))
+	return string(raw), err
+}
+
+func (a *Attributes) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Attributes", value)
+	}
+	return json.Unmarshal(raw, (*map[string]interface{})(a))
+}
+
+// AttributeOperator compares an attribute in a listing filter.
+type AttributeOperator string
+
+const (
+	AttributeEq  AttributeOperator = "="
+	AttributeNe  AttributeOperator = "!="
+	AttributeGt  AttributeOperator = ">"
+	AttributeGte AttributeOperator = ">="
+	AttributeLt  AttributeOperator = "<"
+	AttributeLte AttributeOperator = "<="
+)
+
+// AttributeFilter is a filter as written by the client, e.g. attr.ram_gb>=16.
+type AttributeFilter struct {
+	Name     string
+	Operator AttributeOperator
+	Value    string
+}
+
+func (f AttributeFilter) String() string {
+	return "attr." + f.Name + string(f.Operator) + f.Value
+}
+
+// AttributeCondition is an AttributeFilter with its value converted to the
+// attribute's type: a string, a float64 or a bool.
+type AttributeCondition struct {
+	Name     string
+	Operator AttributeOperator
+	Value    interface{}
+}
+
+// FormatAttributeValue writes a value the way allowed values are listed.
+func FormatAttributeValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// DefaultMaxImageSize is the upload limit for product images when none is configured.
+const DefaultMaxImageSize = 5 << 20
+
+// ProductImage is an uploaded picture of a product together with a smaller
+// copy for listings. The files live in the blob store under Key and
+// ThumbnailKey, the URLs are where clients fetch them.
+type ProductImage struct {
+	ID           uint      `json:"id"`
+	ProductID    uint      `json:"product_id" gorm:"not null;index"`
+	Key          string    `json:"-" gorm:"not null"`
+	ThumbnailKey string    `json:"-" gorm:"not null"`
+	URL          string    `json:"url" gorm:"not null"`
+	ThumbnailURL string    `json:"thumbnail_url" gorm:"not null"`
+	ContentType  string    `json:"content_type" gorm:"not null"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Position     int       `json:"position" gorm:"not null;default:0"`
+	IsPrimary    bool      `json:"is_primary" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ProductImageUpload is an image as received from the client.
+type ProductImageUpload struct {
+	Filename string
+	Data     []byte
+	// Primary makes the new image the product's primary image. The first
+	// image of a product is always primary.
+	Primary bool
+}
+
+// ProductImageUpdate moves an image and/or makes it the primary one. Fields
+// left nil are kept.
+type ProductImageUpdate struct {
+	Position  *int  `json:"position" binding:"omitempty,gte=0"`
+	IsPrimary *bool `json:"is_primary"`
+}
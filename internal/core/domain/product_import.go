@@ -0,0 +1,78 @@
+package domain
+
+import "strings"
+
+// ImportFormat is a file format products are imported from and exported to.
+type ImportFormat string
+
+const (
+	ImportFormatCSV    ImportFormat = "csv"
+	ImportFormatNDJSON ImportFormat = "ndjson"
+)
+
+// CategoryPathSeparator separates the levels of a category path such as "Electronics > Phones".
+const CategoryPathSeparator = ">"
+
+type ImportRowStatus string
+
+const (
+	ImportRowCreated   ImportRowStatus = "created"
+	ImportRowUpdated   ImportRowStatus = "updated"
+	ImportRowUnchanged ImportRowStatus = "unchanged"
+	ImportRowRejected  ImportRowStatus = "rejected"
+)
+
+// ProductImportRow is one product read from an import file. Category is a
+// path of category names from the root down.
+type ProductImportRow struct {
+	Line        int    `json:"-"`
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	Category    string `json:"category"`
+}
+
+// ProductImportResult is the outcome of one row. Before and After are only
+// kept long enough to write the audit log.
+type ProductImportResult struct {
+	Line      int             `json:"line"`
+	SKU       string          `json:"sku,omitempty"`
+	Status    ImportRowStatus `json:"status"`
+	ProductID uint            `json:"product_id,omitempty"`
+	Errors    []string        `json:"errors,omitempty"`
+	Before    *Product        `json:"-"`
+	After     *Product        `json:"-"`
+}
+
+type ProductImportReport struct {
+	DryRun    bool                  `json:"dry_run"`
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Rejected  int                   `json:"rejected"`
+	Rows      []ProductImportResult `json:"rows"`
+}
+
+type ProductExportFilter struct {
+	// CategoryID limits the export to a category and everything below it
+	CategoryID uint
+}
+
+// JoinCategoryPath is the inverse of SplitCategoryPath.
+func JoinCategoryPath(names []string) string {
+	return strings.Join(names, " "+CategoryPathSeparator+" ")
+}
+
+// SplitCategoryPath breaks "Electronics > Phones" into its trimmed names.
+// It returns nil when any level is empty.
+func SplitCategoryPath(path string) []string {
+	parts := strings.Split(path, CategoryPathSeparator)
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+		if parts[i] == "" {
+			return nil
+		}
+	}
+	return parts
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// ProductPrice is a product's price over a period of time. The periods of a
+// product follow each other without gaps or overlaps, the last one has no
+// EffectiveTo. Periods starting in the future are scheduled price changes.
+type ProductPrice struct {
+	ID            uint       `json:"id"`
+	ProductID     uint       `json:"product_id" gorm:"not null;uniqueIndex:idx_product_prices_from"`
+	Price         Money      `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	EffectiveFrom time.Time  `json:"effective_from" gorm:"not null;uniqueIndex:idx_product_prices_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	// Upcoming is set on periods that have not started yet.
+	Upcoming bool `json:"upcoming" gorm:"-"`
+}
+
+// Covers reports whether the price is in force at t.
+func (p ProductPrice) Covers(t time.Time) bool {
+	return !p.EffectiveFrom.After(t) && (p.EffectiveTo == nil || p.EffectiveTo.After(t))
+}
+
+// PriceChange schedules a new price from EffectiveFrom on. With EffectiveTo
+// set the change is temporary, like a promotion, and the price in force
+// before it comes back at EffectiveTo.
+type PriceChange struct {
+	Price         Money      `json:"price"`
+	EffectiveFrom time.Time  `json:"effective_from" binding:"required"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	Note          string     `json:"note" binding:"max=255"`
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type ProductSortField string
+
+const (
+	ProductSortPrice   ProductSortField = "price"
+	ProductSortName    ProductSortField = "name"
+	ProductSortCreated ProductSortField = "created_at"
+)
+
+// ProductSort orders a product listing. It is written as the field name,
+// prefixed with "-" for descending order, e.g. "-price".
+type ProductSort struct {
+	Field      ProductSortField
+	Descending bool
+}
+
+var DefaultProductSort = ProductSort{Field: ProductSortCreated, Descending: true}
+
+func ParseProductSort(value string) (ProductSort, error) {
+	if value == "" {
+		return DefaultProductSort, nil
+	}
+	sort := ProductSort{Field: ProductSortField(strings.TrimPrefix(value, "-")), Descending: strings.HasPrefix(value, "-")}
+	switch sort.Field {
+	case ProductSortPrice, ProductSortName, ProductSortCreated:
+		return sort, nil
+	}
+	return sort, NewValidationError("sort must be one of price, name or created_at, prefixed with - for descending order")
+}
+
+func (s ProductSort) String() string {
+	if s.Descending {
+		return "-" + string(s.Field)
+	}
+	return string(s.Field)
+}
+
+// ProductQuery is a page of the product listing as requested by a client.
+type ProductQuery struct {
+	Cursor             string
+	Limit              int
+	Sort               string
+	CategoryID         uint
+	IncludeDescendants bool
+	MinPrice           *Money
+	MaxPrice           *Money
+	Name               string
+	Attributes         []AttributeFilter
+}
+
+// ProductListQuery is a ProductQuery resolved for the repository: the cursor
+// decoded and the category expanded to the IDs to match.
+type ProductListQuery struct {
+	Sort        ProductSort
+	After       *ProductCursor
+	Limit       int
+	CategoryIDs []uint
+	MinPrice    *Money
+	MaxPrice    *Money
+	Name        string
+	Attributes  []AttributeCondition
+}
+
+type ProductPage struct {
+	Products   []Product `json:"data"`
+	Limit      int       `json:"limit"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ProductCursor marks the last product of a page by its sort key and ID, so
+// the next page starts right after it even when rows are added in between.
+type ProductCursor struct {
+	Sort      string     `json:"s"`
+	ID        uint       `json:"id"`
+	Price     int64      `json:"p,omitempty"`
+	Name      string     `json:"n,omitempty"`
+	CreatedAt *time.Time `json:"c,omitempty"`
+}
+
+var ErrInvalidCursor = NewValidationError("invalid cursor")
+
+func NewProductCursor(sort ProductSort, product *Product) ProductCursor {
+	cursor := ProductCursor{Sort: sort.String(), ID: product.ID}
+	switch sort.Field {
+	case ProductSortPrice:
+		cursor.Price = product.Price.Amount
+	case ProductSortName:
+		cursor.Name = product.Name
+	case ProductSortCreated:
+		createdAt := product.CreatedAt
+		cursor.CreatedAt = &createdAt
+	}
+	return cursor
+}
+
+func (c ProductCursor) Encode() string {
+	encoded, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeProductCursor reads a cursor and checks it was issued for the same sort order.
+func DecodeProductCursor(value string, sort ProductSort) (*ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor ProductCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort != sort.String() {
+		return nil, ErrInvalidCursor
+	}
+	if sort.Field == ProductSortCreated && cursor.CreatedAt == nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,25 @@
+package domain
+
+type ProductSearchQuery struct {
+	Query    string
+	Page     int
+	PageSize int
+}
+
+type ProductSearchHit struct {
+	Product Product `json:"product"`
+	Rank    float64 `json:"rank"`
+}
+
+type ProductSearchPage struct {
+	Hits     []ProductSearchHit `json:"data"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Total    int64              `json:"total"`
+}
+
+// ProductSuggestion is a type-ahead match, kept small so it can be fetched on every keystroke.
+type ProductSuggestion struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVariantRequired   = NewValidationError("the product comes in several variants, choose one with variant_id")
+	ErrInsufficientStock = NewValidationError("not enough stock for the chosen variant")
+)
+
+// ProductOption is something a product varies by, like "Size" or "Colour",
+// with the values its variants pick from.
+type ProductOption struct {
+	ID        uint                 `json:"id"`
+	ProductID uint                 `json:"product_id" gorm:"not null;uniqueIndex:idx_product_options_name"`
+	Name      string               `json:"name" gorm:"not null;uniqueIndex:idx_product_options_name"`
+	Position  int                  `json:"position"`
+	Values    []ProductOptionValue `json:"values" gorm:"foreignKey:OptionID"`
+}
+
+type ProductOptionValue struct {
+	ID       uint           `json:"id"`
+	OptionID uint           `json:"option_id" gorm:"not null;uniqueIndex:idx_product_option_values_value"`
+	Option   *ProductOption `json:"-" gorm:"foreignKey:OptionID"`
+	Value    string         `json:"value" gorm:"not null;uniqueIndex:idx_product_option_values_value"`
+	Position int            `json:"position"`
+}
+
+// VariantOption is one option a variant picks, e.g. Size: M.
+type VariantOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ProductVariant is one sellable version of a product. Price overrides the
+// product's price when set, and stock is tracked per variant.
+type ProductVariant struct {
+	ID           uint                 `json:"id"`
+	ProductID    uint                 `json:"product_id" gorm:"not null;index"`
+	SKU          string               `json:"sku" gorm:"index:idx_product_variants_sku,unique,where:sku <> ''"`
+	Price        *Money               `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Stock        int                  `json:"stock" gorm:"not null;default:0"`
+	Options      []VariantOption      `json:"options" gorm:"-"`
+	OptionValues []ProductOptionValue `json:"-" gorm:"many2many:product_variant_values"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt       `json:"deleted_at" gorm:"index"`
+}
+
+// EffectivePrice is the variant's own price, or the product's when it has none.
+func (v *ProductVariant) EffectivePrice(productPrice Money) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return productPrice
+}
+
+// AfterFind leaves Price nil when the variant has no price of its own, gorm
+// fills an embedded pointer even when its columns are NULL.
+func (v *ProductVariant) AfterFind(tx *gorm.DB) error {
+	if v.Price != nil && v.Price.Currency == "" {
+		v.Price = nil
+	}
+	return nil
+}
+
+// ProductVariants lists a product's options and its variants.
+type ProductVariants struct {
+	Options  []ProductOption  `json:"options"`
+	Variants []ProductVariant `json:"variants"`
+}
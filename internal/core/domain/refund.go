@@ -0,0 +1,108 @@
+package domain
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderPlaced    OrderStatus = "placed"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+type RefundStatus string
+
+const (
+	// RefundPending is a refund the provider accepted and hasn't confirmed
+	// yet, M-Pesa reports back on its payouts later.
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+var (
+	ErrOrderCancelled  = NewValidationError("the order is cancelled")
+	ErrOrderNotPaid    = NewValidationError("only paid orders can be refunded, cancel the order instead")
+	ErrNothingToRefund = NewValidationError("everything on the order has been refunded already")
+)
+
+// OrderRefund is money given back to the customer for some of an order's
+// units, or all of them and the shipping. Refunds are never deleted, they
+// are the order's accounting record.
+type OrderRefund struct {
+	ID      uint              `json:"id" gorm:"primaryKey"`
+	OrderID uint              `json:"order_id" gorm:"index"`
+	Lines   []OrderRefundLine `json:"lines" gorm:"foreignKey:RefundID"`
+	// Shipping is the part of Amount that refunds the order's shipping cost.
+	Shipping Money  `json:"shipping" gorm:"embedded;embeddedPrefix:shipping_"`
+	Amount   Money  `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Reason   string `json:"reason"`
+	// Restocked is whether the refunded units went back into stock.
+	Restocked bool `json:"restocked" gorm:"not null;default:false"`
+	// PaymentIntentID is the payment the money went back to, and Provider and
+	// ProviderRef how the provider knows the refund. Like a payment's, the
+	// reference is kept from customers.
+	PaymentIntentID *uint        `json:"payment_intent_id"`
+	Provider        string       `json:"provider" gorm:"size:20;uniqueIndex:idx_order_refunds_provider_ref"`
+	ProviderRef     *string      `json:"-" gorm:"size:100;uniqueIndex:idx_order_refunds_provider_ref"`
+	Status          RefundStatus `json:"status" gorm:"size:20"`
+	FailureReason   string       `json:"failure_reason"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// OrderRefundLine is the refund of Quantity units of an order line, Amount
+// being what the customer paid for them after discounts and with tax.
+type OrderRefundLine struct {
+	ID          uint  `json:"id" gorm:"primaryKey"`
+	RefundID    uint  `json:"refund_id" gorm:"index"`
+	OrderItemID uint  `json:"order_item_id"`
+	Quantity    int   `json:"quantity"`
+	Amount      Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+// RefundRequest says what to refund of an order.
+type RefundRequest struct {
+	// Lines to refund. Everything not refunded yet, shipping included, is
+	// refunded when there are none.
+	Lines  []RefundLineRequest
+	Reason string
+	// Restock puts the refunded units back into stock.
+	Restock bool
+	// StoreCredit gives the refund as store credit instead of paying it back.
+	StoreCredit bool
+}
+
+type RefundLineRequest struct {
+	OrderItemID uint
+	Quantity    int
+}
+
+// PaidFor is what the customer paid for the line, after its discount and
+// with the tax when it came on top of the price.
+func (i *OrderItem) PaidFor(pricesIncludeTax bool) Money {
+	paid := Money{Amount: i.Price.Amount - i.Discount.Amount, Currency: i.Price.Currency}
+	if !pricesIncludeTax {
+		paid.Amount += i.Tax.Amount
+	}
+	return paid
+}
+
+// RefundFor is what refunding quantity more units of the line gives back. The
+// last unit refunded takes up any rounding, so a line refunded unit by unit
+// adds up to what was paid for it.
+func (i *OrderItem) RefundFor(quantity int, pricesIncludeTax bool) Money {
+	paid := i.PaidFor(pricesIncludeTax)
+	before := paid.Scale(int64(i.RefundedQuantity), int64(i.Quantity))
+	after := paid.Scale(int64(i.RefundedQuantity+quantity), int64(i.Quantity))
+	return Money{Amount: after.Amount - before.Amount, Currency: paid.Currency}
+}
+
+// ShippingRefunded reports whether one of the order's refunds gave back the shipping.
+func (o *Order) ShippingRefunded() bool {
+	for _, refund := range o.Refunds {
+		if refund.Shipping.Amount != 0 {
+			return true
+		}
+	}
+	return false
+}
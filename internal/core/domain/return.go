@@ -0,0 +1,108 @@
+package domain
+
+import "time"
+
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "requested"
+	ReturnApproved  ReturnStatus = "approved"
+	ReturnRejected  ReturnStatus = "rejected"
+	// ReturnReceived is a return whose goods are back with us, waiting to be
+	// refunded or credited.
+	ReturnReceived ReturnStatus = "received"
+	ReturnRefunded ReturnStatus = "refunded"
+	ReturnCredited ReturnStatus = "credited"
+)
+
+// Open reports whether a return in the status still holds on to its units,
+// so they can't be returned a second time.
+func (s ReturnStatus) Open() bool {
+	return s == ReturnRequested || s == ReturnApproved || s == ReturnReceived
+}
+
+// ReturnResolution is how the customer is paid back for returned goods.
+type ReturnResolution string
+
+const (
+	ResolveRefund      ReturnResolution = "refund"
+	ResolveStoreCredit ReturnResolution = "store_credit"
+)
+
+// MaxReturnPhotos is how many photos a return can have.
+const MaxReturnPhotos = 5
+
+var (
+	ErrReturnNotPaid = NewValidationError("only paid orders can be returned")
+	// ErrReturnChanged is a step taken on a return someone else moved on in the meantime.
+	ErrReturnChanged = NewValidationError("the return was changed in the meantime, fetch it again")
+)
+
+// OrderReturn is a customer's request to send back units of a paid order,
+// followed from the request through to the refund or store credit that
+// settles it.
+type OrderReturn struct {
+	ID         uint              `json:"id" gorm:"primaryKey"`
+	OrderID    uint              `json:"order_id" gorm:"index"`
+	CustomerID uint              `json:"customer_id" gorm:"index"`
+	Status     ReturnStatus      `json:"status" gorm:"size:20;index"`
+	Lines      []OrderReturnLine `json:"lines" gorm:"foreignKey:ReturnID"`
+	Photos     []ReturnPhoto     `json:"photos" gorm:"foreignKey:ReturnID"`
+	// Comment is what the customer added about the return as a whole, and
+	// Note what staff told them on approving or rejecting it.
+	Comment string `json:"comment"`
+	Note    string `json:"note"`
+	// Restocked is whether the units went back into stock when received.
+	Restocked  bool             `json:"restocked" gorm:"not null;default:false"`
+	Resolution ReturnResolution `json:"resolution" gorm:"size:20"`
+	// RefundID is the order refund that paid for the units, in money or in
+	// store credit, and Amount what it gave back.
+	RefundID   *uint      `json:"refund_id"`
+	Amount     Money      `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	ApprovedAt *time.Time `json:"approved_at"`
+	RejectedAt *time.Time `json:"rejected_at"`
+	ReceivedAt *time.Time `json:"received_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// OrderReturnLine is Quantity units of an order line being sent back.
+type OrderReturnLine struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ReturnID    uint   `json:"return_id" gorm:"index"`
+	OrderItemID uint   `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+	Reason      string `json:"reason"`
+}
+
+// ReturnPhoto is a picture the customer sent of the goods. The file lives in
+// the blob store under Key, URL is where clients fetch it.
+type ReturnPhoto struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ReturnID    uint      `json:"return_id" gorm:"index"`
+	Key         string    `json:"-" gorm:"not null"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OpenReturnRequest is what a customer asks to return of one of their orders.
+type OpenReturnRequest struct {
+	OrderID uint
+	Lines   []ReturnLineRequest
+	Comment string
+}
+
+type ReturnLineRequest struct {
+	OrderItemID uint
+	Quantity    int
+	Reason      string
+}
+
+// ReturnPhotoUpload is a photo as received from the client.
+type ReturnPhotoUpload struct {
+	Filename string
+	Data     []byte
+}
@@ -0,0 +1,242 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ShippingMethodType string
+
+const (
+	// ShippingFlatRate charges Fee whatever the order.
+	ShippingFlatRate ShippingMethodType = "flat_rate"
+	// ShippingWeightBased charges Fee for the first IncludedWeight grams and
+	// PerKg for each started kilogram above it.
+	ShippingWeightBased ShippingMethodType = "weight_based"
+	// ShippingZoneBased charges the fee of the first zone that covers the
+	// address's county, and doesn't deliver outside its zones.
+	ShippingZoneBased ShippingMethodType = "zone_based"
+)
+
+// VolumetricDivisor turns a parcel's volume in cubic millimetres into the
+// grams it is charged as, the usual 5000 cm³ per kilogram of couriers.
+const VolumetricDivisor = 5000
+
+var ErrShippingMethodRequired = NewValidationError("choose a shipping method, see the shipping quotes for the ones that deliver to the address")
+
+// ShippingMethod is a way of delivering orders and how it is charged. Any
+// method can be made free for orders worth FreeOver or more.
+type ShippingMethod struct {
+	ID          uint               `json:"id"`
+	Name        string             `json:"name" gorm:"not null"`
+	Description string             `json:"description" gorm:"not null;default:''"`
+	Type        ShippingMethodType `json:"type" gorm:"size:20;not null"`
+	// Fee is the flat rate, the base fee of a weight based method, and unused
+	// by zone based methods, whose zones have their own fees.
+	Fee Money `json:"fee" gorm:"embedded;embeddedPrefix:fee_"`
+	// PerKg, IncludedWeight and MaxWeight are for weight based methods. The
+	// weights are in grams, a MaxWeight of 0 means no limit.
+	PerKg          Money         `json:"per_kg" gorm:"embedded;embeddedPrefix:per_kg_"`
+	IncludedWeight int           `json:"included_weight_grams" gorm:"not null;default:0"`
+	MaxWeight      int           `json:"max_weight_grams" gorm:"not null;default:0"`
+	Zones          ShippingZones `json:"zones" gorm:"type:jsonb"`
+	// FreeOver is what the items have to be worth, after discounts, for
+	// shipping to be free. A zero amount means never.
+	FreeOver  Money     `json:"free_over" gorm:"embedded;embeddedPrefix:free_over_"`
+	Active    bool      `json:"active" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ShippingZone is a set of Kenyan counties, named directly or through the
+// region they are in, delivered to at one fee.
+type ShippingZone struct {
+	Name     string   `json:"name"`
+	Counties []string `json:"counties,omitempty"`
+	Regions  []string `json:"regions,omitempty"`
+	Fee      Money    `json:"fee"`
+}
+
+// Covers tells whether the county is in the zone.
+func (z ShippingZone) Covers(county string) bool {
+	key := countyKey(county)
+	if key == "" {
+		return false
+	}
+	for _, listed := range z.Counties {
+		if countyKey(listed) == key {
+			return true
+		}
+	}
+	region, ok := CountyRegion(county)
+	if !ok {
+		return false
+	}
+	for _, listed := range z.Regions {
+		if strings.EqualFold(strings.TrimSpace(listed), region) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShippingZones are stored in a jsonb column.
+type ShippingZones []ShippingZone
+
+func (z ShippingZones) Value() (driver.Value, error) {
+	if len(z) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal([]ShippingZone(z))
+	return string(raw), err
+}
+
+func (z *ShippingZones) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*z = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ShippingZones", value)
+	}
+	return json.Unmarshal(raw, (*[]ShippingZone)(z))
+}
+
+// Validate checks the method makes sense for its type. Amounts without a
+// currency must have been given one already.
+func (m *ShippingMethod) Validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return NewValidationError("name is required")
+	}
+	if err := validateFee("fee", m.Fee); err != nil {
+		return err
+	}
+	switch m.Type {
+	case ShippingFlatRate:
+	case ShippingWeightBased:
+		if err := validateFee("per_kg", m.PerKg); err != nil {
+			return err
+		}
+		if m.IncludedWeight < 0 || m.MaxWeight < 0 {
+			return NewValidationError("included_weight_grams and max_weight_grams must not be negative")
+		}
+	case ShippingZoneBased:
+		if len(m.Zones) == 0 {
+			return NewValidationError("a zone based method needs at least one zone")
+		}
+		for _, zone := range m.Zones {
+			if err := zone.validate(); err != nil {
+				return err
+			}
+		}
+	default:
+		return NewValidationError("type must be one of %s, %s or %s", ShippingFlatRate, ShippingWeightBased, ShippingZoneBased)
+	}
+	if !m.FreeOver.IsZero() {
+		return validateFee("free_over", m.FreeOver)
+	}
+	return nil
+}
+
+func (z ShippingZone) validate() error {
+	if strings.TrimSpace(z.Name) == "" {
+		return NewValidationError("every zone needs a name")
+	}
+	if len(z.Counties) == 0 && len(z.Regions) == 0 {
+		return NewValidationError("zone %q needs counties or regions", z.Name)
+	}
+	for _, county := range z.Counties {
+		if _, ok := CountyRegion(county); !ok {
+			return NewValidationError("zone %q: %q is not a Kenyan county", z.Name, county)
+		}
+	}
+	for _, region := range z.Regions {
+		if !isRegion(region) {
+			return NewValidationError("zone %q: %q is not a region, use one of %s", z.Name, region, strings.Join(Regions, ", "))
+		}
+	}
+	return validateFee(fmt.Sprintf("zone %q fee", z.Name), z.Fee)
+}
+
+func validateFee(name string, fee Money) error {
+	if err := fee.Validate(); err != nil {
+		return err
+	}
+	if fee.IsNegative() {
+		return NewValidationError("%s must not be negative", name)
+	}
+	return nil
+}
+
+// Cost is what the method charges, in the currency of its fees, for a parcel
+// of the given weight in grams going to the county. ok is false when the
+// method doesn't deliver there or can't carry that much. zone names the zone
+// that set the fee.
+func (m *ShippingMethod) Cost(county string, weight int) (cost Money, zone string, ok bool) {
+	switch m.Type {
+	case ShippingFlatRate:
+		return m.Fee, "", true
+	case ShippingWeightBased:
+		if m.MaxWeight > 0 && weight > m.MaxWeight {
+			return Money{}, "", false
+		}
+		cost = m.Fee
+		if extra := weight - m.IncludedWeight; extra > 0 {
+			kilograms := (extra + 999) / 1000
+			cost.Amount += m.PerKg.Mul(int64(kilograms)).Amount
+		}
+		return cost, "", true
+	case ShippingZoneBased:
+		for _, z := range m.Zones {
+			if z.Covers(county) {
+				return z.Fee, z.Name, true
+			}
+		}
+	}
+	return Money{}, "", false
+}
+
+// NeedsWeight tells whether the cost depends on the parcel's weight.
+func (m *ShippingMethod) NeedsWeight() bool {
+	return m.Type == ShippingWeightBased
+}
+
+// ShippingQuote is what a method would charge to deliver an order.
+type ShippingQuote struct {
+	MethodID    uint               `json:"method_id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Type        ShippingMethodType `json:"type"`
+	// Cost is in the currency the order is charged in
+	Cost Money `json:"cost"`
+	// Free is set when the order is worth the method's free_over or more
+	Free bool `json:"free"`
+	// Zone names the zone of a zone based method that covers the address
+	Zone string `json:"zone,omitempty"`
+}
+
+// ShippingWeight is the weight in grams a product is charged as, the larger
+// of its own weight and its volumetric weight.
+func (p *Product) ShippingWeight() int {
+	volumetric := int64(p.LengthMM) * int64(p.WidthMM) * int64(p.HeightMM) / VolumetricDivisor
+	if volumetric > int64(p.WeightGrams) {
+		return int(volumetric)
+	}
+	return p.WeightGrams
+}
+
+// ValidateParcel checks the product's weight and dimensions.
+func (p *Product) ValidateParcel() error {
+	if p.WeightGrams < 0 || p.LengthMM < 0 || p.WidthMM < 0 || p.HeightMM < 0 {
+		return NewValidationError("weight and dimensions must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// StoreCreditProvider is the Provider of order refunds given as store credit
+// rather than paid back through the payment provider.
+const StoreCreditProvider = "store_credit"
+
+// StoreCredit is an entry in a customer's store credit ledger. Refunds given
+// as store credit add one, the balance is the sum of the entries in each
+// currency.
+type StoreCredit struct {
+	ID         uint  `json:"id" gorm:"primaryKey"`
+	CustomerID uint  `json:"customer_id" gorm:"index"`
+	Amount     Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	OrderID    uint  `json:"order_id"`
+	// RefundID is the order refund the credit was given for.
+	RefundID  *uint     `json:"refund_id" gorm:"uniqueIndex"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoreCreditAccount is a customer's store credit, the balance having one
+// amount per currency credited.
+type StoreCreditAccount struct {
+	Balance []Money       `json:"balance"`
+	Entries []StoreCredit `json:"entries"`
+}
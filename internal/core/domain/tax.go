@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"math/big"
+	"strings"
+	"time"
+)
+
+// maxTaxDecimals is the most decimal places a tax percentage can have.
+const maxTaxDecimals = 4
+
+// TaxClass groups goods taxed at the same rate, like standard rated or zero
+// rated goods under Kenyan VAT. Products take their own class, or else the
+// class of their category or of the nearest ancestor category that has one.
+// The default class covers products none of those name.
+type TaxClass struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name" gorm:"not null;uniqueIndex"`
+	Description string `json:"description" gorm:"not null;default:''"`
+	// IsDefault marks the class used when neither a product nor its
+	// categories name one. At most one class is the default.
+	IsDefault bool      `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Rate is the rate in force now, filled in when classes are listed.
+	Rate *TaxRate `json:"rate,omitempty" gorm:"-"`
+}
+
+// TaxRate is the percentage charged on a tax class from EffectiveFrom on.
+// Setting a rate adds a row, so older rows are the class's rate history.
+type TaxRate struct {
+	ID            uint      `json:"id"`
+	TaxClassID    uint      `json:"tax_class_id" gorm:"not null;uniqueIndex:idx_tax_rates_from"`
+	Percent       Rate      `json:"percent" gorm:"type:numeric(9,4);not null"`
+	EffectiveFrom time.Time `json:"effective_from" gorm:"not null;uniqueIndex:idx_tax_rates_from"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ParseTaxPercent reads a percentage between 0 and 100 with at most four
+// decimal places, like "16" or "12.5". Zero rated classes have a rate of 0.
+func ParseTaxPercent(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+	whole, fraction, hasPoint := strings.Cut(value, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || (hasPoint && fraction == "") {
+		return "", NewValidationError("tax rate %q is not a decimal number", value)
+	}
+	if len(fraction) > maxTaxDecimals {
+		return "", NewValidationError("tax rate %q has more than %d decimal places", value, maxTaxDecimals)
+	}
+	percent := normalizeRate(value)
+	if percent.Rat().Cmp(big.NewRat(100, 1)) > 0 {
+		return "", NewValidationError("tax rate must be at most 100")
+	}
+	return percent, nil
+}
+
+// TaxOn returns the tax in an amount. With inclusive the amount already has
+// the tax in it, otherwise the tax comes on top. Either way it is rounded
+// once, to the nearest minor unit with halves away from zero.
+func (r Rate) TaxOn(amount Money, inclusive bool) Money {
+	percent := r.Rat()
+	numerator, denominator := percent.Num().Int64(), percent.Denom().Int64()
+	if inclusive {
+		// tax = amount * p / (100 + p)
+		return amount.Scale(numerator, numerator+100*denominator)
+	}
+	return amount.Scale(numerator, 100*denominator)
+}
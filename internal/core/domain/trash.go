@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// Trash lists the soft deleted products and categories, which can still be
+// restored until they are purged.
+type Trash struct {
+	Products   []Product  `json:"products"`
+	Categories []Category `json:"categories"`
+	// RetentionDays is how long items stay in the trash before a purge removes them.
+	RetentionDays int `json:"retention_days"`
+}
+
+// TrashPurgeSummary counts what a purge removed. Items past retention that
+// are still referenced, by past orders for instance, stay in the trash.
+type TrashPurgeSummary struct {
+	DeletedBefore    time.Time `json:"deleted_before"`
+	ProductsPurged   int64     `json:"products_purged"`
+	CategoriesPurged int64     `json:"categories_purged"`
+}
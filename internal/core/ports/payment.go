@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+// PaymentGateway collects payments from customers through a provider such as
+// M-Pesa. Requests are answered later, by a callback the provider posts or by
+// asking with QueryPayment.
+type PaymentGateway interface {
+	// Provider names the provider on the intents it handles, e.g. "mpesa".
+	Provider() string
+	// RequestPayment asks the customer to pay intent.Amount from intent.Phone
+	// and returns the provider's reference for the request. Requests the
+	// provider refuses for bad input return a domain.ValidationError.
+	RequestPayment(ctx context.Context, intent *domain.PaymentIntent) (string, error)
+	// ParseCallback verifies a callback the provider posted, token being the
+	// secret the callback URL carries, and returns the result it reports.
+	// Callbacks that fail verification return a domain.ValidationError.
+	ParseCallback(ctx context.Context, token string, body []byte) (*domain.PaymentResult, error)
+	// QueryPayment asks the provider what became of a request whose callback
+	// hasn't come.
+	QueryPayment(ctx context.Context, intent *domain.PaymentIntent) (*domain.PaymentResult, error)
+	// Refund pays amount of the intent's successful payment back to the
+	// customer. The result is pending for providers that report back on it
+	// later, in a callback marked Refund.
+	Refund(ctx context.Context, intent *domain.PaymentIntent, amount domain.Money, reason string) (*domain.PaymentResult, error)
+}
@@ -2,17 +2,118 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
 )
 
+// AttributeCheck validates a product's attributes against those defined on
+// its category's lineage, given from the category up to the root.
+type AttributeCheck func(attributes domain.Attributes, lineage []uint, defined []domain.CategoryAttribute) error
+
 type ProductRepository interface {
+	// Create and Update start a new period in the price history when the
+	// price is set or changes, as does ImportBatch.
 	Create(ctx context.Context, product *domain.Product) error
 	Get(ctx context.Context, id uint) (*domain.Product, error)
-	List(ctx context.Context) ([]domain.Product, error)
+	List(ctx context.Context, query domain.ProductListQuery) ([]domain.Product, error)
 	Update(ctx context.Context, product *domain.Product) error
 	Delete(ctx context.Context, id uint) error
-	GetAverageCategoryPrice(ctx context.Context, productID uint) (float64, error)
+	// GetCategoryPriceTotals adds up the product prices in the category per
+	// currency. With byVariant each variant counts at its own price instead
+	// of its product.
+	GetCategoryPriceTotals(ctx context.Context, categoryID uint, byVariant bool) ([]domain.PriceTotal, error)
+	// ImportBatch upserts the rows by SKU in one transaction, creating missing
+	// categories along the way. Rows whose product check finds breaking its
+	// category's attributes are rejected. A dry run reports the same results
+	// and rolls back.
+	ImportBatch(ctx context.Context, rows []domain.ProductImportRow, dryRun bool, check AttributeCheck) ([]domain.ProductImportResult, error)
+	// ListAfter returns up to limit products with an ID above afterID in ID
+	// order, optionally only those in categoryIDs, so callers can walk the
+	// whole catalog one page at a time.
+	ListAfter(ctx context.Context, afterID uint, limit int, categoryIDs []uint) ([]domain.Product, error)
+	// GetDeleted, ListDeleted and Restore work on soft deleted products only.
+	GetDeleted(ctx context.Context, id uint) (*domain.Product, error)
+	ListDeleted(ctx context.Context) ([]domain.Product, error)
+	Restore(ctx context.Context, id uint) error
+	// PurgeDeleted removes products deleted before the given time for good,
+	// except those that past orders still point at.
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// ProductVariantRepository stores variants together with the option values
+// they pick. Create and Update add the product options and values named in
+// variant.Options that do not exist yet.
+type ProductVariantRepository interface {
+	Create(ctx context.Context, variant *domain.ProductVariant) error
+	Get(ctx context.Context, id uint) (*domain.ProductVariant, error)
+	ListByProduct(ctx context.Context, productID uint) ([]domain.ProductVariant, error)
+	ListOptions(ctx context.Context, productID uint) ([]domain.ProductOption, error)
+	Update(ctx context.Context, variant *domain.ProductVariant) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// ProductPriceRepository keeps each product's price history as consecutive
+// periods. ProductRepository records a new period whenever a product's price
+// is set, so the history starts with the product.
+type ProductPriceRepository interface {
+	// ListByProduct returns the product's periods, oldest first.
+	ListByProduct(ctx context.Context, productID uint) ([]domain.ProductPrice, error)
+	// PriceAt returns the period in force at the given time, domain.ErrNotFound when there is none.
+	PriceAt(ctx context.Context, productID uint, at time.Time) (*domain.ProductPrice, error)
+	// Schedule splits the periods at change.EffectiveFrom, and at
+	// change.EffectiveTo when set, so the new price applies in between.
+	Schedule(ctx context.Context, productID uint, change domain.PriceChange) error
+	// Cancel removes the period, the one before it is extended to cover its time.
+	Cancel(ctx context.Context, id uint) error
+	// ApplyDue copies the price in force at the given time onto every product
+	// whose price differs, and returns how many were changed.
+	ApplyDue(ctx context.Context, at time.Time) (int64, error)
+}
+
+// ExchangeRateRepository keeps every rate that has been set, so the rates in
+// force at any time can be looked up.
+type ExchangeRateRepository interface {
+	Create(ctx context.Context, rate *domain.ExchangeRate) error
+	// ListAt returns the rate in force at the given time for each currency
+	// that has one, ordered by currency.
+	ListAt(ctx context.Context, at time.Time) ([]domain.ExchangeRate, error)
+	// History returns every rate set for the currency, oldest first.
+	History(ctx context.Context, currency string) ([]domain.ExchangeRate, error)
+}
+
+// TaxRepository stores tax classes, their rate history and which products
+// and categories they are assigned to.
+type TaxRepository interface {
+	// CreateClass and UpdateClass clear the default flag of the other
+	// classes when the class is the default.
+	CreateClass(ctx context.Context, class *domain.TaxClass) error
+	GetClass(ctx context.Context, id uint) (*domain.TaxClass, error)
+	ListClasses(ctx context.Context) ([]domain.TaxClass, error)
+	UpdateClass(ctx context.Context, class *domain.TaxClass) error
+	// DeleteClass removes the class with its rates and unassigns it from
+	// products and categories. Orders keep the rate they were taxed at.
+	DeleteClass(ctx context.Context, id uint) error
+	CreateRate(ctx context.Context, rate *domain.TaxRate) error
+	// RatesAt returns the rate in force at the given time for each class that has one.
+	RatesAt(ctx context.Context, at time.Time) ([]domain.TaxRate, error)
+	// RateHistory returns every rate set for the class, oldest first.
+	RateHistory(ctx context.Context, classID uint) ([]domain.TaxRate, error)
+	// SetCategoryClass and SetProductClass assign a class, nil unassigns it.
+	// They return domain.ErrNotFound when there is no such category or product.
+	SetCategoryClass(ctx context.Context, categoryID uint, classID *uint) error
+	SetProductClass(ctx context.Context, productID uint, classID *uint) error
+}
+
+// ProductImageRepository stores image records, the files themselves are in a BlobStore.
+type ProductImageRepository interface {
+	Create(ctx context.Context, image *domain.ProductImage) error
+	Get(ctx context.Context, id uint) (*domain.ProductImage, error)
+	// ListByProduct returns the product's images in display order.
+	ListByProduct(ctx context.Context, productID uint) ([]domain.ProductImage, error)
+	// SaveOrder writes the position and primary flag of each image in one transaction.
+	SaveOrder(ctx context.Context, images []domain.ProductImage) error
+	Delete(ctx context.Context, id uint) error
 }
 
 type CategoryRepository interface {
@@ -21,20 +122,181 @@ type CategoryRepository interface {
 	List(ctx context.Context) ([]domain.Category, error)
 	Update(ctx context.Context, category *domain.Category) error
 	Delete(ctx context.Context, id uint) error
+	// ListAttributes returns the attributes defined directly on any of the categories.
+	ListAttributes(ctx context.Context, categoryIDs []uint) ([]domain.CategoryAttribute, error)
+	// ReplaceAttributes swaps the category's attribute definitions for the given ones.
+	ReplaceAttributes(ctx context.Context, categoryID uint, attributes []domain.CategoryAttribute) error
+	GetDeleted(ctx context.Context, id uint) (*domain.Category, error)
+	ListDeleted(ctx context.Context) ([]domain.Category, error)
+	Restore(ctx context.Context, id uint) error
+	// PurgeDeleted removes categories deleted before the given time for good,
+	// except those that products or other categories still point at.
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type OrderRepository interface {
+	// Create takes the stock of the ordered variants and counts the use of
+	// each discount in the order's adjustments in one transaction. It returns
+	// domain.ErrDiscountUsedUp when a discount has reached one of its limits.
 	Create(ctx context.Context, order *domain.Order) (*domain.Order, error)
 	List(ctx context.Context) ([]domain.Order, error)
 	Get(ctx context.Context, id uint) (*domain.Order, error)
-	Update(ctx context.Context, order *domain.Order) error
-	Delete(ctx context.Context, id uint) error
+	// Amend saves the order's amended lines, adjustments and totals, removing
+	// the lines it no longer has, records the amendment and takes
+	// stock[variantID] more units of each variant from stock, putting them
+	// back when negative, in one transaction. Taking more than is in stock
+	// fails with domain.ErrInsufficientStock.
+	Amend(ctx context.Context, order *domain.Order, amendment *domain.OrderAmendment, stock map[uint]int) error
+	// SaveRefund saves the order's status, payment status, refunded total and
+	// refunded quantities, creates the refund when there is one, with its
+	// store credit entry when given as domain.StoreCreditProvider, and puts
+	// restock[variantID] units back into stock, in one transaction.
+	SaveRefund(ctx context.Context, order *domain.Order, refund *domain.OrderRefund, restock map[uint]int) error
+	// Archive sets the order's ArchivedAt, which keeps it out of List.
+	Archive(ctx context.Context, id uint, at time.Time) error
 	DeleteOrderItems(ctx context.Context, orderID uint) error
+	ListByCustomer(ctx context.Context, customerID uint) ([]domain.Order, error)
 	GetOrderProduct(ctx context.Context, productID uint) (*domain.Product, error)
+	GetOrderVariant(ctx context.Context, variantID uint) (*domain.ProductVariant, error)
+	HasVariants(ctx context.Context, productID uint) (bool, error)
+}
+
+// PaymentRepository stores payment intents.
+type PaymentRepository interface {
+	CreateIntent(ctx context.Context, intent *domain.PaymentIntent) error
+	// GetIntentByRef finds the intent the provider knows by ref.
+	GetIntentByRef(ctx context.Context, provider, ref string) (*domain.PaymentIntent, error)
+	// ListByOrder returns the order's intents, oldest first.
+	ListByOrder(ctx context.Context, orderID uint) ([]domain.PaymentIntent, error)
+	// ListPending returns up to limit intents still pending that were created
+	// before the given time, oldest first.
+	ListPending(ctx context.Context, createdBefore time.Time, limit int) ([]domain.PaymentIntent, error)
+	UpdateIntent(ctx context.Context, intent *domain.PaymentIntent) error
+	// Settle saves an intent the provider has given an outcome for and, when it
+	// succeeded, marks its order paid at intent.CompletedAt, in one
+	// transaction. A cancelled order is left unpaid. It returns false and
+	// changes nothing when the intent had already succeeded, so a result
+	// delivered twice is only applied once.
+	Settle(ctx context.Context, intent *domain.PaymentIntent) (bool, error)
+	// SettleRefund records the outcome of a pending refund. It returns false
+	// when the refund isn't pending any more, and domain.ErrNotFound when there
+	// is no such refund.
+	SettleRefund(ctx context.Context, provider, ref string, status domain.RefundStatus, reason string) (bool, error)
+}
+
+// ShippingRepository stores shipping methods.
+type ShippingRepository interface {
+	CreateMethod(ctx context.Context, method *domain.ShippingMethod) error
+	GetMethod(ctx context.Context, id uint) (*domain.ShippingMethod, error)
+	// ListMethods returns the methods by name, only the active ones when activeOnly is set.
+	ListMethods(ctx context.Context, activeOnly bool) ([]domain.ShippingMethod, error)
+	UpdateMethod(ctx context.Context, method *domain.ShippingMethod) error
+	DeleteMethod(ctx context.Context, id uint) error
+}
+
+// DiscountRepository stores discount rules. OrderRepository.Create counts
+// their use, against the limits, when it saves an order's adjustments.
+type DiscountRepository interface {
+	Create(ctx context.Context, discount *domain.Discount) error
+	Get(ctx context.Context, id uint) (*domain.Discount, error)
+	// GetByCode looks the discount up by its normalized code.
+	GetByCode(ctx context.Context, code string) (*domain.Discount, error)
+	List(ctx context.Context) ([]domain.Discount, error)
+	Update(ctx context.Context, discount *domain.Discount) error
+	Delete(ctx context.Context, id uint) error
+	// ListAutomatic returns the active discounts without a code whose
+	// validity window includes the given time.
+	ListAutomatic(ctx context.Context, at time.Time) ([]domain.Discount, error)
+	// CountCustomerUses counts the customer's orders the discount was applied to.
+	CountCustomerUses(ctx context.Context, discountID, customerID uint) (int64, error)
+}
+
+type CartRepository interface {
+	// GetByCustomer and GetByToken load the cart with its items.
+	GetByCustomer(ctx context.Context, customerID uint) (*domain.Cart, error)
+	GetByToken(ctx context.Context, token string) (*domain.Cart, error)
+	Create(ctx context.Context, cart *domain.Cart) error
+	// SaveItem adds the item to its cart, or updates it when it has an ID.
+	SaveItem(ctx context.Context, item *domain.CartItem) error
+	DeleteItem(ctx context.Context, cartID, itemID uint) error
+	ClearItems(ctx context.Context, cartID uint) error
+	// Merge saves into's items and deletes the cart fromID in one transaction.
+	Merge(ctx context.Context, into *domain.Cart, fromID uint) error
 }
 
 type CustomerRepository interface {
 	UpsertCustomer(ctx context.Context, customer *domain.Customer) (*domain.Customer, error)
 	CreateCustomer(ctx context.Context, customer *domain.Customer) (*domain.Customer, error)
 	GetCustomer(ctx context.Context, id uint) (*domain.Customer, error)
+	GetCustomerByEmail(ctx context.Context, email string) (*domain.Customer, error)
+	UpdateCustomer(ctx context.Context, customer *domain.Customer) error
+	ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error)
+}
+
+type PhoneVerificationRepository interface {
+	Create(ctx context.Context, verification *domain.PhoneVerification) error
+	GetLatest(ctx context.Context, customerID uint) (*domain.PhoneVerification, error)
+	Update(ctx context.Context, verification *domain.PhoneVerification) error
+	// ListPhones returns every number the customer has asked to verify, including old ones.
+	ListPhones(ctx context.Context, customerID uint) ([]string, error)
+}
+
+// ReturnRepository stores return requests and the store credit ledger.
+type ReturnRepository interface {
+	Create(ctx context.Context, ret *domain.OrderReturn) error
+	// Get returns the return with its lines and photos.
+	Get(ctx context.Context, id uint) (*domain.OrderReturn, error)
+	// List returns the returns in the status, all of them when it is empty,
+	// oldest first.
+	List(ctx context.Context, status domain.ReturnStatus) ([]domain.OrderReturn, error)
+	ListByCustomer(ctx context.Context, customerID uint) ([]domain.OrderReturn, error)
+	ListByOrder(ctx context.Context, orderID uint) ([]domain.OrderReturn, error)
+	AddPhoto(ctx context.Context, photo *domain.ReturnPhoto) error
+	// Transition saves the return's status and the fields that came with it
+	// when the return is still in status from, putting restock[variantID]
+	// units back into stock in the same transaction. It returns
+	// domain.ErrReturnChanged when the return has moved on.
+	Transition(ctx context.Context, ret *domain.OrderReturn, from domain.ReturnStatus, restock map[uint]int) error
+	// ListStoreCredit returns the customer's store credit entries, oldest first.
+	ListStoreCredit(ctx context.Context, customerID uint) ([]domain.StoreCredit, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	Get(ctx context.Context, id uint) (*domain.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	List(ctx context.Context) ([]domain.APIKey, error)
+	Revoke(ctx context.Context, id uint) error
+	TouchLastUsed(ctx context.Context, id uint) error
+}
+
+type AddressRepository interface {
+	Create(ctx context.Context, address *domain.Address) error
+	Get(ctx context.Context, id uint) (*domain.Address, error)
+	GetDefault(ctx context.Context, customerID uint) (*domain.Address, error)
+	ListByCustomer(ctx context.Context, customerID uint) ([]domain.Address, error)
+	Update(ctx context.Context, address *domain.Address) error
+	Delete(ctx context.Context, id uint) error
+	SetDefault(ctx context.Context, customerID, id uint) error
+}
+
+type NotificationLogRepository interface {
+	Create(ctx context.Context, log *domain.NotificationLog) error
+	ListByRecipients(ctx context.Context, recipients []string) ([]domain.NotificationLog, error)
+}
+
+type PrivacyRepository interface {
+	CreateErasureRequest(ctx context.Context, request *domain.ErasureRequest) error
+	GetErasureRequest(ctx context.Context, id uint) (*domain.ErasureRequest, error)
+	ListErasureRequests(ctx context.Context, status domain.ErasureStatus) ([]domain.ErasureRequest, error)
+	ListCustomerErasureRequests(ctx context.Context, customerID uint) ([]domain.ErasureRequest, error)
+	UpdateErasureRequest(ctx context.Context, request *domain.ErasureRequest) error
+	// AnonymizeCustomer strips personal data from the customer and everything
+	// linked to them in one transaction, leaving order amounts untouched.
+	AnonymizeCustomer(ctx context.Context, customerID uint) (*domain.ErasureSummary, error)
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *domain.AuditEntry) error
+	List(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error)
 }
@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+// ProductSearch is a search engine over the catalog. Queries arrive trimmed
+// and with pagination already bounded.
+type ProductSearch interface {
+	// Search returns products matching the query, best match first, tolerating small typos.
+	Search(ctx context.Context, query domain.ProductSearchQuery) (*domain.ProductSearchPage, error)
+	// Suggest completes a partially typed query.
+	Suggest(ctx context.Context, prefix string, limit int) ([]domain.ProductSuggestion, error)
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"io"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
 )
@@ -9,10 +10,14 @@ import (
 type ProductService interface {
 	CreateProduct(ctx context.Context, product *domain.Product) error
 	GetProduct(ctx context.Context, id uint) (*domain.Product, error)
-	ListProducts(ctx context.Context) ([]domain.Product, error)
+	ListProducts(ctx context.Context, query domain.ProductQuery) (*domain.ProductPage, error)
 	UpdateProduct(ctx context.Context, product *domain.Product) error
 	DeleteProduct(ctx context.Context, id uint) error
-	GetAverageCategoryPrice(ctx context.Context, id uint) (float64, error)
+	// GetAverageCategoryPrice averages the prices in the category in the given
+	// currency, converting prices in other currencies at today's rates.
+	GetAverageCategoryPrice(ctx context.Context, id uint, byVariant bool, currency string) (domain.Money, error)
+	ImportProducts(ctx context.Context, r io.Reader, format domain.ImportFormat, dryRun bool) (*domain.ProductImportReport, error)
+	ExportProducts(ctx context.Context, w io.Writer, format domain.ImportFormat, filter domain.ProductExportFilter) error
 }
 
 type CategoryService interface {
@@ -21,19 +26,269 @@ type CategoryService interface {
 	ListCategories(ctx context.Context) ([]domain.Category, error)
 	UpdateCategory(ctx context.Context, category *domain.Category) error
 	DeleteCategory(ctx context.Context, id uint) error
+	// ListCategoryAttributes returns the attributes products in the category
+	// can carry, including those inherited from its ancestors.
+	ListCategoryAttributes(ctx context.Context, categoryID uint) ([]domain.CategoryAttribute, error)
+	// SetCategoryAttributes replaces the attributes defined on the category itself.
+	SetCategoryAttributes(ctx context.Context, categoryID uint, attributes []domain.CategoryAttribute) ([]domain.CategoryAttribute, error)
+}
+
+// TrashService lists, restores and purges soft deleted products and categories.
+type TrashService interface {
+	ListTrash(ctx context.Context) (*domain.Trash, error)
+	RestoreProduct(ctx context.Context, id uint) (*domain.Product, error)
+	RestoreCategory(ctx context.Context, id uint) (*domain.Category, error)
+	PurgeTrash(ctx context.Context) (*domain.TrashPurgeSummary, error)
 }
 
 type OrderService interface {
+	// CreateOrder charges the order in order.Currency, converting the item
+	// prices at today's rate when they are in another currency, and records
+	// the rate used. It then applies the automatic discounts the order matches
+	// and those named in order.DiscountCodes, recording each as an adjustment,
+	// and works out the tax on each discounted line from its tax class.
+	// Finally it adds what order.ShippingMethodID charges to deliver to the
+	// shipping address, which is required once any method is active.
 	CreateOrder(ctx context.Context, order *domain.Order) error
+	// QuoteShipping prices a draft order the way CreateOrder would and returns
+	// what each active method that can deliver it charges, cheapest first.
+	// The draft's ShippingAddress is used when it has a county or country,
+	// otherwise the customer's address as CreateOrder would pick it.
+	QuoteShipping(ctx context.Context, order *domain.Order) ([]domain.ShippingQuote, error)
 	ListOrders(ctx context.Context) ([]domain.Order, error)
+	// ListCustomerOrders returns the customer's orders, archived ones included.
+	ListCustomerOrders(ctx context.Context, customerID uint) ([]domain.Order, error)
 	GetOrder(ctx context.Context, id uint) (*domain.Order, error)
-	UpdateOrder(ctx context.Context, order *domain.Order) error
-	DeleteOrder(ctx context.Context, id uint) error
+	// AmendOrder changes the lines of an unpaid order that is still placed to
+	// request.Lines. Lines already on the order keep the unit price they were
+	// ordered at and new ones are priced as CreateOrder would. The discounts
+	// the order had are worked out again on the new lines, but none are added,
+	// and the tax and shipping are charged again. Stock is taken or put back
+	// for the difference and the change is kept in the order's Amendments.
+	AmendOrder(ctx context.Context, id uint, request domain.AmendRequest) (*domain.Order, error)
+	// CancelOrder cancels the order and puts back the stock of everything not
+	// refunded yet. A paid order is refunded in full, shipping included,
+	// through the payment provider. The customer is told either way.
+	CancelOrder(ctx context.Context, id uint, reason string) (*domain.Order, error)
+	// RefundOrder refunds lines of a paid order, or everything not refunded
+	// yet when request.Lines is empty, through the payment provider or as
+	// store credit. Each line gives back what was paid for its units after
+	// discounts and with tax. The customer is told of the refund.
+	RefundOrder(ctx context.Context, id uint, request domain.RefundRequest) (*domain.OrderRefund, error)
+	// ArchiveOrder takes the order off the order list. Orders aren't deleted,
+	// accounting needs them and their refunds.
+	ArchiveOrder(ctx context.Context, id uint) error
+	// GetOrderProduct returns a product that can be ordered, priced at the
+	// price in force now.
 	GetOrderProduct(ctx context.Context, productID uint) (*domain.Product, error)
+	// GetOrderVariant checks the variant chosen for a product. It returns nil
+	// when the product has no variants and none was chosen.
+	GetOrderVariant(ctx context.Context, productID uint, variantID *uint) (*domain.ProductVariant, error)
+}
+
+// DiscountService manages discount rules, which OrderService applies when
+// pricing orders.
+type DiscountService interface {
+	CreateDiscount(ctx context.Context, discount *domain.Discount) error
+	GetDiscount(ctx context.Context, id uint) (*domain.Discount, error)
+	ListDiscounts(ctx context.Context) ([]domain.Discount, error)
+	UpdateDiscount(ctx context.Context, discount *domain.Discount) error
+	// DeleteDiscount refuses discounts that orders have used, deactivate those instead.
+	DeleteDiscount(ctx context.Context, id uint) error
+}
+
+// ShippingService manages shipping methods, which OrderService charges for
+// and quotes.
+type ShippingService interface {
+	CreateShippingMethod(ctx context.Context, method *domain.ShippingMethod) error
+	GetShippingMethod(ctx context.Context, id uint) (*domain.ShippingMethod, error)
+	ListShippingMethods(ctx context.Context) ([]domain.ShippingMethod, error)
+	UpdateShippingMethod(ctx context.Context, method *domain.ShippingMethod) error
+	// DeleteShippingMethod leaves orders with the method's name and cost.
+	DeleteShippingMethod(ctx context.Context, id uint) error
+}
+
+// PaymentService collects payment for orders through the PaymentGateway.
+type PaymentService interface {
+	// StartPayment asks the customer to pay the order's total from phone,
+	// their own phone when it is empty. Only the customer who placed the order
+	// can pay for it, it is domain.ErrNotFound to anyone else. An order can't
+	// have two payments waiting on the customer at once.
+	StartPayment(ctx context.Context, customerID, orderID uint, phone string) (*domain.PaymentIntent, error)
+	// ListOrderPayments returns the customer's attempts at paying the order, oldest first.
+	ListOrderPayments(ctx context.Context, customerID, orderID uint) ([]domain.PaymentIntent, error)
+	// HandleCallback applies the result a provider posted, marking the order
+	// paid when the payment succeeded. A result delivered twice is applied once.
+	HandleCallback(ctx context.Context, token string, body []byte) error
+	// ReconcilePayments asks the provider about intents that have waited
+	// longer than a callback should take, settles those it has an answer for
+	// and times out the expired ones. It returns how many it settled.
+	ReconcilePayments(ctx context.Context) (int, error)
+	// RefundPayment pays refund.Amount of the order's successful payment back
+	// to the customer and records on the refund the payment, the provider's
+	// reference and the status, pending for providers that confirm later.
+	RefundPayment(ctx context.Context, refund *domain.OrderRefund) error
+}
+
+// TaxService manages tax classes and their rates, which OrderService uses to
+// tax order lines.
+type TaxService interface {
+	CreateTaxClass(ctx context.Context, class *domain.TaxClass) error
+	GetTaxClass(ctx context.Context, id uint) (*domain.TaxClass, error)
+	// ListTaxClasses returns the classes with the rate in force now.
+	ListTaxClasses(ctx context.Context) ([]domain.TaxClass, error)
+	UpdateTaxClass(ctx context.Context, class *domain.TaxClass) error
+	DeleteTaxClass(ctx context.Context, id uint) error
+	// SetTaxRate adds a rate for the class from rate.EffectiveFrom on, which
+	// defaults to now and can't be in the past.
+	SetTaxRate(ctx context.Context, rate *domain.TaxRate) error
+	TaxRateHistory(ctx context.Context, classID uint) ([]domain.TaxRate, error)
+	// AssignCategoryTaxClass and AssignProductTaxClass set the class of a
+	// category or product, nil to inherit it instead.
+	AssignCategoryTaxClass(ctx context.Context, categoryID uint, classID *uint) error
+	AssignProductTaxClass(ctx context.Context, productID uint, classID *uint) error
+}
+
+// CartService keeps a shopper's cart between requests and prices it afresh
+// on every call. A customer who also sends the token of an anonymous cart
+// has that cart merged into theirs first.
+type CartService interface {
+	GetCart(ctx context.Context, owner domain.CartOwner) (*domain.CartView, error)
+	// AddItem adds to the quantity when the cart already holds the product
+	// and variant, and creates the cart when there is none yet.
+	AddItem(ctx context.Context, owner domain.CartOwner, item domain.CartItem) (*domain.CartView, error)
+	// UpdateItem sets the line's quantity, a quantity of 0 removes it.
+	UpdateItem(ctx context.Context, owner domain.CartOwner, itemID uint, quantity int) (*domain.CartView, error)
+	RemoveItem(ctx context.Context, owner domain.CartOwner, itemID uint) (*domain.CartView, error)
+	ClearCart(ctx context.Context, owner domain.CartOwner) (*domain.CartView, error)
+	// Checkout places an order for the cart through the OrderService and
+	// empties the cart. Only customers can check out.
+	Checkout(ctx context.Context, owner domain.CartOwner, checkout domain.CartCheckout) (*domain.Order, error)
+	// QuoteShipping quotes shipping for the cart as it would be checked out.
+	// Anonymous shoppers can get quotes by giving an address.
+	QuoteShipping(ctx context.Context, owner domain.CartOwner, checkout domain.CartCheckout, address *domain.ShippingAddress) ([]domain.ShippingQuote, error)
+}
+
+// ProductVariantService manages the variants of a product. Variants of other
+// products are reported as not found.
+type ProductVariantService interface {
+	ListVariants(ctx context.Context, productID uint) (*domain.ProductVariants, error)
+	GetVariant(ctx context.Context, productID, id uint) (*domain.ProductVariant, error)
+	CreateVariant(ctx context.Context, productID uint, variant *domain.ProductVariant) error
+	UpdateVariant(ctx context.Context, productID uint, variant *domain.ProductVariant) error
+	DeleteVariant(ctx context.Context, productID, id uint) error
+}
+
+// ProductPriceService manages price history and scheduled price changes.
+type ProductPriceService interface {
+	// PriceHistory returns the product's price periods, oldest first,
+	// including scheduled ones.
+	PriceHistory(ctx context.Context, productID uint) ([]domain.ProductPrice, error)
+	SchedulePriceChange(ctx context.Context, productID uint, change domain.PriceChange) ([]domain.ProductPrice, error)
+	// CancelPriceChange removes a price change that has not started yet.
+	CancelPriceChange(ctx context.Context, productID, id uint) error
+	// ApplyDuePrices brings product prices in line with the periods in force now.
+	ApplyDuePrices(ctx context.Context) (int64, error)
+}
+
+// ExchangeRateService maintains the exchange rates against
+// domain.DefaultCurrency that prices are converted with.
+type ExchangeRateService interface {
+	// ListRates returns the rate in force now for each currency.
+	ListRates(ctx context.Context) ([]domain.ExchangeRate, error)
+	// RateHistory returns every rate set for the currency, oldest first.
+	RateHistory(ctx context.Context, currency string) ([]domain.ExchangeRate, error)
+	// SetRate adds a rate, in force from rate.EffectiveFrom, or now when it is not set.
+	SetRate(ctx context.Context, rate *domain.ExchangeRate) error
+	// CurrentRates returns the rates in force now, for converting prices.
+	CurrentRates(ctx context.Context) (domain.ExchangeRates, error)
+}
+
+// ProductImageService manages a product's images. Images of other products
+// are reported as not found.
+type ProductImageService interface {
+	ListImages(ctx context.Context, productID uint) ([]domain.ProductImage, error)
+	// UploadImage checks the upload is a JPEG, PNG or GIF within the size
+	// limit, stores it with a thumbnail and adds it after the product's other images.
+	UploadImage(ctx context.Context, productID uint, upload domain.ProductImageUpload) (*domain.ProductImage, error)
+	UpdateImage(ctx context.Context, productID, id uint, update domain.ProductImageUpdate) (*domain.ProductImage, error)
+	DeleteImage(ctx context.Context, productID, id uint) error
 }
 
 type CustomerService interface {
 	CreateCustomer(ctx context.Context, customer *domain.Customer) (*domain.Customer, error)
 	GetCustomer(ctx context.Context, id uint) (*domain.Customer, error)
 	UpsertCustomer(ctx context.Context, customer *domain.Customer) (*domain.Customer, error)
+	UpdateProfile(ctx context.Context, id uint, update domain.CustomerProfileUpdate) (*domain.Customer, error)
+	RequestPhoneVerification(ctx context.Context, id uint) error
+	VerifyPhone(ctx context.Context, id uint, code string) (*domain.Customer, error)
+	ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error)
+}
+
+type APIKeyService interface {
+	// CreateAPIKey returns the stored key together with the raw secret, which is never persisted.
+	CreateAPIKey(ctx context.Context, name string, scopes []string, createdBy uint) (*domain.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uint) error
+	Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error)
+}
+
+// AddressService manages a customer's address book. Every call is scoped to
+// the customer, addresses owned by someone else are reported as not found.
+type AddressService interface {
+	CreateAddress(ctx context.Context, customerID uint, address *domain.Address) error
+	GetAddress(ctx context.Context, customerID, id uint) (*domain.Address, error)
+	ListAddresses(ctx context.Context, customerID uint) ([]domain.Address, error)
+	UpdateAddress(ctx context.Context, customerID uint, address *domain.Address) error
+	DeleteAddress(ctx context.Context, customerID, id uint) error
+	SetDefaultAddress(ctx context.Context, customerID, id uint) error
+}
+
+// ReturnService runs returns. A customer opens a return for units of a paid
+// order, which staff approve or reject, receive when the goods come back and
+// resolve with a refund or store credit. Each step moves the return's status
+// on, is audited and is told to the customer. The customer's calls are scoped
+// to them, returns of someone else are reported as not found.
+type ReturnService interface {
+	// OpenReturn asks to return units of one of the customer's paid orders,
+	// each line with a reason. Units refunded or on another open return can't
+	// be returned.
+	OpenReturn(ctx context.Context, customerID uint, request domain.OpenReturnRequest) (*domain.OrderReturn, error)
+	// AddReturnPhoto adds a JPEG, PNG or GIF of the goods to a return that
+	// hasn't been received yet, up to domain.MaxReturnPhotos.
+	AddReturnPhoto(ctx context.Context, customerID, id uint, upload domain.ReturnPhotoUpload) (*domain.ReturnPhoto, error)
+	GetCustomerReturn(ctx context.Context, customerID, id uint) (*domain.OrderReturn, error)
+	ListCustomerReturns(ctx context.Context, customerID uint) ([]domain.OrderReturn, error)
+	GetStoreCredit(ctx context.Context, customerID uint) (*domain.StoreCreditAccount, error)
+	GetReturn(ctx context.Context, id uint) (*domain.OrderReturn, error)
+	ListReturns(ctx context.Context, status domain.ReturnStatus) ([]domain.OrderReturn, error)
+	// ApproveReturn accepts a requested return, note telling the customer
+	// how to send the goods back.
+	ApproveReturn(ctx context.Context, id uint, note string) (*domain.OrderReturn, error)
+	// RejectReturn turns down a return that hasn't been received, note
+	// telling the customer why.
+	RejectReturn(ctx context.Context, id uint, note string) (*domain.OrderReturn, error)
+	// ReceiveReturn records that the goods of an approved return came back,
+	// putting them back into stock when restock is set.
+	ReceiveReturn(ctx context.Context, id uint, restock bool) (*domain.OrderReturn, error)
+	// ResolveReturn refunds the units of a received return, through the
+	// payment provider or as store credit, with OrderService.RefundOrder.
+	ResolveReturn(ctx context.Context, id uint, resolution domain.ReturnResolution) (*domain.OrderReturn, error)
+}
+
+type PrivacyService interface {
+	ExportCustomerData(ctx context.Context, customerID uint) (*domain.CustomerDataExport, error)
+	RequestErasure(ctx context.Context, customerID uint, reason string) (*domain.ErasureRequest, error)
+	ListErasureRequests(ctx context.Context, status domain.ErasureStatus) ([]domain.ErasureRequest, error)
+	ApproveErasure(ctx context.Context, id uint, reviewer string) (*domain.ErasureRequest, error)
+	RejectErasure(ctx context.Context, id uint, reviewer, note string) (*domain.ErasureRequest, error)
+}
+
+type AuditService interface {
+	ListAuditEntries(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error)
+}
+
+type ProductSearchService interface {
+	SearchProducts(ctx context.Context, query domain.ProductSearchQuery) (*domain.ProductSearchPage, error)
+	SuggestProducts(ctx context.Context, prefix string, limit int) ([]domain.ProductSuggestion, error)
 }
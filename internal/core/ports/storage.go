@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore keeps uploaded files, like product images, under keys such as
+// "products/12/3f9c.jpg" and knows the URL clients fetch them from.
+type BlobStore interface {
+	// Put stores size bytes read from body under key, replacing any existing file.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the file stored under key. It returns domain.ErrNotFound when there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key. Deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL is the public address of the file stored under key.
+	URL(key string) string
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+const defaultCountry = "KE"
+
+var ErrNoShippingAddress = domain.NewValidationError("a shipping address is required, add one to your address book first")
+
+type addressService struct {
+	addressRepo ports.AddressRepository
+}
+
+func NewAddressService(addressRepo ports.AddressRepository) ports.AddressService {
+	return &addressService{addressRepo: addressRepo}
+}
+
+func (s *addressService) CreateAddress(ctx context.Context, customerID uint, address *domain.Address) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressService.CreateAddress")
+	defer span.End()
+
+	address.ID = 0
+	address.CustomerID = customerID
+	if err := normalizeAddress(address); err != nil {
+		return err
+	}
+
+	// The first address a customer saves becomes their default
+	if !address.IsDefault {
+		if _, err := s.addressRepo.GetDefault(ctx, customerID); errors.Is(err, domain.ErrNotFound) {
+			address.IsDefault = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return s.addressRepo.Create(ctx, address)
+}
+
+func (s *addressService) GetAddress(ctx context.Context, customerID, id uint) (*domain.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressService.GetAddress")
+	defer span.End()
+
+	return getOwnedAddress(ctx, s.addressRepo, customerID, id)
+}
+
+func (s *addressService) ListAddresses(ctx context.Context, customerID uint) ([]domain.Address, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressService.ListAddresses")
+	defer span.End()
+
+	return s.addressRepo.ListByCustomer(ctx, customerID)
+}
+
+func (s *addressService) UpdateAddress(ctx context.Context, customerID uint, address *domain.Address) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressService.UpdateAddress")
+	defer span.End()
+
+	existing, err := getOwnedAddress(ctx, s.addressRepo, customerID, address.ID)
+	if err != nil {
+		return err
+	}
+
+	address.CustomerID = customerID
+	address.CreatedAt = existing.CreatedAt
+	// Unsetting the default is done by choosing another default, not by clearing the flag
+	if existing.IsDefault {
+		address.IsDefault = true
+	}
+	if err := normalizeAddress(address); err != nil {
+		return err
+	}
+
+	return s.addressRepo.Update(ctx, address)
+}
+
+func (s *addressService) DeleteAddress(ctx context.Context, customerID, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressService.DeleteAddress")
+	defer span.End()
+
+	address, err := getOwnedAddress(ctx, s.addressRepo, customerID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.addressRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// Promote the next address so the customer keeps a default to ship to
+	if address.IsDefault {
+		remaining, err := s.addressRepo.ListByCustomer(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		if len(remaining) > 0 {
+			return s.addressRepo.SetDefault(ctx, customerID, remaining[0].ID)
+		}
+	}
+
+	return nil
+}
+
+func (s *addressService) SetDefaultAddress(ctx context.Context, customerID, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "AddressService.SetDefaultAddress")
+	defer span.End()
+
+	return s.addressRepo.SetDefault(ctx, customerID, id)
+}
+
+// getOwnedAddress hides other customers' addresses behind ErrNotFound.
+func getOwnedAddress(ctx context.Context, addressRepo ports.AddressRepository, customerID, id uint) (*domain.Address, error) {
+	address, err := addressRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if address.CustomerID != customerID {
+		return nil, domain.ErrNotFound
+	}
+	return address, nil
+}
+
+// resolveShippingAddress picks the requested address, or the customer's
+// default when none was given, for an order being placed.
+func resolveShippingAddress(ctx context.Context, addressRepo ports.AddressRepository, customerID uint, addressID *uint) (*domain.Address, error) {
+	if addressID != nil {
+		return getOwnedAddress(ctx, addressRepo, customerID, *addressID)
+	}
+
+	address, err := addressRepo.GetDefault(ctx, customerID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrNoShippingAddress
+	}
+	return address, err
+}
+
+func normalizeAddress(address *domain.Address) error {
+	address.Label = strings.TrimSpace(address.Label)
+	address.RecipientName = strings.TrimSpace(address.RecipientName)
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.Line2 = strings.TrimSpace(address.Line2)
+	address.City = strings.TrimSpace(address.City)
+	address.County = strings.TrimSpace(address.County)
+	address.PostalCode = strings.TrimSpace(address.PostalCode)
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	if address.Country == "" {
+		address.Country = defaultCountry
+	}
+
+	if address.Phone != "" {
+		address.Phone = normalizePhone(address.Phone)
+		if !phonePattern.MatchString(address.Phone) {
+			return ErrInvalidPhone
+		}
+	}
+
+	switch {
+	case address.RecipientName == "":
+		return domain.NewValidationError("recipient name is required")
+	case address.Line1 == "":
+		return domain.NewValidationError("address line 1 is required")
+	case address.City == "":
+		return domain.NewValidationError("city is required")
+	case len(address.Country) != 2:
+		return domain.NewValidationError("country must be a two letter ISO code")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAddressRepository struct {
+	mock.Mock
+}
+
+func (m *MockAddressRepository) Create(ctx context.Context, address *domain.Address) error {
+	args := m.Called(ctx, address)
+	return args.Error(0)
+}
+
+func (m *MockAddressRepository) Get(ctx context.Context, id uint) (*domain.Address, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Address), args.Error(1)
+}
+
+func (m *MockAddressRepository) GetDefault(ctx context.Context, customerID uint) (*domain.Address, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Address), args.Error(1)
+}
+
+func (m *MockAddressRepository) ListByCustomer(ctx context.Context, customerID uint) ([]domain.Address, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]domain.Address), args.Error(1)
+}
+
+func (m *MockAddressRepository) Update(ctx context.Context, address *domain.Address) error {
+	args := m.Called(ctx, address)
+	return args.Error(0)
+}
+
+func (m *MockAddressRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAddressRepository) SetDefault(ctx context.Context, customerID, id uint) error {
+	args := m.Called(ctx, customerID, id)
+	return args.Error(0)
+}
+
+func TestAddressService_CreateAddress_FirstBecomesDefault(t *testing.T) {
+	mockRepo := new(MockAddressRepository)
+	service := NewAddressService(mockRepo)
+
+	mockRepo.On("GetDefault", mock.Anything, uint(1)).Return(nil, domain.ErrNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Address")).Return(nil)
+
+	address := &domain.Address{RecipientName: "Jane", Line1: "Moi Avenue", City: "Nairobi"}
+	err := service.CreateAddress(context.Background(), 1, address)
+	assert.NoError(t, err)
+	assert.True(t, address.IsDefault)
+	assert.Equal(t, uint(1), address.CustomerID)
+	assert.Equal(t, "KE", address.Country)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAddressService_GetAddress_OtherCustomer(t *testing.T) {
+	mockRepo := new(MockAddressRepository)
+	service := NewAddressService(mockRepo)
+
+	mockRepo.On("Get", mock.Anything, uint(5)).Return(&domain.Address{ID: 5, CustomerID: 2}, nil)
+
+	_, err := service.GetAddress(context.Background(), 1, 5)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestAddressService_DeleteAddress_PromotesNextDefault(t *testing.T) {
+	mockRepo := new(MockAddressRepository)
+	service := NewAddressService(mockRepo)
+
+	mockRepo.On("Get", mock.Anything, uint(5)).Return(&domain.Address{ID: 5, CustomerID: 1, IsDefault: true}, nil)
+	mockRepo.On("Delete", mock.Anything, uint(5)).Return(nil)
+	mockRepo.On("ListByCustomer", mock.Anything, uint(1)).Return([]domain.Address{{ID: 6, CustomerID: 1}}, nil)
+	mockRepo.On("SetDefault", mock.Anything, uint(1), uint(6)).Return(nil)
+
+	err := service.DeleteAddress(context.Background(), 1, 5)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
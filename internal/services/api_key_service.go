@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+// Raw keys look like sk_<prefix>_<secret>. The prefix is stored in clear so the
+// key can be looked up, the whole key is only ever stored as a SHA-256 hash.
+const apiKeyTag = "sk"
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrRevokedAPIKey = errors.New("api key has been revoked")
+)
+
+type apiKeyService struct {
+	apiKeyRepo ports.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo ports.APIKeyRepository) ports.APIKeyService {
+	return &apiKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, name string, scopes []string, createdBy uint) (*domain.APIKey, string, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyService.CreateAPIKey")
+	defer span.End()
+
+	if strings.TrimSpace(name) == "" {
+		return nil, "", domain.NewValidationError("api key name is required")
+	}
+	if len(scopes) == 0 {
+		return nil, "", domain.NewValidationError("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !domain.Scopes(domain.KnownScopes).Has(scope) {
+			return nil, "", domain.NewValidationError("unknown scope %q", scope)
+		}
+	}
+
+	prefix, err := randomHex(4)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, "", err
+	}
+	rawKey := fmt.Sprintf("%s_%s_%s", apiKeyTag, prefix, secret)
+
+	key := &domain.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   sha256Hex(rawKey),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, rawKey, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyService.ListAPIKeys")
+	defer span.End()
+
+	return s.apiKeyRepo.List(ctx)
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyService.RevokeAPIKey")
+	defer span.End()
+
+	return s.apiKeyRepo.Revoke(ctx, id)
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "APIKeyService.Authenticate")
+	defer span.End()
+
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByPrefix(ctx, parts[1])
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(sha256Hex(rawKey))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.Revoked() {
+		return nil, ErrRevokedAPIKey
+	}
+
+	// Failing to record usage should not lock out an otherwise valid key
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID); err != nil {
+		span.RecordError(err)
+	}
+
+	return key, nil
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
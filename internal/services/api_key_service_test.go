@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Get(ctx context.Context, id uint) (*domain.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(mockRepo)
+
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Return(nil)
+
+	key, rawKey, err := service.CreateAPIKey(context.Background(), "warehouse", []string{domain.ScopeProductsWrite}, 1)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rawKey, "sk_"+key.Prefix+"_"))
+	assert.NotContains(t, key.KeyHash, rawKey)
+	assert.Equal(t, sha256Hex(rawKey), key.KeyHash)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_CreateAPIKey_UnknownScope(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(mockRepo)
+
+	_, _, err := service.CreateAPIKey(context.Background(), "warehouse", []string{"products:delete"}, 1)
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	rawKey := "sk_abcd1234_secret"
+	stored := &domain.APIKey{ID: 7, Prefix: "abcd1234", KeyHash: sha256Hex(rawKey), Scopes: domain.Scopes{domain.ScopeOrdersRead}}
+
+	t.Run("valid key", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo)
+		mockRepo.On("GetByPrefix", mock.Anything, "abcd1234").Return(stored, nil)
+		mockRepo.On("TouchLastUsed", mock.Anything, uint(7)).Return(nil)
+
+		key, err := service.Authenticate(context.Background(), rawKey)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), key.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo)
+		mockRepo.On("GetByPrefix", mock.Anything, "abcd1234").Return(stored, nil)
+
+		_, err := service.Authenticate(context.Background(), "sk_abcd1234_guess")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		revokedAt := time.Now()
+		revoked := *stored
+		revoked.RevokedAt = &revokedAt
+
+		mockRepo := new(MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo)
+		mockRepo.On("GetByPrefix", mock.Anything, "abcd1234").Return(&revoked, nil)
+
+		_, err := service.Authenticate(context.Background(), rawKey)
+		assert.ErrorIs(t, err, ErrRevokedAPIKey)
+	})
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// Fields that change on every write and would only add noise to a diff
+// or, for preloaded relations, duplicate the foreign key next to them
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"category":   true,
+	"customer":   true,
+}
+
+type auditService struct {
+	auditRepo ports.AuditRepository
+}
+
+func NewAuditService(auditRepo ports.AuditRepository) ports.AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+func (s *auditService) ListAuditEntries(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "AuditService.ListAuditEntries")
+	defer span.End()
+
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return nil, domain.NewValidationError("to must not be before from")
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultAuditPageSize
+	}
+	if filter.PageSize > maxAuditPageSize {
+		filter.PageSize = maxAuditPageSize
+	}
+
+	return s.auditRepo.List(ctx, filter)
+}
+
+// recordAudit writes an audit entry for a change that has already been saved.
+// before is nil for creates and after is nil for deletes. The actor, request ID
+// and trace ID are taken from ctx; calls without an actor are attributed to the
+// system. A failed audit write is logged rather than failing the change.
+func recordAudit(ctx context.Context, auditRepo ports.AuditRepository, action, entityType string, entityID uint, before, after interface{}) {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		log.Printf("audit: failed to diff %s %d: %v", entityType, entityID, err)
+		return
+	}
+	if action == domain.AuditActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		log.Printf("audit: failed to encode %s %d changes: %v", entityType, entityID, err)
+		return
+	}
+
+	entry := &domain.AuditEntry{
+		ActorType:  string(auth.ActorSystem),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    encoded,
+		RequestID:  requestid.FromContext(ctx),
+	}
+	if actor, ok := auth.ActorFromContext(ctx); ok {
+		entry.ActorType = string(actor.Type)
+		entry.ActorID = actor.ID
+		entry.ActorLabel = actor.String()
+		if actor.Type == auth.ActorAPIKey {
+			entry.ActorLabel = actor.Name
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		entry.TraceID = sc.TraceID().String()
+	}
+
+	if err := auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("audit: failed to record %s of %s %d: %v", action, entityType, entityID, err)
+	}
+}
+
+// auditDiff compares the JSON form of two versions of an entity field by field
+// and returns only the fields that differ.
+func auditDiff(before, after interface{}) (map[string]domain.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]domain.AuditChange)
+	for field, value := range beforeFields {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		if next, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, next) {
+			changes[field] = domain.AuditChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = domain.AuditChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func auditFields(entity interface{}) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	if v := reflect.ValueOf(entity); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Create(ctx context.Context, entry *domain.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AuditPage), args.Error(1)
+}
+
+// newMockAuditRepository accepts any audit entry, for tests that are not about auditing
+func newMockAuditRepository() *MockAuditRepository {
+	m := new(MockAuditRepository)
+	m.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return m
+}
+
+func TestProductService_UpdateProduct_RecordsPriceChange(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), newAttributeCategoryRepository(nil), mockAuditRepo, newMockExchangeRateRepository())
+
+	before := &domain.Product{ID: 7, Name: "Kettle", Price: kes(2500), CategoryID: 1, Attributes: domain.Attributes{}}
+	after := &domain.Product{ID: 7, Name: "Kettle", Price: kes(2800), CategoryID: 1}
+	mockProductRepo.On("Get", mock.Anything, uint(7)).Return(before, nil)
+	mockProductRepo.On("Update", mock.Anything, after).Return(nil)
+
+	var entry *domain.AuditEntry
+	mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).
+		Run(func(args mock.Arguments) { entry = args.Get(1).(*domain.AuditEntry) }).
+		Return(nil)
+
+	ctx := auth.WithActor(context.Background(), &auth.Actor{Type: auth.ActorAPIKey, ID: 3, Name: "warehouse"})
+	ctx = requestid.WithRequestID(ctx, "req-1")
+	err := service.UpdateProduct(ctx, after)
+	assert.NoError(t, err)
+
+	assert.Equal(t, domain.AuditActionUpdate, entry.Action)
+	assert.Equal(t, domain.AuditEntityProduct, entry.EntityType)
+	assert.Equal(t, uint(7), entry.EntityID)
+	assert.Equal(t, "api_key", entry.ActorType)
+	assert.Equal(t, uint(3), entry.ActorID)
+	assert.Equal(t, "warehouse", entry.ActorLabel)
+	assert.Equal(t, "req-1", entry.RequestID)
+
+	var changes map[string]domain.AuditChange
+	assert.NoError(t, json.Unmarshal(entry.Changes, &changes))
+	assert.Equal(t, map[string]domain.AuditChange{"price": {
+		Before: map[string]interface{}{"amount": 2500.0, "currency": "KES"},
+		After:  map[string]interface{}{"amount": 2800.0, "currency": "KES"},
+	}}, changes)
+}
+
+func TestProductService_UpdateProduct_NoChangeSkipsAudit(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), newAttributeCategoryRepository(nil), mockAuditRepo, newMockExchangeRateRepository())
+
+	product := &domain.Product{ID: 7, Name: "Kettle", Price: kes(2500), CategoryID: 1}
+	mockProductRepo.On("Get", mock.Anything, uint(7)).Return(&domain.Product{ID: 7, Name: "Kettle", Price: kes(2500), CategoryID: 1, Attributes: domain.Attributes{}}, nil)
+	mockProductRepo.On("Update", mock.Anything, product).Return(nil)
+
+	err := service.UpdateProduct(context.Background(), product)
+	assert.NoError(t, err)
+	mockAuditRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRecordAudit_DefaultsToSystemActor(t *testing.T) {
+	mockAuditRepo := new(MockAuditRepository)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.ActorType == "system" && entry.ActorID == 0 && entry.Action == domain.AuditActionDelete
+	})).Return(nil)
+
+	recordAudit(context.Background(), mockAuditRepo, domain.AuditActionDelete, domain.AuditEntityOrder, 4, &domain.Order{ID: 4}, nil)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestAuditService_ListAuditEntries_ClampsPageSize(t *testing.T) {
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewAuditService(mockAuditRepo)
+
+	expected := domain.AuditFilter{EntityType: domain.AuditEntityProduct, Page: 1, PageSize: maxAuditPageSize}
+	mockAuditRepo.On("List", mock.Anything, expected).Return(&domain.AuditPage{Page: 1, PageSize: maxAuditPageSize}, nil)
+
+	_, err := service.ListAuditEntries(context.Background(), domain.AuditFilter{EntityType: domain.AuditEntityProduct, PageSize: 1000})
+	assert.NoError(t, err)
+	mockAuditRepo.AssertExpectations(t)
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+var ErrCheckoutLoginRequired = domain.NewValidationError("sign in as a customer to check out")
+
+type cartService struct {
+	cartRepo     ports.CartRepository
+	orderService ports.OrderService
+	rateRepo     ports.ExchangeRateRepository
+	now          func() time.Time
+}
+
+func NewCartService(cartRepo ports.CartRepository, orderService ports.OrderService, rateRepo ports.ExchangeRateRepository) ports.CartService {
+	return &cartService{
+		cartRepo:     cartRepo,
+		orderService: orderService,
+		rateRepo:     rateRepo,
+		now:          time.Now,
+	}
+}
+
+func (s *cartService) GetCart(ctx context.Context, owner domain.CartOwner) (*domain.CartView, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartService.GetCart")
+	defer span.End()
+
+	cart, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return s.view(ctx, cart)
+}
+
+func (s *cartService) AddItem(ctx context.Context, owner domain.CartOwner, item domain.CartItem) (*domain.CartView, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartService.AddItem")
+	defer span.End()
+
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	if err := validateCartQuantity(item.Quantity); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	line := &item
+	if cart != nil {
+		for i := range cart.Items {
+			if cart.Items[i].Matches(item.ProductID, item.VariantID) {
+				line = &cart.Items[i]
+				line.Quantity += item.Quantity
+				break
+			}
+		}
+	}
+	if err := validateCartQuantity(line.Quantity); err != nil {
+		return nil, err
+	}
+	if err := s.checkLine(ctx, *line); err != nil {
+		return nil, err
+	}
+
+	// The cart is only created once there is something to put in it
+	if cart == nil {
+		if cart, err = s.createCart(ctx, owner); err != nil {
+			return nil, err
+		}
+	}
+	if line == &item {
+		item.ID = 0
+		item.CartID = cart.ID
+		cart.Items = append(cart.Items, item)
+		line = &cart.Items[len(cart.Items)-1]
+	}
+	if err := s.cartRepo.SaveItem(ctx, line); err != nil {
+		return nil, err
+	}
+	return s.view(ctx, cart)
+}
+
+func (s *cartService) UpdateItem(ctx context.Context, owner domain.CartOwner, itemID uint, quantity int) (*domain.CartView, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartService.UpdateItem")
+	defer span.End()
+
+	if quantity == 0 {
+		return s.RemoveItem(ctx, owner, itemID)
+	}
+	if err := validateCartQuantity(quantity); err != nil {
+		return nil, err
+	}
+
+	cart, line, err := s.findItem(ctx, owner, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lowering the quantity is how a shopper fixes a line short of stock,
+	// so lines are only checked when the quantity goes up
+	if quantity > line.Quantity {
+		raised := *line
+		raised.Quantity = quantity
+		if err := s.checkLine(ctx, raised); err != nil {
+			return nil, err
+		}
+	}
+
+	line.Quantity = quantity
+	if err := s.cartRepo.SaveItem(ctx, line); err != nil {
+		return nil, err
+	}
+	return s.view(ctx, cart)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, owner domain.CartOwner, itemID uint) (*domain.CartView, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartService.RemoveItem")
+	defer span.End()
+
+	cart, _, err := s.findItem(ctx, owner, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cartRepo.DeleteItem(ctx, cart.ID, itemID); err != nil {
+		return nil, err
+	}
+
+	for i := range cart.Items {
+		if cart.Items[i].ID == itemID {
+			cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+			break
+		}
+	}
+	return s.view(ctx, cart)
+}
+
+func (s *cartService) ClearCart(ctx context.Context, owner domain.CartOwner) (*domain.CartView, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartService.ClearCart")
+	defer span.End()
+
+	cart, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart != nil && len(cart.Items) > 0 {
+		if err := s.cartRepo.ClearItems(ctx, cart.ID); err != nil {
+			return nil, err
+		}
+		cart.Items = nil
+	}
+	return s.view(ctx, cart)
+}
+
+func (s *cartService) Checkout(ctx context.Context, owner domain.CartOwner, checkout domain.CartCheckout) (*domain.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartService.Checkout")
+	defer span.End()
+
+	if owner.CustomerID == 0 {
+		return nil, ErrCheckoutLoginRequired
+	}
+
+	cart, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil || len(cart.Items) == 0 {
+		return nil, domain.ErrCartEmpty
+	}
+
+	order, err := s.draftOrder(ctx, owner, cart, checkout)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orderService.CreateOrder(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// The order is placed by now, failing to empty the cart must not report it as failed
+	if err := s.cartRepo.ClearItems(ctx, cart.ID); err != nil {
+		log.Printf("cart: failed to clear cart %d after order %d: %v", cart.ID, order.ID, err)
+	}
+	return order, nil
+}
+
+func (s *cartService) QuoteShipping(ctx context.Context, owner domain.CartOwner, checkout domain.CartCheckout, address *domain.ShippingAddress) ([]domain.ShippingQuote, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CartService.QuoteShipping")
+	defer span.End()
+
+	cart, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil || len(cart.Items) == 0 {
+		return nil, domain.ErrCartEmpty
+	}
+
+	order, err := s.draftOrder(ctx, owner, cart, checkout)
+	if err != nil {
+		return nil, err
+	}
+	if address != nil {
+		order.ShippingAddress = *address
+	}
+	return s.orderService.QuoteShipping(ctx, order)
+}
+
+// draftOrder is the order checkout would place for the cart. The cart is
+// priced again here, not taken from an earlier view.
+func (s *cartService) draftOrder(ctx context.Context, owner domain.CartOwner, cart *domain.Cart, checkout domain.CartCheckout) (*domain.Order, error) {
+	items := make([]domain.OrderItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		line, err := s.priceLine(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		if !line.Available {
+			return nil, lineProblem(line)
+		}
+		items = append(items, domain.OrderItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			Price:     *line.Price,
+		})
+	}
+
+	return &domain.Order{
+		CustomerID:        owner.CustomerID,
+		Items:             items,
+		CreatedAt:         s.now(),
+		ShippingAddressID: checkout.AddressID,
+		Currency:          checkout.Currency,
+		DiscountCodes:     checkout.DiscountCodes,
+		ShippingMethodID:  checkout.ShippingMethodID,
+	}, nil
+}
+
+// loadCart returns the owner's cart, or nil when they have none. A
+// customer's anonymous cart is merged into theirs on the way.
+func (s *cartService) loadCart(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error) {
+	var cart *domain.Cart
+	var err error
+	if owner.CustomerID != 0 {
+		cart, err = s.cartRepo.GetByCustomer(ctx, owner.CustomerID)
+	} else {
+		cart, err = s.cartRepo.GetByToken(ctx, owner.Token)
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		cart = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if owner.CustomerID != 0 && owner.Token != "" {
+		return s.mergeAnonymous(ctx, owner, cart)
+	}
+	return cart, nil
+}
+
+// mergeAnonymous moves the lines of the cart filled before signing in into
+// the customer's cart, adding up quantities of lines for the same product
+// and variant, and deletes the anonymous cart.
+func (s *cartService) mergeAnonymous(ctx context.Context, owner domain.CartOwner, cart *domain.Cart) (*domain.Cart, error) {
+	anonymous, err := s.cartRepo.GetByToken(ctx, owner.Token)
+	if errors.Is(err, domain.ErrNotFound) {
+		return cart, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if anonymous.CustomerID != nil {
+		return cart, nil
+	}
+
+	if cart == nil {
+		if cart, err = s.createCart(ctx, domain.CartOwner{CustomerID: owner.CustomerID}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range anonymous.Items {
+		merged := false
+		for i := range cart.Items {
+			if cart.Items[i].Matches(item.ProductID, item.VariantID) {
+				cart.Items[i].Quantity = min(cart.Items[i].Quantity+item.Quantity, domain.MaxCartQuantity)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			item.ID = 0
+			item.CartID = cart.ID
+			cart.Items = append(cart.Items, item)
+		}
+	}
+
+	if err := s.cartRepo.Merge(ctx, cart, anonymous.ID); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (s *cartService) createCart(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error) {
+	cart := &domain.Cart{}
+	if owner.CustomerID != 0 {
+		customerID := owner.CustomerID
+		cart.CustomerID = &customerID
+	} else {
+		token, err := randomHex(24)
+		if err != nil {
+			return nil, err
+		}
+		cart.Token = token
+	}
+
+	if err := s.cartRepo.Create(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// findItem returns the owner's cart and the line with the given ID in it.
+func (s *cartService) findItem(ctx context.Context, owner domain.CartOwner, itemID uint) (*domain.Cart, *domain.CartItem, error) {
+	cart, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cart == nil {
+		return nil, nil, domain.ErrNotFound
+	}
+	for i := range cart.Items {
+		if cart.Items[i].ID == itemID {
+			return cart, &cart.Items[i], nil
+		}
+	}
+	return nil, nil, domain.ErrNotFound
+}
+
+// checkLine rejects a line that could not be ordered as it is.
+func (s *cartService) checkLine(ctx context.Context, item domain.CartItem) error {
+	line, err := s.priceLine(ctx, item)
+	if err != nil {
+		return err
+	}
+	if !line.Available {
+		return lineProblem(line)
+	}
+	return nil
+}
+
+// priceLine prices the item at the price in force now, the same way an
+// order would be, and flags it when it can't be ordered.
+func (s *cartService) priceLine(ctx context.Context, item domain.CartItem) (domain.CartLine, error) {
+	line := domain.CartLine{
+		ID:        item.ID,
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Quantity:  item.Quantity,
+	}
+
+	product, err := s.orderService.GetOrderProduct(ctx, item.ProductID)
+	if errors.Is(err, domain.ErrNotFound) {
+		line.Problem = "the product is no longer available"
+		return line, nil
+	}
+	if err != nil {
+		return line, err
+	}
+	line.Name = product.Name
+
+	unitPrice := product.Price
+	variant, err := s.orderService.GetOrderVariant(ctx, product.ID, item.VariantID)
+	switch {
+	case errors.Is(err, domain.ErrVariantRequired):
+		line.Problem = "the product comes in several variants, choose one"
+		return line, nil
+	case domain.IsValidationError(err):
+		line.Problem = "the variant is no longer available"
+		return line, nil
+	case err != nil:
+		return line, err
+	}
+	if variant != nil {
+		unitPrice = variant.EffectivePrice(product.Price)
+		if variant.Stock == 0 {
+			line.Problem = "out of stock"
+		} else if variant.Stock < item.Quantity {
+			line.Problem = fmt.Sprintf("only %d left in stock", variant.Stock)
+		}
+	}
+
+	price := unitPrice.Mul(int64(item.Quantity))
+	line.UnitPrice = &unitPrice
+	line.Price = &price
+	line.Available = line.Problem == ""
+	return line, nil
+}
+
+// view prices every line of the cart. The subtotal adds up the lines that
+// can be ordered, converted to the first line's currency.
+func (s *cartService) view(ctx context.Context, cart *domain.Cart) (*domain.CartView, error) {
+	view := &domain.CartView{Items: []domain.CartLine{}}
+	if cart == nil {
+		return view, nil
+	}
+	view.Token = cart.Token
+	view.CheckoutReady = len(cart.Items) > 0
+
+	var rates domain.ExchangeRates
+	for _, item := range cart.Items {
+		line, err := s.priceLine(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		view.Quantity += item.Quantity
+
+		if line.Available {
+			if view.Subtotal == nil {
+				subtotal := domain.NewMoney(0, line.Price.Currency)
+				view.Subtotal = &subtotal
+			}
+			price := *line.Price
+			if price.Currency != view.Subtotal.Currency {
+				if rates == nil {
+					if rates, err = currentRates(ctx, s.rateRepo, s.now()); err != nil {
+						return nil, err
+					}
+				}
+				if price, err = rates.Convert(price, view.Subtotal.Currency); err != nil {
+					line.Available = false
+					line.Problem = "its price can't be converted to " + view.Subtotal.Currency
+				}
+			}
+			if line.Available {
+				if *view.Subtotal, err = view.Subtotal.Add(price); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if !line.Available {
+			view.CheckoutReady = false
+		}
+		view.Items = append(view.Items, line)
+	}
+	return view, nil
+}
+
+func validateCartQuantity(quantity int) error {
+	if quantity < 1 || quantity > domain.MaxCartQuantity {
+		return domain.NewValidationError("quantity must be between 1 and %d", domain.MaxCartQuantity)
+	}
+	return nil
+}
+
+func lineProblem(line domain.CartLine) error {
+	if line.Name == "" {
+		return domain.NewValidationError("product %d: %s", line.ProductID, line.Problem)
+	}
+	return domain.NewValidationError("%s: %s", line.Name, line.Problem)
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCartRepository struct {
+	mock.Mock
+}
+
+func (m *MockCartRepository) GetByCustomer(ctx context.Context, customerID uint) (*domain.Cart, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *MockCartRepository) GetByToken(ctx context.Context, token string) (*domain.Cart, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *MockCartRepository) Create(ctx context.Context, cart *domain.Cart) error {
+	args := m.Called(ctx, cart)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) SaveItem(ctx context.Context, item *domain.CartItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) DeleteItem(ctx context.Context, cartID, itemID uint) error {
+	args := m.Called(ctx, cartID, itemID)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) ClearItems(ctx context.Context, cartID uint) error {
+	args := m.Called(ctx, cartID)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) Merge(ctx context.Context, into *domain.Cart, fromID uint) error {
+	args := m.Called(ctx, into, fromID)
+	return args.Error(0)
+}
+
+// newCartTestOrderRepository serves a small catalog: product 1 has no
+// variants, product 2 only sells as variant 5 with 3 in stock or variant 6
+// which is sold out, and product 3 has been deleted.
+func newCartTestOrderRepository() *MockOrderRepository {
+	m := new(MockOrderRepository)
+	m.On("GetOrderProduct", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, Name: "Kikoy", Price: kes(150000)}, nil).Maybe()
+	m.On("GetOrderProduct", mock.Anything, uint(2)).Return(&domain.Product{ID: 2, Name: "Sandals", Price: kes(120000)}, nil).Maybe()
+	m.On("GetOrderProduct", mock.Anything, uint(3)).Return(nil, domain.ErrNotFound).Maybe()
+	m.On("HasVariants", mock.Anything, uint(1)).Return(false, nil).Maybe()
+	m.On("HasVariants", mock.Anything, uint(2)).Return(true, nil).Maybe()
+	large := kes(135000)
+	m.On("GetOrderVariant", mock.Anything, uint(5)).Return(&domain.ProductVariant{ID: 5, ProductID: 2, Price: &large, Stock: 3}, nil).Maybe()
+	m.On("GetOrderVariant", mock.Anything, uint(6)).Return(&domain.ProductVariant{ID: 6, ProductID: 2, Stock: 0}, nil).Maybe()
+	return m
+}
+
+func newTestCartService(cartRepo *MockCartRepository, orderRepo *MockOrderRepository, addressRepo *MockAddressRepository) *cartService {
+	notificationRepo := new(MockNotificationRepository)
+	notificationRepo.On("SendEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return newTestCartServiceNotifying(cartRepo, orderRepo, addressRepo, notificationRepo)
+}
+
+func newTestCartServiceNotifying(cartRepo *MockCartRepository, orderRepo *MockOrderRepository, addressRepo *MockAddressRepository, notificationRepo *MockNotificationRepository) *cartService {
+	priceRepo := new(MockProductPriceRepository)
+	priceRepo.On("PriceAt", mock.Anything, mock.Anything, mock.Anything).Return(nil, domain.ErrNotFound).Maybe()
+
+	orderService := NewOrderService(orderRepo, new(MockProductRepository), priceRepo, notificationRepo, addressRepo, newMockAuditRepository(), newMockExchangeRateRepository(), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true)
+	return NewCartService(cartRepo, orderService, newMockExchangeRateRepository()).(*cartService)
+}
+
+func uintPtr(value uint) *uint {
+	return &value
+}
+
+func TestCartService_AddItem_StartsAnonymousCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	service := newTestCartService(mockCartRepo, newCartTestOrderRepository(), new(MockAddressRepository))
+
+	mockCartRepo.On("GetByToken", mock.Anything, "").Return(nil, domain.ErrNotFound)
+	mockCartRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Cart).ID = 7
+	}).Return(nil)
+	mockCartRepo.On("SaveItem", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.CartItem).ID = 11
+	}).Return(nil)
+
+	cart, err := service.AddItem(context.Background(), domain.CartOwner{}, domain.CartItem{ProductID: 1, Quantity: 2})
+	assert.NoError(t, err)
+	assert.Len(t, cart.Token, 48)
+	assert.Len(t, cart.Items, 1)
+	assert.Equal(t, uint(11), cart.Items[0].ID)
+	assert.Equal(t, kes(300000), *cart.Items[0].Price)
+	assert.Equal(t, kes(300000), *cart.Subtotal)
+	assert.True(t, cart.CheckoutReady)
+
+	saved := mockCartRepo.Calls[len(mockCartRepo.Calls)-1].Arguments.Get(1).(*domain.CartItem)
+	assert.Equal(t, uint(7), saved.CartID)
+}
+
+func TestCartService_AddItem_AddsToExistingLine(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	service := newTestCartService(mockCartRepo, newCartTestOrderRepository(), new(MockAddressRepository))
+
+	cart := &domain.Cart{ID: 7, CustomerID: uintPtr(1), Items: []domain.CartItem{
+		{ID: 11, CartID: 7, ProductID: 2, VariantID: uintPtr(5), Quantity: 2},
+	}}
+	mockCartRepo.On("GetByCustomer", mock.Anything, uint(1)).Return(cart, nil)
+	mockCartRepo.On("SaveItem", mock.Anything, mock.MatchedBy(func(item *domain.CartItem) bool {
+		return item.ID == 11 && item.Quantity == 3
+	})).Return(nil)
+
+	owner := domain.CartOwner{CustomerID: 1}
+	view, err := service.AddItem(context.Background(), owner, domain.CartItem{ProductID: 2, VariantID: uintPtr(5)})
+	assert.NoError(t, err)
+	assert.Len(t, view.Items, 1)
+	assert.Equal(t, kes(405000), *view.Subtotal)
+	mockCartRepo.AssertExpectations(t)
+
+	// Only 3 are in stock
+	_, err = service.AddItem(context.Background(), owner, domain.CartItem{ProductID: 2, VariantID: uintPtr(5)})
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	// Products with variants can't go in the cart without one
+	_, err = service.AddItem(context.Background(), owner, domain.CartItem{ProductID: 2})
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	_, err = service.AddItem(context.Background(), owner, domain.CartItem{ProductID: 1, Quantity: domain.MaxCartQuantity + 1})
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+	mockCartRepo.AssertNumberOfCalls(t, "SaveItem", 1)
+}
+
+func TestCartService_GetCart_FlagsUnavailableLines(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	service := newTestCartService(mockCartRepo, newCartTestOrderRepository(), new(MockAddressRepository))
+
+	mockCartRepo.On("GetByToken", mock.Anything, "abc").Return(&domain.Cart{ID: 7, Token: "abc", Items: []domain.CartItem{
+		{ID: 1, ProductID: 1, Quantity: 1},
+		{ID: 2, ProductID: 3, Quantity: 1},
+		{ID: 3, ProductID: 2, VariantID: uintPtr(6), Quantity: 1},
+		{ID: 4, ProductID: 2, VariantID: uintPtr(5), Quantity: 4},
+		{ID: 5, ProductID: 2, Quantity: 1},
+	}}, nil)
+
+	cart, err := service.GetCart(context.Background(), domain.CartOwner{Token: "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", cart.Token)
+	assert.Equal(t, 8, cart.Quantity)
+	assert.False(t, cart.CheckoutReady)
+
+	problems := make([]string, 0, len(cart.Items))
+	for _, line := range cart.Items {
+		assert.Equal(t, line.Problem == "", line.Available)
+		problems = append(problems, line.Problem)
+	}
+	assert.Equal(t, []string{
+		"",
+		"the product is no longer available",
+		"out of stock",
+		"only 3 left in stock",
+		"the product comes in several variants, choose one",
+	}, problems)
+
+	// Unavailable lines are left out of the subtotal, but still priced when possible
+	assert.Equal(t, kes(150000), *cart.Subtotal)
+	assert.Equal(t, kes(540000), *cart.Items[3].Price)
+}
+
+func TestCartService_GetCart_MergesAnonymousCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	service := newTestCartService(mockCartRepo, newCartTestOrderRepository(), new(MockAddressRepository))
+
+	mockCartRepo.On("GetByCustomer", mock.Anything, uint(1)).Return(&domain.Cart{ID: 7, CustomerID: uintPtr(1), Items: []domain.CartItem{
+		{ID: 11, CartID: 7, ProductID: 1, Quantity: 1},
+	}}, nil)
+	mockCartRepo.On("GetByToken", mock.Anything, "abc").Return(&domain.Cart{ID: 8, Token: "abc", Items: []domain.CartItem{
+		{ID: 21, CartID: 8, ProductID: 1, Quantity: 2},
+		{ID: 22, CartID: 8, ProductID: 2, VariantID: uintPtr(5), Quantity: 1},
+	}}, nil)
+	mockCartRepo.On("Merge", mock.Anything, mock.Anything, uint(8)).Return(nil)
+
+	cart, err := service.GetCart(context.Background(), domain.CartOwner{CustomerID: 1, Token: "abc"})
+	assert.NoError(t, err)
+	assert.Empty(t, cart.Token)
+	assert.Equal(t, 4, cart.Quantity)
+
+	merged := mockCartRepo.Calls[len(mockCartRepo.Calls)-1].Arguments.Get(1).(*domain.Cart)
+	assert.Equal(t, uint(7), merged.ID)
+	assert.Equal(t, []domain.CartItem{
+		{ID: 11, CartID: 7, ProductID: 1, Quantity: 3},
+		{CartID: 7, ProductID: 2, VariantID: uintPtr(5), Quantity: 1},
+	}, merged.Items)
+}
+
+func TestCartService_UpdateItem(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	service := newTestCartService(mockCartRepo, newCartTestOrderRepository(), new(MockAddressRepository))
+
+	cart := func() *domain.Cart {
+		return &domain.Cart{ID: 7, CustomerID: uintPtr(1), Items: []domain.CartItem{
+			{ID: 11, CartID: 7, ProductID: 2, VariantID: uintPtr(5), Quantity: 5},
+		}}
+	}
+	for i := 0; i < 3; i++ {
+		mockCartRepo.On("GetByCustomer", mock.Anything, uint(1)).Return(cart(), nil).Once()
+	}
+	mockCartRepo.On("SaveItem", mock.Anything, mock.Anything).Return(nil)
+	mockCartRepo.On("DeleteItem", mock.Anything, uint(7), uint(11)).Return(nil)
+	owner := domain.CartOwner{CustomerID: 1}
+
+	// Lowering the quantity fixes a line short of stock
+	view, err := service.UpdateItem(context.Background(), owner, 11, 3)
+	assert.NoError(t, err)
+	assert.True(t, view.Items[0].Available)
+
+	_, err = service.UpdateItem(context.Background(), owner, 12, 1)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	view, err = service.UpdateItem(context.Background(), owner, 11, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, view.Items)
+	assert.Nil(t, view.Subtotal)
+	assert.False(t, view.CheckoutReady)
+	mockCartRepo.AssertCalled(t, "DeleteItem", mock.Anything, uint(7), uint(11))
+}
+
+func TestCartService_Checkout(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := newCartTestOrderRepository()
+	mockAddressRepo := new(MockAddressRepository)
+	service := newTestCartService(mockCartRepo, mockOrderRepo, mockAddressRepo)
+
+	mockCartRepo.On("GetByCustomer", mock.Anything, uint(1)).Return(&domain.Cart{ID: 7, CustomerID: uintPtr(1), Items: []domain.CartItem{
+		{ID: 11, CartID: 7, ProductID: 1, Quantity: 2},
+		{ID: 12, CartID: 7, ProductID: 2, VariantID: uintPtr(5), Quantity: 1},
+	}}, nil)
+	mockCartRepo.On("ClearItems", mock.Anything, uint(7)).Return(nil)
+	mockAddressRepo.On("GetDefault", mock.Anything, uint(1)).Return(&domain.Address{ID: 3, CustomerID: 1, Line1: "Moi Avenue"}, nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.Anything).Return(&domain.Order{ID: 9}, nil)
+
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	order, err := service.Checkout(ctx, domain.CartOwner{CustomerID: 1}, domain.CartCheckout{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), order.CustomerID)
+	assert.Equal(t, "Moi Avenue", order.ShippingAddress.Line1)
+	assert.Equal(t, []domain.OrderItem{
+		{ProductID: 1, Quantity: 2, Price: kes(300000), Discount: kes(0), Tax: kes(0), TaxRate: "0"},
+		{ProductID: 2, VariantID: uintPtr(5), Quantity: 1, Price: kes(135000), Discount: kes(0), Tax: kes(0), TaxRate: "0"},
+	}, order.Items)
+	assert.Equal(t, kes(435000), order.TotalPrice)
+	mockCartRepo.AssertCalled(t, "ClearItems", mock.Anything, uint(7))
+}
+
+func TestCartService_Checkout_MailerFails(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := newCartTestOrderRepository()
+	mockAddressRepo := new(MockAddressRepository)
+	notificationRepo := new(MockNotificationRepository)
+	notificationRepo.On("SendEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("smtp: connection refused"))
+	service := newTestCartServiceNotifying(mockCartRepo, mockOrderRepo, mockAddressRepo, notificationRepo)
+
+	mockCartRepo.On("GetByCustomer", mock.Anything, uint(1)).Return(&domain.Cart{ID: 7, CustomerID: uintPtr(1), Items: []domain.CartItem{
+		{ID: 11, CartID: 7, ProductID: 1, Quantity: 2},
+	}}, nil)
+	mockCartRepo.On("ClearItems", mock.Anything, uint(7)).Return(nil)
+	mockAddressRepo.On("GetDefault", mock.Anything, uint(1)).Return(&domain.Address{ID: 3, CustomerID: 1, Line1: "Moi Avenue"}, nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.Anything).Return(&domain.Order{ID: 9}, nil)
+
+	// The order is placed and the cart emptied even though no confirmation went out
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	order, err := service.Checkout(ctx, domain.CartOwner{CustomerID: 1}, domain.CartCheckout{})
+	assert.NoError(t, err)
+	assert.NotNil(t, order)
+	mockOrderRepo.AssertNumberOfCalls(t, "Create", 1)
+	mockCartRepo.AssertCalled(t, "ClearItems", mock.Anything, uint(7))
+	notificationRepo.AssertNumberOfCalls(t, "SendEmail", 1)
+}
+
+func TestCartService_Checkout_Rejected(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := newCartTestOrderRepository()
+	service := newTestCartService(mockCartRepo, mockOrderRepo, new(MockAddressRepository))
+
+	mockCartRepo.On("GetByCustomer", mock.Anything, uint(1)).Return(&domain.Cart{ID: 7, Items: []domain.CartItem{
+		{ID: 11, CartID: 7, ProductID: 1, Quantity: 1},
+		{ID: 12, CartID: 7, ProductID: 3, Quantity: 1},
+	}}, nil)
+	mockCartRepo.On("GetByCustomer", mock.Anything, uint(2)).Return(nil, domain.ErrNotFound)
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+
+	_, err := service.Checkout(ctx, domain.CartOwner{Token: "abc"}, domain.CartCheckout{})
+	assert.ErrorIs(t, err, ErrCheckoutLoginRequired)
+
+	_, err = service.Checkout(ctx, domain.CartOwner{CustomerID: 2}, domain.CartCheckout{})
+	assert.ErrorIs(t, err, domain.ErrCartEmpty)
+
+	_, err = service.Checkout(ctx, domain.CartOwner{CustomerID: 1}, domain.CartCheckout{})
+	assert.EqualError(t, err, "product 3: the product is no longer available")
+
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "ClearItems", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+var ErrCategoryInUse = domain.NewValidationError("category still has products or subcategories, move or delete them first")
+
+type categoryService struct {
+	categoryRepo ports.CategoryRepository
+	productRepo  ports.ProductRepository
+	auditRepo    ports.AuditRepository
+}
+
+func NewCategoryService(categoryRepo ports.CategoryRepository, productRepo ports.ProductRepository, auditRepo ports.AuditRepository) ports.CategoryService {
+	return &categoryService{
+		categoryRepo: categoryRepo,
+		productRepo:  productRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+func (s *categoryService) CreateCategory(ctx context.Context, category *domain.Category) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryService.CreateCategory")
+	defer span.End()
+
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityCategory, category.ID, nil, category)
+	return nil
+}
+
+func (s *categoryService) GetCategory(ctx context.Context, id uint) (*domain.Category, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryService.GetCategory")
+	defer span.End()
+
+	return s.categoryRepo.Get(ctx, id)
+}
+
+func (s *categoryService) ListCategories(ctx context.Context) ([]domain.Category, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryService.ListCategories")
+	defer span.End()
+
+	return s.categoryRepo.List(ctx)
+}
+
+func (s *categoryService) UpdateCategory(ctx context.Context, category *domain.Category) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryService.UpdateCategory")
+	defer span.End()
+
+	before, err := s.categoryRepo.Get(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityCategory, category.ID, before, category)
+	return nil
+}
+
+// DeleteCategory moves an empty category to the trash. Categories that still
+// hold products or subcategories are refused, so nothing visible is left
+// pointing at a deleted category.
+func (s *categoryService) DeleteCategory(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryService.DeleteCategory")
+	defer span.End()
+
+	before, err := s.categoryRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tree, err := loadCategoryTree(ctx, s.categoryRepo)
+	if err != nil {
+		return err
+	}
+	if len(tree.subtree(id)) > 1 {
+		return ErrCategoryInUse
+	}
+	products, err := s.productRepo.List(ctx, domain.ProductListQuery{
+		Sort:        domain.DefaultProductSort,
+		CategoryIDs: []uint{id},
+		Limit:       1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(products) > 0 {
+		return ErrCategoryInUse
+	}
+
+	if err := s.categoryRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionDelete, domain.AuditEntityCategory, id, before, nil)
+	return nil
+}
+
+func (s *categoryService) ListCategoryAttributes(ctx context.Context, categoryID uint) ([]domain.CategoryAttribute, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryService.ListCategoryAttributes")
+	defer span.End()
+
+	if _, err := s.categoryRepo.Get(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	schema, err := loadAttributeSchema(ctx, s.categoryRepo, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return schema.list(), nil
+}
+
+// SetCategoryAttributes replaces the category's attribute definitions.
+// Products already saved are not checked again, the new rules apply the
+// next time each product is created or updated.
+func (s *categoryService) SetCategoryAttributes(ctx context.Context, categoryID uint, attributes []domain.CategoryAttribute) ([]domain.CategoryAttribute, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CategoryService.SetCategoryAttributes")
+	defer span.End()
+
+	if _, err := s.categoryRepo.Get(ctx, categoryID); err != nil {
+		return nil, err
+	}
+	if err := validateAttributeDefinitions(attributes); err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryRepo.ReplaceAttributes(ctx, categoryID, attributes); err != nil {
+		return nil, err
+	}
+	return s.ListCategoryAttributes(ctx, categoryID)
+}
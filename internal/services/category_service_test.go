@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCategoryService_DeleteCategory_InUse(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewCategoryService(mockCategoryRepo, mockProductRepo, newMockAuditRepository())
+
+	mockCategoryRepo.On("List", mock.Anything).Return(exportTestCategories(), nil)
+
+	// Electronics has subcategories
+	mockCategoryRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Category{ID: 1}, nil)
+	err := service.DeleteCategory(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrCategoryInUse)
+
+	// A leaf that still holds products
+	mockCategoryRepo.On("Get", mock.Anything, uint(3)).Return(&domain.Category{ID: 3}, nil)
+	mockProductRepo.On("List", mock.Anything, mock.MatchedBy(func(q domain.ProductListQuery) bool {
+		return assert.ObjectsAreEqual([]uint{3}, q.CategoryIDs)
+	})).Return([]domain.Product{{ID: 9, CategoryID: 3}}, nil)
+	err = service.DeleteCategory(context.Background(), 3)
+	assert.ErrorIs(t, err, ErrCategoryInUse)
+
+	mockCategoryRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+)
+
+// categoryTree indexes the categories by ID and parent, so paths and subtrees
+// can be worked out without a query per level.
+type categoryTree struct {
+	byID     map[uint]domain.Category
+	children map[uint][]uint
+	paths    map[uint]string
+}
+
+func loadCategoryTree(ctx context.Context, repo ports.CategoryRepository) (*categoryTree, error) {
+	categories, err := repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newCategoryTree(categories), nil
+}
+
+func newCategoryTree(categories []domain.Category) *categoryTree {
+	tree := &categoryTree{
+		byID:     make(map[uint]domain.Category, len(categories)),
+		children: make(map[uint][]uint),
+		paths:    make(map[uint]string, len(categories)),
+	}
+	for _, category := range categories {
+		tree.byID[category.ID] = category
+		if category.ParentCategoryID != nil {
+			tree.children[*category.ParentCategoryID] = append(tree.children[*category.ParentCategoryID], category.ID)
+		}
+	}
+	return tree
+}
+
+func (t *categoryTree) has(id uint) bool {
+	_, ok := t.byID[id]
+	return ok
+}
+
+// path returns the category's names from the root down, e.g. "Electronics > Phones".
+func (t *categoryTree) path(id uint) string {
+	if path, ok := t.paths[id]; ok {
+		return path
+	}
+
+	var names []string
+	seen := make(map[uint]bool)
+	for current, ok := t.byID[id]; ok && !seen[current.ID]; {
+		seen[current.ID] = true
+		names = append([]string{current.Name}, names...)
+		if current.ParentCategoryID == nil {
+			break
+		}
+		current, ok = t.byID[*current.ParentCategoryID]
+	}
+
+	path := domain.JoinCategoryPath(names)
+	t.paths[id] = path
+	return path
+}
+
+// ancestors returns the category followed by its parent, its grandparent and
+// so on up to the root.
+func (t *categoryTree) ancestors(id uint) []uint {
+	var ids []uint
+	seen := make(map[uint]bool)
+	for current, ok := t.byID[id]; ok && !seen[current.ID]; {
+		seen[current.ID] = true
+		ids = append(ids, current.ID)
+		if current.ParentCategoryID == nil {
+			break
+		}
+		current, ok = t.byID[*current.ParentCategoryID]
+	}
+	return ids
+}
+
+// subtree returns the category and all of its descendants.
+func (t *categoryTree) subtree(id uint) []uint {
+	ids := []uint{id}
+	seen := map[uint]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range t.children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
@@ -2,25 +2,66 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
 	"go.opentelemetry.io/otel"
 )
 
+const (
+	smsSenderID                 = "Sil"
+	phoneCodeTTL                = 10 * time.Minute
+	maxPhoneCodeAttempts        = 5
+	defaultCustomerPageSize     = 20
+	maxCustomerPageSize         = 100
+	phoneVerificationCodeDigits = 6
+)
+
+var (
+	ErrInvalidPhone             = domain.NewValidationError("phone number must be in international format, e.g. +254712345678")
+	ErrNoPhone                  = domain.NewValidationError("customer has no phone number to verify")
+	ErrPhoneAlreadyVerified     = domain.NewValidationError("phone number is already verified")
+	ErrInvalidVerificationCode  = domain.NewValidationError("invalid or expired verification code")
+	ErrTooManyVerificationTries = domain.NewValidationError("too many attempts, request a new verification code")
+
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+)
+
 type CustomerService struct {
-	customerRepo ports.CustomerRepository
+	customerRepo     ports.CustomerRepository
+	verificationRepo ports.PhoneVerificationRepository
+	notificationRepo ports.NotificationRepository
+	auditRepo        ports.AuditRepository
 }
 
-func NewCustomerService(customerRepo ports.CustomerRepository) *CustomerService {
-	return &CustomerService{customerRepo: customerRepo}
+func NewCustomerService(customerRepo ports.CustomerRepository, verificationRepo ports.PhoneVerificationRepository, notificationRepo ports.NotificationRepository, auditRepo ports.AuditRepository) *CustomerService {
+	return &CustomerService{
+		customerRepo:     customerRepo,
+		verificationRepo: verificationRepo,
+		notificationRepo: notificationRepo,
+		auditRepo:        auditRepo,
+	}
 }
 
 func (s *CustomerService) CreateCustomer(ctx context.Context, customer *domain.Customer) (*domain.Customer, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "CustomerService.CreateNewCustomer")
 	defer span.End()
 
-	return s.customerRepo.CreateCustomer(ctx, customer)
+	created, err := s.customerRepo.CreateCustomer(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityCustomer, created.ID, nil, created)
+	return created, nil
 }
 
 func (s *CustomerService) GetCustomer(ctx context.Context, id uint) (*domain.Customer, error) {
@@ -34,5 +75,199 @@ func (r *CustomerService) UpsertCustomer(ctx context.Context, customer *domain.C
 	ctx, span := otel.Tracer("").Start(ctx, "CustomerService.UpsertCustomer")
 	defer span.End()
 
-	return r.customerRepo.UpsertCustomer(ctx, customer)
+	// Logins upsert the customer every time, only actual changes end up in the audit log
+	before, err := r.customerRepo.GetCustomerByEmail(ctx, customer.Email)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	saved, err := r.customerRepo.UpsertCustomer(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+
+	if before == nil {
+		recordAudit(ctx, r.auditRepo, domain.AuditActionCreate, domain.AuditEntityCustomer, saved.ID, nil, saved)
+	} else {
+		recordAudit(ctx, r.auditRepo, domain.AuditActionUpdate, domain.AuditEntityCustomer, saved.ID, before, saved)
+	}
+	return saved, nil
+}
+
+// UpdateProfile applies a customer's own changes. Changing the phone number
+// resets its verification and sends a new code to the new number.
+func (s *CustomerService) UpdateProfile(ctx context.Context, id uint, update domain.CustomerProfileUpdate) (*domain.Customer, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CustomerService.UpdateProfile")
+	defer span.End()
+
+	customer, err := s.customerRepo.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *customer
+	phoneChanged := false
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return nil, domain.NewValidationError("name cannot be empty")
+		}
+		customer.Name = name
+	}
+	if update.Phone != nil {
+		phone := normalizePhone(*update.Phone)
+		if phone != "" && !phonePattern.MatchString(phone) {
+			return nil, ErrInvalidPhone
+		}
+		if phone != customer.Phone {
+			customer.Phone = phone
+			customer.PhoneVerified = false
+			phoneChanged = phone != ""
+		}
+	}
+	if update.Preferences != nil {
+		customer.Preferences = *update.Preferences
+	}
+	// SMS can only go to a number the customer has proven they own
+	if !customer.PhoneVerified {
+		customer.Preferences.SmsNotifications = false
+	}
+
+	if err := s.customerRepo.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityCustomer, customer.ID, &before, customer)
+
+	if phoneChanged {
+		if err := s.sendVerificationCode(ctx, customer); err != nil {
+			return nil, err
+		}
+	}
+
+	return customer, nil
+}
+
+func (s *CustomerService) RequestPhoneVerification(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CustomerService.RequestPhoneVerification")
+	defer span.End()
+
+	customer, err := s.customerRepo.GetCustomer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if customer.Phone == "" {
+		return ErrNoPhone
+	}
+	if customer.PhoneVerified {
+		return ErrPhoneAlreadyVerified
+	}
+
+	return s.sendVerificationCode(ctx, customer)
+}
+
+func (s *CustomerService) VerifyPhone(ctx context.Context, id uint, code string) (*domain.Customer, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CustomerService.VerifyPhone")
+	defer span.End()
+
+	customer, err := s.customerRepo.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if customer.PhoneVerified {
+		return nil, ErrPhoneAlreadyVerified
+	}
+
+	verification, err := s.verificationRepo.GetLatest(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidVerificationCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A code is only good for the number it was sent to
+	if verification.VerifiedAt != nil || verification.Phone != customer.Phone || time.Now().After(verification.ExpiresAt) {
+		return nil, ErrInvalidVerificationCode
+	}
+	if verification.Attempts >= maxPhoneCodeAttempts {
+		return nil, ErrTooManyVerificationTries
+	}
+
+	if subtle.ConstantTimeCompare([]byte(verification.CodeHash), []byte(sha256Hex(strings.TrimSpace(code)))) != 1 {
+		verification.Attempts++
+		if err := s.verificationRepo.Update(ctx, verification); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+
+	now := time.Now()
+	verification.VerifiedAt = &now
+	if err := s.verificationRepo.Update(ctx, verification); err != nil {
+		return nil, err
+	}
+
+	before := *customer
+	customer.PhoneVerified = true
+	if err := s.customerRepo.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityCustomer, customer.ID, &before, customer)
+
+	return customer, nil
+}
+
+func (s *CustomerService) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CustomerService.ListCustomers")
+	defer span.End()
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultCustomerPageSize
+	}
+	if filter.PageSize > maxCustomerPageSize {
+		filter.PageSize = maxCustomerPageSize
+	}
+	filter.Search = strings.TrimSpace(filter.Search)
+
+	return s.customerRepo.ListCustomers(ctx, filter)
+}
+
+func (s *CustomerService) sendVerificationCode(ctx context.Context, customer *domain.Customer) error {
+	code, err := generateNumericCode(phoneVerificationCodeDigits)
+	if err != nil {
+		return err
+	}
+
+	verification := &domain.PhoneVerification{
+		CustomerID: customer.ID,
+		Phone:      customer.Phone,
+		CodeHash:   sha256Hex(code),
+		ExpiresAt:  time.Now().Add(phoneCodeTTL),
+	}
+	if err := s.verificationRepo.Create(ctx, verification); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(phoneCodeTTL.Minutes()))
+	return s.notificationRepo.SendSms(ctx, []string{customer.Phone}, smsSenderID, message)
+}
+
+func normalizePhone(phone string) string {
+	replacer := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+	return replacer.Replace(strings.TrimSpace(phone))
+}
+
+func generateNumericCode(digits int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < digits; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate verification code: %w", err)
+		}
+		b.WriteByte(byte('0' + n.Int64()))
+	}
+	return b.String(), nil
 }
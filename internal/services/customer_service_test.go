@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCustomerRepository struct {
+	mock.Mock
+}
+
+func (m *MockCustomerRepository) UpsertCustomer(ctx context.Context, customer *domain.Customer) (*domain.Customer, error) {
+	args := m.Called(ctx, customer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+
+func (m *MockCustomerRepository) CreateCustomer(ctx context.Context, customer *domain.Customer) (*domain.Customer, error) {
+	args := m.Called(ctx, customer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+
+func (m *MockCustomerRepository) GetCustomer(ctx context.Context, id uint) (*domain.Customer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+
+func (m *MockCustomerRepository) GetCustomerByEmail(ctx context.Context, email string) (*domain.Customer, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+
+func (m *MockCustomerRepository) UpdateCustomer(ctx context.Context, customer *domain.Customer) error {
+	args := m.Called(ctx, customer)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepository) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CustomerPage), args.Error(1)
+}
+
+type MockPhoneVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockPhoneVerificationRepository) Create(ctx context.Context, verification *domain.PhoneVerification) error {
+	args := m.Called(ctx, verification)
+	return args.Error(0)
+}
+
+func (m *MockPhoneVerificationRepository) GetLatest(ctx context.Context, customerID uint) (*domain.PhoneVerification, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PhoneVerification), args.Error(1)
+}
+
+func (m *MockPhoneVerificationRepository) ListPhones(ctx context.Context, customerID uint) ([]string, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockPhoneVerificationRepository) Update(ctx context.Context, verification *domain.PhoneVerification) error {
+	args := m.Called(ctx, verification)
+	return args.Error(0)
+}
+
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) SendSms(ctx context.Context, phoneNumber []string, senderID, message string) error {
+	args := m.Called(ctx, phoneNumber, senderID, message)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) SendEmail(ctx context.Context, email, subject, to string) error {
+	args := m.Called(ctx, email, subject, to)
+	return args.Error(0)
+}
+
+func TestCustomerService_UpdateProfile_NewPhoneSendsCode(t *testing.T) {
+	mockCustomerRepo := new(MockCustomerRepository)
+	mockVerificationRepo := new(MockPhoneVerificationRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+	service := NewCustomerService(mockCustomerRepo, mockVerificationRepo, mockNotificationRepo, newMockAuditRepository())
+
+	customer := &domain.Customer{ID: 1, Name: "Jane", Phone: "+254700000001", PhoneVerified: true}
+	mockCustomerRepo.On("GetCustomer", mock.Anything, uint(1)).Return(customer, nil)
+	mockCustomerRepo.On("UpdateCustomer", mock.Anything, customer).Return(nil)
+	mockVerificationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.PhoneVerification")).Return(nil)
+	mockNotificationRepo.On("SendSms", mock.Anything, []string{"+254712345678"}, smsSenderID, mock.Anything).Return(nil)
+
+	phone := "+254 712 345 678"
+	updated, err := service.UpdateProfile(context.Background(), 1, domain.CustomerProfileUpdate{Phone: &phone})
+	assert.NoError(t, err)
+	assert.Equal(t, "+254712345678", updated.Phone)
+	assert.False(t, updated.PhoneVerified)
+	mockCustomerRepo.AssertExpectations(t)
+	mockVerificationRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+func TestCustomerService_UpdateProfile_InvalidPhone(t *testing.T) {
+	mockCustomerRepo := new(MockCustomerRepository)
+	service := NewCustomerService(mockCustomerRepo, new(MockPhoneVerificationRepository), new(MockNotificationRepository), newMockAuditRepository())
+
+	mockCustomerRepo.On("GetCustomer", mock.Anything, uint(1)).Return(&domain.Customer{ID: 1}, nil)
+
+	phone := "0712"
+	_, err := service.UpdateProfile(context.Background(), 1, domain.CustomerProfileUpdate{Phone: &phone})
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+	assert.True(t, domain.IsValidationError(err))
+	mockCustomerRepo.AssertNotCalled(t, "UpdateCustomer", mock.Anything, mock.Anything)
+}
+
+func TestCustomerService_VerifyPhone(t *testing.T) {
+	newVerification := func() *domain.PhoneVerification {
+		return &domain.PhoneVerification{
+			CustomerID: 1,
+			Phone:      "+254712345678",
+			CodeHash:   sha256Hex("123456"),
+			ExpiresAt:  time.Now().Add(time.Minute),
+		}
+	}
+
+	t.Run("correct code", func(t *testing.T) {
+		mockCustomerRepo := new(MockCustomerRepository)
+		mockVerificationRepo := new(MockPhoneVerificationRepository)
+		service := NewCustomerService(mockCustomerRepo, mockVerificationRepo, new(MockNotificationRepository), newMockAuditRepository())
+
+		customer := &domain.Customer{ID: 1, Phone: "+254712345678"}
+		mockCustomerRepo.On("GetCustomer", mock.Anything, uint(1)).Return(customer, nil)
+		mockCustomerRepo.On("UpdateCustomer", mock.Anything, customer).Return(nil)
+		mockVerificationRepo.On("GetLatest", mock.Anything, uint(1)).Return(newVerification(), nil)
+		mockVerificationRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+		verified, err := service.VerifyPhone(context.Background(), 1, "123456")
+		assert.NoError(t, err)
+		assert.True(t, verified.PhoneVerified)
+	})
+
+	t.Run("wrong code counts an attempt", func(t *testing.T) {
+		mockCustomerRepo := new(MockCustomerRepository)
+		mockVerificationRepo := new(MockPhoneVerificationRepository)
+		service := NewCustomerService(mockCustomerRepo, mockVerificationRepo, new(MockNotificationRepository), newMockAuditRepository())
+
+		verification := newVerification()
+		mockCustomerRepo.On("GetCustomer", mock.Anything, uint(1)).Return(&domain.Customer{ID: 1, Phone: "+254712345678"}, nil)
+		mockVerificationRepo.On("GetLatest", mock.Anything, uint(1)).Return(verification, nil)
+		mockVerificationRepo.On("Update", mock.Anything, verification).Return(nil)
+
+		_, err := service.VerifyPhone(context.Background(), 1, "000000")
+		assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+		assert.Equal(t, 1, verification.Attempts)
+		mockCustomerRepo.AssertNotCalled(t, "UpdateCustomer", mock.Anything, mock.Anything)
+	})
+
+	t.Run("code sent to a previous number", func(t *testing.T) {
+		mockCustomerRepo := new(MockCustomerRepository)
+		mockVerificationRepo := new(MockPhoneVerificationRepository)
+		service := NewCustomerService(mockCustomerRepo, mockVerificationRepo, new(MockNotificationRepository), newMockAuditRepository())
+
+		mockCustomerRepo.On("GetCustomer", mock.Anything, uint(1)).Return(&domain.Customer{ID: 1, Phone: "+254799999999"}, nil)
+		mockVerificationRepo.On("GetLatest", mock.Anything, uint(1)).Return(newVerification(), nil)
+
+		_, err := service.VerifyPhone(context.Background(), 1, "123456")
+		assert.ErrorIs(t, err, ErrInvalidVerificationCode)
+	})
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type discountService struct {
+	discountRepo ports.DiscountRepository
+	auditRepo    ports.AuditRepository
+}
+
+func NewDiscountService(discountRepo ports.DiscountRepository, auditRepo ports.AuditRepository) ports.DiscountService {
+	return &discountService{
+		discountRepo: discountRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+func (s *discountService) CreateDiscount(ctx context.Context, discount *domain.Discount) error {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountService.CreateDiscount")
+	defer span.End()
+
+	discount.ID = 0
+	discount.TimesUsed = 0
+	if err := s.validate(ctx, discount); err != nil {
+		return err
+	}
+
+	if err := s.discountRepo.Create(ctx, discount); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityDiscount, discount.ID, nil, discount)
+	return nil
+}
+
+func (s *discountService) GetDiscount(ctx context.Context, id uint) (*domain.Discount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountService.GetDiscount")
+	defer span.End()
+
+	return s.discountRepo.Get(ctx, id)
+}
+
+func (s *discountService) ListDiscounts(ctx context.Context) ([]domain.Discount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountService.ListDiscounts")
+	defer span.End()
+
+	discounts, err := s.discountRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if discounts == nil {
+		discounts = []domain.Discount{}
+	}
+	return discounts, nil
+}
+
+func (s *discountService) UpdateDiscount(ctx context.Context, discount *domain.Discount) error {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountService.UpdateDiscount")
+	defer span.End()
+
+	before, err := s.discountRepo.Get(ctx, discount.ID)
+	if err != nil {
+		return err
+	}
+	discount.TimesUsed = before.TimesUsed
+	discount.CreatedAt = before.CreatedAt
+	if err := s.validate(ctx, discount); err != nil {
+		return err
+	}
+
+	if err := s.discountRepo.Update(ctx, discount); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityDiscount, discount.ID, before, discount)
+	return nil
+}
+
+func (s *discountService) DeleteDiscount(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "DiscountService.DeleteDiscount")
+	defer span.End()
+
+	before, err := s.discountRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Orders point at the discounts they used
+	if before.TimesUsed > 0 {
+		return domain.NewValidationError("discount %d has been used on orders, deactivate it instead", id)
+	}
+
+	if err := s.discountRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionDelete, domain.AuditEntityDiscount, id, before, nil)
+	return nil
+}
+
+// validate normalizes the code and the currencies, which default to KES, and
+// checks the rule and that no other discount has the code.
+func (s *discountService) validate(ctx context.Context, discount *domain.Discount) error {
+	discount.Code = domain.NormalizeDiscountCode(discount.Code)
+	for _, amount := range []*domain.Money{&discount.AmountOff, &discount.MinOrderValue} {
+		if amount.Currency == "" {
+			amount.Currency = domain.DefaultCurrency
+		}
+	}
+	if err := discount.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.discountRepo.GetByCode(ctx, discount.Code)
+	if err == nil && existing.ID != discount.ID {
+		return domain.NewValidationError("discount code %q is already taken", discount.Code)
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	return nil
+}
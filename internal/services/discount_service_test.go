@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDiscountRepository struct {
+	mock.Mock
+}
+
+func (m *MockDiscountRepository) Create(ctx context.Context, discount *domain.Discount) error {
+	args := m.Called(ctx, discount)
+	return args.Error(0)
+}
+
+func (m *MockDiscountRepository) Get(ctx context.Context, id uint) (*domain.Discount, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Discount), args.Error(1)
+}
+
+func (m *MockDiscountRepository) GetByCode(ctx context.Context, code string) (*domain.Discount, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Discount), args.Error(1)
+}
+
+func (m *MockDiscountRepository) List(ctx context.Context) ([]domain.Discount, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Discount), args.Error(1)
+}
+
+func (m *MockDiscountRepository) Update(ctx context.Context, discount *domain.Discount) error {
+	args := m.Called(ctx, discount)
+	return args.Error(0)
+}
+
+func (m *MockDiscountRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDiscountRepository) ListAutomatic(ctx context.Context, at time.Time) ([]domain.Discount, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]domain.Discount), args.Error(1)
+}
+
+func (m *MockDiscountRepository) CountCustomerUses(ctx context.Context, discountID, customerID uint) (int64, error) {
+	args := m.Called(ctx, discountID, customerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// newMockDiscountRepository applies the given automatic discounts to every order.
+func newMockDiscountRepository(automatic ...domain.Discount) *MockDiscountRepository {
+	m := new(MockDiscountRepository)
+	m.On("ListAutomatic", mock.Anything, mock.Anything).Return(automatic, nil).Maybe()
+	return m
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// newDiscountTestOrderService places orders for customer 1 at rateTestNow.
+func newDiscountTestOrderService(discountRepo *MockDiscountRepository, orderRepo *MockOrderRepository) *orderService {
+	addressRepo := new(MockAddressRepository)
+	addressRepo.On("GetDefault", mock.Anything, uint(1)).Return(&domain.Address{ID: 3, CustomerID: 1}, nil)
+	orderRepo.On("Create", mock.Anything, mock.Anything).Return(&domain.Order{ID: 9}, nil)
+	notificationRepo := new(MockNotificationRepository)
+	notificationRepo.On("SendEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	categoryRepo := new(MockCategoryRepository)
+	categoryRepo.On("List", mock.Anything).Return(exportTestCategories(), nil)
+
+	service := NewOrderService(orderRepo, new(MockProductRepository), new(MockProductPriceRepository), notificationRepo, addressRepo, newMockAuditRepository(), newMockExchangeRateRepository(usdRate("129.45")), discountRepo, categoryRepo, newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true).(*orderService)
+	service.now = func() time.Time { return rateTestNow }
+	return service
+}
+
+func TestDiscountService_CreateDiscount(t *testing.T) {
+	mockDiscountRepo := new(MockDiscountRepository)
+	service := NewDiscountService(mockDiscountRepo, newMockAuditRepository())
+
+	mockDiscountRepo.On("GetByCode", mock.Anything, "SAVE10").Return(nil, domain.ErrNotFound).Once()
+	mockDiscountRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	discount := &domain.Discount{Code: " save10 ", Name: "Ten off", Type: domain.DiscountPercentage, PercentOff: 10, MinOrderValue: domain.Money{Amount: 500000}, TimesUsed: 4}
+	err := service.CreateDiscount(context.Background(), discount)
+	assert.NoError(t, err)
+	assert.Equal(t, "SAVE10", discount.Code)
+	assert.Equal(t, kes(500000), discount.MinOrderValue)
+	assert.Equal(t, 0, discount.TimesUsed)
+
+	// Codes are unique
+	mockDiscountRepo.On("GetByCode", mock.Anything, "SAVE10").Return(&domain.Discount{ID: 1, Code: "SAVE10"}, nil)
+	err = service.CreateDiscount(context.Background(), &domain.Discount{Code: "save10", Name: "Again", Type: domain.DiscountPercentage, PercentOff: 5})
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	invalid := []domain.Discount{
+		{Name: "No percent", Type: domain.DiscountPercentage},
+		{Name: "Too much", Type: domain.DiscountPercentage, PercentOff: 101},
+		{Name: "No amount", Type: domain.DiscountFixedAmount},
+		{Name: "No quantities", Type: domain.DiscountBuyXGetY, PercentOff: 100},
+		{Name: "Unknown", Type: "bogus"},
+		{Type: domain.DiscountPercentage, PercentOff: 10},
+		{Name: "Backwards", Type: domain.DiscountPercentage, PercentOff: 10, StartsAt: &rateTestNow, EndsAt: &rateTestNow},
+		{Name: "No uses", Type: domain.DiscountPercentage, PercentOff: 10, UsageLimit: intPtr(0)},
+	}
+	for _, discount := range invalid {
+		err := service.CreateDiscount(context.Background(), &discount)
+		assert.True(t, domain.IsValidationError(err), "%s: got %v", discount.Name, err)
+	}
+	mockDiscountRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestDiscountService_DeleteDiscount_RefusesUsed(t *testing.T) {
+	mockDiscountRepo := new(MockDiscountRepository)
+	service := NewDiscountService(mockDiscountRepo, newMockAuditRepository())
+
+	mockDiscountRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Discount{ID: 1, TimesUsed: 3}, nil)
+	mockDiscountRepo.On("Get", mock.Anything, uint(2)).Return(&domain.Discount{ID: 2}, nil)
+	mockDiscountRepo.On("Delete", mock.Anything, uint(2)).Return(nil)
+
+	err := service.DeleteDiscount(context.Background(), 1)
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	err = service.DeleteDiscount(context.Background(), 2)
+	assert.NoError(t, err)
+	mockDiscountRepo.AssertNotCalled(t, "Delete", mock.Anything, uint(1))
+}
+
+func TestOrderService_CreateOrder_AppliesDiscounts(t *testing.T) {
+	// 10% off phones, subcategories included, applies by itself
+	phones := domain.Discount{ID: 1, Name: "Phone week", Type: domain.DiscountPercentage, PercentOff: 10, CategoryIDs: domain.IDList{2}, Active: true}
+	mockDiscountRepo := newMockDiscountRepository(phones)
+	mockDiscountRepo.On("GetByCode", mock.Anything, "TAKE500").Return(&domain.Discount{ID: 2, Code: "TAKE500", Name: "KES 500 off", Type: domain.DiscountFixedAmount, AmountOff: kes(50000), Active: true}, nil)
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, CategoryID: 3}, nil)
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(2)).Return(&domain.Product{ID: 2, CategoryID: 4}, nil)
+	service := newDiscountTestOrderService(mockDiscountRepo, mockOrderRepo)
+
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	order := &domain.Order{CustomerID: 1, DiscountCodes: []string{"take500"}, Items: []domain.OrderItem{
+		{ProductID: 1, Quantity: 1, Price: kes(200000)},
+		{ProductID: 2, Quantity: 2, Price: kes(100000)},
+	}}
+	err := service.CreateOrder(ctx, order)
+	assert.NoError(t, err)
+
+	assert.Equal(t, kes(300000), order.Subtotal)
+	if assert.Len(t, order.Adjustments, 2) {
+		assert.Equal(t, domain.AdjustmentDiscount, order.Adjustments[0].Type)
+		assert.Equal(t, uint(1), *order.Adjustments[0].DiscountID)
+		assert.Equal(t, kes(-20000), order.Adjustments[0].Amount)
+		assert.Equal(t, "TAKE500", order.Adjustments[1].Code)
+		assert.Equal(t, kes(-50000), order.Adjustments[1].Amount)
+	}
+	// The fixed amount is spread over what the lines have left, 1800 and 1000
+	assert.Equal(t, kes(20000+32143), order.Items[0].Discount)
+	assert.Equal(t, kes(17857), order.Items[1].Discount)
+	assert.Equal(t, kes(70000), order.DiscountTotal)
+	assert.Equal(t, kes(230000), order.TotalPrice)
+}
+
+func TestOrderService_CreateOrder_BuyXGetY(t *testing.T) {
+	// Buy two, get one free, the cheapest units go free
+	mockDiscountRepo := newMockDiscountRepository(domain.Discount{ID: 1, Name: "3 for 2", Type: domain.DiscountBuyXGetY, BuyQuantity: 2, GetQuantity: 1, PercentOff: 100, ProductIDs: domain.IDList{1, 2}, Active: true})
+	service := newDiscountTestOrderService(mockDiscountRepo, new(MockOrderRepository))
+
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	order := &domain.Order{CustomerID: 1, Items: []domain.OrderItem{
+		{ProductID: 1, Quantity: 4, Price: kes(40000)},
+		{ProductID: 2, Quantity: 2, Price: kes(10000)},
+		{ProductID: 3, Quantity: 3, Price: kes(3000)},
+	}}
+	err := service.CreateOrder(ctx, order)
+	assert.NoError(t, err)
+
+	// Six covered units make two free ones, both of product 2, product 3 isn't covered
+	assert.Equal(t, kes(0), order.Items[0].Discount)
+	assert.Equal(t, kes(10000), order.Items[1].Discount)
+	assert.Equal(t, kes(0), order.Items[2].Discount)
+	assert.Equal(t, kes(43000), order.TotalPrice)
+}
+
+func TestOrderService_CreateOrder_DiscountCodeChecks(t *testing.T) {
+	ended := rateTestNow.Add(-time.Hour)
+	mockDiscountRepo := newMockDiscountRepository()
+	mockDiscountRepo.On("GetByCode", mock.Anything, "OLD").Return(&domain.Discount{ID: 1, Code: "OLD", Name: "Old", Type: domain.DiscountPercentage, PercentOff: 10, EndsAt: &ended, Active: true}, nil)
+	mockDiscountRepo.On("GetByCode", mock.Anything, "OFF").Return(&domain.Discount{ID: 2, Code: "OFF", Name: "Off", Type: domain.DiscountPercentage, PercentOff: 10}, nil)
+	mockDiscountRepo.On("GetByCode", mock.Anything, "BIG").Return(&domain.Discount{ID: 3, Code: "BIG", Name: "Big", Type: domain.DiscountPercentage, PercentOff: 10, MinOrderValue: domain.NewMoney(1000, "USD"), Active: true}, nil)
+	mockDiscountRepo.On("GetByCode", mock.Anything, "GONE").Return(&domain.Discount{ID: 4, Code: "GONE", Name: "Gone", Type: domain.DiscountPercentage, PercentOff: 10, UsageLimit: intPtr(5), TimesUsed: 5, Active: true}, nil)
+	mockDiscountRepo.On("GetByCode", mock.Anything, "ONCE").Return(&domain.Discount{ID: 5, Code: "ONCE", Name: "Once", Type: domain.DiscountPercentage, PercentOff: 10, PerCustomerLimit: intPtr(1), Active: true}, nil)
+	mockDiscountRepo.On("GetByCode", mock.Anything, "SHOES").Return(&domain.Discount{ID: 6, Code: "SHOES", Name: "Shoes", Type: domain.DiscountPercentage, PercentOff: 10, ProductIDs: domain.IDList{7}, Active: true}, nil)
+	mockDiscountRepo.On("GetByCode", mock.Anything, "NOPE").Return(nil, domain.ErrNotFound)
+	mockDiscountRepo.On("CountCustomerUses", mock.Anything, uint(5), uint(1)).Return(int64(1), nil)
+	mockOrderRepo := new(MockOrderRepository)
+	service := newDiscountTestOrderService(mockDiscountRepo, mockOrderRepo)
+
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	for _, code := range []string{"nope", "old", "off", "big", "gone", "once", "shoes"} {
+		// USD 1000 is KES 129,450, more than the order
+		order := &domain.Order{CustomerID: 1, DiscountCodes: []string{code}, Items: []domain.OrderItem{{ProductID: 1, Quantity: 1, Price: kes(100000)}}}
+		err := service.CreateOrder(ctx, order)
+		assert.True(t, domain.IsValidationError(err), "%s: got %v", code, err)
+	}
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
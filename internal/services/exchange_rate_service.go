@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type exchangeRateService struct {
+	rateRepo  ports.ExchangeRateRepository
+	auditRepo ports.AuditRepository
+	now       func() time.Time
+}
+
+func NewExchangeRateService(rateRepo ports.ExchangeRateRepository, auditRepo ports.AuditRepository) ports.ExchangeRateService {
+	return &exchangeRateService{
+		rateRepo:  rateRepo,
+		auditRepo: auditRepo,
+		now:       time.Now,
+	}
+}
+
+func (s *exchangeRateService) ListRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ExchangeRateService.ListRates")
+	defer span.End()
+
+	rates, err := s.rateRepo.ListAt(ctx, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if rates == nil {
+		rates = []domain.ExchangeRate{}
+	}
+	return rates, nil
+}
+
+func (s *exchangeRateService) RateHistory(ctx context.Context, currency string) ([]domain.ExchangeRate, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ExchangeRateService.RateHistory")
+	defer span.End()
+
+	currency = strings.ToUpper(currency)
+	if _, ok := domain.CurrencyExponent(currency); !ok {
+		return nil, domain.ErrNotFound
+	}
+	rates, err := s.rateRepo.History(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+	if rates == nil {
+		rates = []domain.ExchangeRate{}
+	}
+	return rates, nil
+}
+
+func (s *exchangeRateService) SetRate(ctx context.Context, rate *domain.ExchangeRate) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ExchangeRateService.SetRate")
+	defer span.End()
+
+	rate.ID = 0
+	rate.Currency = strings.ToUpper(strings.TrimSpace(rate.Currency))
+	if _, ok := domain.CurrencyExponent(rate.Currency); !ok {
+		return domain.NewValidationError("unsupported currency %q", rate.Currency)
+	}
+	if rate.Currency == domain.DefaultCurrency {
+		return domain.NewValidationError("%s is the base currency, its rate is always 1", domain.DefaultCurrency)
+	}
+	parsed, err := domain.ParseRate(string(rate.Rate))
+	if err != nil {
+		return err
+	}
+	rate.Rate = parsed
+
+	// Past rates stay as they were, orders placed at them point back to them
+	now := s.now()
+	if rate.EffectiveFrom.IsZero() {
+		rate.EffectiveFrom = now
+	} else if rate.EffectiveFrom.Before(now) {
+		return domain.NewValidationError("effective_from must not be in the past")
+	}
+
+	if err := s.rateRepo.Create(ctx, rate); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityRate, rate.ID, nil, rate)
+	return nil
+}
+
+func (s *exchangeRateService) CurrentRates(ctx context.Context) (domain.ExchangeRates, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ExchangeRateService.CurrentRates")
+	defer span.End()
+
+	return currentRates(ctx, s.rateRepo, s.now())
+}
+
+// currentRates loads the rates in force at the given time.
+func currentRates(ctx context.Context, repo ports.ExchangeRateRepository, at time.Time) (domain.ExchangeRates, error) {
+	rates, err := repo.ListAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewExchangeRates(rates), nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockExchangeRateRepository struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateRepository) Create(ctx context.Context, rate *domain.ExchangeRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func (m *MockExchangeRateRepository) ListAt(ctx context.Context, at time.Time) ([]domain.ExchangeRate, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]domain.ExchangeRate), args.Error(1)
+}
+
+func (m *MockExchangeRateRepository) History(ctx context.Context, currency string) ([]domain.ExchangeRate, error) {
+	args := m.Called(ctx, currency)
+	return args.Get(0).([]domain.ExchangeRate), args.Error(1)
+}
+
+// newMockExchangeRateRepository serves the given rates as the ones in force,
+// for tests that are not about setting rates
+func newMockExchangeRateRepository(rates ...domain.ExchangeRate) *MockExchangeRateRepository {
+	m := new(MockExchangeRateRepository)
+	m.On("ListAt", mock.Anything, mock.Anything).Return(rates, nil).Maybe()
+	return m
+}
+
+var rateTestNow = time.Date(2024, 11, 20, 9, 0, 0, 0, time.UTC)
+
+func usdRate(rate string) domain.ExchangeRate {
+	return domain.ExchangeRate{Currency: "USD", Rate: domain.Rate(rate), EffectiveFrom: rateTestNow.Add(-time.Hour)}
+}
+
+func TestExchangeRateService_SetRate(t *testing.T) {
+	mockRateRepo := new(MockExchangeRateRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewExchangeRateService(mockRateRepo, mockAuditRepo).(*exchangeRateService)
+	service.now = func() time.Time { return rateTestNow }
+
+	mockRateRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.ExchangeRate).ID = 4
+	}).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.EntityType == domain.AuditEntityRate && entry.EntityID == 4
+	})).Return(nil)
+
+	rate := &domain.ExchangeRate{Currency: " usd", Rate: "0129.4500"}
+	err := service.SetRate(context.Background(), rate)
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", rate.Currency)
+	assert.Equal(t, domain.Rate("129.45"), rate.Rate)
+	assert.Equal(t, rateTestNow, rate.EffectiveFrom)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestExchangeRateService_SetRate_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rate domain.ExchangeRate
+	}{
+		{"unsupported currency", domain.ExchangeRate{Currency: "XYZ", Rate: "2"}},
+		{"base currency", domain.ExchangeRate{Currency: "KES", Rate: "1"}},
+		{"not a number", domain.ExchangeRate{Currency: "USD", Rate: "1,29"}},
+		{"zero", domain.ExchangeRate{Currency: "USD", Rate: "0.000"}},
+		{"negative", domain.ExchangeRate{Currency: "USD", Rate: "-129.45"}},
+		{"too precise", domain.ExchangeRate{Currency: "USD", Rate: "0.0000000000001"}},
+		{"in the past", domain.ExchangeRate{Currency: "USD", Rate: "129.45", EffectiveFrom: rateTestNow.Add(-time.Minute)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRateRepo := new(MockExchangeRateRepository)
+			service := NewExchangeRateService(mockRateRepo, newMockAuditRepository()).(*exchangeRateService)
+			service.now = func() time.Time { return rateTestNow }
+
+			err := service.SetRate(context.Background(), &tt.rate)
+			assert.True(t, domain.IsValidationError(err), "got %v", err)
+			mockRateRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestExchangeRateService_CurrentRates_Convert(t *testing.T) {
+	ugx := domain.ExchangeRate{Currency: "UGX", Rate: "0.035", EffectiveFrom: rateTestNow.Add(-time.Hour)}
+	service := NewExchangeRateService(newMockExchangeRateRepository(usdRate("129.45"), ugx), newMockAuditRepository())
+
+	rates, err := service.CurrentRates(context.Background())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		from domain.Money
+		to   string
+		want domain.Money
+	}{
+		{"same currency", kes(150050), "KES", kes(150050)},
+		{"to USD", kes(1000000), "USD", domain.NewMoney(7725, "USD")},
+		{"from USD", domain.NewMoney(1000, "USD"), "KES", kes(129450)},
+		{"to a currency without minor units", kes(10000), "UGX", domain.NewMoney(2857, "UGX")},
+		{"between two foreign currencies", domain.NewMoney(100, "USD"), "UGX", domain.NewMoney(3699, "UGX")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.from, tt.to)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// KES 1.00 is half a cent, which rounds away from zero
+	half := domain.NewExchangeRates([]domain.ExchangeRate{usdRate("200")})
+	got, err := half.Convert(kes(99), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(0, "USD"), got)
+	got, err = half.Convert(kes(100), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(1, "USD"), got)
+
+	_, err = rates.Convert(kes(100), "EUR")
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+}
+
+func TestProductService_GetAverageCategoryPrice_InCurrency(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), newAttributeCategoryRepository(nil), newMockAuditRepository(), newMockExchangeRateRepository(usdRate("129.45"))).(*productService)
+	service.now = func() time.Time { return rateTestNow }
+
+	totals := []domain.PriceTotal{
+		{Currency: "KES", Sum: 300000, Count: 2},
+		{Currency: "USD", Sum: 1000, Count: 1},
+	}
+	mockProductRepo.On("GetCategoryPriceTotals", mock.Anything, uint(1), false).Return(totals, nil)
+	mockProductRepo.On("GetCategoryPriceTotals", mock.Anything, uint(2), false).Return([]domain.PriceTotal{}, nil)
+
+	average, err := service.GetAverageCategoryPrice(context.Background(), 1, false, "")
+	assert.NoError(t, err)
+	assert.Equal(t, kes(143150), average)
+
+	average, err = service.GetAverageCategoryPrice(context.Background(), 1, false, "usd")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(1106, "USD"), average)
+
+	_, err = service.GetAverageCategoryPrice(context.Background(), 1, false, "EUR")
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	_, err = service.GetAverageCategoryPrice(context.Background(), 2, false, "USD")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
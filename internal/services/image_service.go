@@ -0,0 +1,336 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// thumbnailSize is the longest side of a thumbnail in pixels.
+	thumbnailSize = 320
+	// maxImagePixels stops small files that decode to huge images.
+	maxImagePixels = 40_000_000
+)
+
+// imageExtensions are the formats accepted for upload, by sniffed content type.
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+type productImageService struct {
+	productRepo ports.ProductRepository
+	imageRepo   ports.ProductImageRepository
+	blobStore   ports.BlobStore
+	auditRepo   ports.AuditRepository
+	maxSize     int64
+}
+
+// NewProductImageService accepts images up to maxSize bytes,
+// domain.DefaultMaxImageSize when it is not positive.
+func NewProductImageService(productRepo ports.ProductRepository, imageRepo ports.ProductImageRepository, blobStore ports.BlobStore, auditRepo ports.AuditRepository, maxSize int64) ports.ProductImageService {
+	if maxSize <= 0 {
+		maxSize = domain.DefaultMaxImageSize
+	}
+	return &productImageService{
+		productRepo: productRepo,
+		imageRepo:   imageRepo,
+		blobStore:   blobStore,
+		auditRepo:   auditRepo,
+		maxSize:     maxSize,
+	}
+}
+
+func (s *productImageService) ListImages(ctx context.Context, productID uint) ([]domain.ProductImage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageService.ListImages")
+	defer span.End()
+
+	if _, err := s.productRepo.Get(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if images == nil {
+		images = []domain.ProductImage{}
+	}
+	return images, nil
+}
+
+func (s *productImageService) UploadImage(ctx context.Context, productID uint, upload domain.ProductImageUpload) (*domain.ProductImage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageService.UploadImage")
+	defer span.End()
+
+	if _, err := s.productRepo.Get(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	if len(upload.Data) == 0 {
+		return nil, domain.NewValidationError("the image is empty")
+	}
+	if int64(len(upload.Data)) > s.maxSize {
+		return nil, domain.NewValidationError("the image is larger than %d bytes", s.maxSize)
+	}
+
+	// The content decides the type, not the file name or the client's header
+	contentType := http.DetectContentType(upload.Data)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, domain.NewValidationError("only JPEG, PNG and GIF images are supported, got %s", contentType)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(upload.Data))
+	if err != nil {
+		return nil, domain.NewValidationError("the image could not be read: %v", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, domain.NewValidationError("the image is too large at %dx%d pixels", config.Width, config.Height)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(upload.Data))
+	if err != nil {
+		return nil, domain.NewValidationError("the image could not be read: %v", err)
+	}
+
+	thumb, thumbContentType, err := encodeThumbnail(decoded, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	img := &domain.ProductImage{
+		ProductID:    productID,
+		Key:          fmt.Sprintf("products/%d/%s.%s", productID, name, extension),
+		ThumbnailKey: fmt.Sprintf("products/%d/%s_thumb.%s", productID, name, imageExtensions[thumbContentType]),
+		ContentType:  contentType,
+		Size:         int64(len(upload.Data)),
+		Width:        config.Width,
+		Height:       config.Height,
+	}
+	img.URL = s.blobStore.URL(img.Key)
+	img.ThumbnailURL = s.blobStore.URL(img.ThumbnailKey)
+
+	if err := s.blobStore.Put(ctx, img.Key, bytes.NewReader(upload.Data), img.Size, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.blobStore.Put(ctx, img.ThumbnailKey, bytes.NewReader(thumb), int64(len(thumb)), thumbContentType); err != nil {
+		s.deleteFiles(ctx, img)
+		return nil, err
+	}
+
+	images, err := s.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		s.deleteFiles(ctx, img)
+		return nil, err
+	}
+	img.Position = len(images)
+	img.IsPrimary = len(images) == 0 || upload.Primary
+	if err := s.imageRepo.Create(ctx, img); err != nil {
+		s.deleteFiles(ctx, img)
+		return nil, err
+	}
+
+	if img.IsPrimary && len(images) > 0 {
+		for i := range images {
+			images[i].IsPrimary = false
+		}
+		if err := s.imageRepo.SaveOrder(ctx, images); err != nil {
+			return nil, err
+		}
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityImage, img.ID, nil, img)
+	return img, nil
+}
+
+func (s *productImageService) UpdateImage(ctx context.Context, productID, id uint, update domain.ProductImageUpdate) (*domain.ProductImage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageService.UpdateImage")
+	defer span.End()
+
+	before, err := s.getImage(ctx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+	images, err := s.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Position != nil {
+		if *update.Position < 0 {
+			return nil, domain.NewValidationError("position must not be negative")
+		}
+		images = moveImage(images, id, *update.Position)
+	}
+	if update.IsPrimary != nil {
+		if !*update.IsPrimary && before.IsPrimary {
+			return nil, domain.NewValidationError("make another image primary instead")
+		}
+		if *update.IsPrimary {
+			for i := range images {
+				images[i].IsPrimary = images[i].ID == id
+			}
+		}
+	}
+	for i := range images {
+		images[i].Position = i
+	}
+
+	if err := s.imageRepo.SaveOrder(ctx, images); err != nil {
+		return nil, err
+	}
+
+	var after *domain.ProductImage
+	for i := range images {
+		if images[i].ID == id {
+			after = &images[i]
+		}
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityImage, id, before, after)
+	return after, nil
+}
+
+func (s *productImageService) DeleteImage(ctx context.Context, productID, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductImageService.DeleteImage")
+	defer span.End()
+
+	before, err := s.getImage(ctx, productID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.imageRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// Close the gap and hand the primary flag to the next image
+	remaining, err := s.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+	for i := range remaining {
+		remaining[i].Position = i
+	}
+	if before.IsPrimary && len(remaining) > 0 {
+		remaining[0].IsPrimary = true
+	}
+	if err := s.imageRepo.SaveOrder(ctx, remaining); err != nil {
+		return err
+	}
+
+	s.deleteFiles(ctx, before)
+	recordAudit(ctx, s.auditRepo, domain.AuditActionDelete, domain.AuditEntityImage, id, before, nil)
+	return nil
+}
+
+func (s *productImageService) getImage(ctx context.Context, productID, id uint) (*domain.ProductImage, error) {
+	img, err := s.imageRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if img.ProductID != productID {
+		return nil, domain.ErrNotFound
+	}
+	return img, nil
+}
+
+// deleteFiles removes the image's files. Failures leave an unused file behind
+// but don't fail the request, so they are only logged.
+func (s *productImageService) deleteFiles(ctx context.Context, img *domain.ProductImage) {
+	for _, key := range []string{img.Key, img.ThumbnailKey} {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			log.Printf("images: failed to delete %s: %v", key, err)
+		}
+	}
+}
+
+// moveImage moves the image with the given ID to position, or to the end
+// when position is past the last image.
+func moveImage(images []domain.ProductImage, id uint, position int) []domain.ProductImage {
+	var moved domain.ProductImage
+	rest := make([]domain.ProductImage, 0, len(images))
+	for _, img := range images {
+		if img.ID == id {
+			moved = img
+		} else {
+			rest = append(rest, img)
+		}
+	}
+	if position > len(rest) {
+		position = len(rest)
+	}
+
+	ordered := make([]domain.ProductImage, 0, len(images))
+	ordered = append(ordered, rest[:position]...)
+	ordered = append(ordered, moved)
+	return append(ordered, rest[position:]...)
+}
+
+// encodeThumbnail scales the image down and encodes it as a JPEG for JPEG
+// sources and as a PNG otherwise, to keep transparency.
+func encodeThumbnail(img image.Image, contentType string) ([]byte, string, error) {
+	thumb := thumbnail(img, thumbnailSize)
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), contentType, nil
+	}
+	if err := png.Encode(&buf, thumb); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// thumbnail scales img down to fit in a size by size square, averaging the
+// source pixels each thumbnail pixel covers. Smaller images keep their size.
+func thumbnail(img image.Image, size int) *image.RGBA64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	thumbWidth, thumbHeight := width, height
+	if width > size || height > size {
+		if width >= height {
+			thumbWidth, thumbHeight = size, max(1, height*size/width)
+		} else {
+			thumbWidth, thumbHeight = max(1, width*size/height), size
+		}
+	}
+
+	thumb := image.NewRGBA64(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0 := bounds.Min.Y + y*height/thumbHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/thumbHeight)
+		for x := 0; x < thumbWidth; x++ {
+			x0 := bounds.Min.X + x*width/thumbWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/thumbWidth)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			thumb.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return thumb
+}
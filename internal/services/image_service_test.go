@@ -0,0 +1,222 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductImageRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductImageRepository) Create(ctx context.Context, image *domain.ProductImage) error {
+	args := m.Called(ctx, image)
+	return args.Error(0)
+}
+
+func (m *MockProductImageRepository) Get(ctx context.Context, id uint) (*domain.ProductImage, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductImage), args.Error(1)
+}
+
+func (m *MockProductImageRepository) ListByProduct(ctx context.Context, productID uint) ([]domain.ProductImage, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).([]domain.ProductImage), args.Error(1)
+}
+
+func (m *MockProductImageRepository) SaveOrder(ctx context.Context, images []domain.ProductImage) error {
+	args := m.Called(ctx, images)
+	return args.Error(0)
+}
+
+func (m *MockProductImageRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// memoryBlobStore keeps blobs in a map.
+type memoryBlobStore struct {
+	blobs map[string][]byte
+}
+
+func newMemoryBlobStore() *memoryBlobStore {
+	return &memoryBlobStore{blobs: map[string][]byte{}}
+}
+
+func (s *memoryBlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	s.blobs[key] = data
+	return err
+}
+
+func (s *memoryBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *memoryBlobStore) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestProductImageService_UploadImage_StoresImageAndThumbnail(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockImageRepo := new(MockProductImageRepository)
+	blobs := newMemoryBlobStore()
+	service := NewProductImageService(mockProductRepo, mockImageRepo, blobs, newMockAuditRepository(), 0)
+
+	mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1}, nil)
+	mockImageRepo.On("ListByProduct", mock.Anything, uint(1)).Return([]domain.ProductImage{}, nil)
+	mockImageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ProductImage")).Return(nil)
+
+	img, err := service.UploadImage(context.Background(), 1, domain.ProductImageUpload{Filename: "shirt.jpg", Data: testPNG(t, 800, 400)})
+	assert.NoError(t, err)
+
+	// The content decides the type, not the file name
+	assert.Equal(t, "image/png", img.ContentType)
+	assert.Equal(t, 800, img.Width)
+	assert.Equal(t, 400, img.Height)
+	assert.True(t, img.IsPrimary)
+	assert.Equal(t, 0, img.Position)
+	assert.Equal(t, "https://cdn.example.com/"+img.Key, img.URL)
+	assert.Regexp(t, `^products/1/[0-9a-f]{32}\.png$`, img.Key)
+	assert.Len(t, blobs.blobs, 2)
+
+	thumb, err := png.DecodeConfig(bytes.NewReader(blobs.blobs[img.ThumbnailKey]))
+	assert.NoError(t, err)
+	assert.Equal(t, thumbnailSize, thumb.Width)
+	assert.Equal(t, thumbnailSize/2, thumb.Height)
+}
+
+func TestProductImageService_UploadImage_PrimaryReplacesCurrent(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockImageRepo := new(MockProductImageRepository)
+	service := NewProductImageService(mockProductRepo, mockImageRepo, newMemoryBlobStore(), newMockAuditRepository(), 0)
+
+	mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1}, nil)
+	mockImageRepo.On("ListByProduct", mock.Anything, uint(1)).Return([]domain.ProductImage{{ID: 5, ProductID: 1, IsPrimary: true}}, nil)
+	mockImageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ProductImage")).Return(nil)
+	mockImageRepo.On("SaveOrder", mock.Anything, []domain.ProductImage{{ID: 5, ProductID: 1, IsPrimary: false}}).Return(nil)
+
+	img, err := service.UploadImage(context.Background(), 1, domain.ProductImageUpload{Data: testPNG(t, 10, 10), Primary: true})
+	assert.NoError(t, err)
+	assert.True(t, img.IsPrimary)
+	assert.Equal(t, 1, img.Position)
+	mockImageRepo.AssertExpectations(t)
+}
+
+func TestProductImageService_UploadImage_RejectsBadUploads(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "not an image", data: []byte("<html><body>hello</body></html>")},
+		{name: "too large", data: append(testPNG(t, 10, 10), make([]byte, 1000)...)},
+		{name: "truncated", data: testPNG(t, 20, 20)[:60]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProductRepo := new(MockProductRepository)
+			mockImageRepo := new(MockProductImageRepository)
+			blobs := newMemoryBlobStore()
+			service := NewProductImageService(mockProductRepo, mockImageRepo, blobs, newMockAuditRepository(), 1000)
+			mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1}, nil)
+
+			_, err := service.UploadImage(context.Background(), 1, domain.ProductImageUpload{Data: tt.data})
+			assert.True(t, domain.IsValidationError(err), "got %v", err)
+			assert.Empty(t, blobs.blobs)
+			mockImageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestProductImageService_UpdateImage_MovesAndMakesPrimary(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepository)
+	service := NewProductImageService(new(MockProductRepository), mockImageRepo, newMemoryBlobStore(), newMockAuditRepository(), 0)
+
+	images := []domain.ProductImage{
+		{ID: 1, ProductID: 1, Position: 0, IsPrimary: true},
+		{ID: 2, ProductID: 1, Position: 1},
+		{ID: 3, ProductID: 1, Position: 2},
+	}
+	mockImageRepo.On("Get", mock.Anything, uint(3)).Return(&images[2], nil)
+	mockImageRepo.On("ListByProduct", mock.Anything, uint(1)).Return(images, nil)
+	mockImageRepo.On("SaveOrder", mock.Anything, []domain.ProductImage{
+		{ID: 3, ProductID: 1, Position: 0, IsPrimary: true},
+		{ID: 1, ProductID: 1, Position: 1},
+		{ID: 2, ProductID: 1, Position: 2},
+	}).Return(nil)
+
+	position := 0
+	primary := true
+	img, err := service.UpdateImage(context.Background(), 1, 3, domain.ProductImageUpdate{Position: &position, IsPrimary: &primary})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), img.ID)
+	mockImageRepo.AssertExpectations(t)
+}
+
+func TestProductImageService_UpdateImage_OtherProduct(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepository)
+	service := NewProductImageService(new(MockProductRepository), mockImageRepo, newMemoryBlobStore(), newMockAuditRepository(), 0)
+	mockImageRepo.On("Get", mock.Anything, uint(3)).Return(&domain.ProductImage{ID: 3, ProductID: 2}, nil)
+
+	_, err := service.UpdateImage(context.Background(), 1, 3, domain.ProductImageUpdate{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestProductImageService_DeleteImage_PromotesNextImage(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepository)
+	blobs := newMemoryBlobStore()
+	blobs.blobs["products/1/a.png"] = []byte("a")
+	blobs.blobs["products/1/a_thumb.png"] = []byte("a")
+	service := NewProductImageService(new(MockProductRepository), mockImageRepo, blobs, newMockAuditRepository(), 0)
+
+	mockImageRepo.On("Get", mock.Anything, uint(1)).Return(&domain.ProductImage{ID: 1, ProductID: 1, Key: "products/1/a.png", ThumbnailKey: "products/1/a_thumb.png", IsPrimary: true}, nil)
+	mockImageRepo.On("Delete", mock.Anything, uint(1)).Return(nil)
+	mockImageRepo.On("ListByProduct", mock.Anything, uint(1)).Return([]domain.ProductImage{
+		{ID: 2, ProductID: 1, Position: 1},
+		{ID: 3, ProductID: 1, Position: 2},
+	}, nil)
+	mockImageRepo.On("SaveOrder", mock.Anything, []domain.ProductImage{
+		{ID: 2, ProductID: 1, Position: 0, IsPrimary: true},
+		{ID: 3, ProductID: 1, Position: 1},
+	}).Return(nil)
+
+	err := service.DeleteImage(context.Background(), 1, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, blobs.blobs)
+	mockImageRepo.AssertExpectations(t)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// notifyCustomer sends the customer the message by the channels they have
+// chosen, email unless they turned it off and SMS to a verified phone when
+// they turned it on. Failures are only reported on the span.
+func notifyCustomer(ctx context.Context, customerRepo ports.CustomerRepository, notificationRepo ports.NotificationRepository, customerID uint, subject, message string) {
+	span := trace.SpanFromContext(ctx)
+	customer, err := customerRepo.GetCustomer(ctx, customerID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		return
+	}
+
+	if customer.Preferences.EmailNotifications && customer.Email != "" {
+		if err := notificationRepo.SendEmail(ctx, message, subject, customer.Email); err != nil {
+			span.RecordError(err)
+		}
+	}
+	if customer.Preferences.SmsNotifications && customer.PhoneVerified && customer.Phone != "" {
+		if err := notificationRepo.SendSms(ctx, []string{customer.Phone}, smsSenderID, message); err != nil {
+			span.RecordError(err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+func (s *orderService) AmendOrder(ctx context.Context, id uint, request domain.AmendRequest) (*domain.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.AmendOrder")
+	defer span.End()
+
+	order, err := s.orderRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == domain.OrderCancelled {
+		return nil, domain.ErrOrderCancelled
+	}
+	if !order.Status.Amendable() || order.PaymentStatus != domain.OrderUnpaid {
+		return nil, domain.ErrOrderNotAmendable
+	}
+	if len(request.Lines) == 0 {
+		return nil, domain.NewValidationError("an order needs at least one item, cancel it instead")
+	}
+
+	// The customer may be answering a request for the old total
+	payments, err := s.paymentService.ListOrderPayments(ctx, order.CustomerID, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		if payment.Status == domain.PaymentPending {
+			return nil, domain.ErrPaymentPending
+		}
+	}
+
+	before := orderSnapshot(order)
+	amendment := &domain.OrderAmendment{OrderID: order.ID, Reason: request.Reason, PreviousTotal: order.TotalPrice}
+	changed, stock, err := s.amendLines(ctx, order, amendment, request.Lines)
+	if err != nil {
+		return nil, err
+	}
+	if len(amendment.Lines) == 0 {
+		return order, nil
+	}
+
+	// The order keeps the rate it was placed at, new lines are converted at today's
+	exchangeRate := order.ExchangeRate
+	if err := s.chargeInCurrency(ctx, order); err != nil {
+		return nil, err
+	}
+	order.ExchangeRate = exchangeRate
+	for i, item := range changed {
+		if item >= 0 {
+			amendment.Lines[i].Price = order.Items[item].Price
+		}
+	}
+	amendment.NewTotal = order.TotalPrice
+
+	if err := s.orderRepo.Amend(ctx, order, amendment, stock); err != nil {
+		return nil, err
+	}
+	order.Amendments = append(order.Amendments, *amendment)
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityOrder, order.ID, before, order)
+
+	subject := fmt.Sprintf("Order %d changed", order.ID)
+	message := fmt.Sprintf("Your order %d has been changed", order.ID)
+	if request.Reason != "" {
+		message += ": " + request.Reason
+	}
+	message += fmt.Sprintf(". The new total is %s.", order.TotalPrice)
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, order.CustomerID, subject, message)
+	return order, nil
+}
+
+// amendLines gives the order the requested lines, recording each line added,
+// changed or removed on the amendment. Lines already on the order keep their
+// unit price. It returns, for each amendment line, the index of its item in
+// order.Items or -1 for a removed line, and the units of each variant to take
+// from stock, negative for units put back.
+func (s *orderService) amendLines(ctx context.Context, order *domain.Order, amendment *domain.OrderAmendment, requested []domain.AmendLineRequest) ([]int, map[uint]int, error) {
+	existing := order.Items
+	matched := make([]bool, len(existing))
+	var items []domain.OrderItem
+	var changed []int
+	stock := make(map[uint]int)
+
+	for _, line := range requested {
+		if line.Quantity < 1 {
+			return nil, nil, domain.NewValidationError("Invalid quantity for product ID: %d", line.ProductID)
+		}
+		for _, earlier := range items {
+			if earlier.Matches(line.ProductID, line.VariantID) {
+				return nil, nil, domain.NewValidationError("product %d is listed twice", line.ProductID)
+			}
+		}
+
+		match := -1
+		for j := range existing {
+			if !matched[j] && existing[j].Matches(line.ProductID, line.VariantID) {
+				match = j
+				break
+			}
+		}
+		if match >= 0 {
+			matched[match] = true
+			item := existing[match]
+			if item.Quantity == line.Quantity {
+				items = append(items, item)
+				continue
+			}
+			amendment.Lines = append(amendment.Lines, domain.OrderAmendmentLine{
+				OrderItemID:      item.ID,
+				ProductID:        item.ProductID,
+				VariantID:        item.VariantID,
+				PreviousQuantity: item.Quantity,
+				Quantity:         line.Quantity,
+				PreviousPrice:    item.Price,
+			})
+			if item.VariantID != nil {
+				stock[*item.VariantID] += line.Quantity - item.Quantity
+			}
+			item.Price = item.Price.Scale(int64(line.Quantity), int64(item.Quantity))
+			item.Quantity = line.Quantity
+			items = append(items, item)
+			changed = append(changed, len(items)-1)
+			continue
+		}
+
+		item, err := s.newOrderItem(ctx, order, line)
+		if err != nil {
+			return nil, nil, err
+		}
+		amendment.Lines = append(amendment.Lines, domain.OrderAmendmentLine{
+			ProductID:     item.ProductID,
+			VariantID:     item.VariantID,
+			Quantity:      item.Quantity,
+			PreviousPrice: domain.NewMoney(0, order.Currency),
+		})
+		if item.VariantID != nil {
+			stock[*item.VariantID] += item.Quantity
+		}
+		items = append(items, *item)
+		changed = append(changed, len(items)-1)
+	}
+
+	for j, item := range existing {
+		if matched[j] {
+			continue
+		}
+		amendment.Lines = append(amendment.Lines, domain.OrderAmendmentLine{
+			OrderItemID:      item.ID,
+			ProductID:        item.ProductID,
+			VariantID:        item.VariantID,
+			PreviousQuantity: item.Quantity,
+			PreviousPrice:    item.Price,
+			Price:            domain.NewMoney(0, order.Currency),
+		})
+		if item.VariantID != nil {
+			stock[*item.VariantID] -= item.Quantity
+		}
+		changed = append(changed, -1)
+	}
+
+	order.Items = items
+	return changed, stock, nil
+}
+
+// newOrderItem prices a line added to the order at the prices in force now,
+// a variant's own price winning over its product's.
+func (s *orderService) newOrderItem(ctx context.Context, order *domain.Order, line domain.AmendLineRequest) (*domain.OrderItem, error) {
+	product, err := s.GetOrderProduct(ctx, line.ProductID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.NewValidationError("Invalid product ID: %d", line.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unitPrice := product.Price
+	variant, err := s.GetOrderVariant(ctx, product.ID, line.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	if variant != nil {
+		unitPrice = variant.EffectivePrice(product.Price)
+	}
+	return &domain.OrderItem{
+		OrderID:   order.ID,
+		ProductID: product.ID,
+		VariantID: line.VariantID,
+		Quantity:  line.Quantity,
+		Price:     unitPrice.Mul(int64(line.Quantity)),
+	}, nil
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+// discountLine is an order item as the discount rules see it.
+type discountLine struct {
+	item *domain.OrderItem
+	// categories are the item's category and its ancestors, only loaded when
+	// a discount is limited to categories.
+	categories []uint
+	// remaining is what is left of the line's price after the discounts applied so far.
+	remaining int64
+}
+
+// applyDiscounts works out the order's discounts from its converted items.
+// Automatic discounts are applied first, then the entered codes in the order
+// they were given, each on what the ones before it left of the lines. A code
+// that can't be used fails the order, an automatic discount that doesn't
+// match is skipped. An order already placed keeps the discounts it had.
+func (s *orderService) applyDiscounts(ctx context.Context, order *domain.Order, rates domain.ExchangeRates, catalog *orderCatalog) error {
+	redeemed := order.Adjustments
+	subtotal := domain.NewMoney(0, order.Currency)
+	lines := make([]discountLine, len(order.Items))
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.Discount = domain.NewMoney(0, order.Currency)
+		lines[i] = discountLine{item: item, remaining: item.Price.Amount}
+		subtotal.Amount += item.Price.Amount
+	}
+	order.Subtotal = subtotal
+	order.DiscountTotal = domain.NewMoney(0, order.Currency)
+	order.TotalPrice = subtotal
+	order.Adjustments = nil
+
+	var discounts []domain.Discount
+	var err error
+	if order.ID != 0 {
+		discounts, err = s.redeemedDiscounts(ctx, order, rates, redeemed)
+	} else {
+		discounts, err = s.orderDiscounts(ctx, order, rates)
+	}
+	if err != nil {
+		return err
+	}
+	if len(discounts) == 0 {
+		return nil
+	}
+	if err := loadLineCategories(ctx, catalog, lines, discounts); err != nil {
+		return err
+	}
+
+	for i := range discounts {
+		discount := &discounts[i]
+		amounts, err := discountAmounts(discount, lines, rates, order.Currency)
+		if err != nil {
+			return err
+		}
+
+		var total int64
+		for j, amount := range amounts {
+			lines[j].remaining -= amount
+			lines[j].item.Discount.Amount += amount
+			total += amount
+		}
+		if total == 0 {
+			if discount.Code != "" {
+				return domain.NewValidationError("discount code %q doesn't apply to any item in the order", discount.Code)
+			}
+			continue
+		}
+
+		discountID := discount.ID
+		order.Adjustments = append(order.Adjustments, domain.OrderAdjustment{
+			Type:        domain.AdjustmentDiscount,
+			DiscountID:  &discountID,
+			Code:        discount.Code,
+			Description: discount.Label(),
+			Amount:      domain.NewMoney(-total, order.Currency),
+		})
+		order.DiscountTotal.Amount += total
+	}
+
+	order.TotalPrice = domain.NewMoney(subtotal.Amount-order.DiscountTotal.Amount, order.Currency)
+	return nil
+}
+
+// orderDiscounts returns the automatic discounts the order qualifies for
+// followed by the discounts of its codes, checking the codes can be used.
+func (s *orderService) orderDiscounts(ctx context.Context, order *domain.Order, rates domain.ExchangeRates) ([]domain.Discount, error) {
+	now := s.now()
+	automatic, err := s.discountRepo.ListAutomatic(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var discounts []domain.Discount
+	for _, discount := range automatic {
+		usable, err := s.discountUsable(ctx, &discount, order, rates)
+		if err != nil && !domain.IsValidationError(err) {
+			return nil, err
+		}
+		if usable {
+			discounts = append(discounts, discount)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range order.DiscountCodes {
+		code = domain.NormalizeDiscountCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		discount, err := s.discountRepo.GetByCode(ctx, code)
+		if errors.Is(err, domain.ErrNotFound) || (err == nil && !discount.ValidAt(now)) {
+			return nil, domain.NewValidationError("discount code %q is not valid", code)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.discountUsable(ctx, discount, order, rates); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, *discount)
+	}
+	return discounts, nil
+}
+
+// redeemedDiscounts returns the discounts of the adjustments an order already
+// placed was given, for working them out again when its lines change. Their
+// uses were counted when the order was placed, so only the minimum order
+// value is checked again, and no discount is added. A discount deleted since
+// is dropped.
+func (s *orderService) redeemedDiscounts(ctx context.Context, order *domain.Order, rates domain.ExchangeRates, adjustments []domain.OrderAdjustment) ([]domain.Discount, error) {
+	var discounts []domain.Discount
+	for _, adjustment := range adjustments {
+		if adjustment.DiscountID == nil {
+			continue
+		}
+		discount, err := s.discountRepo.Get(ctx, *adjustment.DiscountID)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := minimumOrderValue(discount, order, rates); err != nil {
+			if discount.Code == "" && domain.IsValidationError(err) {
+				continue
+			}
+			return nil, err
+		}
+		discounts = append(discounts, *discount)
+	}
+	return discounts, nil
+}
+
+// discountUsable checks the discount's limits and minimum order value
+// against the order, with a validation error saying why it can't be used.
+func (s *orderService) discountUsable(ctx context.Context, discount *domain.Discount, order *domain.Order, rates domain.ExchangeRates) (bool, error) {
+	name := discount.Code
+	if name == "" {
+		name = discount.Name
+	}
+
+	if discount.UsedUp() {
+		return false, domain.NewValidationError("discount %q has been used up", name)
+	}
+	if discount.PerCustomerLimit != nil {
+		uses, err := s.discountRepo.CountCustomerUses(ctx, discount.ID, order.CustomerID)
+		if err != nil {
+			return false, err
+		}
+		if uses >= int64(*discount.PerCustomerLimit) {
+			return false, domain.NewValidationError("discount %q can be used %d times per customer", name, *discount.PerCustomerLimit)
+		}
+	}
+	if err := minimumOrderValue(discount, order, rates); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// minimumOrderValue checks the order's subtotal reaches the discount's
+// minimum order value.
+func minimumOrderValue(discount *domain.Discount, order *domain.Order, rates domain.ExchangeRates) error {
+	if discount.MinOrderValue.IsZero() {
+		return nil
+	}
+	minimum, err := rates.Convert(discount.MinOrderValue, order.Currency)
+	if err != nil {
+		return err
+	}
+	if order.Subtotal.Amount < minimum.Amount {
+		name := discount.Code
+		if name == "" {
+			name = discount.Name
+		}
+		return domain.NewValidationError("discount %q needs an order of at least %s", name, minimum)
+	}
+	return nil
+}
+
+// loadLineCategories looks up the categories of the lines' products when a
+// discount is limited to categories.
+func loadLineCategories(ctx context.Context, catalog *orderCatalog, lines []discountLine, discounts []domain.Discount) error {
+	needed := false
+	for _, discount := range discounts {
+		needed = needed || len(discount.CategoryIDs) > 0
+	}
+	if !needed {
+		return nil
+	}
+
+	tree, err := catalog.categories(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range lines {
+		product, err := catalog.product(ctx, lines[i].item.ProductID)
+		if err != nil {
+			return err
+		}
+		lines[i].categories = tree.ancestors(product.CategoryID)
+	}
+	return nil
+}
+
+// discountCovers tells whether the discount applies to the line.
+func discountCovers(discount *domain.Discount, line discountLine) bool {
+	if len(discount.ProductIDs) == 0 && len(discount.CategoryIDs) == 0 {
+		return true
+	}
+	if discount.ProductIDs.Contains(line.item.ProductID) {
+		return true
+	}
+	for _, categoryID := range line.categories {
+		if discount.CategoryIDs.Contains(categoryID) {
+			return true
+		}
+	}
+	return false
+}
+
+// discountAmounts works out what the discount takes off each line, in minor
+// units of the order's currency. No line loses more than it has left.
+func discountAmounts(discount *domain.Discount, lines []discountLine, rates domain.ExchangeRates, currency string) ([]int64, error) {
+	amounts := make([]int64, len(lines))
+	var covered []int
+	for i, line := range lines {
+		if line.remaining > 0 && discountCovers(discount, line) {
+			covered = append(covered, i)
+		}
+	}
+	if len(covered) == 0 {
+		return amounts, nil
+	}
+
+	switch discount.Type {
+	case domain.DiscountPercentage:
+		for _, i := range covered {
+			amounts[i] = domain.NewMoney(lines[i].remaining, currency).Scale(int64(discount.PercentOff), 100).Amount
+		}
+
+	case domain.DiscountFixedAmount:
+		off, err := rates.Convert(discount.AmountOff, currency)
+		if err != nil {
+			return nil, err
+		}
+		spreadAmount(off.Amount, lines, covered, amounts)
+
+	case domain.DiscountBuyXGetY:
+		buyXGetY(discount, lines, covered, amounts, currency)
+	}
+	return amounts, nil
+}
+
+// spreadAmount shares amount out over the covered lines in proportion to what
+// they have left. The shares are rounded down and the minor units left over
+// go one each to the lines in order, so they add up to amount exactly, or to
+// all that is left when that is less.
+func spreadAmount(amount int64, lines []discountLine, covered []int, amounts []int64) {
+	var left int64
+	for _, i := range covered {
+		left += lines[i].remaining
+	}
+	if amount > left {
+		amount = left
+	}
+
+	var given int64
+	for _, i := range covered {
+		amounts[i] = amount * lines[i].remaining / left
+		given += amounts[i]
+	}
+	for given < amount {
+		for _, i := range covered {
+			if given < amount && amounts[i] < lines[i].remaining {
+				amounts[i]++
+				given++
+			}
+		}
+	}
+}
+
+// buyXGetY discounts GetQuantity of every BuyQuantity+GetQuantity covered
+// units, the cheapest ones.
+func buyXGetY(discount *domain.Discount, lines []discountLine, covered []int, amounts []int64, currency string) {
+	unitPrice := func(i int) int64 {
+		return lines[i].remaining / int64(lines[i].item.Quantity)
+	}
+
+	var cheapest []int
+	units := 0
+	for _, i := range covered {
+		if lines[i].item.Quantity > 0 {
+			cheapest = append(cheapest, i)
+			units += lines[i].item.Quantity
+		}
+	}
+	sort.SliceStable(cheapest, func(a, b int) bool { return unitPrice(cheapest[a]) < unitPrice(cheapest[b]) })
+
+	free := units / (discount.BuyQuantity + discount.GetQuantity) * discount.GetQuantity
+	for _, i := range cheapest {
+		if free == 0 {
+			break
+		}
+		quantity := lines[i].item.Quantity
+		if quantity > free {
+			quantity = free
+		}
+		free -= quantity
+		amounts[i] = domain.NewMoney(unitPrice(i), currency).Mul(int64(quantity)).Scale(int64(discount.PercentOff), 100).Amount
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+func (s *orderService) CancelOrder(ctx context.Context, id uint, reason string) (*domain.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.CancelOrder")
+	defer span.End()
+
+	order, err := s.orderRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == domain.OrderCancelled {
+		return nil, domain.ErrOrderCancelled
+	}
+
+	// The customer may be paying for it right now
+	payments, err := s.paymentService.ListOrderPayments(ctx, order.CustomerID, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		if payment.Status == domain.PaymentPending {
+			return nil, domain.ErrPaymentPending
+		}
+	}
+	before := orderSnapshot(order)
+
+	// A paid order gets back everything not refunded yet, whose stock goes
+	// back with the refund. Nothing was taken for an unpaid one, only its
+	// stock goes back.
+	var refund *domain.OrderRefund
+	restock := make(map[uint]int)
+	if paidFor(order) {
+		refund, restock, err = s.prepareRefund(order, domain.RefundRequest{Reason: reason, Restock: true})
+		if errors.Is(err, domain.ErrNothingToRefund) {
+			refund, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, item := range order.Items {
+			if item.VariantID != nil && item.Quantity > item.RefundedQuantity {
+				restock[*item.VariantID] += item.Quantity - item.RefundedQuantity
+			}
+		}
+	}
+	if refund != nil {
+		if err := s.payBack(ctx, refund); err != nil {
+			return nil, err
+		}
+	}
+
+	cancelledAt := s.now()
+	order.Status = domain.OrderCancelled
+	order.CancelledAt = &cancelledAt
+	order.CancelReason = reason
+	if err := s.saveRefund(ctx, order, refund, restock); err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityOrder, order.ID, before, order)
+
+	subject := fmt.Sprintf("Order %d cancelled", order.ID)
+	message := fmt.Sprintf("Your order %d has been cancelled", order.ID)
+	if reason != "" {
+		message += ": " + reason
+	}
+	message += "."
+	if refund != nil {
+		message += fmt.Sprintf(" %s is being refunded to you.", refund.Amount)
+	}
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, order.CustomerID, subject, message)
+	return order, nil
+}
+
+func (s *orderService) RefundOrder(ctx context.Context, id uint, request domain.RefundRequest) (*domain.OrderRefund, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.RefundOrder")
+	defer span.End()
+
+	order, err := s.orderRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == domain.OrderCancelled {
+		return nil, domain.ErrOrderCancelled
+	}
+	if order.PaymentStatus == domain.OrderRefunded {
+		return nil, domain.ErrNothingToRefund
+	}
+	if !paidFor(order) {
+		return nil, domain.ErrOrderNotPaid
+	}
+	before := orderSnapshot(order)
+
+	refund, restock, err := s.prepareRefund(order, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.payBack(ctx, refund); err != nil {
+		return nil, err
+	}
+	if err := s.saveRefund(ctx, order, refund, restock); err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityOrder, order.ID, before, order)
+
+	subject := fmt.Sprintf("Refund for order %d", order.ID)
+	message := fmt.Sprintf("We are refunding %s for order %d", refund.Amount, order.ID)
+	if request.StoreCredit {
+		message = fmt.Sprintf("We have added %s of store credit to your account for order %d", refund.Amount, order.ID)
+	}
+	if request.Reason != "" {
+		message += ": " + request.Reason
+	}
+	message += "."
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, order.CustomerID, subject, message)
+	return refund, nil
+}
+
+func (s *orderService) ArchiveOrder(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.ArchiveOrder")
+	defer span.End()
+
+	order, err := s.orderRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if order.ArchivedAt != nil {
+		return nil
+	}
+	before := orderSnapshot(order)
+
+	archivedAt := s.now()
+	if err := s.orderRepo.Archive(ctx, id, archivedAt); err != nil {
+		return err
+	}
+	order.ArchivedAt = &archivedAt
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityOrder, id, before, order)
+	return nil
+}
+
+// prepareRefund works out the refund request asks for and applies it to the
+// order, its lines' refunded quantities, its refunded total and its payment
+// status. It returns the refund with the units to put back into stock by
+// variant, products without variants don't track stock.
+func (s *orderService) prepareRefund(order *domain.Order, request domain.RefundRequest) (*domain.OrderRefund, map[uint]int, error) {
+	currency := order.TotalPrice.Currency
+	refund := &domain.OrderRefund{
+		OrderID:   order.ID,
+		Shipping:  domain.NewMoney(0, currency),
+		Amount:    domain.NewMoney(0, currency),
+		Reason:    request.Reason,
+		Restocked: request.Restock,
+	}
+	if request.StoreCredit {
+		refund.Provider = domain.StoreCreditProvider
+	}
+
+	lines := request.Lines
+	everything := len(lines) == 0
+	if everything {
+		for _, item := range order.Items {
+			if item.Quantity > item.RefundedQuantity {
+				lines = append(lines, domain.RefundLineRequest{OrderItemID: item.ID, Quantity: item.Quantity - item.RefundedQuantity})
+			}
+		}
+	}
+
+	restock := make(map[uint]int)
+	seen := make(map[uint]bool, len(lines))
+	for _, line := range lines {
+		item := orderItem(order, line.OrderItemID)
+		if item == nil {
+			return nil, nil, domain.NewValidationError("order %d has no item %d", order.ID, line.OrderItemID)
+		}
+		if seen[item.ID] {
+			return nil, nil, domain.NewValidationError("order item %d is listed twice", item.ID)
+		}
+		seen[item.ID] = true
+		if line.Quantity <= 0 {
+			return nil, nil, domain.NewValidationError("quantity to refund of order item %d must be positive", item.ID)
+		}
+		if line.Quantity > item.Quantity-item.RefundedQuantity {
+			return nil, nil, domain.NewValidationError("only %d of order item %d can still be refunded", item.Quantity-item.RefundedQuantity, item.ID)
+		}
+
+		amount := item.RefundFor(line.Quantity, order.PricesIncludeTax)
+		item.RefundedQuantity += line.Quantity
+		refund.Lines = append(refund.Lines, domain.OrderRefundLine{OrderItemID: item.ID, Quantity: line.Quantity, Amount: amount})
+		refund.Amount.Amount += amount.Amount
+		if request.Restock && item.VariantID != nil {
+			restock[*item.VariantID] += line.Quantity
+		}
+	}
+	if everything && order.ShippingCost.Amount > 0 && !order.ShippingRefunded() {
+		refund.Shipping = order.ShippingCost
+		refund.Amount.Amount += order.ShippingCost.Amount
+	}
+	if len(refund.Lines) == 0 && refund.Shipping.Amount == 0 {
+		return nil, nil, domain.ErrNothingToRefund
+	}
+
+	if order.RefundedTotal.Currency == "" {
+		order.RefundedTotal = domain.NewMoney(0, currency)
+	}
+	// Rounding never pays back more than was paid
+	if left := order.TotalPrice.Amount - order.RefundedTotal.Amount; refund.Amount.Amount > left {
+		refund.Amount.Amount = left
+	}
+	order.RefundedTotal.Amount += refund.Amount.Amount
+	order.PaymentStatus = domain.OrderPartiallyRefunded
+	if order.RefundedTotal.Amount >= order.TotalPrice.Amount {
+		order.PaymentStatus = domain.OrderRefunded
+	}
+	return refund, restock, nil
+}
+
+// payBack sends the refund's money back through the payment provider. A
+// refund of nothing, free lines, needs no provider and neither does store
+// credit, which is given when the refund is saved.
+func (s *orderService) payBack(ctx context.Context, refund *domain.OrderRefund) error {
+	if refund.Amount.Amount == 0 || refund.Provider == domain.StoreCreditProvider {
+		refund.Status = domain.RefundSucceeded
+		return nil
+	}
+	return s.paymentService.RefundPayment(ctx, refund)
+}
+
+// saveRefund saves the order with its refund. The provider already has the
+// refund when saving fails, so that is logged loudly for staff to record it
+// by hand.
+func (s *orderService) saveRefund(ctx context.Context, order *domain.Order, refund *domain.OrderRefund, restock map[uint]int) error {
+	if err := s.orderRepo.SaveRefund(ctx, order, refund, restock); err != nil {
+		if refund != nil && refund.ProviderRef != nil {
+			log.Printf("orders: refund %s of %s for order %d went to %s but wasn't saved: %v", *refund.ProviderRef, refund.Amount, order.ID, refund.Provider, err)
+		}
+		return err
+	}
+	if refund != nil {
+		order.Refunds = append(order.Refunds, *refund)
+	}
+	return nil
+}
+
+// paidFor reports whether the customer paid for the order and hasn't had
+// all of it back.
+func paidFor(order *domain.Order) bool {
+	return order.PaymentStatus == domain.OrderPaid || order.PaymentStatus == domain.OrderPartiallyRefunded
+}
+
+func orderItem(order *domain.Order, id uint) *domain.OrderItem {
+	for i := range order.Items {
+		if order.Items[i].ID == id {
+			return &order.Items[i]
+		}
+	}
+	return nil
+}
+
+// orderSnapshot copies the order for the audit log before its lines and
+// refunds are changed in place.
+func orderSnapshot(order *domain.Order) *domain.Order {
+	before := *order
+	before.Items = append([]domain.OrderItem(nil), order.Items...)
+	before.Refunds = append([]domain.OrderRefund(nil), order.Refunds...)
+	return &before
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
@@ -12,14 +16,41 @@ import (
 type orderService struct {
 	orderRepo        ports.OrderRepository
 	productRepo      ports.ProductRepository
+	priceRepo        ports.ProductPriceRepository
 	notificationRepo ports.NotificationRepository
+	addressRepo      ports.AddressRepository
+	auditRepo        ports.AuditRepository
+	rateRepo         ports.ExchangeRateRepository
+	discountRepo     ports.DiscountRepository
+	categoryRepo     ports.CategoryRepository
+	taxRepo          ports.TaxRepository
+	shippingRepo     ports.ShippingRepository
+	customerRepo     ports.CustomerRepository
+	paymentService   ports.PaymentService
+	pricesIncludeTax bool
+	now              func() time.Time
 }
 
-func NewOrderService(orderRepo ports.OrderRepository, productRepo ports.ProductRepository, notificationRepo ports.NotificationRepository) ports.OrderService {
+// NewOrderService taxes orders on the understanding that catalog prices
+// include tax when pricesIncludeTax is set, and that it comes on top otherwise.
+// Refunds of paid orders go through paymentService.
+func NewOrderService(orderRepo ports.OrderRepository, productRepo ports.ProductRepository, priceRepo ports.ProductPriceRepository, notificationRepo ports.NotificationRepository, addressRepo ports.AddressRepository, auditRepo ports.AuditRepository, rateRepo ports.ExchangeRateRepository, discountRepo ports.DiscountRepository, categoryRepo ports.CategoryRepository, taxRepo ports.TaxRepository, shippingRepo ports.ShippingRepository, customerRepo ports.CustomerRepository, paymentService ports.PaymentService, pricesIncludeTax bool) ports.OrderService {
 	return &orderService{
 		orderRepo:        orderRepo,
 		productRepo:      productRepo,
+		priceRepo:        priceRepo,
 		notificationRepo: notificationRepo,
+		addressRepo:      addressRepo,
+		auditRepo:        auditRepo,
+		rateRepo:         rateRepo,
+		discountRepo:     discountRepo,
+		categoryRepo:     categoryRepo,
+		taxRepo:          taxRepo,
+		shippingRepo:     shippingRepo,
+		customerRepo:     customerRepo,
+		paymentService:   paymentService,
+		pricesIncludeTax: pricesIncludeTax,
+		now:              time.Now,
 	}
 }
 
@@ -32,17 +63,37 @@ func (s *orderService) CreateOrder(ctx context.Context, order *domain.Order) err
 		return fmt.Errorf("email not found in context")
 	}
 
-	order, err := s.orderRepo.Create(ctx, order)
+	// Copy the address onto the order so editing the address book later leaves it untouched
+	address, err := resolveShippingAddress(ctx, s.addressRepo, order.CustomerID, order.ShippingAddressID)
 	if err != nil {
 		return err
 	}
-	// Send message to admin of order creation and send message to customer
-	message := fmt.Sprintf("New Order Created!\n\nOrder ID: %d\nTotal Price: %.2f", order.ID, order.TotalPrice)
+	order.ShippingAddressID = &address.ID
+	order.ShippingAddress = address.Snapshot()
+
+	if err := s.chargeInCurrency(ctx, order); err != nil {
+		return err
+	}
+	order.PaymentStatus = domain.OrderUnpaid
+	order.PaidAt = nil
+	order.Status = domain.OrderPlaced
+	order.RefundedTotal = domain.NewMoney(0, order.TotalPrice.Currency)
 
-	err = s.notificationRepo.SendEmail(ctx, message, "New Order Purchased", email)
+	order, err = s.orderRepo.Create(ctx, order)
 	if err != nil {
 		return err
 	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityOrder, order.ID, nil, order)
+
+	// Send message to admin of order creation and send message to customer
+	message := fmt.Sprintf("New Order Created!\n\nOrder ID: %d\nTotal Price: %s", order.ID, order.TotalPrice)
+
+	// The order is placed by now, a confirmation that doesn't go out must not
+	// report it as failed or trying again would place it twice
+	if err := s.notificationRepo.SendEmail(ctx, message, "New Order Purchased", email); err != nil {
+		span.RecordError(err)
+		log.Printf("orders: failed to send the confirmation of order %d: %v", order.ID, err)
+	}
 
 	// Send customer message notifying of orde creation
 	// err = s.notificationRepo.SendSms(ctx, []string{"+254700000000"}, "Sil", "Order has been created")
@@ -53,6 +104,87 @@ func (s *orderService) CreateOrder(ctx context.Context, order *domain.Order) err
 	return nil
 }
 
+// chargeInCurrency prices the items and then charges for shipping them.
+func (s *orderService) chargeInCurrency(ctx context.Context, order *domain.Order) error {
+	rates, catalog, err := s.priceItems(ctx, order)
+	if err != nil {
+		return err
+	}
+	return s.applyShipping(ctx, order, rates, catalog)
+}
+
+// priceItems converts the items to the currency the order is charged in, the
+// items' own currency when none was asked for, and records the rate. The
+// discounts and then the tax are worked out on the converted items, so the
+// subtotal, the adjustments, the tax and the total always add up.
+func (s *orderService) priceItems(ctx context.Context, order *domain.Order) (domain.ExchangeRates, *orderCatalog, error) {
+	order.Currency = strings.ToUpper(order.Currency)
+	if order.Currency == "" && len(order.Items) > 0 {
+		order.Currency = order.Items[0].Price.Currency
+	}
+	if order.Currency == "" {
+		order.Currency = domain.DefaultCurrency
+	}
+
+	rates, err := currentRates(ctx, s.rateRepo, s.now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.ExchangeRate, err = rates.Rate(order.Currency); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		if item.Price, err = rates.Convert(item.Price, order.Currency); err != nil {
+			return nil, nil, err
+		}
+	}
+	catalog := &orderCatalog{orderRepo: s.orderRepo, categoryRepo: s.categoryRepo}
+	if err := s.applyDiscounts(ctx, order, rates, catalog); err != nil {
+		return nil, nil, err
+	}
+	if err := s.applyTax(ctx, order, catalog); err != nil {
+		return nil, nil, err
+	}
+	return rates, catalog, nil
+}
+
+// orderCatalog loads the ordered products and the category tree once, and
+// only when a discount, tax or shipping rule needs more than the items say.
+type orderCatalog struct {
+	orderRepo    ports.OrderRepository
+	categoryRepo ports.CategoryRepository
+	products     map[uint]*domain.Product
+	tree         *categoryTree
+}
+
+func (c *orderCatalog) product(ctx context.Context, id uint) (*domain.Product, error) {
+	if product, ok := c.products[id]; ok {
+		return product, nil
+	}
+	product, err := c.orderRepo.GetOrderProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.products == nil {
+		c.products = make(map[uint]*domain.Product)
+	}
+	c.products[id] = product
+	return product, nil
+}
+
+func (c *orderCatalog) categories(ctx context.Context) (*categoryTree, error) {
+	if c.tree == nil {
+		tree, err := loadCategoryTree(ctx, c.categoryRepo)
+		if err != nil {
+			return nil, err
+		}
+		c.tree = tree
+	}
+	return c.tree, nil
+}
+
 func (s *orderService) ListOrders(ctx context.Context) ([]domain.Order, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "OrderService.ListOrders")
 	defer span.End()
@@ -60,31 +192,68 @@ func (s *orderService) ListOrders(ctx context.Context) ([]domain.Order, error) {
 	return s.orderRepo.List(ctx)
 }
 
-func (s *orderService) GetOrder(ctx context.Context, id uint) (*domain.Order, error) {
-	ctx, span := otel.Tracer("").Start(ctx, "OrderService.GetOrder")
+func (s *orderService) ListCustomerOrders(ctx context.Context, customerID uint) ([]domain.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.ListCustomerOrders")
 	defer span.End()
 
-	return s.orderRepo.Get(ctx, id)
+	orders, err := s.orderRepo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []domain.Order{}
+	}
+	return orders, nil
 }
 
-func (s *orderService) UpdateOrder(ctx context.Context, order *domain.Order) error {
-	ctx, span := otel.Tracer("").Start(ctx, "OrderService.UpdateOrder")
+func (s *orderService) GetOrder(ctx context.Context, id uint) (*domain.Order, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.GetOrder")
 	defer span.End()
 
-	return s.orderRepo.Update(ctx, order)
+	return s.orderRepo.Get(ctx, id)
 }
 
-// create a delete order route that delete orders items forthat order then it deletes the order after
-func (s *orderService) DeleteOrder(ctx context.Context, id uint) error {
-	ctx, span := otel.Tracer("").Start(ctx, "OrderService.DeleteOrder")
+func (s *orderService) GetOrderProduct(ctx context.Context, productID uint) (*domain.Product, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.GetOrderProduct")
 	defer span.End()
 
-	return s.orderRepo.Delete(ctx, id)
+	product, err := s.orderRepo.GetOrderProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The product's price column lags scheduled changes until the scheduler
+	// runs, the price history is always current
+	price, err := s.priceRepo.PriceAt(ctx, productID, s.now())
+	if err == nil {
+		product.Price = price.Price
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	return product, nil
 }
 
-func (s *orderService) GetOrderProduct(ctx context.Context, productID uint) (*domain.Product, error) {
-	ctx, span := otel.Tracer("").Start(ctx, "OrderService.GetOrderProduct")
+func (s *orderService) GetOrderVariant(ctx context.Context, productID uint, variantID *uint) (*domain.ProductVariant, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.GetOrderVariant")
 	defer span.End()
 
-	return s.orderRepo.GetOrderProduct(ctx, productID)
+	if variantID == nil {
+		hasVariants, err := s.orderRepo.HasVariants(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		if hasVariants {
+			return nil, domain.ErrVariantRequired
+		}
+		return nil, nil
+	}
+
+	variant, err := s.orderRepo.GetOrderVariant(ctx, *variantID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && variant.ProductID != productID) {
+		return nil, domain.NewValidationError("variant %d is not a variant of product %d", *variantID, productID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return variant, nil
 }
@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"testing"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -32,13 +35,18 @@ func (m *MockOrderRepository) Get(ctx context.Context, id uint) (*domain.Order,
 	return args.Get(0).(*domain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) Update(ctx context.Context, order *domain.Order) error {
-	args := m.Called(ctx, order)
+func (m *MockOrderRepository) Amend(ctx context.Context, order *domain.Order, amendment *domain.OrderAmendment, stock map[uint]int) error {
+	args := m.Called(ctx, order, amendment, stock)
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) Delete(ctx context.Context, id uint) error {
-	args := m.Called(ctx, id)
+func (m *MockOrderRepository) SaveRefund(ctx context.Context, order *domain.Order, refund *domain.OrderRefund, restock map[uint]int) error {
+	args := m.Called(ctx, order, refund, restock)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) Archive(ctx context.Context, id uint, at time.Time) error {
+	args := m.Called(ctx, id, at)
 	return args.Error(0)
 }
 
@@ -47,6 +55,11 @@ func (m *MockOrderRepository) DeleteOrderItems(ctx context.Context, orderID uint
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) ListByCustomer(ctx context.Context, customerID uint) ([]domain.Order, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]domain.Order), args.Error(1)
+}
+
 func (m *MockOrderRepository) GetOrderProduct(ctx context.Context, productID uint) (*domain.Product, error) {
 	args := m.Called(ctx, productID)
 	if args.Get(0) == nil {
@@ -54,3 +67,433 @@ func (m *MockOrderRepository) GetOrderProduct(ctx context.Context, productID uin
 	}
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
+
+func (m *MockOrderRepository) GetOrderVariant(ctx context.Context, variantID uint) (*domain.ProductVariant, error) {
+	args := m.Called(ctx, variantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductVariant), args.Error(1)
+}
+
+func (m *MockOrderRepository) HasVariants(ctx context.Context, productID uint) (bool, error) {
+	args := m.Called(ctx, productID)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestOrderService_CreateOrder_SnapshotsDefaultAddress(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockAddressRepo := new(MockAddressRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+	service := NewOrderService(mockOrderRepo, new(MockProductRepository), new(MockProductPriceRepository), mockNotificationRepo, mockAddressRepo, newMockAuditRepository(), newMockExchangeRateRepository(), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true)
+
+	address := &domain.Address{ID: 3, CustomerID: 1, RecipientName: "Jane", Line1: "Moi Avenue", City: "Nairobi", Country: "KE"}
+	order := &domain.Order{CustomerID: 1}
+	mockAddressRepo.On("GetDefault", mock.Anything, uint(1)).Return(address, nil)
+	mockOrderRepo.On("Create", mock.Anything, order).Return(order, nil)
+	mockNotificationRepo.On("SendEmail", mock.Anything, mock.Anything, mock.Anything, "jane@example.com").Return(nil)
+
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	err := service.CreateOrder(ctx, order)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), *order.ShippingAddressID)
+	assert.Equal(t, "Moi Avenue", order.ShippingAddress.Line1)
+
+	// Editing the address book afterwards must not reach the order
+	address.Line1 = "Kenyatta Avenue"
+	assert.Equal(t, "Moi Avenue", order.ShippingAddress.Line1)
+}
+
+func TestOrderService_CreateOrder_ChargesInCurrency(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockAddressRepo := new(MockAddressRepository)
+	mockNotificationRepo := new(MockNotificationRepository)
+	service := NewOrderService(mockOrderRepo, new(MockProductRepository), new(MockProductPriceRepository), mockNotificationRepo, mockAddressRepo, newMockAuditRepository(), newMockExchangeRateRepository(usdRate("129.45")), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true).(*orderService)
+	service.now = func() time.Time { return rateTestNow }
+
+	mockAddressRepo.On("GetDefault", mock.Anything, uint(1)).Return(&domain.Address{ID: 3, CustomerID: 1}, nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.Anything).Return(&domain.Order{ID: 9}, nil)
+	mockNotificationRepo.On("SendEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+
+	order := &domain.Order{CustomerID: 1, Currency: "usd", Items: []domain.OrderItem{
+		{ProductID: 1, Quantity: 1, Price: kes(129450)},
+		{ProductID: 2, Quantity: 2, Price: kes(51780)},
+		{ProductID: 3, Quantity: 1, Price: kes(1000)},
+	}}
+	err := service.CreateOrder(ctx, order)
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", order.Currency)
+	assert.Equal(t, domain.Rate("129.45"), order.ExchangeRate)
+	assert.Equal(t, domain.NewMoney(1000, "USD"), order.Items[0].Price)
+	assert.Equal(t, domain.NewMoney(400, "USD"), order.Items[1].Price)
+	assert.Equal(t, domain.NewMoney(8, "USD"), order.Items[2].Price)
+	// The total is the sum of the rounded lines
+	assert.Equal(t, domain.NewMoney(1408, "USD"), order.TotalPrice)
+
+	// Without a currency the order is charged in its prices' currency
+	order = &domain.Order{CustomerID: 1, Items: []domain.OrderItem{{ProductID: 1, Quantity: 1, Price: kes(129450)}}}
+	err = service.CreateOrder(ctx, order)
+	assert.NoError(t, err)
+	assert.Equal(t, "KES", order.Currency)
+	assert.Equal(t, domain.Rate("1"), order.ExchangeRate)
+	assert.Equal(t, kes(129450), order.TotalPrice)
+
+	// A currency without a rate can't be charged in
+	order = &domain.Order{CustomerID: 1, Currency: "EUR", Items: []domain.OrderItem{{ProductID: 1, Quantity: 1, Price: kes(129450)}}}
+	err = service.CreateOrder(ctx, order)
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+	mockOrderRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestOrderService_CreateOrder_RequiresAddress(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockAddressRepo := new(MockAddressRepository)
+	service := NewOrderService(mockOrderRepo, new(MockProductRepository), new(MockProductPriceRepository), new(MockNotificationRepository), mockAddressRepo, newMockAuditRepository(), newMockExchangeRateRepository(), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true)
+
+	mockAddressRepo.On("GetDefault", mock.Anything, uint(1)).Return(nil, domain.ErrNotFound)
+
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	err := service.CreateOrder(ctx, &domain.Order{CustomerID: 1})
+	assert.ErrorIs(t, err, ErrNoShippingAddress)
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_GetOrderVariant(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	service := NewOrderService(mockOrderRepo, new(MockProductRepository), new(MockProductPriceRepository), new(MockNotificationRepository), new(MockAddressRepository), newMockAuditRepository(), newMockExchangeRateRepository(), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true)
+
+	mockOrderRepo.On("HasVariants", mock.Anything, uint(1)).Return(true, nil)
+	mockOrderRepo.On("HasVariants", mock.Anything, uint(2)).Return(false, nil)
+	mockOrderRepo.On("GetOrderVariant", mock.Anything, uint(5)).Return(&domain.ProductVariant{ID: 5, ProductID: 1}, nil)
+
+	// A product with variants can't be ordered without picking one
+	_, err := service.GetOrderVariant(context.Background(), 1, nil)
+	assert.ErrorIs(t, err, domain.ErrVariantRequired)
+
+	variant, err := service.GetOrderVariant(context.Background(), 2, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, variant)
+
+	variantID := uint(5)
+	variant, err = service.GetOrderVariant(context.Background(), 1, &variantID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), variant.ID)
+
+	// The variant has to belong to the product being ordered
+	_, err = service.GetOrderVariant(context.Background(), 2, &variantID)
+	assert.True(t, domain.IsValidationError(err))
+}
+
+// refundTestOrder is paymentTestOrder paid: three units of variant 7 at
+// KES 1,000 with KES 300 off, a KES 200 line without variants and KES 500
+// of shipping.
+func refundTestOrder() *domain.Order {
+	order := paymentTestOrder()
+	order.PaymentStatus = domain.OrderPaid
+	order.Items = []domain.OrderItem{
+		{ID: 1, OrderID: 5, VariantID: uintPtr(7), Quantity: 3, Price: kes(300000), Discount: kes(30000)},
+		{ID: 2, OrderID: 5, Quantity: 1, Price: kes(20000), Discount: kes(0)},
+	}
+	order.ShippingCost = kes(50000)
+	order.RefundedTotal = kes(0)
+	return order
+}
+
+// newRefundTestService refunds the order through the fake gateway, to which
+// it was paid with payment intent 1.
+func newRefundTestService(order *domain.Order) (*orderService, *MockOrderRepository, *MockNotificationRepository) {
+	paymentRepo := new(MockPaymentRepository)
+	paymentRepo.On("ListByOrder", mock.Anything, uint(5)).Return([]domain.PaymentIntent{
+		{ID: 1, OrderID: 5, Provider: "fake", Amount: order.TotalPrice, Phone: "+254712345678", Status: domain.PaymentSucceeded},
+	}, nil)
+	payments, notificationRepo := newPaymentTestService(paymentRepo, order)
+
+	orderRepo := new(MockOrderRepository)
+	orderRepo.On("Get", mock.Anything, uint(5)).Return(order, nil)
+	service := NewOrderService(orderRepo, new(MockProductRepository), new(MockProductPriceRepository), notificationRepo, new(MockAddressRepository), newMockAuditRepository(), newMockExchangeRateRepository(), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), payments.customerRepo, payments, true).(*orderService)
+	service.now = func() time.Time { return rateTestNow }
+	return service, orderRepo, notificationRepo
+}
+
+func TestOrderService_ListCustomerOrders(t *testing.T) {
+	order := refundTestOrder()
+	service, orderRepo, _ := newRefundTestService(order)
+	orderRepo.On("ListByCustomer", mock.Anything, uint(1)).Return([]domain.Order{*order}, nil)
+	orderRepo.On("ListByCustomer", mock.Anything, uint(2)).Return([]domain.Order(nil), nil)
+
+	orders, err := service.ListCustomerOrders(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	orders, err = service.ListCustomerOrders(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.NotNil(t, orders)
+	orderRepo.AssertNotCalled(t, "List", mock.Anything)
+}
+
+func TestOrderService_RefundOrder_Lines(t *testing.T) {
+	order := refundTestOrder()
+	service, orderRepo, notificationRepo := newRefundTestService(order)
+	orderRepo.On("SaveRefund", mock.Anything, order, mock.Anything, map[uint]int{7: 1}).Return(nil)
+
+	refund, err := service.RefundOrder(context.Background(), 5, domain.RefundRequest{
+		Lines:   []domain.RefundLineRequest{{OrderItemID: 1, Quantity: 1}},
+		Reason:  "Arrived broken",
+		Restock: true,
+	})
+	assert.NoError(t, err)
+	// A third of what was paid for the line, after its discount
+	assert.Equal(t, kes(90000), refund.Amount)
+	assert.Equal(t, kes(0), refund.Shipping)
+	assert.Equal(t, domain.RefundSucceeded, refund.Status)
+	assert.Equal(t, uint(1), *refund.PaymentIntentID)
+	assert.Contains(t, *refund.ProviderRef, "fake_refund_")
+	assert.Equal(t, 1, order.Items[0].RefundedQuantity)
+	assert.Equal(t, kes(90000), order.RefundedTotal)
+	assert.Equal(t, domain.OrderPartiallyRefunded, order.PaymentStatus)
+	assert.Len(t, order.Refunds, 1)
+	notificationRepo.AssertCalled(t, "SendEmail", mock.Anything, "We are refunding KES 900.00 for order 5: Arrived broken.", "Refund for order 5", "jane@example.com")
+
+	// Only the two units left can still be refunded
+	_, err = service.RefundOrder(context.Background(), 5, domain.RefundRequest{Lines: []domain.RefundLineRequest{{OrderItemID: 1, Quantity: 3}}})
+	assert.True(t, domain.IsValidationError(err))
+	_, err = service.RefundOrder(context.Background(), 5, domain.RefundRequest{Lines: []domain.RefundLineRequest{{OrderItemID: 9, Quantity: 1}}})
+	assert.True(t, domain.IsValidationError(err))
+}
+
+func TestOrderService_RefundOrder_Everything(t *testing.T) {
+	order := refundTestOrder()
+	order.Items[0].RefundedQuantity = 1
+	order.RefundedTotal = kes(90000)
+	order.PaymentStatus = domain.OrderPartiallyRefunded
+	service, orderRepo, _ := newRefundTestService(order)
+	// Without restocking nothing goes back into stock
+	orderRepo.On("SaveRefund", mock.Anything, order, mock.Anything, map[uint]int{}).Return(nil)
+
+	refund, err := service.RefundOrder(context.Background(), 5, domain.RefundRequest{Reason: "Goodwill"})
+	assert.NoError(t, err)
+	assert.Equal(t, kes(250000), refund.Amount)
+	assert.Equal(t, kes(50000), refund.Shipping)
+	assert.Len(t, refund.Lines, 2)
+	assert.Equal(t, kes(340000), order.RefundedTotal)
+	assert.Equal(t, domain.OrderRefunded, order.PaymentStatus)
+
+	_, err = service.RefundOrder(context.Background(), 5, domain.RefundRequest{})
+	assert.ErrorIs(t, err, domain.ErrNothingToRefund)
+}
+
+func TestOrderService_RefundOrder_Unpaid(t *testing.T) {
+	order := refundTestOrder()
+	order.PaymentStatus = domain.OrderUnpaid
+	service, orderRepo, _ := newRefundTestService(order)
+
+	_, err := service.RefundOrder(context.Background(), 5, domain.RefundRequest{})
+	assert.ErrorIs(t, err, domain.ErrOrderNotPaid)
+	orderRepo.AssertNotCalled(t, "SaveRefund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderItem_RefundForAddsUp(t *testing.T) {
+	item := domain.OrderItem{Quantity: 3, Price: kes(100000), Discount: kes(0), Tax: kes(16000)}
+
+	var refunded int64
+	for _, want := range []int64{38667, 38666, 38667} {
+		amount := item.RefundFor(1, false)
+		assert.Equal(t, want, amount.Amount)
+		refunded += amount.Amount
+		item.RefundedQuantity++
+	}
+	assert.Equal(t, item.PaidFor(false).Amount, refunded)
+}
+
+func TestOrderService_CancelOrder(t *testing.T) {
+	t.Run("unpaid", func(t *testing.T) {
+		order := refundTestOrder()
+		order.PaymentStatus = domain.OrderUnpaid
+		service, orderRepo, notificationRepo := newRefundTestService(order)
+		orderRepo.On("SaveRefund", mock.Anything, order, (*domain.OrderRefund)(nil), map[uint]int{7: 3}).Return(nil)
+
+		cancelled, err := service.CancelOrder(context.Background(), 5, "Out of stock at the warehouse")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, cancelled.Status)
+		assert.Equal(t, rateTestNow, *cancelled.CancelledAt)
+		assert.Equal(t, domain.OrderUnpaid, cancelled.PaymentStatus)
+		assert.Empty(t, cancelled.Refunds)
+		notificationRepo.AssertCalled(t, "SendEmail", mock.Anything, "Your order 5 has been cancelled: Out of stock at the warehouse.", "Order 5 cancelled", "jane@example.com")
+
+		_, err = service.CancelOrder(context.Background(), 5, "Again")
+		assert.ErrorIs(t, err, domain.ErrOrderCancelled)
+	})
+
+	t.Run("paid", func(t *testing.T) {
+		order := refundTestOrder()
+		service, orderRepo, _ := newRefundTestService(order)
+		orderRepo.On("SaveRefund", mock.Anything, order, mock.Anything, map[uint]int{7: 3}).Return(nil)
+
+		cancelled, err := service.CancelOrder(context.Background(), 5, "Customer changed their mind")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, cancelled.Status)
+		assert.Equal(t, domain.OrderRefunded, cancelled.PaymentStatus)
+		assert.Len(t, cancelled.Refunds, 1)
+		assert.Equal(t, kes(340000), cancelled.Refunds[0].Amount)
+		assert.True(t, cancelled.Refunds[0].Restocked)
+
+		_, err = service.RefundOrder(context.Background(), 5, domain.RefundRequest{})
+		assert.ErrorIs(t, err, domain.ErrOrderCancelled)
+	})
+
+	t.Run("payment pending", func(t *testing.T) {
+		order := refundTestOrder()
+		order.PaymentStatus = domain.OrderUnpaid
+		service, orderRepo, _ := newAmendTestService(order, []domain.PaymentIntent{{ID: 1, OrderID: 5, Status: domain.PaymentPending}})
+
+		_, err := service.CancelOrder(context.Background(), 5, "Out of stock at the warehouse")
+		assert.ErrorIs(t, err, domain.ErrPaymentPending)
+		assert.NotEqual(t, domain.OrderCancelled, order.Status)
+		orderRepo.AssertNotCalled(t, "SaveRefund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderService_GetOrderProduct(t *testing.T) {
+	orderRepo := new(MockOrderRepository)
+	orderRepo.On("GetOrderProduct", mock.Anything, uint(4)).Return(&domain.Product{ID: 4, Price: kes(30000)}, nil)
+	// The price changed at the service's now, the product's column doesn't show it yet
+	priceRepo := new(MockProductPriceRepository)
+	priceRepo.On("PriceAt", mock.Anything, uint(4), rateTestNow).Return(&domain.ProductPrice{ProductID: 4, Price: kes(25000), EffectiveFrom: rateTestNow}, nil)
+
+	service := NewOrderService(orderRepo, new(MockProductRepository), priceRepo, new(MockNotificationRepository), new(MockAddressRepository), newMockAuditRepository(), newMockExchangeRateRepository(), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true).(*orderService)
+	service.now = func() time.Time { return rateTestNow }
+
+	product, err := service.GetOrderProduct(context.Background(), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, kes(25000), product.Price)
+	priceRepo.AssertExpectations(t)
+}
+
+func TestOrderService_ArchiveOrder(t *testing.T) {
+	order := refundTestOrder()
+	service, orderRepo, _ := newRefundTestService(order)
+	orderRepo.On("Archive", mock.Anything, uint(5), rateTestNow).Return(nil).Once()
+
+	assert.NoError(t, service.ArchiveOrder(context.Background(), 5))
+	assert.Equal(t, rateTestNow, *order.ArchivedAt)
+
+	// Archiving again changes nothing
+	assert.NoError(t, service.ArchiveOrder(context.Background(), 5))
+	orderRepo.AssertExpectations(t)
+}
+
+// amendTestOrder is an unpaid order of two units of variant 7 of product 1
+// and one of product 2, with code SAVE10 taking 10% off.
+func amendTestOrder() *domain.Order {
+	return &domain.Order{
+		ID: 5, CustomerID: 1, Currency: "KES", ExchangeRate: "1",
+		Status: domain.OrderPlaced, PaymentStatus: domain.OrderUnpaid,
+		Items: []domain.OrderItem{
+			{ID: 1, OrderID: 5, ProductID: 1, VariantID: uintPtr(7), Quantity: 2, Price: kes(200000), Discount: kes(20000)},
+			{ID: 2, OrderID: 5, ProductID: 2, Quantity: 1, Price: kes(50000), Discount: kes(5000)},
+		},
+		Adjustments: []domain.OrderAdjustment{
+			{ID: 1, OrderID: 5, Type: domain.AdjustmentDiscount, DiscountID: uintPtr(3), Code: "SAVE10", Amount: kes(-25000)},
+		},
+		Subtotal:      kes(250000),
+		DiscountTotal: kes(25000),
+		TotalPrice:    kes(225000),
+	}
+}
+
+func newAmendTestService(order *domain.Order, payments []domain.PaymentIntent) (*orderService, *MockOrderRepository, *MockNotificationRepository) {
+	paymentRepo := new(MockPaymentRepository)
+	paymentRepo.On("ListByOrder", mock.Anything, uint(5)).Return(payments, nil)
+	paymentService, notificationRepo := newPaymentTestService(paymentRepo, order)
+
+	orderRepo := new(MockOrderRepository)
+	orderRepo.On("Get", mock.Anything, uint(5)).Return(order, nil)
+	orderRepo.On("GetOrderProduct", mock.Anything, uint(4)).Return(&domain.Product{ID: 4, Price: kes(30000)}, nil)
+	orderRepo.On("HasVariants", mock.Anything, uint(4)).Return(false, nil)
+	priceRepo := new(MockProductPriceRepository)
+	priceRepo.On("PriceAt", mock.Anything, uint(4), mock.Anything).Return(nil, domain.ErrNotFound)
+	discountRepo := newMockDiscountRepository()
+	discountRepo.On("Get", mock.Anything, uint(3)).Return(&domain.Discount{ID: 3, Code: "SAVE10", Name: "Ten off", Type: domain.DiscountPercentage, PercentOff: 10, UsageLimit: intPtr(1), TimesUsed: 1}, nil)
+
+	service := NewOrderService(orderRepo, new(MockProductRepository), priceRepo, notificationRepo, new(MockAddressRepository), newMockAuditRepository(), newMockExchangeRateRepository(), discountRepo, new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), paymentService.customerRepo, paymentService, true).(*orderService)
+	service.now = func() time.Time { return rateTestNow }
+	return service, orderRepo, notificationRepo
+}
+
+func TestOrderService_AmendOrder(t *testing.T) {
+	order := amendTestOrder()
+	service, orderRepo, notificationRepo := newAmendTestService(order, []domain.PaymentIntent{{ID: 1, OrderID: 5, Status: domain.PaymentCancelled}})
+	var amendment *domain.OrderAmendment
+	orderRepo.On("Amend", mock.Anything, order, mock.Anything, map[uint]int{7: 1}).Run(func(args mock.Arguments) {
+		amendment = args.Get(2).(*domain.OrderAmendment)
+	}).Return(nil)
+
+	amended, err := service.AmendOrder(context.Background(), 5, domain.AmendRequest{
+		Lines: []domain.AmendLineRequest{
+			{ProductID: 1, VariantID: uintPtr(7), Quantity: 3},
+			{ProductID: 4, Quantity: 1},
+		},
+		Reason: "Customer called",
+	})
+	assert.NoError(t, err)
+
+	// The kept line keeps its unit price, the new one is priced now, and the
+	// used-up code the order already had is worked out again
+	assert.Len(t, amended.Items, 2)
+	assert.Equal(t, uint(1), amended.Items[0].ID)
+	assert.Equal(t, kes(300000), amended.Items[0].Price)
+	assert.Equal(t, kes(30000), amended.Items[1].Price)
+	assert.Equal(t, kes(330000), amended.Subtotal)
+	assert.Equal(t, kes(33000), amended.DiscountTotal)
+	assert.Equal(t, kes(297000), amended.TotalPrice)
+	assert.Len(t, amended.Adjustments, 1)
+	assert.Equal(t, domain.Rate("1"), amended.ExchangeRate)
+
+	assert.Equal(t, kes(225000), amendment.PreviousTotal)
+	assert.Equal(t, kes(297000), amendment.NewTotal)
+	assert.Equal(t, []domain.OrderAmendmentLine{
+		{OrderItemID: 1, ProductID: 1, VariantID: uintPtr(7), PreviousQuantity: 2, Quantity: 3, PreviousPrice: kes(200000), Price: kes(300000)},
+		{ProductID: 4, Quantity: 1, PreviousPrice: kes(0), Price: kes(30000)},
+		{OrderItemID: 2, ProductID: 2, PreviousQuantity: 1, PreviousPrice: kes(50000), Price: kes(0)},
+	}, amendment.Lines)
+	assert.Len(t, amended.Amendments, 1)
+	notificationRepo.AssertCalled(t, "SendEmail", mock.Anything, "Your order 5 has been changed: Customer called. The new total is KES 2970.00.", "Order 5 changed", "jane@example.com")
+}
+
+func TestOrderService_AmendOrder_Refused(t *testing.T) {
+	request := domain.AmendRequest{Lines: []domain.AmendLineRequest{{ProductID: 1, VariantID: uintPtr(7), Quantity: 1}}}
+
+	t.Run("paid", func(t *testing.T) {
+		order := amendTestOrder()
+		order.PaymentStatus = domain.OrderPaid
+		service, _, _ := newAmendTestService(order, nil)
+		_, err := service.AmendOrder(context.Background(), 5, request)
+		assert.ErrorIs(t, err, domain.ErrOrderNotAmendable)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		order := amendTestOrder()
+		order.Status = domain.OrderCancelled
+		service, _, _ := newAmendTestService(order, nil)
+		_, err := service.AmendOrder(context.Background(), 5, request)
+		assert.ErrorIs(t, err, domain.ErrOrderCancelled)
+	})
+
+	t.Run("payment pending", func(t *testing.T) {
+		service, _, _ := newAmendTestService(amendTestOrder(), []domain.PaymentIntent{{ID: 1, OrderID: 5, Status: domain.PaymentPending}})
+		_, err := service.AmendOrder(context.Background(), 5, request)
+		assert.ErrorIs(t, err, domain.ErrPaymentPending)
+	})
+
+	t.Run("invalid lines", func(t *testing.T) {
+		service, orderRepo, _ := newAmendTestService(amendTestOrder(), nil)
+		_, err := service.AmendOrder(context.Background(), 5, domain.AmendRequest{})
+		assert.True(t, domain.IsValidationError(err))
+		_, err = service.AmendOrder(context.Background(), 5, domain.AmendRequest{Lines: []domain.AmendLineRequest{
+			{ProductID: 2, Quantity: 1},
+			{ProductID: 2, Quantity: 2},
+		}})
+		assert.True(t, domain.IsValidationError(err))
+		orderRepo.AssertNotCalled(t, "Amend", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
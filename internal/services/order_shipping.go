@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+)
+
+func (s *orderService) QuoteShipping(ctx context.Context, order *domain.Order) ([]domain.ShippingQuote, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OrderService.QuoteShipping")
+	defer span.End()
+
+	if len(order.Items) == 0 {
+		return nil, domain.NewValidationError("the order has no items")
+	}
+	address := order.ShippingAddress
+	if address.County == "" && address.Country == "" && order.CustomerID != 0 {
+		book, err := resolveShippingAddress(ctx, s.addressRepo, order.CustomerID, order.ShippingAddressID)
+		if err != nil {
+			return nil, err
+		}
+		order.ShippingAddress = book.Snapshot()
+	}
+
+	rates, catalog, err := s.priceItems(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := s.shippingRepo.ListMethods(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := []domain.ShippingQuote{}
+	for i := range methods {
+		quote, ok, err := s.quoteMethod(ctx, order, &methods[i], rates, catalog)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			quotes = append(quotes, quote)
+		}
+	}
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].Cost.Amount < quotes[j].Cost.Amount
+	})
+	return quotes, nil
+}
+
+// applyShipping charges the method the customer chose for delivering the
+// order to its address and adds the cost to the total. An order can go
+// without a method only while no method is active.
+func (s *orderService) applyShipping(ctx context.Context, order *domain.Order, rates domain.ExchangeRates, catalog *orderCatalog) error {
+	order.ShippingMethodName = ""
+	order.ShippingCost = domain.NewMoney(0, order.Currency)
+
+	if order.ShippingMethodID == nil {
+		methods, err := s.shippingRepo.ListMethods(ctx, true)
+		if err != nil {
+			return err
+		}
+		if len(methods) > 0 {
+			return domain.ErrShippingMethodRequired
+		}
+		return nil
+	}
+
+	method, err := s.shippingRepo.GetMethod(ctx, *order.ShippingMethodID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && !method.Active) {
+		return domain.NewValidationError("shipping method %d is not available", *order.ShippingMethodID)
+	}
+	if err != nil {
+		return err
+	}
+
+	quote, ok, err := s.quoteMethod(ctx, order, method, rates, catalog)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if method.NeedsWeight() {
+			return domain.NewValidationError("%s can't carry this order, it is too heavy", method.Name)
+		}
+		return domain.NewValidationError("%s doesn't deliver to this address", method.Name)
+	}
+
+	order.ShippingMethodName = method.Name
+	order.ShippingCost = quote.Cost
+	order.TotalPrice.Amount += quote.Cost.Amount
+	return nil
+}
+
+// quoteMethod works out what the method charges for the order in its
+// currency. ok is false when the method can't deliver it.
+func (s *orderService) quoteMethod(ctx context.Context, order *domain.Order, method *domain.ShippingMethod, rates domain.ExchangeRates, catalog *orderCatalog) (domain.ShippingQuote, bool, error) {
+	weight := 0
+	if method.NeedsWeight() {
+		var err error
+		if weight, err = catalog.weight(ctx, order.Items); err != nil {
+			return domain.ShippingQuote{}, false, err
+		}
+	}
+	cost, zone, ok := method.Cost(order.ShippingAddress.County, weight)
+	if !ok {
+		return domain.ShippingQuote{}, false, nil
+	}
+
+	quote := domain.ShippingQuote{
+		MethodID:    method.ID,
+		Name:        method.Name,
+		Description: method.Description,
+		Type:        method.Type,
+		Zone:        zone,
+	}
+	var err error
+	if quote.Cost, err = rates.Convert(cost, order.Currency); err != nil {
+		return domain.ShippingQuote{}, false, err
+	}
+
+	// The threshold is met by what the items cost after discounts
+	if !method.FreeOver.IsZero() {
+		threshold, err := rates.Convert(method.FreeOver, order.Currency)
+		if err != nil {
+			return domain.ShippingQuote{}, false, err
+		}
+		goods, err := order.Subtotal.Sub(order.DiscountTotal)
+		if err != nil {
+			return domain.ShippingQuote{}, false, err
+		}
+		if goods.Amount >= threshold.Amount {
+			quote.Free = true
+			quote.Cost.Amount = 0
+		}
+	}
+	return quote, true, nil
+}
+
+// weight is what the items weigh for shipping, in grams.
+func (c *orderCatalog) weight(ctx context.Context, items []domain.OrderItem) (int, error) {
+	total := 0
+	for _, item := range items {
+		product, err := c.product(ctx, item.ProductID)
+		if err != nil {
+			return 0, err
+		}
+		total += product.ShippingWeight() * item.Quantity
+	}
+	return total, nil
+}
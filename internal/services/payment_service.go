@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultPaymentTimeout = 3 * time.Minute
+	// paymentQueryDelay is how long a callback gets before the reconciler
+	// asks the provider instead.
+	paymentQueryDelay = time.Minute
+	// reconcileBatch caps the intents one reconciliation run asks about.
+	reconcileBatch = 100
+)
+
+type paymentService struct {
+	paymentRepo      ports.PaymentRepository
+	orderRepo        ports.OrderRepository
+	customerRepo     ports.CustomerRepository
+	notificationRepo ports.NotificationRepository
+	auditRepo        ports.AuditRepository
+	gateway          ports.PaymentGateway
+	timeout          time.Duration
+	now              func() time.Time
+}
+
+// NewPaymentService gives customers timeout to answer a payment request
+// before it is given up on, 3 minutes when timeout is 0.
+func NewPaymentService(paymentRepo ports.PaymentRepository, orderRepo ports.OrderRepository, customerRepo ports.CustomerRepository, notificationRepo ports.NotificationRepository, auditRepo ports.AuditRepository, gateway ports.PaymentGateway, timeout time.Duration) ports.PaymentService {
+	if timeout <= 0 {
+		timeout = defaultPaymentTimeout
+	}
+	return &paymentService{
+		paymentRepo:      paymentRepo,
+		orderRepo:        orderRepo,
+		customerRepo:     customerRepo,
+		notificationRepo: notificationRepo,
+		auditRepo:        auditRepo,
+		gateway:          gateway,
+		timeout:          timeout,
+		now:              time.Now,
+	}
+}
+
+func (s *paymentService) StartPayment(ctx context.Context, customerID, orderID uint, phone string) (*domain.PaymentIntent, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentService.StartPayment")
+	defer span.End()
+
+	order, err := s.customerOrder(ctx, customerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == domain.OrderCancelled {
+		return nil, domain.ErrOrderCancelled
+	}
+	if order.PaymentStatus != domain.OrderUnpaid {
+		return nil, domain.ErrOrderAlreadyPaid
+	}
+	if order.TotalPrice.Amount <= 0 {
+		return nil, domain.NewValidationError("the order has nothing to pay")
+	}
+
+	// A request the customer may still answer blocks another, one past its
+	// time is settled first in case the customer paid after all
+	intents, err := s.paymentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range intents {
+		intent := &intents[i]
+		if intent.Status != domain.PaymentPending {
+			continue
+		}
+		if s.now().Before(intent.ExpiresAt) {
+			return nil, domain.ErrPaymentPending
+		}
+		if _, err := s.reconcile(ctx, intent); err != nil {
+			return nil, err
+		}
+		if intent.Status == domain.PaymentSucceeded {
+			return nil, domain.ErrOrderAlreadyPaid
+		}
+	}
+
+	if phone == "" {
+		customer, err := s.customerRepo.GetCustomer(ctx, customerID)
+		if err != nil {
+			return nil, err
+		}
+		phone = customer.Phone
+	}
+	phone = normalizePhone(phone)
+	if !phonePattern.MatchString(phone) {
+		return nil, ErrInvalidPhone
+	}
+
+	intent := &domain.PaymentIntent{
+		OrderID:   order.ID,
+		Provider:  s.gateway.Provider(),
+		Amount:    order.TotalPrice,
+		Phone:     phone,
+		Status:    domain.PaymentPending,
+		ExpiresAt: s.now().Add(s.timeout),
+	}
+	if err := s.paymentRepo.CreateIntent(ctx, intent); err != nil {
+		return nil, err
+	}
+
+	ref, err := s.gateway.RequestPayment(ctx, intent)
+	if err != nil {
+		// The request never reached the customer, so the intent is over
+		completedAt := s.now()
+		intent.Status = domain.PaymentFailed
+		intent.FailureReason = err.Error()
+		intent.CompletedAt = &completedAt
+		if updateErr := s.paymentRepo.UpdateIntent(ctx, intent); updateErr != nil {
+			trace.SpanFromContext(ctx).RecordError(updateErr)
+		}
+		return nil, err
+	}
+	intent.ProviderRef = &ref
+	if err := s.paymentRepo.UpdateIntent(ctx, intent); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityPayment, intent.ID, nil, intent)
+	return intent, nil
+}
+
+func (s *paymentService) ListOrderPayments(ctx context.Context, customerID, orderID uint) ([]domain.PaymentIntent, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentService.ListOrderPayments")
+	defer span.End()
+
+	if _, err := s.customerOrder(ctx, customerID, orderID); err != nil {
+		return nil, err
+	}
+	intents, err := s.paymentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if intents == nil {
+		intents = []domain.PaymentIntent{}
+	}
+	return intents, nil
+}
+
+func (s *paymentService) HandleCallback(ctx context.Context, token string, body []byte) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentService.HandleCallback")
+	defer span.End()
+
+	result, err := s.gateway.ParseCallback(ctx, token, body)
+	if err != nil {
+		return err
+	}
+	if result.Refund {
+		return s.settleRefund(ctx, result)
+	}
+	intent, err := s.paymentRepo.GetIntentByRef(ctx, s.gateway.Provider(), result.ProviderRef)
+	if err != nil {
+		return err
+	}
+	_, err = s.settle(ctx, intent, result)
+	return err
+}
+
+func (s *paymentService) ReconcilePayments(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentService.ReconcilePayments")
+	defer span.End()
+
+	intents, err := s.paymentRepo.ListPending(ctx, s.now().Add(-paymentQueryDelay), reconcileBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	// One provider hiccup shouldn't hold up the rest, they're retried next run
+	settled := 0
+	for i := range intents {
+		ok, err := s.reconcile(ctx, &intents[i])
+		if err != nil {
+			log.Printf("payments: failed to reconcile payment intent %d: %v", intents[i].ID, err)
+			continue
+		}
+		if ok {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+func (s *paymentService) RefundPayment(ctx context.Context, refund *domain.OrderRefund) error {
+	ctx, span := otel.Tracer("").Start(ctx, "PaymentService.RefundPayment")
+	defer span.End()
+
+	intents, err := s.paymentRepo.ListByOrder(ctx, refund.OrderID)
+	if err != nil {
+		return err
+	}
+	var paid *domain.PaymentIntent
+	for i := range intents {
+		if intents[i].Status == domain.PaymentSucceeded {
+			paid = &intents[i]
+		}
+	}
+	if paid == nil {
+		return domain.NewValidationError("order %d has no payment to refund", refund.OrderID)
+	}
+	if paid.Provider != s.gateway.Provider() {
+		return domain.NewValidationError("order %d was paid with %s, refund the customer by hand", refund.OrderID, paid.Provider)
+	}
+
+	result, err := s.gateway.Refund(ctx, paid, refund.Amount, refund.Reason)
+	if err != nil {
+		return err
+	}
+	ref := result.ProviderRef
+	refund.PaymentIntentID = &paid.ID
+	refund.Provider = paid.Provider
+	refund.ProviderRef = &ref
+	refund.Status = domain.RefundPending
+	switch result.Status {
+	case domain.PaymentSucceeded:
+		refund.Status = domain.RefundSucceeded
+	case domain.PaymentPending:
+	default:
+		refund.Status = domain.RefundFailed
+		refund.FailureReason = result.Reason
+	}
+	return nil
+}
+
+// settleRefund records what the provider reported about a refund. A refund
+// that failed is left for staff to pay by hand, the order still counts it
+// as refunded.
+func (s *paymentService) settleRefund(ctx context.Context, result *domain.PaymentResult) error {
+	status := domain.RefundSucceeded
+	if result.Status != domain.PaymentSucceeded {
+		status = domain.RefundFailed
+	}
+	settled, err := s.paymentRepo.SettleRefund(ctx, s.gateway.Provider(), result.ProviderRef, status, result.Reason)
+	if err != nil {
+		return err
+	}
+	if settled && status == domain.RefundFailed {
+		log.Printf("payments: refund %s failed, pay it by hand: %s", result.ProviderRef, result.Reason)
+	}
+	return nil
+}
+
+// reconcile asks the provider about a pending intent and settles it when
+// there is an answer. An intent past its time with no answer times out, and
+// so does one the provider never got.
+func (s *paymentService) reconcile(ctx context.Context, intent *domain.PaymentIntent) (bool, error) {
+	result := &domain.PaymentResult{Status: domain.PaymentPending}
+	if intent.ProviderRef != nil {
+		var err error
+		if result, err = s.gateway.QueryPayment(ctx, intent); err != nil {
+			return false, err
+		}
+	}
+	if result.Status == domain.PaymentPending && !s.now().Before(intent.ExpiresAt) {
+		result = &domain.PaymentResult{Status: domain.PaymentTimedOut, Reason: "no answer from the customer in time"}
+	}
+	return s.settle(ctx, intent, result)
+}
+
+// settle records the outcome the provider reported for the intent, marking
+// the order paid when it succeeded, and lets the customer know. Results that
+// change nothing, a repeat or a failure reported after the intent ended, are
+// ignored. A success is applied even to an intent given up on, the customer
+// has paid. It reports whether the intent was settled.
+func (s *paymentService) settle(ctx context.Context, intent *domain.PaymentIntent, result *domain.PaymentResult) (bool, error) {
+	if !result.Status.Final() || intent.Status == domain.PaymentSucceeded {
+		return false, nil
+	}
+	if intent.Status.Final() && result.Status != domain.PaymentSucceeded {
+		return false, nil
+	}
+
+	before := *intent
+	completedAt := s.now()
+	intent.Status = result.Status
+	intent.Receipt = result.Receipt
+	intent.FailureReason = result.Reason
+	intent.CompletedAt = &completedAt
+	if result.Status == domain.PaymentSucceeded && (result.Amount.Currency != intent.Amount.Currency || result.Amount.Amount < intent.Amount.Amount) {
+		// Paying less than the order's total, or in another currency, doesn't
+		// pay for it, the receipt is kept for refunding the customer
+		intent.Status = domain.PaymentFailed
+		intent.FailureReason = fmt.Sprintf("paid %s of %s", result.Amount, intent.Amount)
+	}
+
+	settled, err := s.paymentRepo.Settle(ctx, intent)
+	if err != nil || !settled {
+		return false, err
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityPayment, intent.ID, &before, intent)
+	s.notifyPayment(ctx, intent)
+	return true, nil
+}
+
+// notifyPayment tells the customer how their payment went. It never fails
+// the payment, a message that doesn't go out is only reported on the span.
+// A payment for an order cancelled meanwhile is left for staff to refund.
+func (s *paymentService) notifyPayment(ctx context.Context, intent *domain.PaymentIntent) {
+	order, err := s.orderRepo.Get(ctx, intent.OrderID)
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		return
+	}
+
+	subject := fmt.Sprintf("Payment for order %d received", order.ID)
+	message := fmt.Sprintf("We have received your payment of %s for order %d.", intent.Amount, order.ID)
+	if intent.Status == domain.PaymentSucceeded && order.Status == domain.OrderCancelled {
+		// The order was cancelled while the customer was paying, the payment
+		// left it unpaid and has to be given back
+		log.Printf("payments: payment %d came in for cancelled order %d, refund %s by hand", intent.ID, order.ID, intent.Amount)
+		subject = fmt.Sprintf("Payment for order %d will be refunded", order.ID)
+		message = fmt.Sprintf("We have received your payment of %s for order %d, but the order was cancelled. We will refund it.", intent.Amount, order.ID)
+	}
+	if intent.Receipt != "" {
+		message += fmt.Sprintf(" Receipt: %s.", intent.Receipt)
+	}
+	if intent.Status != domain.PaymentSucceeded {
+		subject = fmt.Sprintf("Payment for order %d didn't go through", order.ID)
+		message = fmt.Sprintf("Your payment of %s for order %d didn't go through", intent.Amount, order.ID)
+		if intent.FailureReason != "" {
+			message += ": " + intent.FailureReason
+		}
+		message += ". You can try again from your order."
+	}
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, order.CustomerID, subject, message)
+}
+
+// customerOrder returns the order when it belongs to the customer, and
+// domain.ErrNotFound otherwise so others can't tell it exists.
+func (s *paymentService) customerOrder(ctx context.Context, customerID, orderID uint) (*domain.Order, error) {
+	order, err := s.orderRepo.Get(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, domain.ErrNotFound
+	}
+	return order, nil
+}
+
+// RunPaymentReconciler settles stuck payment intents every interval until ctx
+// is done. Settling is idempotent, so several instances can run it at once.
+func RunPaymentReconciler(ctx context.Context, payments ports.PaymentService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		settled, err := payments.ReconcilePayments(ctx)
+		if err != nil {
+			log.Printf("payments: failed to reconcile payments: %v", err)
+		} else if settled > 0 {
+			log.Printf("payments: settled %d stuck payments", settled)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/adapters/payment"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPaymentRepository struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRepository) CreateIntent(ctx context.Context, intent *domain.PaymentIntent) error {
+	args := m.Called(ctx, intent)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) GetIntentByRef(ctx context.Context, provider, ref string) (*domain.PaymentIntent, error) {
+	args := m.Called(ctx, provider, ref)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentIntent), args.Error(1)
+}
+
+func (m *MockPaymentRepository) ListByOrder(ctx context.Context, orderID uint) ([]domain.PaymentIntent, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).([]domain.PaymentIntent), args.Error(1)
+}
+
+func (m *MockPaymentRepository) ListPending(ctx context.Context, createdBefore time.Time, limit int) ([]domain.PaymentIntent, error) {
+	args := m.Called(ctx, createdBefore, limit)
+	return args.Get(0).([]domain.PaymentIntent), args.Error(1)
+}
+
+func (m *MockPaymentRepository) UpdateIntent(ctx context.Context, intent *domain.PaymentIntent) error {
+	args := m.Called(ctx, intent)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) Settle(ctx context.Context, intent *domain.PaymentIntent) (bool, error) {
+	args := m.Called(ctx, intent)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentRepository) SettleRefund(ctx context.Context, provider, ref string, status domain.RefundStatus, reason string) (bool, error) {
+	args := m.Called(ctx, provider, ref, status, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+// newPaymentTestService collects payment for customer 1's order 5 of
+// KES 3,400 through the fake gateway, which never calls back.
+func newPaymentTestService(paymentRepo *MockPaymentRepository, order *domain.Order) (*paymentService, *MockNotificationRepository) {
+	orderRepo := new(MockOrderRepository)
+	orderRepo.On("Get", mock.Anything, uint(5)).Return(order, nil)
+
+	customerRepo := new(MockCustomerRepository)
+	customerRepo.On("GetCustomer", mock.Anything, uint(1)).Return(&domain.Customer{
+		ID:          1,
+		Email:       "jane@example.com",
+		Phone:       "+254712345678",
+		Preferences: domain.CustomerPreferences{EmailNotifications: true},
+	}, nil)
+
+	notificationRepo := new(MockNotificationRepository)
+	notificationRepo.On("SendEmail", mock.Anything, mock.Anything, mock.Anything, "jane@example.com").Return(nil)
+
+	gateway := payment.NewFakeGateway("", "secret", 0)
+	service := NewPaymentService(paymentRepo, orderRepo, customerRepo, notificationRepo, newMockAuditRepository(), gateway, 0).(*paymentService)
+	service.now = func() time.Time { return rateTestNow }
+	return service, notificationRepo
+}
+
+func paymentTestOrder() *domain.Order {
+	return &domain.Order{ID: 5, CustomerID: 1, TotalPrice: kes(340000), PaymentStatus: domain.OrderUnpaid}
+}
+
+func fakeCallbackBody(t *testing.T, ref string, status domain.PaymentStatus, amount domain.Money) []byte {
+	body, err := json.Marshal(map[string]interface{}{
+		"reference": ref,
+		"status":    status,
+		"amount":    amount,
+		"receipt":   "SGR7ABC123",
+	})
+	assert.NoError(t, err)
+	return body
+}
+
+func TestPaymentService_StartPayment(t *testing.T) {
+	mockPaymentRepo := new(MockPaymentRepository)
+	service, _ := newPaymentTestService(mockPaymentRepo, paymentTestOrder())
+
+	mockPaymentRepo.On("ListByOrder", mock.Anything, uint(5)).Return([]domain.PaymentIntent{
+		{ID: 1, OrderID: 5, Status: domain.PaymentCancelled},
+	}, nil).Once()
+	mockPaymentRepo.On("CreateIntent", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.PaymentIntent).ID = 2
+	}).Return(nil)
+	mockPaymentRepo.On("UpdateIntent", mock.Anything, mock.Anything).Return(nil)
+
+	intent, err := service.StartPayment(context.Background(), 1, 5, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentPending, intent.Status)
+	assert.Equal(t, "fake", intent.Provider)
+	assert.Equal(t, "+254712345678", intent.Phone)
+	assert.Equal(t, kes(340000), intent.Amount)
+	assert.Equal(t, rateTestNow.Add(defaultPaymentTimeout), intent.ExpiresAt)
+	if assert.NotNil(t, intent.ProviderRef) {
+		assert.NotEmpty(t, *intent.ProviderRef)
+	}
+
+	// Another customer's order doesn't exist to them
+	_, err = service.StartPayment(context.Background(), 2, 5, "")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// The customer is still being asked to pay
+	mockPaymentRepo.On("ListByOrder", mock.Anything, uint(5)).Return([]domain.PaymentIntent{
+		{ID: 2, OrderID: 5, Status: domain.PaymentPending, ExpiresAt: rateTestNow.Add(time.Minute)},
+	}, nil).Once()
+	_, err = service.StartPayment(context.Background(), 1, 5, "0712 345 678")
+	assert.ErrorIs(t, err, domain.ErrPaymentPending)
+
+	mockPaymentRepo.On("ListByOrder", mock.Anything, uint(5)).Return([]domain.PaymentIntent{}, nil).Once()
+	_, err = service.StartPayment(context.Background(), 1, 5, "0712 345 678")
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+
+	paid := paymentTestOrder()
+	paid.PaymentStatus = domain.OrderPaid
+	service, _ = newPaymentTestService(mockPaymentRepo, paid)
+	_, err = service.StartPayment(context.Background(), 1, 5, "")
+	assert.ErrorIs(t, err, domain.ErrOrderAlreadyPaid)
+	mockPaymentRepo.AssertNumberOfCalls(t, "CreateIntent", 1)
+}
+
+func TestPaymentService_HandleCallback(t *testing.T) {
+	ref := "fake_0123456789abcdef"
+	pending := func() *domain.PaymentIntent {
+		return &domain.PaymentIntent{ID: 2, OrderID: 5, Provider: "fake", ProviderRef: &ref, Amount: kes(340000), Status: domain.PaymentPending}
+	}
+
+	t.Run("paid", func(t *testing.T) {
+		mockPaymentRepo := new(MockPaymentRepository)
+		service, notificationRepo := newPaymentTestService(mockPaymentRepo, paymentTestOrder())
+		mockPaymentRepo.On("GetIntentByRef", mock.Anything, "fake", ref).Return(pending(), nil).Once()
+		mockPaymentRepo.On("Settle", mock.Anything, mock.MatchedBy(func(intent *domain.PaymentIntent) bool {
+			return intent.Status == domain.PaymentSucceeded && intent.Receipt == "SGR7ABC123" && intent.CompletedAt != nil
+		})).Return(true, nil).Once()
+
+		err := service.HandleCallback(context.Background(), "secret", fakeCallbackBody(t, ref, domain.PaymentSucceeded, kes(340000)))
+		assert.NoError(t, err)
+		notificationRepo.AssertNumberOfCalls(t, "SendEmail", 1)
+
+		// The same callback again finds the intent already paid and changes nothing
+		succeeded := pending()
+		succeeded.Status = domain.PaymentSucceeded
+		mockPaymentRepo.On("GetIntentByRef", mock.Anything, "fake", ref).Return(succeeded, nil).Once()
+		err = service.HandleCallback(context.Background(), "secret", fakeCallbackBody(t, ref, domain.PaymentSucceeded, kes(340000)))
+		assert.NoError(t, err)
+		mockPaymentRepo.AssertNumberOfCalls(t, "Settle", 1)
+		notificationRepo.AssertNumberOfCalls(t, "SendEmail", 1)
+	})
+
+	t.Run("paid after the order was cancelled", func(t *testing.T) {
+		order := paymentTestOrder()
+		order.Status = domain.OrderCancelled
+		mockPaymentRepo := new(MockPaymentRepository)
+		service, notificationRepo := newPaymentTestService(mockPaymentRepo, order)
+		mockPaymentRepo.On("GetIntentByRef", mock.Anything, "fake", ref).Return(pending(), nil).Once()
+		mockPaymentRepo.On("Settle", mock.Anything, mock.Anything).Return(true, nil).Once()
+
+		err := service.HandleCallback(context.Background(), "secret", fakeCallbackBody(t, ref, domain.PaymentSucceeded, kes(340000)))
+		assert.NoError(t, err)
+		// The order stays cancelled and the customer is told the money is coming back
+		assert.Equal(t, domain.OrderCancelled, order.Status)
+		assert.Equal(t, domain.OrderUnpaid, order.PaymentStatus)
+		notificationRepo.AssertCalled(t, "SendEmail", mock.Anything,
+			"We have received your payment of KES 3400.00 for order 5, but the order was cancelled. We will refund it. Receipt: SGR7ABC123.",
+			"Payment for order 5 will be refunded", "jane@example.com")
+	})
+
+	t.Run("underpaid", func(t *testing.T) {
+		mockPaymentRepo := new(MockPaymentRepository)
+		service, _ := newPaymentTestService(mockPaymentRepo, paymentTestOrder())
+		mockPaymentRepo.On("GetIntentByRef", mock.Anything, "fake", ref).Return(pending(), nil)
+		mockPaymentRepo.On("Settle", mock.Anything, mock.MatchedBy(func(intent *domain.PaymentIntent) bool {
+			return intent.Status == domain.PaymentFailed && intent.FailureReason == "paid KES 100.00 of KES 3400.00"
+		})).Return(true, nil).Once()
+
+		err := service.HandleCallback(context.Background(), "secret", fakeCallbackBody(t, ref, domain.PaymentSucceeded, kes(10000)))
+		assert.NoError(t, err)
+		mockPaymentRepo.AssertExpectations(t)
+	})
+
+	t.Run("paid in another currency", func(t *testing.T) {
+		mockPaymentRepo := new(MockPaymentRepository)
+		service, _ := newPaymentTestService(mockPaymentRepo, paymentTestOrder())
+		mockPaymentRepo.On("GetIntentByRef", mock.Anything, "fake", ref).Return(pending(), nil)
+		mockPaymentRepo.On("Settle", mock.Anything, mock.MatchedBy(func(intent *domain.PaymentIntent) bool {
+			return intent.Status == domain.PaymentFailed && intent.FailureReason == "paid USD 3400.00 of KES 3400.00"
+		})).Return(true, nil).Once()
+
+		err := service.HandleCallback(context.Background(), "secret", fakeCallbackBody(t, ref, domain.PaymentSucceeded, domain.NewMoney(340000, "USD")))
+		assert.NoError(t, err)
+		mockPaymentRepo.AssertExpectations(t)
+	})
+
+	t.Run("cancelled after timing out", func(t *testing.T) {
+		mockPaymentRepo := new(MockPaymentRepository)
+		service, _ := newPaymentTestService(mockPaymentRepo, paymentTestOrder())
+		timedOut := pending()
+		timedOut.Status = domain.PaymentTimedOut
+		mockPaymentRepo.On("GetIntentByRef", mock.Anything, "fake", ref).Return(timedOut, nil)
+
+		err := service.HandleCallback(context.Background(), "secret", fakeCallbackBody(t, ref, domain.PaymentCancelled, domain.Money{}))
+		assert.NoError(t, err)
+		mockPaymentRepo.AssertNotCalled(t, "Settle", mock.Anything, mock.Anything)
+	})
+
+	t.Run("forged", func(t *testing.T) {
+		mockPaymentRepo := new(MockPaymentRepository)
+		service, _ := newPaymentTestService(mockPaymentRepo, paymentTestOrder())
+
+		err := service.HandleCallback(context.Background(), "guess", fakeCallbackBody(t, ref, domain.PaymentSucceeded, kes(340000)))
+		assert.True(t, domain.IsValidationError(err), "got %v", err)
+		mockPaymentRepo.AssertNotCalled(t, "GetIntentByRef", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPaymentService_ReconcilePayments(t *testing.T) {
+	mockPaymentRepo := new(MockPaymentRepository)
+	service, _ := newPaymentTestService(mockPaymentRepo, paymentTestOrder())
+
+	ref := func(s string) *string { return &s }
+	expired := rateTestNow.Add(-time.Minute)
+	mockPaymentRepo.On("ListPending", mock.Anything, rateTestNow.Add(-paymentQueryDelay), reconcileBatch).Return([]domain.PaymentIntent{
+		{ID: 1, OrderID: 5, ProviderRef: ref("fake_1"), Phone: "+254712341032", Amount: kes(340000), Status: domain.PaymentPending, ExpiresAt: expired},
+		{ID: 2, OrderID: 5, ProviderRef: ref("fake_2"), Phone: "+254712340000", Amount: kes(340000), Status: domain.PaymentPending, ExpiresAt: expired},
+		{ID: 3, OrderID: 5, ProviderRef: ref("fake_3"), Phone: "+254712340000", Amount: kes(340000), Status: domain.PaymentPending, ExpiresAt: rateTestNow.Add(time.Minute)},
+		{ID: 4, OrderID: 5, ProviderRef: ref("fake_4"), Phone: "+254712345678", Amount: kes(340000), Status: domain.PaymentPending, ExpiresAt: expired},
+		{ID: 5, OrderID: 5, Phone: "+254712345678", Amount: kes(340000), Status: domain.PaymentPending, ExpiresAt: expired},
+	}, nil)
+	statuses := map[uint]domain.PaymentStatus{}
+	mockPaymentRepo.On("Settle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		intent := args.Get(1).(*domain.PaymentIntent)
+		statuses[intent.ID] = intent.Status
+	}).Return(true, nil)
+
+	settled, err := service.ReconcilePayments(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, settled)
+	// The customer still has a minute to answer the third
+	assert.Equal(t, map[uint]domain.PaymentStatus{
+		1: domain.PaymentCancelled,
+		2: domain.PaymentTimedOut,
+		4: domain.PaymentSucceeded,
+		5: domain.PaymentTimedOut,
+	}, statuses)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type productPriceService struct {
+	productRepo ports.ProductRepository
+	priceRepo   ports.ProductPriceRepository
+	auditRepo   ports.AuditRepository
+	now         func() time.Time
+}
+
+func NewProductPriceService(productRepo ports.ProductRepository, priceRepo ports.ProductPriceRepository, auditRepo ports.AuditRepository) ports.ProductPriceService {
+	return &productPriceService{
+		productRepo: productRepo,
+		priceRepo:   priceRepo,
+		auditRepo:   auditRepo,
+		now:         time.Now,
+	}
+}
+
+func (s *productPriceService) PriceHistory(ctx context.Context, productID uint) ([]domain.ProductPrice, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceService.PriceHistory")
+	defer span.End()
+
+	if _, err := s.productRepo.Get(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.history(ctx, productID)
+}
+
+func (s *productPriceService) SchedulePriceChange(ctx context.Context, productID uint, change domain.PriceChange) ([]domain.ProductPrice, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceService.SchedulePriceChange")
+	defer span.End()
+
+	product, err := s.productRepo.Get(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.Price.Currency == "" {
+		change.Price.Currency = product.Price.Currency
+	}
+	if err := normalizePrice(&change.Price); err != nil {
+		return nil, err
+	}
+	if change.Price.Currency != product.Price.Currency {
+		return nil, domain.NewValidationError("price must be in the product's currency %s", product.Price.Currency)
+	}
+	if !change.EffectiveFrom.After(s.now()) {
+		return nil, domain.NewValidationError("effective_from must be in the future, change the product's price to change it now")
+	}
+	if change.EffectiveTo != nil && !change.EffectiveTo.After(change.EffectiveFrom) {
+		return nil, domain.NewValidationError("effective_to must be after effective_from")
+	}
+
+	// Changes can't overlap, a temporary one must not have another change
+	// starting while it runs
+	history, err := s.history(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, period := range history {
+		starts := period.EffectiveFrom
+		if starts.Equal(change.EffectiveFrom) || change.EffectiveTo != nil && starts.After(change.EffectiveFrom) && !starts.After(*change.EffectiveTo) {
+			return nil, domain.NewValidationError("a price change is already scheduled for %s", starts.Format(time.RFC3339))
+		}
+	}
+
+	if err := s.priceRepo.Schedule(ctx, productID, change); err != nil {
+		return nil, err
+	}
+
+	history, err = s.history(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		if history[i].EffectiveFrom.Equal(change.EffectiveFrom) {
+			recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityPrice, history[i].ID, nil, history[i])
+		}
+	}
+	return history, nil
+}
+
+func (s *productPriceService) CancelPriceChange(ctx context.Context, productID, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceService.CancelPriceChange")
+	defer span.End()
+
+	history, err := s.history(ctx, productID)
+	if err != nil {
+		return err
+	}
+	var period *domain.ProductPrice
+	for i := range history {
+		if history[i].ID == id {
+			period = &history[i]
+		}
+	}
+	if period == nil {
+		return domain.ErrNotFound
+	}
+	if !period.Upcoming {
+		return domain.NewValidationError("only price changes that have not started can be cancelled")
+	}
+
+	if err := s.priceRepo.Cancel(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionDelete, domain.AuditEntityPrice, id, period, nil)
+	return nil
+}
+
+func (s *productPriceService) ApplyDuePrices(ctx context.Context) (int64, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductPriceService.ApplyDuePrices")
+	defer span.End()
+
+	return s.priceRepo.ApplyDue(ctx, s.now())
+}
+
+// history lists the product's periods, flagging the ones yet to start.
+func (s *productPriceService) history(ctx context.Context, productID uint) ([]domain.ProductPrice, error) {
+	history, err := s.priceRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []domain.ProductPrice{}
+	}
+	now := s.now()
+	for i := range history {
+		history[i].Upcoming = history[i].EffectiveFrom.After(now)
+	}
+	return history, nil
+}
+
+// RunPriceScheduler applies scheduled price changes every interval until ctx
+// is done. Applying is idempotent, so several instances can run it at once.
+func RunPriceScheduler(ctx context.Context, prices ports.ProductPriceService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		applied, err := prices.ApplyDuePrices(ctx)
+		if err != nil {
+			log.Printf("prices: failed to apply scheduled prices: %v", err)
+		} else if applied > 0 {
+			log.Printf("prices: applied scheduled prices to %d products", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
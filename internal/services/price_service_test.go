@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductPriceRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductPriceRepository) ListByProduct(ctx context.Context, productID uint) ([]domain.ProductPrice, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).([]domain.ProductPrice), args.Error(1)
+}
+
+func (m *MockProductPriceRepository) PriceAt(ctx context.Context, productID uint, at time.Time) (*domain.ProductPrice, error) {
+	args := m.Called(ctx, productID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductPrice), args.Error(1)
+}
+
+func (m *MockProductPriceRepository) Schedule(ctx context.Context, productID uint, change domain.PriceChange) error {
+	args := m.Called(ctx, productID, change)
+	return args.Error(0)
+}
+
+func (m *MockProductPriceRepository) Cancel(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProductPriceRepository) ApplyDue(ctx context.Context, at time.Time) (int64, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var priceTestNow = time.Date(2024, 11, 20, 9, 0, 0, 0, time.UTC)
+
+func priceTestHistory() []domain.ProductPrice {
+	launched := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	blackFriday := time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)
+	return []domain.ProductPrice{
+		{ID: 1, ProductID: 1, Price: kes(2500), EffectiveFrom: launched, EffectiveTo: &blackFriday},
+		{ID: 2, ProductID: 1, Price: kes(1999), EffectiveFrom: blackFriday},
+	}
+}
+
+func newTestPriceService(productRepo *MockProductRepository, priceRepo *MockProductPriceRepository) *productPriceService {
+	service := NewProductPriceService(productRepo, priceRepo, newMockAuditRepository()).(*productPriceService)
+	service.now = func() time.Time { return priceTestNow }
+	return service
+}
+
+func TestProductPriceService_PriceHistory_FlagsUpcoming(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockPriceRepo := new(MockProductPriceRepository)
+	service := newTestPriceService(mockProductRepo, mockPriceRepo)
+
+	mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, Price: kes(2500)}, nil)
+	mockPriceRepo.On("ListByProduct", mock.Anything, uint(1)).Return(priceTestHistory(), nil)
+
+	history, err := service.PriceHistory(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.False(t, history[0].Upcoming)
+	assert.True(t, history[1].Upcoming)
+}
+
+func TestProductPriceService_SchedulePriceChange(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockPriceRepo := new(MockProductPriceRepository)
+	service := newTestPriceService(mockProductRepo, mockPriceRepo)
+
+	from := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC)
+	change := domain.PriceChange{Price: kes(1800), EffectiveFrom: from, EffectiveTo: &to, Note: "Christmas"}
+	mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, Price: kes(2500)}, nil)
+	mockPriceRepo.On("ListByProduct", mock.Anything, uint(1)).Return(priceTestHistory(), nil)
+	mockPriceRepo.On("Schedule", mock.Anything, uint(1), change).Return(nil)
+
+	_, err := service.SchedulePriceChange(context.Background(), 1, change)
+	assert.NoError(t, err)
+	mockPriceRepo.AssertExpectations(t)
+}
+
+func TestProductPriceService_SchedulePriceChange_Rejects(t *testing.T) {
+	past := priceTestNow.Add(-time.Hour)
+	from := time.Date(2024, 11, 25, 0, 0, 0, 0, time.UTC)
+	overBlackFriday := time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)
+	beforeFrom := from.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		change domain.PriceChange
+	}{
+		{name: "in the past", change: domain.PriceChange{Price: kes(10), EffectiveFrom: past}},
+		{name: "negative", change: domain.PriceChange{Price: kes(-1), EffectiveFrom: from}},
+		{name: "other currency", change: domain.PriceChange{Price: domain.NewMoney(10, "USD"), EffectiveFrom: from}},
+		{name: "ends before it starts", change: domain.PriceChange{Price: kes(10), EffectiveFrom: from, EffectiveTo: &beforeFrom}},
+		{name: "same start as another", change: domain.PriceChange{Price: kes(10), EffectiveFrom: priceTestHistory()[1].EffectiveFrom}},
+		{name: "another starts while it runs", change: domain.PriceChange{Price: kes(10), EffectiveFrom: from, EffectiveTo: &overBlackFriday}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProductRepo := new(MockProductRepository)
+			mockPriceRepo := new(MockProductPriceRepository)
+			service := newTestPriceService(mockProductRepo, mockPriceRepo)
+			mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, Price: kes(2500)}, nil)
+			mockPriceRepo.On("ListByProduct", mock.Anything, uint(1)).Return(priceTestHistory(), nil)
+
+			_, err := service.SchedulePriceChange(context.Background(), 1, tt.change)
+			assert.True(t, domain.IsValidationError(err), "got %v", err)
+			mockPriceRepo.AssertNotCalled(t, "Schedule", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestProductPriceService_CancelPriceChange(t *testing.T) {
+	mockPriceRepo := new(MockProductPriceRepository)
+	service := newTestPriceService(new(MockProductRepository), mockPriceRepo)
+	mockPriceRepo.On("ListByProduct", mock.Anything, uint(1)).Return(priceTestHistory(), nil)
+	mockPriceRepo.On("Cancel", mock.Anything, uint(2)).Return(nil)
+
+	// The price in force now is history, not a change that can be cancelled
+	err := service.CancelPriceChange(context.Background(), 1, 1)
+	assert.True(t, domain.IsValidationError(err))
+
+	err = service.CancelPriceChange(context.Background(), 1, 7)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	err = service.CancelPriceChange(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	mockPriceRepo.AssertExpectations(t)
+}
+
+func TestOrderService_GetOrderProduct_UsesPriceInForce(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockPriceRepo := new(MockProductPriceRepository)
+	service := NewOrderService(mockOrderRepo, new(MockProductRepository), mockPriceRepo, new(MockNotificationRepository), new(MockAddressRepository), newMockAuditRepository(), newMockExchangeRateRepository(), newMockDiscountRepository(), new(MockCategoryRepository), newMockTaxRepository(), newMockShippingRepository(), new(MockCustomerRepository), nil, true)
+
+	// The scheduler has not caught up with the price change yet
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, Price: kes(2500)}, nil)
+	mockPriceRepo.On("PriceAt", mock.Anything, uint(1), mock.AnythingOfType("time.Time")).Return(&domain.ProductPrice{ProductID: 1, Price: kes(1999)}, nil)
+
+	product, err := service.GetOrderProduct(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, kes(1999), product.Price)
+}
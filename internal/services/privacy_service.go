@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrErasureAlreadyRequested = domain.NewValidationError("an erasure request is already waiting for review")
+	ErrErasureNotPending       = domain.NewValidationError("erasure request has already been reviewed")
+)
+
+type privacyService struct {
+	customerRepo        ports.CustomerRepository
+	addressRepo         ports.AddressRepository
+	orderRepo           ports.OrderRepository
+	verificationRepo    ports.PhoneVerificationRepository
+	notificationLogRepo ports.NotificationLogRepository
+	privacyRepo         ports.PrivacyRepository
+	notificationRepo    ports.NotificationRepository
+}
+
+func NewPrivacyService(
+	customerRepo ports.CustomerRepository,
+	addressRepo ports.AddressRepository,
+	orderRepo ports.OrderRepository,
+	verificationRepo ports.PhoneVerificationRepository,
+	notificationLogRepo ports.NotificationLogRepository,
+	privacyRepo ports.PrivacyRepository,
+	notificationRepo ports.NotificationRepository,
+) ports.PrivacyService {
+	return &privacyService{
+		customerRepo:        customerRepo,
+		addressRepo:         addressRepo,
+		orderRepo:           orderRepo,
+		verificationRepo:    verificationRepo,
+		notificationLogRepo: notificationLogRepo,
+		privacyRepo:         privacyRepo,
+		notificationRepo:    notificationRepo,
+	}
+}
+
+func (s *privacyService) ExportCustomerData(ctx context.Context, customerID uint) (*domain.CustomerDataExport, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyService.ExportCustomerData")
+	defer span.End()
+
+	customer, err := s.customerRepo.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.addressRepo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orderRepo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := s.customerRecipients(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := s.notificationLogRepo.ListByRecipients(ctx, recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	erasureRequests, err := s.privacyRepo.ListCustomerErasureRequests(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CustomerDataExport{
+		ExportedAt:      time.Now().UTC(),
+		Customer:        customer,
+		Addresses:       addresses,
+		Orders:          orders,
+		Notifications:   notifications,
+		ErasureRequests: erasureRequests,
+	}, nil
+}
+
+func (s *privacyService) RequestErasure(ctx context.Context, customerID uint, reason string) (*domain.ErasureRequest, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyService.RequestErasure")
+	defer span.End()
+
+	existing, err := s.privacyRepo.ListCustomerErasureRequests(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range existing {
+		if request.Status == domain.ErasureStatusPending {
+			return nil, ErrErasureAlreadyRequested
+		}
+	}
+
+	request := &domain.ErasureRequest{
+		CustomerID: customerID,
+		Status:     domain.ErasureStatusPending,
+		Reason:     strings.TrimSpace(reason),
+	}
+	if err := s.privacyRepo.CreateErasureRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func (s *privacyService) ListErasureRequests(ctx context.Context, status domain.ErasureStatus) ([]domain.ErasureRequest, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyService.ListErasureRequests")
+	defer span.End()
+
+	return s.privacyRepo.ListErasureRequests(ctx, status)
+}
+
+// ApproveErasure anonymizes the customer. The confirmation email is sent first
+// because afterwards there is no address left to send it to.
+func (s *privacyService) ApproveErasure(ctx context.Context, id uint, reviewer string) (*domain.ErasureRequest, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyService.ApproveErasure")
+	defer span.End()
+
+	request, err := s.pendingErasureRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.GetCustomer(ctx, request.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	message := "Your request to erase your personal data has been approved. Your profile, addresses and contact details are being removed. Order amounts are kept for accounting."
+	if err := s.notificationRepo.SendEmail(ctx, message, "Your data erasure request", customer.Email); err != nil {
+		span.RecordError(err)
+	}
+
+	summary, err := s.privacyRepo.AnonymizeCustomer(ctx, request.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	request.Status = domain.ErasureStatusCompleted
+	request.ReviewedBy = reviewer
+	request.ReviewedAt = &now
+	request.CompletedAt = &now
+	request.AddressesDeleted = summary.AddressesDeleted
+	request.OrdersAnonymized = summary.OrdersAnonymized
+	request.NotificationsScrubbed = summary.NotificationsScrubbed
+	if err := s.privacyRepo.UpdateErasureRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func (s *privacyService) RejectErasure(ctx context.Context, id uint, reviewer, note string) (*domain.ErasureRequest, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "PrivacyService.RejectErasure")
+	defer span.End()
+
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, domain.NewValidationError("a note explaining the rejection is required")
+	}
+
+	request, err := s.pendingErasureRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	request.Status = domain.ErasureStatusRejected
+	request.ReviewedBy = reviewer
+	request.ReviewedAt = &now
+	request.ReviewNote = note
+	if err := s.privacyRepo.UpdateErasureRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.GetCustomer(ctx, request.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Your request to erase your personal data could not be completed.\n\nReason: %s", note)
+	if err := s.notificationRepo.SendEmail(ctx, message, "Your data erasure request", customer.Email); err != nil {
+		span.RecordError(err)
+	}
+
+	return request, nil
+}
+
+func (s *privacyService) pendingErasureRequest(ctx context.Context, id uint) (*domain.ErasureRequest, error) {
+	request, err := s.privacyRepo.GetErasureRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != domain.ErasureStatusPending {
+		return nil, ErrErasureNotPending
+	}
+	return request, nil
+}
+
+// customerRecipients lists every address notifications may have gone to,
+// including phone numbers the customer has since replaced.
+func (s *privacyService) customerRecipients(ctx context.Context, customer *domain.Customer) ([]string, error) {
+	phones, err := s.verificationRepo.ListPhones(ctx, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := append([]string{customer.Email}, phones...)
+	if customer.Phone != "" {
+		recipients = append(recipients, customer.Phone)
+	}
+	return recipients, nil
+}
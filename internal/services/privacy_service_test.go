@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPrivacyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPrivacyRepository) CreateErasureRequest(ctx context.Context, request *domain.ErasureRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockPrivacyRepository) GetErasureRequest(ctx context.Context, id uint) (*domain.ErasureRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureRequest), args.Error(1)
+}
+
+func (m *MockPrivacyRepository) ListErasureRequests(ctx context.Context, status domain.ErasureStatus) ([]domain.ErasureRequest, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]domain.ErasureRequest), args.Error(1)
+}
+
+func (m *MockPrivacyRepository) ListCustomerErasureRequests(ctx context.Context, customerID uint) ([]domain.ErasureRequest, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]domain.ErasureRequest), args.Error(1)
+}
+
+func (m *MockPrivacyRepository) UpdateErasureRequest(ctx context.Context, request *domain.ErasureRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockPrivacyRepository) AnonymizeCustomer(ctx context.Context, customerID uint) (*domain.ErasureSummary, error) {
+	args := m.Called(ctx, customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ErasureSummary), args.Error(1)
+}
+
+type MockNotificationLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationLogRepository) Create(ctx context.Context, log *domain.NotificationLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockNotificationLogRepository) ListByRecipients(ctx context.Context, recipients []string) ([]domain.NotificationLog, error) {
+	args := m.Called(ctx, recipients)
+	return args.Get(0).([]domain.NotificationLog), args.Error(1)
+}
+
+type privacyMocks struct {
+	customers     *MockCustomerRepository
+	addresses     *MockAddressRepository
+	orders        *MockOrderRepository
+	verifications *MockPhoneVerificationRepository
+	logs          *MockNotificationLogRepository
+	privacy       *MockPrivacyRepository
+	notifications *MockNotificationRepository
+}
+
+func newPrivacyMocks() privacyMocks {
+	return privacyMocks{
+		customers:     new(MockCustomerRepository),
+		addresses:     new(MockAddressRepository),
+		orders:        new(MockOrderRepository),
+		verifications: new(MockPhoneVerificationRepository),
+		logs:          new(MockNotificationLogRepository),
+		privacy:       new(MockPrivacyRepository),
+		notifications: new(MockNotificationRepository),
+	}
+}
+
+func (m privacyMocks) service() *privacyService {
+	return NewPrivacyService(m.customers, m.addresses, m.orders, m.verifications, m.logs, m.privacy, m.notifications).(*privacyService)
+}
+
+func TestPrivacyService_ExportCustomerData(t *testing.T) {
+	m := newPrivacyMocks()
+	customer := &domain.Customer{ID: 1, Email: "jane@example.com", Phone: "+254712345678"}
+
+	m.customers.On("GetCustomer", mock.Anything, uint(1)).Return(customer, nil)
+	m.addresses.On("ListByCustomer", mock.Anything, uint(1)).Return([]domain.Address{{ID: 2}}, nil)
+	m.orders.On("ListByCustomer", mock.Anything, uint(1)).Return([]domain.Order{{ID: 3, TotalPrice: kes(10)}}, nil)
+	m.verifications.On("ListPhones", mock.Anything, uint(1)).Return([]string{"+254700000001"}, nil)
+	m.logs.On("ListByRecipients", mock.Anything, []string{"jane@example.com", "+254700000001", "+254712345678"}).
+		Return([]domain.NotificationLog{{ID: 4}}, nil)
+	m.privacy.On("ListCustomerErasureRequests", mock.Anything, uint(1)).Return([]domain.ErasureRequest{}, nil)
+
+	export, err := m.service().ExportCustomerData(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, customer, export.Customer)
+	assert.Len(t, export.Addresses, 1)
+	assert.Len(t, export.Orders, 1)
+	assert.Len(t, export.Notifications, 1)
+	m.logs.AssertExpectations(t)
+}
+
+func TestPrivacyService_RequestErasure_OnlyOnePending(t *testing.T) {
+	m := newPrivacyMocks()
+	m.privacy.On("ListCustomerErasureRequests", mock.Anything, uint(1)).
+		Return([]domain.ErasureRequest{{ID: 1, Status: domain.ErasureStatusPending}}, nil)
+
+	_, err := m.service().RequestErasure(context.Background(), 1, "")
+	assert.ErrorIs(t, err, ErrErasureAlreadyRequested)
+	m.privacy.AssertNotCalled(t, "CreateErasureRequest", mock.Anything, mock.Anything)
+}
+
+func TestPrivacyService_ApproveErasure(t *testing.T) {
+	m := newPrivacyMocks()
+	request := &domain.ErasureRequest{ID: 9, CustomerID: 1, Status: domain.ErasureStatusPending}
+	summary := &domain.ErasureSummary{AddressesDeleted: 2, OrdersAnonymized: 3, NotificationsScrubbed: 4}
+
+	m.privacy.On("GetErasureRequest", mock.Anything, uint(9)).Return(request, nil)
+	m.customers.On("GetCustomer", mock.Anything, uint(1)).Return(&domain.Customer{ID: 1, Email: "jane@example.com"}, nil)
+	m.notifications.On("SendEmail", mock.Anything, mock.Anything, mock.Anything, "jane@example.com").Return(nil)
+	m.privacy.On("AnonymizeCustomer", mock.Anything, uint(1)).Return(summary, nil)
+	m.privacy.On("UpdateErasureRequest", mock.Anything, request).Return(nil)
+
+	approved, err := m.service().ApproveErasure(context.Background(), 9, "customer:admin@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ErasureStatusCompleted, approved.Status)
+	assert.Equal(t, "customer:admin@example.com", approved.ReviewedBy)
+	assert.Equal(t, int64(3), approved.OrdersAnonymized)
+	assert.NotNil(t, approved.CompletedAt)
+	m.privacy.AssertExpectations(t)
+	m.notifications.AssertExpectations(t)
+}
+
+func TestPrivacyService_ApproveErasure_AlreadyReviewed(t *testing.T) {
+	m := newPrivacyMocks()
+	m.privacy.On("GetErasureRequest", mock.Anything, uint(9)).
+		Return(&domain.ErasureRequest{ID: 9, CustomerID: 1, Status: domain.ErasureStatusRejected}, nil)
+
+	_, err := m.service().ApproveErasure(context.Background(), 9, "customer:admin@example.com")
+	assert.ErrorIs(t, err, ErrErasureNotPending)
+	m.privacy.AssertNotCalled(t, "AnonymizeCustomer", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+)
+
+// attributeSchema is the set of attributes a category's products can carry,
+// its own together with those inherited from its ancestors.
+type attributeSchema map[string]domain.CategoryAttribute
+
+// loadAttributeSchema merges the attributes defined on the category and its
+// ancestors. When an ancestor defines the same name, the closer category wins.
+func loadAttributeSchema(ctx context.Context, repo ports.CategoryRepository, categoryID uint) (attributeSchema, error) {
+	tree, err := loadCategoryTree(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if !tree.has(categoryID) {
+		return nil, domain.NewValidationError("category %d does not exist", categoryID)
+	}
+
+	lineage := tree.ancestors(categoryID)
+	attributes, err := repo.ListAttributes(ctx, lineage)
+	if err != nil {
+		return nil, err
+	}
+	return newAttributeSchema(lineage, attributes), nil
+}
+
+// newAttributeSchema merges the attributes defined on a lineage of categories,
+// the category first and the root last.
+func newAttributeSchema(lineage []uint, attributes []domain.CategoryAttribute) attributeSchema {
+	depth := make(map[uint]int, len(lineage))
+	for i, id := range lineage {
+		depth[id] = i
+	}
+	schema := make(attributeSchema, len(attributes))
+	for _, attribute := range attributes {
+		if current, ok := schema[attribute.Name]; !ok || depth[attribute.CategoryID] < depth[current.CategoryID] {
+			schema[attribute.Name] = attribute
+		}
+	}
+	return schema
+}
+
+// checkAttributes validates attributes against the schema of a lineage, as
+// the importer needs for the categories it resolves.
+func checkAttributes(attributes domain.Attributes, lineage []uint, defined []domain.CategoryAttribute) error {
+	return newAttributeSchema(lineage, defined).validate(attributes)
+}
+
+// list returns the attributes sorted by name.
+func (s attributeSchema) list() []domain.CategoryAttribute {
+	attributes := make([]domain.CategoryAttribute, 0, len(s))
+	for _, attribute := range s {
+		attributes = append(attributes, attribute)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Name < attributes[j].Name
+	})
+	return attributes
+}
+
+// validate checks the product's attribute values against the schema: every
+// value is defined, has the right type and is one of the allowed values, and
+// every required attribute is set.
+func (s attributeSchema) validate(values domain.Attributes) error {
+	for name, value := range values {
+		attribute, ok := s[name]
+		if !ok {
+			return domain.NewValidationError("attribute %q is not defined for this category", name)
+		}
+		if !attributeTypeMatches(attribute.Type, value) {
+			return domain.NewValidationError("attribute %q must be a %s", name, attribute.Type)
+		}
+		if len(attribute.AllowedValues) > 0 && !containsString(attribute.AllowedValues, domain.FormatAttributeValue(value)) {
+			return domain.NewValidationError("attribute %q must be one of %s", name, strings.Join(attribute.AllowedValues, ", "))
+		}
+	}
+	for _, attribute := range s.list() {
+		if _, ok := values[attribute.Name]; attribute.Required && !ok {
+			return domain.NewValidationError("attribute %q is required", attribute.Name)
+		}
+	}
+	return nil
+}
+
+// conditions converts listing filters to typed conditions. With a schema the
+// attribute's type decides how the value is read, without one a number or
+// true/false is read as such and anything else as a string.
+func (s attributeSchema) conditions(filters []domain.AttributeFilter) ([]domain.AttributeCondition, error) {
+	conditions := make([]domain.AttributeCondition, 0, len(filters))
+	for _, filter := range filters {
+		if !domain.AttributeNamePattern.MatchString(filter.Name) {
+			return nil, domain.NewValidationError("invalid attribute filter %s", filter)
+		}
+
+		var value interface{}
+		var err error
+		if s != nil {
+			attribute, ok := s[filter.Name]
+			if !ok {
+				return nil, domain.NewValidationError("attribute %q is not defined for this category", filter.Name)
+			}
+			value, err = parseAttributeValue(attribute.Type, filter.Value)
+			if err != nil {
+				return nil, domain.NewValidationError("%s: value must be a %s", filter, attribute.Type)
+			}
+		} else {
+			value = inferAttributeValue(filter.Value)
+		}
+
+		switch filter.Operator {
+		case domain.AttributeEq, domain.AttributeNe:
+		case domain.AttributeGt, domain.AttributeGte, domain.AttributeLt, domain.AttributeLte:
+			if _, ok := value.(float64); !ok {
+				return nil, domain.NewValidationError("%s: %s only compares numbers", filter, filter.Operator)
+			}
+		default:
+			return nil, domain.NewValidationError("invalid attribute filter %s", filter)
+		}
+		conditions = append(conditions, domain.AttributeCondition{Name: filter.Name, Operator: filter.Operator, Value: value})
+	}
+	return conditions, nil
+}
+
+// validateAttributeDefinitions checks a category's attribute definitions
+// before they replace the existing ones.
+func validateAttributeDefinitions(attributes []domain.CategoryAttribute) error {
+	seen := make(map[string]bool, len(attributes))
+	for i := range attributes {
+		attribute := &attributes[i]
+		attribute.Name = strings.TrimSpace(attribute.Name)
+		if !domain.AttributeNamePattern.MatchString(attribute.Name) {
+			return domain.NewValidationError("attribute name %q must be lower case letters, digits and underscores, starting with a letter", attribute.Name)
+		}
+		if seen[attribute.Name] {
+			return domain.NewValidationError("attribute %q is defined more than once", attribute.Name)
+		}
+		seen[attribute.Name] = true
+
+		switch attribute.Type {
+		case domain.AttributeString, domain.AttributeNumber:
+			for _, allowed := range attribute.AllowedValues {
+				if _, err := parseAttributeValue(attribute.Type, allowed); err != nil {
+					return domain.NewValidationError("allowed value %q of attribute %q is not a %s", allowed, attribute.Name, attribute.Type)
+				}
+			}
+		case domain.AttributeBoolean:
+			if len(attribute.AllowedValues) > 0 {
+				return domain.NewValidationError("boolean attribute %q can't list allowed values", attribute.Name)
+			}
+		default:
+			return domain.NewValidationError("attribute %q must have type string, number or boolean", attribute.Name)
+		}
+	}
+	return nil
+}
+
+func attributeTypeMatches(attributeType domain.AttributeType, value interface{}) bool {
+	switch value.(type) {
+	case string:
+		return attributeType == domain.AttributeString
+	case float64:
+		return attributeType == domain.AttributeNumber
+	case bool:
+		return attributeType == domain.AttributeBoolean
+	}
+	return false
+}
+
+func parseAttributeValue(attributeType domain.AttributeType, raw string) (interface{}, error) {
+	switch attributeType {
+	case domain.AttributeNumber:
+		return strconv.ParseFloat(raw, 64)
+	case domain.AttributeBoolean:
+		return strconv.ParseBool(raw)
+	}
+	return raw, nil
+}
+
+func inferAttributeValue(raw string) interface{} {
+	if number, err := strconv.ParseFloat(raw, 64); err == nil {
+		return number
+	}
+	if raw == "true" || raw == "false" {
+		return raw == "true"
+	}
+	return raw
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
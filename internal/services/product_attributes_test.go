@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newAttributeCategoryRepository serves the export test categories with the
+// given attribute definitions, listing those of the categories asked for.
+func newAttributeCategoryRepository(attributes []domain.CategoryAttribute) *MockCategoryRepository {
+	m := new(MockCategoryRepository)
+	m.On("List", mock.Anything).Return(exportTestCategories(), nil)
+	m.On("ListAttributes", mock.Anything, mock.Anything).Return(func(categoryIDs []uint) []domain.CategoryAttribute {
+		var listed []domain.CategoryAttribute
+		for _, attribute := range attributes {
+			for _, id := range categoryIDs {
+				if attribute.CategoryID == id {
+					listed = append(listed, attribute)
+				}
+			}
+		}
+		return listed
+	}, nil)
+	return m
+}
+
+func phoneAttributes() []domain.CategoryAttribute {
+	return []domain.CategoryAttribute{
+		{ID: 1, CategoryID: 1, Name: "brand", Type: domain.AttributeString, Required: true},
+		{ID: 2, CategoryID: 2, Name: "ram_gb", Type: domain.AttributeNumber, AllowedValues: domain.StringList{"4", "8", "16"}},
+		{ID: 3, CategoryID: 2, Name: "dual_sim", Type: domain.AttributeBoolean},
+		{ID: 4, CategoryID: 3, Name: "brand", Type: domain.AttributeString, AllowedValues: domain.StringList{"Samsung", "Tecno"}},
+	}
+}
+
+func TestProductService_CreateProduct_ValidatesAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		categoryID uint
+		attributes domain.Attributes
+		wantErr    string
+	}{
+		{name: "valid", categoryID: 2, attributes: domain.Attributes{"brand": "Nokia", "ram_gb": 8.0, "dual_sim": true}},
+		{name: "missing required", categoryID: 2, attributes: domain.Attributes{"ram_gb": 8.0}, wantErr: `attribute "brand" is required`},
+		{name: "wrong type", categoryID: 2, attributes: domain.Attributes{"brand": "Nokia", "ram_gb": "8"}, wantErr: `attribute "ram_gb" must be a number`},
+		{name: "not allowed", categoryID: 2, attributes: domain.Attributes{"brand": "Nokia", "ram_gb": 12.0}, wantErr: `attribute "ram_gb" must be one of 4, 8, 16`},
+		{name: "undefined", categoryID: 1, attributes: domain.Attributes{"brand": "Nokia", "ram_gb": 8.0}, wantErr: `attribute "ram_gb" is not defined for this category`},
+		{name: "closer category wins", categoryID: 3, attributes: domain.Attributes{"brand": "Nokia"}, wantErr: `attribute "brand" must be one of Samsung, Tecno`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProductRepo := new(MockProductRepository)
+			mockProductRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			service := NewProductService(mockProductRepo, new(MockOrderRepository), newAttributeCategoryRepository(phoneAttributes()), newMockAuditRepository(), newMockExchangeRateRepository())
+
+			err := service.CreateProduct(context.Background(), &domain.Product{Name: "Phone", Price: kes(100), CategoryID: tt.categoryID, Attributes: tt.attributes})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, domain.IsValidationError(err))
+			assert.EqualError(t, err, tt.wantErr)
+			mockProductRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestProductService_ListProducts_AttributeFilters(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), newAttributeCategoryRepository(phoneAttributes()), newMockAuditRepository(), newMockExchangeRateRepository())
+
+	expected := []domain.AttributeCondition{
+		{Name: "ram_gb", Operator: domain.AttributeGte, Value: 8.0},
+		{Name: "brand", Operator: domain.AttributeEq, Value: "10"},
+		{Name: "dual_sim", Operator: domain.AttributeEq, Value: true},
+	}
+	mockProductRepo.On("List", mock.Anything, mock.MatchedBy(func(query domain.ProductListQuery) bool {
+		return assert.ObjectsAreEqual(expected, query.Attributes)
+	})).Return([]domain.Product{}, nil)
+
+	_, err := service.ListProducts(context.Background(), domain.ProductQuery{
+		CategoryID: 2,
+		Attributes: []domain.AttributeFilter{
+			{Name: "ram_gb", Operator: domain.AttributeGte, Value: "8"},
+			{Name: "brand", Operator: domain.AttributeEq, Value: "10"},
+			{Name: "dual_sim", Operator: domain.AttributeEq, Value: "true"},
+		},
+	})
+	assert.NoError(t, err)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestProductService_ListProducts_RejectsBadAttributeFilters(t *testing.T) {
+	tests := []struct {
+		name       string
+		categoryID uint
+		filter     domain.AttributeFilter
+	}{
+		{name: "undefined", categoryID: 2, filter: domain.AttributeFilter{Name: "colour", Operator: domain.AttributeEq, Value: "red"}},
+		{name: "not a number", categoryID: 2, filter: domain.AttributeFilter{Name: "ram_gb", Operator: domain.AttributeEq, Value: "lots"}},
+		{name: "range on string", filter: domain.AttributeFilter{Name: "brand", Operator: domain.AttributeGt, Value: "Nokia"}},
+		{name: "bad name", filter: domain.AttributeFilter{Name: "Brand\"", Operator: domain.AttributeEq, Value: "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProductRepo := new(MockProductRepository)
+			service := NewProductService(mockProductRepo, new(MockOrderRepository), newAttributeCategoryRepository(phoneAttributes()), newMockAuditRepository(), newMockExchangeRateRepository())
+
+			_, err := service.ListProducts(context.Background(), domain.ProductQuery{
+				CategoryID: tt.categoryID,
+				Attributes: []domain.AttributeFilter{tt.filter},
+			})
+			assert.True(t, domain.IsValidationError(err), "got %v", err)
+			mockProductRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCategoryService_SetCategoryAttributes_RejectsBadDefinitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes []domain.CategoryAttribute
+	}{
+		{name: "bad name", attributes: []domain.CategoryAttribute{{Name: "RAM GB", Type: domain.AttributeNumber}}},
+		{name: "duplicate", attributes: []domain.CategoryAttribute{{Name: "ram_gb", Type: domain.AttributeNumber}, {Name: "ram_gb", Type: domain.AttributeString}}},
+		{name: "unknown type", attributes: []domain.CategoryAttribute{{Name: "ram_gb", Type: "integer"}}},
+		{name: "allowed value of wrong type", attributes: []domain.CategoryAttribute{{Name: "ram_gb", Type: domain.AttributeNumber, AllowedValues: domain.StringList{"eight"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCategoryRepo := newAttributeCategoryRepository(nil)
+			mockCategoryRepo.On("Get", mock.Anything, uint(2)).Return(&domain.Category{ID: 2, Name: "Phones"}, nil)
+			service := NewCategoryService(mockCategoryRepo, new(MockProductRepository), newMockAuditRepository())
+
+			_, err := service.SetCategoryAttributes(context.Background(), 2, tt.attributes)
+			assert.True(t, domain.IsValidationError(err), "got %v", err)
+			mockCategoryRepo.AssertNotCalled(t, "ReplaceAttributes", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
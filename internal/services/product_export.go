@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const exportBatchSize = 500
+
+// ExportProducts streams the catalog to w in the importer's format, one page
+// of products at a time, so the export can be fed back into ImportProducts.
+// Validation errors are returned before anything is written.
+func (s *productService) ExportProducts(ctx context.Context, w io.Writer, format domain.ImportFormat, filter domain.ProductExportFilter) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductService.ExportProducts")
+	defer span.End()
+
+	var header func() error
+	var write func(row domain.ProductImportRow) error
+	var flush func() error
+	switch format {
+	case domain.ImportFormatCSV:
+		writer := csv.NewWriter(w)
+		header = func() error {
+			return writer.Write(ProductImportColumns)
+		}
+		write = func(row domain.ProductImportRow) error {
+			return writer.Write([]string{row.SKU, row.Name, row.Description, row.Price.Decimal(), row.Price.Currency, row.Category})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case domain.ImportFormatNDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(row domain.ProductImportRow) error {
+			return encoder.Encode(row)
+		}
+		flush = func() error { return nil }
+	default:
+		return ErrUnsupportedImportFormat
+	}
+
+	tree, err := loadCategoryTree(ctx, s.categoryrepo)
+	if err != nil {
+		return err
+	}
+
+	var categoryIDs []uint
+	if filter.CategoryID != 0 {
+		if !tree.has(filter.CategoryID) {
+			return domain.ErrNotFound
+		}
+		categoryIDs = tree.subtree(filter.CategoryID)
+	}
+
+	if header != nil {
+		if err := header(); err != nil {
+			return err
+		}
+	}
+
+	exported := 0
+	var afterID uint
+	for {
+		products, err := s.productrepo.ListAfter(ctx, afterID, exportBatchSize, categoryIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			row := domain.ProductImportRow{
+				SKU:         product.SKU,
+				Name:        product.Name,
+				Description: product.Description,
+				Price:       product.Price,
+				Category:    tree.path(product.CategoryID),
+			}
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		exported += len(products)
+
+		// Push each page out to the client rather than buffering the export
+		if err := flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if len(products) < exportBatchSize {
+			break
+		}
+		afterID = products[len(products)-1].ID
+	}
+
+	span.SetAttributes(attribute.Int("export.rows", exported))
+	return nil
+}
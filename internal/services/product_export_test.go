@@ -0,0 +1,74 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func exportTestCategories() []domain.Category {
+	electronics := uint(1)
+	phones := uint(2)
+	return []domain.Category{
+		{ID: 1, Name: "Electronics"},
+		{ID: 2, Name: "Phones", ParentCategoryID: &electronics},
+		{ID: 3, Name: "Android", ParentCategoryID: &phones},
+		{ID: 4, Name: "Clothing"},
+	}
+}
+
+func TestProductService_ExportProducts_CSVSubtree(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), mockCategoryRepo, newMockAuditRepository(), newMockExchangeRateRepository())
+
+	mockCategoryRepo.On("List", mock.Anything).Return(exportTestCategories(), nil)
+	mockProductRepo.On("ListAfter", mock.Anything, uint(0), exportBatchSize, []uint{2, 3}).Return([]domain.Product{
+		{ID: 5, SKU: "PH-1", Name: "Phone, One", Description: "6.1\" screen", Price: kes(1500050), CategoryID: 3},
+	}, nil)
+
+	var out bytes.Buffer
+	err := service.ExportProducts(context.Background(), &out, domain.ImportFormatCSV, domain.ProductExportFilter{CategoryID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "sku,name,description,price,currency,category\nPH-1,\"Phone, One\",\"6.1\"\" screen\",15000.50,KES,Electronics > Phones > Android\n", out.String())
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestProductService_ExportProducts_PagesThroughCatalog(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), mockCategoryRepo, newMockAuditRepository(), newMockExchangeRateRepository())
+
+	firstPage := make([]domain.Product, exportBatchSize)
+	for i := range firstPage {
+		firstPage[i] = domain.Product{ID: uint(i + 1), SKU: "S", Name: "Shirt", Price: kes(10), CategoryID: 4}
+	}
+	mockCategoryRepo.On("List", mock.Anything).Return(exportTestCategories(), nil)
+	mockProductRepo.On("ListAfter", mock.Anything, uint(0), exportBatchSize, []uint(nil)).Return(firstPage, nil)
+	mockProductRepo.On("ListAfter", mock.Anything, uint(exportBatchSize), exportBatchSize, []uint(nil)).Return([]domain.Product{
+		{ID: 600, SKU: "LAST", Name: "Last Shirt", Price: kes(1200), CategoryID: 4},
+	}, nil)
+
+	var out bytes.Buffer
+	err := service.ExportProducts(context.Background(), &out, domain.ImportFormatNDJSON, domain.ProductExportFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, exportBatchSize+1, bytes.Count(out.Bytes(), []byte("\n")))
+	assert.Contains(t, out.String(), `{"sku":"LAST","name":"Last Shirt","description":"","price":{"amount":1200,"currency":"KES"},"category":"Clothing"}`)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestProductService_ExportProducts_UnknownCategory(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewProductService(new(MockProductRepository), new(MockOrderRepository), mockCategoryRepo, newMockAuditRepository(), newMockExchangeRateRepository())
+
+	mockCategoryRepo.On("List", mock.Anything).Return(exportTestCategories(), nil)
+
+	var out bytes.Buffer
+	err := service.ExportProducts(context.Background(), &out, domain.ImportFormatCSV, domain.ProductExportFilter{CategoryID: 42})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Zero(t, out.Len())
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	importBatchSize   = 500
+	maxImportLineSize = 1 << 20
+	maxSKULength      = 64
+)
+
+// ProductImportColumns are the columns the importer reads, in the order the
+// exporter writes them. Prices are written in major units, like 10.50.
+var ProductImportColumns = []string{"sku", "name", "description", "price", "currency", "category"}
+
+// requiredImportColumns must be in every CSV header, description and currency
+// may be left out.
+var requiredImportColumns = []string{"sku", "name", "price", "category"}
+
+var ErrUnsupportedImportFormat = domain.NewValidationError("unsupported import format, use csv or ndjson")
+
+// ImportProducts reads products from r and upserts them by SKU in batches, so
+// only one batch is held in memory at a time. Rows that fail validation are
+// reported and skipped, the rest of the file is still imported.
+func (s *productService) ImportProducts(ctx context.Context, r io.Reader, format domain.ImportFormat, dryRun bool) (*domain.ProductImportReport, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductService.ImportProducts")
+	defer span.End()
+
+	var next func() (*domain.ProductImportRow, []string, error)
+	var err error
+	switch format {
+	case domain.ImportFormatCSV:
+		next, err = csvImportRows(r)
+	case domain.ImportFormatNDJSON:
+		next = ndjsonImportRows(r)
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ProductImportReport{DryRun: dryRun, Rows: []domain.ProductImportResult{}}
+	var batch []domain.ProductImportRow
+	var rejected []domain.ProductImportResult
+	staged := make(map[string]stagedImport)
+
+	flush := func() error {
+		results := rejected
+		if len(batch) > 0 {
+			saved, err := s.productrepo.ImportBatch(ctx, batch, dryRun, checkAttributes)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				restage(staged, batch, saved)
+			}
+			results = append(results, saved...)
+		}
+		sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+
+		for _, result := range results {
+			s.recordImport(ctx, result, dryRun)
+			result.Before, result.After = nil, nil
+			if dryRun && result.Status == domain.ImportRowCreated {
+				// The row was rolled back, the ID it got does not exist
+				result.ProductID = 0
+			}
+			report.Rows = append(report.Rows, result)
+		}
+		batch, rejected = batch[:0], nil
+		return nil
+	}
+
+	for {
+		row, problems, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(problems) == 0 {
+			problems = validateImportRow(row)
+		}
+		if len(problems) > 0 {
+			rejected = append(rejected, domain.ProductImportResult{
+				Line:   row.Line,
+				SKU:    row.SKU,
+				Status: domain.ImportRowRejected,
+				Errors: problems,
+			})
+		} else {
+			batch = append(batch, *row)
+		}
+
+		if len(batch)+len(rejected) >= importBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case domain.ImportRowCreated:
+			report.Created++
+		case domain.ImportRowUpdated:
+			report.Updated++
+		case domain.ImportRowUnchanged:
+			report.Unchanged++
+		case domain.ImportRowRejected:
+			report.Rejected++
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("import.rows", len(report.Rows)),
+		attribute.Int("import.rejected", report.Rejected),
+		attribute.Bool("import.dry_run", dryRun),
+	)
+
+	return report, nil
+}
+
+// stagedImport is what an earlier batch of a dry run left a product as.
+type stagedImport struct {
+	row       domain.ProductImportRow
+	productID uint
+}
+
+// restage reports the batch's results as a real run would. A dry run rolls
+// back every batch, so the products earlier batches would have saved are
+// missing from the next one, and a row repeating one of their SKUs would be
+// reported as a create, or compared with the product before the import.
+func restage(staged map[string]stagedImport, rows []domain.ProductImportRow, results []domain.ProductImportResult) {
+	for i := range results {
+		result := &results[i]
+		if result.Status == domain.ImportRowRejected {
+			continue
+		}
+		if earlier, ok := staged[result.SKU]; ok {
+			result.ProductID = earlier.productID
+			result.Status = domain.ImportRowUpdated
+			if sameImportRow(earlier.row, rows[i]) {
+				result.Status = domain.ImportRowUnchanged
+			}
+		}
+
+		productID := result.ProductID
+		if result.Status == domain.ImportRowCreated {
+			productID = 0
+		}
+		staged[result.SKU] = stagedImport{row: rows[i], productID: productID}
+	}
+}
+
+func sameImportRow(a, b domain.ProductImportRow) bool {
+	return a.Name == b.Name && a.Description == b.Description && a.Price == b.Price &&
+		strings.EqualFold(strings.Join(domain.SplitCategoryPath(a.Category), domain.CategoryPathSeparator), strings.Join(domain.SplitCategoryPath(b.Category), domain.CategoryPathSeparator))
+}
+
+func (s *productService) recordImport(ctx context.Context, result domain.ProductImportResult, dryRun bool) {
+	if dryRun {
+		return
+	}
+	switch result.Status {
+	case domain.ImportRowCreated:
+		recordAudit(ctx, s.auditrepo, domain.AuditActionCreate, domain.AuditEntityProduct, result.ProductID, nil, result.After)
+	case domain.ImportRowUpdated:
+		recordAudit(ctx, s.auditrepo, domain.AuditActionUpdate, domain.AuditEntityProduct, result.ProductID, result.Before, result.After)
+	}
+}
+
+func validateImportRow(row *domain.ProductImportRow) []string {
+	var problems []string
+	if row.SKU == "" {
+		problems = append(problems, "sku is required")
+	} else if len(row.SKU) > maxSKULength {
+		problems = append(problems, fmt.Sprintf("sku must be at most %d characters", maxSKULength))
+	}
+	if len(row.Name) < 3 || len(row.Name) > 255 {
+		problems = append(problems, "name must be between 3 and 255 characters")
+	}
+	if err := validateProductPrice(&row.Price); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if domain.SplitCategoryPath(row.Category) == nil {
+		problems = append(problems, `category must be a path such as "Electronics > Phones"`)
+	}
+	return problems
+}
+
+// csvImportRows reads the header and returns an iterator over the rows. Columns
+// are matched by name, unknown columns are ignored.
+func csvImportRows(r io.Reader) (func() (*domain.ProductImportRow, []string, error), error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, domain.NewValidationError("import file is empty")
+	}
+	if err != nil {
+		return nil, domain.NewValidationError("invalid csv header: " + err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	var missing []string
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, domain.NewValidationError("csv header is missing columns: " + strings.Join(missing, ", "))
+	}
+
+	return func() (*domain.ProductImportRow, []string, error) {
+		for {
+			record, err := reader.Read()
+			line, _ := reader.FieldPos(0)
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return &domain.ProductImportRow{Line: parseErr.StartLine}, []string{parseErr.Err.Error()}, nil
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+				continue
+			}
+
+			field := func(name string) string {
+				if i, ok := columns[name]; ok && i < len(record) {
+					return strings.TrimSpace(record[i])
+				}
+				return ""
+			}
+			row := &domain.ProductImportRow{
+				Line:        line,
+				SKU:         field("sku"),
+				Name:        field("name"),
+				Description: field("description"),
+				Category:    field("category"),
+			}
+
+			var problems []string
+			if raw := field("price"); raw != "" {
+				currency := strings.ToUpper(field("currency"))
+				if currency == "" {
+					currency = domain.DefaultCurrency
+				}
+				price, err := domain.ParseMoney(raw, currency)
+				if err != nil {
+					problems = append(problems, "price: "+err.Error())
+				}
+				row.Price = price
+			}
+			return row, problems, nil
+		}
+	}, nil
+}
+
+func ndjsonImportRows(r io.Reader) func() (*domain.ProductImportRow, []string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+	line := 0
+
+	return func() (*domain.ProductImportRow, []string, error) {
+		for scanner.Scan() {
+			line++
+			raw := strings.TrimSpace(scanner.Text())
+			if raw == "" {
+				continue
+			}
+
+			row := &domain.ProductImportRow{}
+			if err := json.Unmarshal([]byte(raw), row); err != nil {
+				return &domain.ProductImportRow{Line: line}, []string{"invalid json: " + err.Error()}, nil
+			}
+			row.Line = line
+			row.SKU = strings.TrimSpace(row.SKU)
+			row.Name = strings.TrimSpace(row.Name)
+			row.Description = strings.TrimSpace(row.Description)
+			row.Category = strings.TrimSpace(row.Category)
+			return row, nil, nil
+		}
+		if err := scanner.Err(); err != nil {
+			if errors.Is(err, bufio.ErrTooLong) {
+				return nil, nil, domain.NewValidationError(fmt.Sprintf("line %d is longer than %d bytes", line+1, maxImportLineSize))
+			}
+			return nil, nil, err
+		}
+		return nil, nil, io.EOF
+	}
+}
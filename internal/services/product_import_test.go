@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProductService_ImportProducts_CSV(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), new(MockCategoryRepository), newMockAuditRepository(), newMockExchangeRateRepository())
+
+	input := "SKU,Name,Price,Category,Notes\n" +
+		"PH-1,Phone One,15000.50,Electronics > Phones,ignored\n" +
+		",No SKU,10,Electronics,\n" +
+		"PH-2,Phone Two,abc,Electronics > Phones,\n" +
+		"PH-4,Phone Four,9.999,Electronics > Phones,\n" +
+		"PH-3,Phone Three,22000,Electronics > Phones,\n"
+
+	expectedRows := []domain.ProductImportRow{
+		{Line: 2, SKU: "PH-1", Name: "Phone One", Price: kes(1500050), Category: "Electronics > Phones"},
+		{Line: 6, SKU: "PH-3", Name: "Phone Three", Price: kes(2200000), Category: "Electronics > Phones"},
+	}
+	mockProductRepo.On("ImportBatch", mock.Anything, expectedRows, false).Return([]domain.ProductImportResult{
+		{Line: 2, SKU: "PH-1", Status: domain.ImportRowCreated, ProductID: 10},
+		{Line: 6, SKU: "PH-3", Status: domain.ImportRowUpdated, ProductID: 4},
+	}, nil)
+
+	report, err := service.ImportProducts(context.Background(), strings.NewReader(input), domain.ImportFormatCSV, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 3, report.Rejected)
+
+	lines := make([]int, len(report.Rows))
+	for i, row := range report.Rows {
+		lines[i] = row.Line
+	}
+	assert.Equal(t, []int{2, 3, 4, 5, 6}, lines)
+	assert.Contains(t, report.Rows[1].Errors, "sku is required")
+	assert.Equal(t, []string{`price: "abc" is not an amount`}, report.Rows[2].Errors)
+	// Prices are never rounded, more decimals than the currency has is an error
+	assert.Equal(t, []string{`price: KES amounts have at most 2 decimal places, got "9.999"`}, report.Rows[3].Errors)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestProductService_ImportProducts_NDJSONDryRun(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), new(MockCategoryRepository), mockAuditRepo, newMockExchangeRateRepository())
+
+	input := `{"sku":"SH-1","name":"Linen Shirt","description":" Breathable ","price":{"amount":250000,"currency":"KES"},"category":"Clothing > Shirts"}
+
+{"sku":"SH-2","name":"Cotton Shirt","price":{"amount":180000},"category":"Clothing >  > Shirts"}
+not json
+`
+	mockProductRepo.On("ImportBatch", mock.Anything, []domain.ProductImportRow{
+		{Line: 1, SKU: "SH-1", Name: "Linen Shirt", Description: "Breathable", Price: kes(250000), Category: "Clothing > Shirts"},
+	}, true).Return([]domain.ProductImportResult{
+		{Line: 1, SKU: "SH-1", Status: domain.ImportRowCreated, ProductID: 99, After: &domain.Product{ID: 99}},
+	}, nil)
+
+	report, err := service.ImportProducts(context.Background(), strings.NewReader(input), domain.ImportFormatNDJSON, true)
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Rejected)
+	assert.Equal(t, uint(0), report.Rows[0].ProductID)
+	assert.Equal(t, 3, report.Rows[1].Line)
+	assert.Equal(t, 4, report.Rows[2].Line)
+	mockAuditRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductService_ImportProducts_DryRunAcrossBatches(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), new(MockCategoryRepository), newMockAuditRepository(), newMockExchangeRateRepository())
+
+	var input strings.Builder
+	var created []domain.ProductImportResult
+	for i := 0; i < importBatchSize; i++ {
+		fmt.Fprintf(&input, `{"sku":"SH-%d","name":"Shirt %d","price":{"amount":100000},"category":"Clothing > Shirts"}`+"\n", i, i)
+		created = append(created, domain.ProductImportResult{Line: i + 1, SKU: fmt.Sprintf("SH-%d", i), Status: domain.ImportRowCreated, ProductID: uint(i + 1)})
+	}
+	// The second batch repeats two SKUs of the first, one of them as it was
+	input.WriteString(`{"sku":"SH-0","name":"Shirt 0","price":{"amount":100000},"category":"clothing > shirts"}` + "\n")
+	input.WriteString(`{"sku":"SH-1","name":"Shirt 1","price":{"amount":120000},"category":"Clothing > Shirts"}` + "\n")
+
+	mockProductRepo.On("ImportBatch", mock.Anything, mock.MatchedBy(func(rows []domain.ProductImportRow) bool {
+		return len(rows) == importBatchSize
+	}), true).Return(created, nil)
+	// Each batch of a dry run is rolled back, so the repository finds neither
+	mockProductRepo.On("ImportBatch", mock.Anything, mock.MatchedBy(func(rows []domain.ProductImportRow) bool {
+		return len(rows) == 2
+	}), true).Return([]domain.ProductImportResult{
+		{Line: importBatchSize + 1, SKU: "SH-0", Status: domain.ImportRowCreated, ProductID: 900},
+		{Line: importBatchSize + 2, SKU: "SH-1", Status: domain.ImportRowCreated, ProductID: 901},
+	}, nil)
+
+	report, err := service.ImportProducts(context.Background(), strings.NewReader(input.String()), domain.ImportFormatNDJSON, true)
+	assert.NoError(t, err)
+	assert.Equal(t, importBatchSize, report.Created)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, domain.ImportRowUnchanged, report.Rows[importBatchSize].Status)
+	assert.Equal(t, domain.ImportRowUpdated, report.Rows[importBatchSize+1].Status)
+	assert.Equal(t, uint(0), report.Rows[importBatchSize+1].ProductID)
+	mockProductRepo.AssertNumberOfCalls(t, "ImportBatch", 2)
+}
+
+func TestCheckAttributes(t *testing.T) {
+	// Phones (5) under Electronics (1), which requires a brand
+	lineage := []uint{5, 1}
+	defined := []domain.CategoryAttribute{
+		{CategoryID: 1, Name: "brand", Type: domain.AttributeString, Required: true},
+		{CategoryID: 5, Name: "ram_gb", Type: domain.AttributeNumber},
+	}
+
+	err := checkAttributes(domain.Attributes{}, lineage, defined)
+	assert.EqualError(t, err, `attribute "brand" is required`)
+	err = checkAttributes(domain.Attributes{"brand": "Acme", "ram_gb": "lots"}, lineage, defined)
+	assert.EqualError(t, err, `attribute "ram_gb" must be a number`)
+	assert.NoError(t, checkAttributes(domain.Attributes{"brand": "Acme", "ram_gb": float64(8)}, lineage, defined))
+}
+
+func TestProductService_ImportProducts_MissingColumns(t *testing.T) {
+	service := NewProductService(new(MockProductRepository), new(MockOrderRepository), new(MockCategoryRepository), newMockAuditRepository(), newMockExchangeRateRepository())
+
+	_, err := service.ImportProducts(context.Background(), strings.NewReader("sku,name\nA,B\n"), domain.ImportFormatCSV, false)
+	assert.True(t, domain.IsValidationError(err))
+	assert.Contains(t, err.Error(), "price, category")
+}
@@ -2,26 +2,52 @@ package services
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
 	"go.opentelemetry.io/otel"
 )
 
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
+)
+
 type productService struct {
-	productrepo ports.ProductRepository
-	orderrepo   ports.OrderRepository
+	productrepo  ports.ProductRepository
+	orderrepo    ports.OrderRepository
+	categoryrepo ports.CategoryRepository
+	auditrepo    ports.AuditRepository
+	raterepo     ports.ExchangeRateRepository
+	now          func() time.Time
 }
 
-func NewProductService(product ports.ProductRepository, order ports.OrderRepository) ports.ProductService {
-	return &productService{productrepo: product, orderrepo: order}
+func NewProductService(product ports.ProductRepository, order ports.OrderRepository, category ports.CategoryRepository, audit ports.AuditRepository, rate ports.ExchangeRateRepository) ports.ProductService {
+	return &productService{productrepo: product, orderrepo: order, categoryrepo: category, auditrepo: audit, raterepo: rate, now: time.Now}
 }
 
 func (s *productService) CreateProduct(ctx context.Context, product *domain.Product) error {
 	ctx, span := otel.Tracer("").Start(ctx, "ProductService.CreateProduct")
 	defer span.End()
 
-	return s.productrepo.Create(ctx, product)
+	if err := validateProductPrice(&product.Price); err != nil {
+		return err
+	}
+	if err := product.ValidateParcel(); err != nil {
+		return err
+	}
+	if err := s.validateAttributes(ctx, product); err != nil {
+		return err
+	}
+
+	if err := s.productrepo.Create(ctx, product); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditrepo, domain.AuditActionCreate, domain.AuditEntityProduct, product.ID, nil, product)
+	return nil
 }
 
 func (s *productService) GetProduct(ctx context.Context, id uint) (*domain.Product, error) {
@@ -31,30 +57,191 @@ func (s *productService) GetProduct(ctx context.Context, id uint) (*domain.Produ
 	return s.productrepo.Get(ctx, id)
 }
 
-func (s *productService) ListProducts(ctx context.Context) ([]domain.Product, error) {
+func (s *productService) ListProducts(ctx context.Context, query domain.ProductQuery) (*domain.ProductPage, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "ProductService.ListProducts")
 	defer span.End()
 
-	return s.productrepo.List(ctx)
+	sort, err := domain.ParseProductSort(query.Sort)
+	if err != nil {
+		return nil, err
+	}
+	for _, price := range []*domain.Money{query.MinPrice, query.MaxPrice} {
+		if price != nil {
+			if err := normalizePrice(price); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if query.MinPrice != nil && query.MaxPrice != nil {
+		if query.MinPrice.Currency != query.MaxPrice.Currency {
+			return nil, domain.NewValidationError("min_price and max_price must be in the same currency")
+		}
+		if query.MinPrice.Amount > query.MaxPrice.Amount {
+			return nil, domain.NewValidationError("min_price must not be above max_price")
+		}
+	}
+
+	listQuery := domain.ProductListQuery{
+		Sort:     sort,
+		Limit:    query.Limit,
+		MinPrice: query.MinPrice,
+		MaxPrice: query.MaxPrice,
+		Name:     strings.TrimSpace(query.Name),
+	}
+	if listQuery.Limit < 1 {
+		listQuery.Limit = defaultProductPageSize
+	}
+	if listQuery.Limit > maxProductPageSize {
+		listQuery.Limit = maxProductPageSize
+	}
+	if query.Cursor != "" {
+		if listQuery.After, err = domain.DecodeProductCursor(query.Cursor, sort); err != nil {
+			return nil, err
+		}
+	}
+	if query.CategoryID != 0 {
+		listQuery.CategoryIDs = []uint{query.CategoryID}
+		if query.IncludeDescendants {
+			tree, err := loadCategoryTree(ctx, s.categoryrepo)
+			if err != nil {
+				return nil, err
+			}
+			if !tree.has(query.CategoryID) {
+				return nil, domain.ErrNotFound
+			}
+			listQuery.CategoryIDs = tree.subtree(query.CategoryID)
+		}
+	}
+
+	if len(query.Attributes) > 0 {
+		var schema attributeSchema
+		if query.CategoryID != 0 {
+			if schema, err = loadAttributeSchema(ctx, s.categoryrepo, query.CategoryID); err != nil {
+				return nil, err
+			}
+		}
+		if listQuery.Attributes, err = schema.conditions(query.Attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ask for one extra row to learn whether there is a next page
+	limit := listQuery.Limit
+	listQuery.Limit++
+	products, err := s.productrepo.List(ctx, listQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.ProductPage{Products: products, Limit: limit}
+	if len(products) > limit {
+		page.Products = products[:limit]
+		page.NextCursor = domain.NewProductCursor(sort, &page.Products[limit-1]).Encode()
+	}
+	if page.Products == nil {
+		page.Products = []domain.Product{}
+	}
+	return page, nil
 }
 
 func (s *productService) UpdateProduct(ctx context.Context, product *domain.Product) error {
 	ctx, span := otel.Tracer("").Start(ctx, "ProductService.UpdateProduct")
 	defer span.End()
 
-	return s.productrepo.Update(ctx, product)
+	before, err := s.productrepo.Get(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateProductPrice(&product.Price); err != nil {
+		return err
+	}
+	if err := product.ValidateParcel(); err != nil {
+		return err
+	}
+	if err := s.validateAttributes(ctx, product); err != nil {
+		return err
+	}
+
+	if err := s.productrepo.Update(ctx, product); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditrepo, domain.AuditActionUpdate, domain.AuditEntityProduct, product.ID, before, product)
+	return nil
 }
 
 func (s *productService) DeleteProduct(ctx context.Context, id uint) error {
 	ctx, span := otel.Tracer("").Start(ctx, "ProductService.DeleteProduct")
 	defer span.End()
 
-	return s.productrepo.Delete(ctx, id)
+	before, err := s.productrepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.productrepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditrepo, domain.AuditActionDelete, domain.AuditEntityProduct, id, before, nil)
+	return nil
+}
+
+// validateAttributes checks the product's attributes against its category's
+// schema. A product without attributes is saved as an empty object.
+func (s *productService) validateAttributes(ctx context.Context, product *domain.Product) error {
+	if product.Attributes == nil {
+		product.Attributes = domain.Attributes{}
+	}
+	schema, err := loadAttributeSchema(ctx, s.categoryrepo, product.CategoryID)
+	if err != nil {
+		return err
+	}
+	return schema.validate(product.Attributes)
 }
 
-func (s *productService) GetAverageCategoryPrice(ctx context.Context, categoryID uint) (float64, error) {
+func (s *productService) GetAverageCategoryPrice(ctx context.Context, categoryID uint, byVariant bool, currency string) (domain.Money, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "ProductService.GetAverageProducgPrice")
 	defer span.End()
 
-	return s.productrepo.GetAverageCategoryPrice(ctx, categoryID)
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	totals, err := s.productrepo.GetCategoryPriceTotals(ctx, categoryID, byVariant)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	rates, err := currentRates(ctx, s.raterepo, s.now())
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return rates.Average(totals, strings.ToUpper(currency))
+}
+
+// normalizePrice fills in the default currency when the price has none and
+// checks the currency is supported and the amount is not negative.
+func normalizePrice(price *domain.Money) error {
+	if price.Currency == "" {
+		price.Currency = domain.DefaultCurrency
+	}
+	price.Currency = strings.ToUpper(price.Currency)
+	if err := price.Validate(); err != nil {
+		return err
+	}
+	if price.IsNegative() {
+		return domain.NewValidationError("price must not be negative")
+	}
+	return nil
+}
+
+// validateProductPrice is normalizePrice for products, which can't be free.
+func validateProductPrice(price *domain.Money) error {
+	if err := normalizePrice(price); err != nil {
+		return err
+	}
+	if price.IsZero() {
+		return domain.NewValidationError("price must be greater than 0")
+	}
+	return nil
 }
@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -26,8 +28,8 @@ func (m *MockProductRepository) Get(ctx context.Context, id uint) (*domain.Produ
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) List(ctx context.Context) ([]domain.Product, error) {
-	args := m.Called(ctx)
+func (m *MockProductRepository) List(ctx context.Context, query domain.ProductListQuery) ([]domain.Product, error) {
+	args := m.Called(ctx, query)
 	return args.Get(0).([]domain.Product), args.Error(1)
 }
 
@@ -41,19 +43,128 @@ func (m *MockProductRepository) Delete(ctx context.Context, id uint) error {
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) GetAverageCategoryPrice(ctx context.Context, productID uint) (float64, error) {
-	args := m.Called(ctx, productID)
-	return args.Get(0).(float64), args.Error(1)
+func (m *MockProductRepository) GetCategoryPriceTotals(ctx context.Context, categoryID uint, byVariant bool) ([]domain.PriceTotal, error) {
+	args := m.Called(ctx, categoryID, byVariant)
+	return args.Get(0).([]domain.PriceTotal), args.Error(1)
+}
+
+func (m *MockProductRepository) ImportBatch(ctx context.Context, rows []domain.ProductImportRow, dryRun bool, check ports.AttributeCheck) ([]domain.ProductImportResult, error) {
+	args := m.Called(ctx, rows, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ProductImportResult), args.Error(1)
+}
+
+func (m *MockProductRepository) ListAfter(ctx context.Context, afterID uint, limit int, categoryIDs []uint) ([]domain.Product, error) {
+	args := m.Called(ctx, afterID, limit, categoryIDs)
+	return args.Get(0).([]domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetDeleted(ctx context.Context, id uint) (*domain.Product, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) ListDeleted(ctx context.Context) ([]domain.Product, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) Restore(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) Get(ctx context.Context, id uint) (*domain.Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) GetDeleted(ctx context.Context, id uint) (*domain.Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) ListDeleted(ctx context.Context) ([]domain.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Restore(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCategoryRepository) ListAttributes(ctx context.Context, categoryIDs []uint) ([]domain.CategoryAttribute, error) {
+	args := m.Called(ctx, categoryIDs)
+	if list, ok := args.Get(0).(func([]uint) []domain.CategoryAttribute); ok {
+		return list(categoryIDs), args.Error(1)
+	}
+	return args.Get(0).([]domain.CategoryAttribute), args.Error(1)
+}
+
+func (m *MockCategoryRepository) ReplaceAttributes(ctx context.Context, categoryID uint, attributes []domain.CategoryAttribute) error {
+	args := m.Called(ctx, categoryID, attributes)
+	return args.Error(0)
+}
+
+// kes is an amount of Kenyan shillings in cents.
+func kes(amount int64) domain.Money {
+	return domain.NewMoney(amount, "KES")
 }
 
 func TestProductService_CreateProduct(t *testing.T) {
 	mockProductRepo := new(MockProductRepository)
 	mockOrderRepo := new(MockOrderRepository)
-	service := NewProductService(mockProductRepo, mockOrderRepo)
+	service := NewProductService(mockProductRepo, mockOrderRepo, newAttributeCategoryRepository(nil), newMockAuditRepository(), newMockExchangeRateRepository())
 
 	product := &domain.Product{
 		Name:       "Test Product",
-		Price:      99.99,
+		Price:      kes(9999),
 		CategoryID: 1,
 	}
 
@@ -64,15 +175,37 @@ func TestProductService_CreateProduct(t *testing.T) {
 	mockProductRepo.AssertExpectations(t)
 }
 
+func TestProductService_CreateProduct_Price(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), newAttributeCategoryRepository(nil), newMockAuditRepository(), newMockExchangeRateRepository())
+	mockProductRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	// The currency defaults to KES
+	product := &domain.Product{Name: "Kettle", Price: domain.Money{Amount: 250000}, CategoryID: 1}
+	assert.NoError(t, service.CreateProduct(context.Background(), product))
+	assert.Equal(t, kes(250000), product.Price)
+
+	for name, price := range map[string]domain.Money{
+		"free":             kes(0),
+		"negative":         kes(-1),
+		"unknown currency": domain.NewMoney(100, "XYZ"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := service.CreateProduct(context.Background(), &domain.Product{Name: "Kettle", Price: price, CategoryID: 1})
+			assert.True(t, domain.IsValidationError(err), err)
+		})
+	}
+}
+
 func TestProductService_GetProduct(t *testing.T) {
 	mockProductRepo := new(MockProductRepository)
 	mockOrderRepo := new(MockOrderRepository)
-	service := NewProductService(mockProductRepo, mockOrderRepo)
+	service := NewProductService(mockProductRepo, mockOrderRepo, new(MockCategoryRepository), newMockAuditRepository(), newMockExchangeRateRepository())
 
 	expectedProduct := &domain.Product{
 		ID:         1,
 		Name:       "Test Product",
-		Price:      99.99,
+		Price:      kes(9999),
 		CategoryID: 1,
 	}
 
@@ -83,3 +216,88 @@ func TestProductService_GetProduct(t *testing.T) {
 	assert.Equal(t, expectedProduct, product)
 	mockProductRepo.AssertExpectations(t)
 }
+
+func TestProductService_ListProducts_NextCursor(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), new(MockCategoryRepository), newMockAuditRepository(), newMockExchangeRateRepository())
+
+	sort := domain.ProductSort{Field: domain.ProductSortPrice}
+	mockProductRepo.On("List", mock.Anything, domain.ProductListQuery{Sort: sort, Limit: 3}).Return([]domain.Product{
+		{ID: 4, Price: kes(10)},
+		{ID: 2, Price: kes(20)},
+		{ID: 9, Price: kes(30)},
+	}, nil)
+
+	page, err := service.ListProducts(context.Background(), domain.ProductQuery{Sort: "price", Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Products, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	cursor, err := domain.DecodeProductCursor(page.NextCursor, sort)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), cursor.ID)
+	assert.Equal(t, int64(20), cursor.Price)
+
+	mockProductRepo.On("List", mock.Anything, domain.ProductListQuery{Sort: sort, After: cursor, Limit: 3}).Return([]domain.Product{
+		{ID: 9, Price: kes(30)},
+	}, nil)
+
+	page, err = service.ListProducts(context.Background(), domain.ProductQuery{Sort: "price", Limit: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Products, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestProductService_ListProducts_IncludeDescendants(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), mockCategoryRepo, newMockAuditRepository(), newMockExchangeRateRepository())
+
+	mockCategoryRepo.On("List", mock.Anything).Return(exportTestCategories(), nil)
+	mockProductRepo.On("List", mock.Anything, mock.MatchedBy(func(q domain.ProductListQuery) bool {
+		return assert.ObjectsAreEqual([]uint{1, 2, 3}, q.CategoryIDs) && q.Limit == defaultProductPageSize+1
+	})).Return([]domain.Product{}, nil)
+
+	page, err := service.ListProducts(context.Background(), domain.ProductQuery{CategoryID: 1, IncludeDescendants: true})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Products)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestProductService_ListProducts_RejectsBadInput(t *testing.T) {
+	service := NewProductService(new(MockProductRepository), new(MockOrderRepository), new(MockCategoryRepository), newMockAuditRepository(), newMockExchangeRateRepository())
+
+	_, err := service.ListProducts(context.Background(), domain.ProductQuery{Sort: "colour"})
+	assert.True(t, domain.IsValidationError(err))
+
+	low, high := kes(500), domain.NewMoney(1000, "USD")
+	_, err = service.ListProducts(context.Background(), domain.ProductQuery{MinPrice: &low, MaxPrice: &high})
+	assert.True(t, domain.IsValidationError(err))
+
+	// A cursor only works with the sort order it was issued for
+	cursor := domain.NewProductCursor(domain.ProductSort{Field: domain.ProductSortName}, &domain.Product{ID: 1, Name: "A"}).Encode()
+	_, err = service.ListProducts(context.Background(), domain.ProductQuery{Sort: "price", Cursor: cursor})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
+func TestProductService_ListProducts_CreatedCursor(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, new(MockOrderRepository), new(MockCategoryRepository), newMockAuditRepository(), newMockExchangeRateRepository())
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sort := domain.ProductSort{Field: domain.ProductSortCreated, Descending: true}
+	mockProductRepo.On("List", mock.Anything, domain.ProductListQuery{Sort: sort, Limit: 2}).Return([]domain.Product{
+		{ID: 8, Description: "Newest", CreatedAt: created.Add(time.Hour)},
+		{ID: 5, CreatedAt: created},
+	}, nil)
+
+	page, err := service.ListProducts(context.Background(), domain.ProductQuery{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Newest", page.Products[0].Description)
+
+	// The newest-first cursor continues from the creation time, not just the ID
+	cursor, err := domain.DecodeProductCursor(page.NextCursor, sort)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(8), cursor.ID)
+	assert.True(t, created.Add(time.Hour).Equal(*cursor.CreatedAt))
+}
@@ -0,0 +1,443 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type returnService struct {
+	returnRepo       ports.ReturnRepository
+	orderRepo        ports.OrderRepository
+	orderService     ports.OrderService
+	customerRepo     ports.CustomerRepository
+	notificationRepo ports.NotificationRepository
+	auditRepo        ports.AuditRepository
+	blobStore        ports.BlobStore
+	maxPhotoSize     int64
+	now              func() time.Time
+}
+
+// NewReturnService accepts photos up to maxPhotoSize bytes,
+// domain.DefaultMaxImageSize when it is not positive. Returns are refunded
+// with orderService.
+func NewReturnService(returnRepo ports.ReturnRepository, orderRepo ports.OrderRepository, orderService ports.OrderService, customerRepo ports.CustomerRepository, notificationRepo ports.NotificationRepository, auditRepo ports.AuditRepository, blobStore ports.BlobStore, maxPhotoSize int64) ports.ReturnService {
+	if maxPhotoSize <= 0 {
+		maxPhotoSize = domain.DefaultMaxImageSize
+	}
+	return &returnService{
+		returnRepo:       returnRepo,
+		orderRepo:        orderRepo,
+		orderService:     orderService,
+		customerRepo:     customerRepo,
+		notificationRepo: notificationRepo,
+		auditRepo:        auditRepo,
+		blobStore:        blobStore,
+		maxPhotoSize:     maxPhotoSize,
+		now:              time.Now,
+	}
+}
+
+func (s *returnService) OpenReturn(ctx context.Context, customerID uint, request domain.OpenReturnRequest) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.OpenReturn")
+	defer span.End()
+
+	order, err := s.orderRepo.Get(ctx, request.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, domain.ErrNotFound
+	}
+	if order.Status == domain.OrderCancelled {
+		return nil, domain.ErrOrderCancelled
+	}
+	if !paidFor(order) {
+		return nil, domain.ErrReturnNotPaid
+	}
+	if len(request.Lines) == 0 {
+		return nil, domain.NewValidationError("a return needs at least one line")
+	}
+
+	// Units on returns still in progress are spoken for
+	returning := make(map[uint]int)
+	earlier, err := s.returnRepo.ListByOrder(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ret := range earlier {
+		if !ret.Status.Open() {
+			continue
+		}
+		for _, line := range ret.Lines {
+			returning[line.OrderItemID] += line.Quantity
+		}
+	}
+
+	ret := &domain.OrderReturn{
+		OrderID:    order.ID,
+		CustomerID: customerID,
+		Status:     domain.ReturnRequested,
+		Comment:    strings.TrimSpace(request.Comment),
+	}
+	seen := make(map[uint]bool, len(request.Lines))
+	for _, line := range request.Lines {
+		item := orderItem(order, line.OrderItemID)
+		if item == nil {
+			return nil, domain.NewValidationError("order %d has no item %d", order.ID, line.OrderItemID)
+		}
+		if seen[item.ID] {
+			return nil, domain.NewValidationError("order item %d is listed twice", item.ID)
+		}
+		seen[item.ID] = true
+		if line.Quantity <= 0 {
+			return nil, domain.NewValidationError("quantity to return of order item %d must be positive", item.ID)
+		}
+		reason := strings.TrimSpace(line.Reason)
+		if reason == "" {
+			return nil, domain.NewValidationError("a reason is needed to return order item %d", item.ID)
+		}
+		if left := item.Quantity - item.RefundedQuantity - returning[item.ID]; line.Quantity > left {
+			return nil, domain.NewValidationError("only %d of order item %d can still be returned", max(left, 0), item.ID)
+		}
+		ret.Lines = append(ret.Lines, domain.OrderReturnLine{OrderItemID: item.ID, Quantity: line.Quantity, Reason: reason})
+	}
+
+	if err := s.returnRepo.Create(ctx, ret); err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityReturn, ret.ID, nil, ret)
+
+	subject := fmt.Sprintf("Return %d requested", ret.ID)
+	message := fmt.Sprintf("We have your request to return items of order %d. We will let you know once it has been reviewed.", order.ID)
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, customerID, subject, message)
+	return ret, nil
+}
+
+func (s *returnService) AddReturnPhoto(ctx context.Context, customerID, id uint, upload domain.ReturnPhotoUpload) (*domain.ReturnPhoto, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.AddReturnPhoto")
+	defer span.End()
+
+	ret, err := s.customerReturn(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != domain.ReturnRequested && ret.Status != domain.ReturnApproved {
+		return nil, domain.NewValidationError("photos can't be added to a return that is %s", ret.Status)
+	}
+	if len(ret.Photos) >= domain.MaxReturnPhotos {
+		return nil, domain.NewValidationError("a return can have at most %d photos", domain.MaxReturnPhotos)
+	}
+
+	if len(upload.Data) == 0 {
+		return nil, domain.NewValidationError("the photo is empty")
+	}
+	if int64(len(upload.Data)) > s.maxPhotoSize {
+		return nil, domain.NewValidationError("the photo is larger than %d bytes", s.maxPhotoSize)
+	}
+	contentType := http.DetectContentType(upload.Data)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, domain.NewValidationError("only JPEG, PNG and GIF photos are supported, got %s", contentType)
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(upload.Data)); err != nil {
+		return nil, domain.NewValidationError("the photo could not be read: %v", err)
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	photo := &domain.ReturnPhoto{
+		ReturnID:    ret.ID,
+		Key:         fmt.Sprintf("returns/%d/%s.%s", ret.ID, name, extension),
+		ContentType: contentType,
+		Size:        int64(len(upload.Data)),
+	}
+	photo.URL = s.blobStore.URL(photo.Key)
+
+	if err := s.blobStore.Put(ctx, photo.Key, bytes.NewReader(upload.Data), photo.Size, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.returnRepo.AddPhoto(ctx, photo); err != nil {
+		if err := s.blobStore.Delete(ctx, photo.Key); err != nil {
+			log.Printf("returns: failed to delete %s: %v", photo.Key, err)
+		}
+		return nil, err
+	}
+	return photo, nil
+}
+
+func (s *returnService) GetCustomerReturn(ctx context.Context, customerID, id uint) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.GetCustomerReturn")
+	defer span.End()
+
+	return s.customerReturn(ctx, customerID, id)
+}
+
+func (s *returnService) ListCustomerReturns(ctx context.Context, customerID uint) ([]domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.ListCustomerReturns")
+	defer span.End()
+
+	returns, err := s.returnRepo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if returns == nil {
+		returns = []domain.OrderReturn{}
+	}
+	return returns, nil
+}
+
+func (s *returnService) GetStoreCredit(ctx context.Context, customerID uint) (*domain.StoreCreditAccount, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.GetStoreCredit")
+	defer span.End()
+
+	entries, err := s.returnRepo.ListStoreCredit(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	account := &domain.StoreCreditAccount{Balance: []domain.Money{}, Entries: entries}
+	if account.Entries == nil {
+		account.Entries = []domain.StoreCredit{}
+	}
+
+	totals := make(map[string]int64)
+	for _, entry := range entries {
+		totals[entry.Amount.Currency] += entry.Amount.Amount
+	}
+	for currency, amount := range totals {
+		account.Balance = append(account.Balance, domain.NewMoney(amount, currency))
+	}
+	sort.Slice(account.Balance, func(i, j int) bool {
+		return account.Balance[i].Currency < account.Balance[j].Currency
+	})
+	return account, nil
+}
+
+func (s *returnService) GetReturn(ctx context.Context, id uint) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.GetReturn")
+	defer span.End()
+
+	return s.returnRepo.Get(ctx, id)
+}
+
+func (s *returnService) ListReturns(ctx context.Context, status domain.ReturnStatus) ([]domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.ListReturns")
+	defer span.End()
+
+	returns, err := s.returnRepo.List(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+	if returns == nil {
+		returns = []domain.OrderReturn{}
+	}
+	return returns, nil
+}
+
+func (s *returnService) ApproveReturn(ctx context.Context, id uint, note string) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.ApproveReturn")
+	defer span.End()
+
+	ret, err := s.returnRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != domain.ReturnRequested {
+		return nil, returnStatusError(ret, "approved")
+	}
+
+	approvedAt := s.now()
+	before := returnSnapshot(ret)
+	ret.Status = domain.ReturnApproved
+	ret.ApprovedAt = &approvedAt
+	ret.Note = strings.TrimSpace(note)
+	if err := s.transition(ctx, ret, before, nil); err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("Return %d approved", ret.ID)
+	message := fmt.Sprintf("Your return %d for order %d has been approved, please send the items back to us", ret.ID, ret.OrderID)
+	if ret.Note != "" {
+		message += ": " + ret.Note
+	}
+	message += "."
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, ret.CustomerID, subject, message)
+	return ret, nil
+}
+
+func (s *returnService) RejectReturn(ctx context.Context, id uint, note string) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.RejectReturn")
+	defer span.End()
+
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, domain.NewValidationError("a reason is needed to reject a return")
+	}
+	ret, err := s.returnRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != domain.ReturnRequested && ret.Status != domain.ReturnApproved {
+		return nil, returnStatusError(ret, "rejected")
+	}
+
+	rejectedAt := s.now()
+	before := returnSnapshot(ret)
+	ret.Status = domain.ReturnRejected
+	ret.RejectedAt = &rejectedAt
+	ret.Note = note
+	if err := s.transition(ctx, ret, before, nil); err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("Return %d rejected", ret.ID)
+	message := fmt.Sprintf("Your return %d for order %d has been rejected: %s.", ret.ID, ret.OrderID, note)
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, ret.CustomerID, subject, message)
+	return ret, nil
+}
+
+func (s *returnService) ReceiveReturn(ctx context.Context, id uint, restock bool) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.ReceiveReturn")
+	defer span.End()
+
+	ret, err := s.returnRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != domain.ReturnApproved {
+		return nil, returnStatusError(ret, "received")
+	}
+
+	// Products without variants don't track stock
+	stock := make(map[uint]int)
+	if restock {
+		order, err := s.orderRepo.Get(ctx, ret.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range ret.Lines {
+			if item := orderItem(order, line.OrderItemID); item != nil && item.VariantID != nil {
+				stock[*item.VariantID] += line.Quantity
+			}
+		}
+	}
+
+	receivedAt := s.now()
+	before := returnSnapshot(ret)
+	ret.Status = domain.ReturnReceived
+	ret.ReceivedAt = &receivedAt
+	ret.Restocked = restock
+	if err := s.transition(ctx, ret, before, stock); err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("Return %d received", ret.ID)
+	message := fmt.Sprintf("We have received the items of your return %d for order %d and will pay you back for them shortly.", ret.ID, ret.OrderID)
+	notifyCustomer(ctx, s.customerRepo, s.notificationRepo, ret.CustomerID, subject, message)
+	return ret, nil
+}
+
+func (s *returnService) ResolveReturn(ctx context.Context, id uint, resolution domain.ReturnResolution) (*domain.OrderReturn, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReturnService.ResolveReturn")
+	defer span.End()
+
+	var status domain.ReturnStatus
+	switch resolution {
+	case domain.ResolveRefund:
+		status = domain.ReturnRefunded
+	case domain.ResolveStoreCredit:
+		status = domain.ReturnCredited
+	default:
+		return nil, domain.NewValidationError("a return is resolved with %s or %s", domain.ResolveRefund, domain.ResolveStoreCredit)
+	}
+
+	ret, err := s.returnRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != domain.ReturnReceived {
+		return nil, returnStatusError(ret, string(status))
+	}
+
+	// The return is claimed before the refund so two staff resolving it at
+	// once can't pay twice, and handed back when the refund fails
+	resolvedAt := s.now()
+	before := returnSnapshot(ret)
+	ret.Status = status
+	ret.Resolution = resolution
+	ret.ResolvedAt = &resolvedAt
+	if err := s.returnRepo.Transition(ctx, ret, domain.ReturnReceived, nil); err != nil {
+		return nil, err
+	}
+
+	request := domain.RefundRequest{
+		Reason:      fmt.Sprintf("return %d", ret.ID),
+		StoreCredit: resolution == domain.ResolveStoreCredit,
+	}
+	for _, line := range ret.Lines {
+		request.Lines = append(request.Lines, domain.RefundLineRequest{OrderItemID: line.OrderItemID, Quantity: line.Quantity})
+	}
+	// RefundOrder tells the customer of the refund or credit
+	refund, err := s.orderService.RefundOrder(ctx, ret.OrderID, request)
+	if err != nil {
+		ret.Status, ret.Resolution, ret.ResolvedAt = before.Status, before.Resolution, before.ResolvedAt
+		if err := s.returnRepo.Transition(ctx, ret, status, nil); err != nil {
+			log.Printf("returns: return %d stays %s after its refund failed: %v", ret.ID, status, err)
+		}
+		return nil, err
+	}
+
+	ret.RefundID = &refund.ID
+	ret.Amount = refund.Amount
+	if err := s.returnRepo.Transition(ctx, ret, status, nil); err != nil {
+		// The money is gone, only the link to the refund is missing
+		log.Printf("returns: return %d was paid by refund %d but it wasn't recorded: %v", ret.ID, refund.ID, err)
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityReturn, ret.ID, before, ret)
+	return ret, nil
+}
+
+// transition moves the return on from the status it had before and audits
+// the change.
+func (s *returnService) transition(ctx context.Context, ret, before *domain.OrderReturn, restock map[uint]int) error {
+	if err := s.returnRepo.Transition(ctx, ret, before.Status, restock); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityReturn, ret.ID, before, ret)
+	return nil
+}
+
+func (s *returnService) customerReturn(ctx context.Context, customerID, id uint) (*domain.OrderReturn, error) {
+	ret, err := s.returnRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.CustomerID != customerID {
+		return nil, domain.ErrNotFound
+	}
+	return ret, nil
+}
+
+func returnStatusError(ret *domain.OrderReturn, to string) error {
+	return domain.NewValidationError("return %d is %s and can't be %s", ret.ID, ret.Status, to)
+}
+
+// returnSnapshot copies the return for the audit log before it is changed.
+func returnSnapshot(ret *domain.OrderReturn) *domain.OrderReturn {
+	before := *ret
+	before.Lines = append([]domain.OrderReturnLine(nil), ret.Lines...)
+	before.Photos = append([]domain.ReturnPhoto(nil), ret.Photos...)
+	return &before
+}
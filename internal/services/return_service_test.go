@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReturnRepository struct {
+	mock.Mock
+}
+
+func (m *MockReturnRepository) Create(ctx context.Context, ret *domain.OrderReturn) error {
+	args := m.Called(ctx, ret)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) Get(ctx context.Context, id uint) (*domain.OrderReturn, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrderReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) List(ctx context.Context, status domain.ReturnStatus) ([]domain.OrderReturn, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]domain.OrderReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) ListByCustomer(ctx context.Context, customerID uint) ([]domain.OrderReturn, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]domain.OrderReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) ListByOrder(ctx context.Context, orderID uint) ([]domain.OrderReturn, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).([]domain.OrderReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) AddPhoto(ctx context.Context, photo *domain.ReturnPhoto) error {
+	args := m.Called(ctx, photo)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) Transition(ctx context.Context, ret *domain.OrderReturn, from domain.ReturnStatus, restock map[uint]int) error {
+	args := m.Called(ctx, ret, from, restock)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) ListStoreCredit(ctx context.Context, customerID uint) ([]domain.StoreCredit, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]domain.StoreCredit), args.Error(1)
+}
+
+// newReturnTestService returns the paid order through an order service that
+// refunds it to the fake gateway.
+func newReturnTestService(order *domain.Order) (*returnService, *MockReturnRepository, *MockOrderRepository, *MockNotificationRepository) {
+	orders, orderRepo, notificationRepo := newRefundTestService(order)
+	returnRepo := new(MockReturnRepository)
+	service := NewReturnService(returnRepo, orderRepo, orders, orders.customerRepo, notificationRepo, newMockAuditRepository(), newMemoryBlobStore(), 0).(*returnService)
+	service.now = func() time.Time { return rateTestNow }
+	return service, returnRepo, orderRepo, notificationRepo
+}
+
+// returnTestReturn is customer 1 returning one unit of line 1 of order 5.
+func returnTestReturn(status domain.ReturnStatus) *domain.OrderReturn {
+	return &domain.OrderReturn{
+		ID:         3,
+		OrderID:    5,
+		CustomerID: 1,
+		Status:     status,
+		Lines:      []domain.OrderReturnLine{{ID: 1, ReturnID: 3, OrderItemID: 1, Quantity: 1, Reason: "Arrived broken"}},
+	}
+}
+
+func TestReturnService_OpenReturn(t *testing.T) {
+	order := refundTestOrder()
+	service, returnRepo, _, notificationRepo := newReturnTestService(order)
+	// Two units of line 1 are on a return already, a rejected one doesn't count
+	returnRepo.On("ListByOrder", mock.Anything, uint(5)).Return([]domain.OrderReturn{
+		{ID: 1, Status: domain.ReturnApproved, Lines: []domain.OrderReturnLine{{OrderItemID: 1, Quantity: 2}}},
+		{ID: 2, Status: domain.ReturnRejected, Lines: []domain.OrderReturnLine{{OrderItemID: 1, Quantity: 1}}},
+	}, nil)
+	returnRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.OrderReturn).ID = 3
+	}).Return(nil)
+
+	ret, err := service.OpenReturn(context.Background(), 1, domain.OpenReturnRequest{
+		OrderID: 5,
+		Lines:   []domain.ReturnLineRequest{{OrderItemID: 1, Quantity: 1, Reason: " Arrived broken "}},
+		Comment: "The box was crushed",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReturnRequested, ret.Status)
+	assert.Equal(t, uint(1), ret.CustomerID)
+	assert.Equal(t, "Arrived broken", ret.Lines[0].Reason)
+	notificationRepo.AssertCalled(t, "SendEmail", mock.Anything, "We have your request to return items of order 5. We will let you know once it has been reviewed.", "Return 3 requested", "jane@example.com")
+
+	for name, request := range map[string]domain.OpenReturnRequest{
+		"more than is left": {OrderID: 5, Lines: []domain.ReturnLineRequest{{OrderItemID: 1, Quantity: 2, Reason: "Too big"}}},
+		"no reason":         {OrderID: 5, Lines: []domain.ReturnLineRequest{{OrderItemID: 2, Quantity: 1}}},
+		"unknown line":      {OrderID: 5, Lines: []domain.ReturnLineRequest{{OrderItemID: 9, Quantity: 1, Reason: "Too big"}}},
+		"no lines":          {OrderID: 5},
+	} {
+		_, err := service.OpenReturn(context.Background(), 1, request)
+		assert.True(t, domain.IsValidationError(err), name)
+	}
+
+	// Other customers' orders don't exist for them
+	_, err = service.OpenReturn(context.Background(), 2, domain.OpenReturnRequest{OrderID: 5, Lines: []domain.ReturnLineRequest{{OrderItemID: 2, Quantity: 1, Reason: "Too big"}}})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	returnRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestReturnService_OpenReturn_Unpaid(t *testing.T) {
+	order := refundTestOrder()
+	order.PaymentStatus = domain.OrderUnpaid
+	service, returnRepo, _, _ := newReturnTestService(order)
+
+	_, err := service.OpenReturn(context.Background(), 1, domain.OpenReturnRequest{OrderID: 5, Lines: []domain.ReturnLineRequest{{OrderItemID: 1, Quantity: 1, Reason: "Too big"}}})
+	assert.ErrorIs(t, err, domain.ErrReturnNotPaid)
+	returnRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReturnService_Workflow(t *testing.T) {
+	order := refundTestOrder()
+	service, returnRepo, orderRepo, notificationRepo := newReturnTestService(order)
+	ret := returnTestReturn(domain.ReturnRequested)
+	returnRepo.On("Get", mock.Anything, uint(3)).Return(ret, nil)
+	returnRepo.On("Transition", mock.Anything, ret, mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("SaveRefund", mock.Anything, order, mock.Anything, map[uint]int{}).Return(nil)
+
+	_, err := service.ApproveReturn(context.Background(), 3, "Use the prepaid label")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReturnApproved, ret.Status)
+	assert.Equal(t, rateTestNow, *ret.ApprovedAt)
+	returnRepo.AssertCalled(t, "Transition", mock.Anything, ret, domain.ReturnRequested, map[uint]int(nil))
+	notificationRepo.AssertCalled(t, "SendEmail", mock.Anything, "Your return 3 for order 5 has been approved, please send the items back to us: Use the prepaid label.", "Return 3 approved", "jane@example.com")
+
+	// The unit goes back to its variant's stock
+	_, err = service.ReceiveReturn(context.Background(), 3, true)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReturnReceived, ret.Status)
+	assert.True(t, ret.Restocked)
+	returnRepo.AssertCalled(t, "Transition", mock.Anything, ret, domain.ReturnApproved, map[uint]int{7: 1})
+	notificationRepo.AssertCalled(t, "SendEmail", mock.Anything, "We have received the items of your return 3 for order 5 and will pay you back for them shortly.", "Return 3 received", "jane@example.com")
+
+	// Store credit needs no gateway and doesn't restock again
+	_, err = service.ResolveReturn(context.Background(), 3, domain.ResolveStoreCredit)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReturnCredited, ret.Status)
+	assert.Equal(t, domain.ResolveStoreCredit, ret.Resolution)
+	assert.Equal(t, kes(90000), ret.Amount)
+	assert.NotNil(t, ret.RefundID)
+	assert.Equal(t, 1, order.Items[0].RefundedQuantity)
+	refund := order.Refunds[0]
+	assert.Equal(t, domain.StoreCreditProvider, refund.Provider)
+	assert.Equal(t, domain.RefundSucceeded, refund.Status)
+	assert.Nil(t, refund.PaymentIntentID)
+	assert.False(t, refund.Restocked)
+	notificationRepo.AssertCalled(t, "SendEmail", mock.Anything, "We have added KES 900.00 of store credit to your account for order 5: return 3.", "Refund for order 5", "jane@example.com")
+
+	_, err = service.ResolveReturn(context.Background(), 3, domain.ResolveRefund)
+	assert.True(t, domain.IsValidationError(err))
+}
+
+func TestReturnService_Refused(t *testing.T) {
+	t.Run("out of order", func(t *testing.T) {
+		service, returnRepo, _, _ := newReturnTestService(refundTestOrder())
+		returnRepo.On("Get", mock.Anything, uint(3)).Return(returnTestReturn(domain.ReturnReceived), nil)
+
+		_, err := service.ApproveReturn(context.Background(), 3, "")
+		assert.True(t, domain.IsValidationError(err))
+		_, err = service.RejectReturn(context.Background(), 3, "Worn")
+		assert.True(t, domain.IsValidationError(err))
+		_, err = service.ReceiveReturn(context.Background(), 3, true)
+		assert.True(t, domain.IsValidationError(err))
+		returnRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejecting needs a reason", func(t *testing.T) {
+		service, returnRepo, _, _ := newReturnTestService(refundTestOrder())
+
+		_, err := service.RejectReturn(context.Background(), 3, " ")
+		assert.True(t, domain.IsValidationError(err))
+		returnRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("moved on in the meantime", func(t *testing.T) {
+		service, returnRepo, _, _ := newReturnTestService(refundTestOrder())
+		returnRepo.On("Get", mock.Anything, uint(3)).Return(returnTestReturn(domain.ReturnRequested), nil)
+		returnRepo.On("Transition", mock.Anything, mock.Anything, domain.ReturnRequested, mock.Anything).Return(domain.ErrReturnChanged)
+
+		_, err := service.ApproveReturn(context.Background(), 3, "")
+		assert.ErrorIs(t, err, domain.ErrReturnChanged)
+	})
+
+	t.Run("refund fails", func(t *testing.T) {
+		// The unit was refunded directly in the meantime
+		order := refundTestOrder()
+		order.Items[0].RefundedQuantity = 3
+		order.PaymentStatus = domain.OrderPartiallyRefunded
+		service, returnRepo, orderRepo, _ := newReturnTestService(order)
+		ret := returnTestReturn(domain.ReturnReceived)
+		returnRepo.On("Get", mock.Anything, uint(3)).Return(ret, nil)
+		returnRepo.On("Transition", mock.Anything, ret, mock.Anything, mock.Anything).Return(nil)
+
+		_, err := service.ResolveReturn(context.Background(), 3, domain.ResolveRefund)
+		assert.True(t, domain.IsValidationError(err))
+		// Claimed for the refund, then handed back
+		returnRepo.AssertCalled(t, "Transition", mock.Anything, ret, domain.ReturnReceived, map[uint]int(nil))
+		returnRepo.AssertCalled(t, "Transition", mock.Anything, ret, domain.ReturnRefunded, map[uint]int(nil))
+		assert.Equal(t, domain.ReturnReceived, ret.Status)
+		assert.Nil(t, ret.ResolvedAt)
+		orderRepo.AssertNotCalled(t, "SaveRefund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown resolution", func(t *testing.T) {
+		service, returnRepo, _, _ := newReturnTestService(refundTestOrder())
+
+		_, err := service.ResolveReturn(context.Background(), 3, "exchange")
+		assert.True(t, domain.IsValidationError(err))
+		returnRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReturnService_AddReturnPhoto(t *testing.T) {
+	service, returnRepo, _, _ := newReturnTestService(refundTestOrder())
+	blobs := service.blobStore.(*memoryBlobStore)
+	returnRepo.On("Get", mock.Anything, uint(3)).Return(returnTestReturn(domain.ReturnRequested), nil)
+	returnRepo.On("AddPhoto", mock.Anything, mock.Anything).Return(nil)
+
+	photo, err := service.AddReturnPhoto(context.Background(), 1, 3, domain.ReturnPhotoUpload{Filename: "box.png", Data: testPNG(t, 20, 10)})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", photo.ContentType)
+	assert.True(t, strings.HasPrefix(photo.Key, "returns/3/"))
+	assert.Contains(t, blobs.blobs, photo.Key)
+
+	_, err = service.AddReturnPhoto(context.Background(), 1, 3, domain.ReturnPhotoUpload{Filename: "box.png", Data: []byte("not an image")})
+	assert.True(t, domain.IsValidationError(err))
+	_, err = service.AddReturnPhoto(context.Background(), 2, 3, domain.ReturnPhotoUpload{Filename: "box.png", Data: testPNG(t, 20, 10)})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	returnRepo.AssertNumberOfCalls(t, "AddPhoto", 1)
+}
+
+func TestReturnService_AddReturnPhoto_Limits(t *testing.T) {
+	service, returnRepo, _, _ := newReturnTestService(refundTestOrder())
+	full := returnTestReturn(domain.ReturnRequested)
+	full.Photos = make([]domain.ReturnPhoto, domain.MaxReturnPhotos)
+	received := returnTestReturn(domain.ReturnReceived)
+	received.ID = 4
+	returnRepo.On("Get", mock.Anything, uint(3)).Return(full, nil)
+	returnRepo.On("Get", mock.Anything, uint(4)).Return(received, nil)
+
+	_, err := service.AddReturnPhoto(context.Background(), 1, 3, domain.ReturnPhotoUpload{Data: testPNG(t, 20, 10)})
+	assert.True(t, domain.IsValidationError(err))
+	_, err = service.AddReturnPhoto(context.Background(), 1, 4, domain.ReturnPhotoUpload{Data: testPNG(t, 20, 10)})
+	assert.True(t, domain.IsValidationError(err))
+	returnRepo.AssertNotCalled(t, "AddPhoto", mock.Anything, mock.Anything)
+}
+
+func TestReturnService_GetStoreCredit(t *testing.T) {
+	service, returnRepo, _, _ := newReturnTestService(refundTestOrder())
+	returnRepo.On("ListStoreCredit", mock.Anything, uint(1)).Return([]domain.StoreCredit{
+		{ID: 1, CustomerID: 1, Amount: kes(90000)},
+		{ID: 2, CustomerID: 1, Amount: domain.NewMoney(1500, "USD")},
+		{ID: 3, CustomerID: 1, Amount: kes(20000)},
+	}, nil)
+	returnRepo.On("ListStoreCredit", mock.Anything, uint(2)).Return([]domain.StoreCredit(nil), nil)
+
+	account, err := service.GetStoreCredit(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Money{kes(110000), domain.NewMoney(1500, "USD")}, account.Balance)
+	assert.Len(t, account.Entries, 3)
+
+	account, err = service.GetStoreCredit(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Empty(t, account.Balance)
+	assert.NotNil(t, account.Entries)
+}
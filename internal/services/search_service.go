@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	defaultSuggestLimit   = 8
+	maxSuggestLimit       = 20
+	maxSearchQueryLength  = 200
+)
+
+var (
+	ErrEmptySearchQuery   = domain.NewValidationError("search query is required")
+	ErrSearchQueryTooLong = domain.NewValidationError("search query is too long")
+)
+
+type productSearchService struct {
+	search ports.ProductSearch
+}
+
+func NewProductSearchService(search ports.ProductSearch) ports.ProductSearchService {
+	return &productSearchService{search: search}
+}
+
+func (s *productSearchService) SearchProducts(ctx context.Context, query domain.ProductSearchQuery) (*domain.ProductSearchPage, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductSearchService.SearchProducts")
+	defer span.End()
+
+	text, err := normalizeSearchQuery(query.Query)
+	if err != nil {
+		return nil, err
+	}
+	query.Query = text
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = defaultSearchPageSize
+	}
+	if query.PageSize > maxSearchPageSize {
+		query.PageSize = maxSearchPageSize
+	}
+
+	return s.search.Search(ctx, query)
+}
+
+func (s *productSearchService) SuggestProducts(ctx context.Context, prefix string, limit int) ([]domain.ProductSuggestion, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductSearchService.SuggestProducts")
+	defer span.End()
+
+	text, err := normalizeSearchQuery(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	return s.search.Suggest(ctx, text, limit)
+}
+
+func normalizeSearchQuery(query string) (string, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		return "", ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return "", ErrSearchQueryTooLong
+	}
+	return query, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductSearch struct {
+	mock.Mock
+}
+
+func (m *MockProductSearch) Search(ctx context.Context, query domain.ProductSearchQuery) (*domain.ProductSearchPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductSearchPage), args.Error(1)
+}
+
+func (m *MockProductSearch) Suggest(ctx context.Context, prefix string, limit int) ([]domain.ProductSuggestion, error) {
+	args := m.Called(ctx, prefix, limit)
+	return args.Get(0).([]domain.ProductSuggestion), args.Error(1)
+}
+
+func TestProductSearchService_SearchProducts(t *testing.T) {
+	mockSearch := new(MockProductSearch)
+	service := NewProductSearchService(mockSearch)
+
+	expected := domain.ProductSearchQuery{Query: "red running shoes", Page: 1, PageSize: maxSearchPageSize}
+	mockSearch.On("Search", mock.Anything, expected).Return(&domain.ProductSearchPage{Page: 1, PageSize: maxSearchPageSize}, nil)
+
+	_, err := service.SearchProducts(context.Background(), domain.ProductSearchQuery{Query: "  red   running shoes ", PageSize: 500})
+	assert.NoError(t, err)
+	mockSearch.AssertExpectations(t)
+}
+
+func TestProductSearchService_RejectsBadQueries(t *testing.T) {
+	mockSearch := new(MockProductSearch)
+	service := NewProductSearchService(mockSearch)
+
+	_, err := service.SearchProducts(context.Background(), domain.ProductSearchQuery{Query: "   "})
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+
+	_, err = service.SuggestProducts(context.Background(), strings.Repeat("a", maxSearchQueryLength+1), 5)
+	assert.ErrorIs(t, err, ErrSearchQueryTooLong)
+	mockSearch.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+	mockSearch.AssertNotCalled(t, "Suggest", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductSearchService_SuggestProductsDefaultsLimit(t *testing.T) {
+	mockSearch := new(MockProductSearch)
+	service := NewProductSearchService(mockSearch)
+
+	mockSearch.On("Suggest", mock.Anything, "iph", defaultSuggestLimit).Return([]domain.ProductSuggestion{{ID: 1, Name: "iPhone 15"}}, nil)
+
+	suggestions, err := service.SuggestProducts(context.Background(), "iph", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "iPhone 15", suggestions[0].Name)
+}
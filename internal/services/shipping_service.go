@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type shippingService struct {
+	shippingRepo ports.ShippingRepository
+	auditRepo    ports.AuditRepository
+}
+
+func NewShippingService(shippingRepo ports.ShippingRepository, auditRepo ports.AuditRepository) ports.ShippingService {
+	return &shippingService{
+		shippingRepo: shippingRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+func (s *shippingService) CreateShippingMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingService.CreateShippingMethod")
+	defer span.End()
+
+	method.ID = 0
+	if err := validateShippingMethod(method); err != nil {
+		return err
+	}
+
+	if err := s.shippingRepo.CreateMethod(ctx, method); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityShipping, method.ID, nil, method)
+	return nil
+}
+
+func (s *shippingService) GetShippingMethod(ctx context.Context, id uint) (*domain.ShippingMethod, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingService.GetShippingMethod")
+	defer span.End()
+
+	return s.shippingRepo.GetMethod(ctx, id)
+}
+
+func (s *shippingService) ListShippingMethods(ctx context.Context) ([]domain.ShippingMethod, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingService.ListShippingMethods")
+	defer span.End()
+
+	methods, err := s.shippingRepo.ListMethods(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if methods == nil {
+		methods = []domain.ShippingMethod{}
+	}
+	return methods, nil
+}
+
+func (s *shippingService) UpdateShippingMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingService.UpdateShippingMethod")
+	defer span.End()
+
+	before, err := s.shippingRepo.GetMethod(ctx, method.ID)
+	if err != nil {
+		return err
+	}
+	method.CreatedAt = before.CreatedAt
+	if err := validateShippingMethod(method); err != nil {
+		return err
+	}
+
+	if err := s.shippingRepo.UpdateMethod(ctx, method); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityShipping, method.ID, before, method)
+	return nil
+}
+
+func (s *shippingService) DeleteShippingMethod(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ShippingService.DeleteShippingMethod")
+	defer span.End()
+
+	before, err := s.shippingRepo.GetMethod(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.shippingRepo.DeleteMethod(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionDelete, domain.AuditEntityShipping, id, before, nil)
+	return nil
+}
+
+// validateShippingMethod trims the names, defaults the currencies to KES and
+// checks the method.
+func validateShippingMethod(method *domain.ShippingMethod) error {
+	method.Name = strings.TrimSpace(method.Name)
+	method.Description = strings.TrimSpace(method.Description)
+	amounts := []*domain.Money{&method.Fee, &method.PerKg, &method.FreeOver}
+	for i := range method.Zones {
+		method.Zones[i].Name = strings.TrimSpace(method.Zones[i].Name)
+		amounts = append(amounts, &method.Zones[i].Fee)
+	}
+	for _, amount := range amounts {
+		if amount.Currency == "" {
+			amount.Currency = domain.DefaultCurrency
+		}
+	}
+	return method.Validate()
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockShippingRepository struct {
+	mock.Mock
+}
+
+func (m *MockShippingRepository) CreateMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	args := m.Called(ctx, method)
+	return args.Error(0)
+}
+
+func (m *MockShippingRepository) GetMethod(ctx context.Context, id uint) (*domain.ShippingMethod, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ShippingMethod), args.Error(1)
+}
+
+func (m *MockShippingRepository) ListMethods(ctx context.Context, activeOnly bool) ([]domain.ShippingMethod, error) {
+	args := m.Called(ctx, activeOnly)
+	return args.Get(0).([]domain.ShippingMethod), args.Error(1)
+}
+
+func (m *MockShippingRepository) UpdateMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	args := m.Called(ctx, method)
+	return args.Error(0)
+}
+
+func (m *MockShippingRepository) DeleteMethod(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// newMockShippingRepository offers the given methods, none lets orders go
+// without shipping.
+func newMockShippingRepository(methods ...domain.ShippingMethod) *MockShippingRepository {
+	m := new(MockShippingRepository)
+	var active []domain.ShippingMethod
+	for i := range methods {
+		method := methods[i]
+		m.On("GetMethod", mock.Anything, method.ID).Return(&method, nil).Maybe()
+		if method.Active {
+			active = append(active, method)
+		}
+	}
+	m.On("ListMethods", mock.Anything, true).Return(active, nil).Maybe()
+	return m
+}
+
+// newShippingTestOrderService delivers customer 1's orders to Kiambu.
+func newShippingTestOrderService(shippingRepo *MockShippingRepository, orderRepo *MockOrderRepository) *orderService {
+	addressRepo := new(MockAddressRepository)
+	addressRepo.On("GetDefault", mock.Anything, uint(1)).Return(&domain.Address{ID: 3, CustomerID: 1, County: "Kiambu", Country: "KE"}, nil)
+
+	service := newDiscountTestOrderService(newMockDiscountRepository(), orderRepo)
+	service.addressRepo = addressRepo
+	service.shippingRepo = shippingRepo
+	return service
+}
+
+func shippingTestMethods() []domain.ShippingMethod {
+	return []domain.ShippingMethod{
+		{ID: 1, Name: "Pickup station", Type: domain.ShippingFlatRate, Fee: kes(20000), FreeOver: kes(500000), Active: true},
+		{ID: 2, Name: "Courier", Type: domain.ShippingWeightBased, Fee: kes(30000), PerKg: kes(5000), IncludedWeight: 1000, MaxWeight: 30000, Active: true},
+		{ID: 3, Name: "Same day", Type: domain.ShippingZoneBased, Active: true, Zones: domain.ShippingZones{
+			{Name: "Nairobi", Counties: []string{"Nairobi"}, Fee: kes(25000)},
+			{Name: "Central", Regions: []string{"Central"}, Fee: kes(40000)},
+		}},
+		{ID: 4, Name: "Old courier", Type: domain.ShippingFlatRate, Fee: kes(10000)},
+	}
+}
+
+func TestShippingMethod_Cost(t *testing.T) {
+	methods := shippingTestMethods()
+
+	cost, _, ok := methods[0].Cost("", 0)
+	assert.True(t, ok)
+	assert.Equal(t, kes(20000), cost)
+
+	// 2.5 kg is 1.5 kg over the included weight, two started kilograms
+	cost, _, ok = methods[1].Cost("", 2500)
+	assert.True(t, ok)
+	assert.Equal(t, kes(40000), cost)
+	cost, _, _ = methods[1].Cost("", 1000)
+	assert.Equal(t, kes(30000), cost)
+	_, _, ok = methods[1].Cost("", 30001)
+	assert.False(t, ok)
+
+	cost, zone, ok := methods[2].Cost("Murang'a County", 0)
+	assert.True(t, ok)
+	assert.Equal(t, "Central", zone)
+	assert.Equal(t, kes(40000), cost)
+	cost, zone, _ = methods[2].Cost(" nairobi ", 0)
+	assert.Equal(t, "Nairobi", zone)
+	assert.Equal(t, kes(25000), cost)
+	_, _, ok = methods[2].Cost("Mombasa", 0)
+	assert.False(t, ok)
+	_, _, ok = methods[2].Cost("", 0)
+	assert.False(t, ok)
+}
+
+func TestProduct_ShippingWeight(t *testing.T) {
+	// 400 x 300 x 200 mm is 24,000,000 mm³, charged as 4.8 kg
+	product := domain.Product{WeightGrams: 1500, LengthMM: 400, WidthMM: 300, HeightMM: 200}
+	assert.Equal(t, 4800, product.ShippingWeight())
+
+	product = domain.Product{WeightGrams: 1500}
+	assert.Equal(t, 1500, product.ShippingWeight())
+}
+
+func TestShippingService_CreateShippingMethod(t *testing.T) {
+	mockShippingRepo := new(MockShippingRepository)
+	service := NewShippingService(mockShippingRepo, newMockAuditRepository())
+	mockShippingRepo.On("CreateMethod", mock.Anything, mock.Anything).Return(nil)
+
+	method := &domain.ShippingMethod{Name: " Same day ", Type: domain.ShippingZoneBased, Zones: domain.ShippingZones{
+		{Name: "Coast", Regions: []string{"coast"}, Fee: domain.Money{Amount: 50000}},
+	}}
+	err := service.CreateShippingMethod(context.Background(), method)
+	assert.NoError(t, err)
+	assert.Equal(t, "Same day", method.Name)
+	assert.Equal(t, kes(50000), method.Zones[0].Fee)
+
+	for _, invalid := range []*domain.ShippingMethod{
+		{Name: "No zones", Type: domain.ShippingZoneBased},
+		{Name: "Typo", Type: domain.ShippingZoneBased, Zones: domain.ShippingZones{{Name: "West", Counties: []string{"Kakameg"}}}},
+		{Name: "Unknown region", Type: domain.ShippingZoneBased, Zones: domain.ShippingZones{{Name: "North", Regions: []string{"North"}}}},
+		{Name: "Negative", Type: domain.ShippingFlatRate, Fee: domain.Money{Amount: -100}},
+		{Name: "Drone", Type: "drone"},
+	} {
+		err := service.CreateShippingMethod(context.Background(), invalid)
+		assert.True(t, domain.IsValidationError(err), "%s: got %v", invalid.Name, err)
+	}
+	mockShippingRepo.AssertNumberOfCalls(t, "CreateMethod", 1)
+}
+
+func TestOrderService_QuoteShipping(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, WeightGrams: 1200}, nil)
+	service := newShippingTestOrderService(newMockShippingRepository(shippingTestMethods()...), mockOrderRepo)
+
+	order := &domain.Order{CustomerID: 1, Items: []domain.OrderItem{{ProductID: 1, Quantity: 2, Price: kes(300000)}}}
+	quotes, err := service.QuoteShipping(context.Background(), order)
+	assert.NoError(t, err)
+
+	// 2.4 kg by courier, same day to Central, the inactive method is left out
+	if assert.Len(t, quotes, 3) {
+		assert.Equal(t, "Pickup station", quotes[0].Name)
+		assert.Equal(t, kes(20000), quotes[0].Cost)
+		assert.False(t, quotes[0].Free)
+		assert.Equal(t, "Courier", quotes[1].Name)
+		assert.Equal(t, kes(40000), quotes[1].Cost)
+		assert.Equal(t, "Same day", quotes[2].Name)
+		assert.Equal(t, "Central", quotes[2].Zone)
+	}
+
+	// An anonymous shopper asking for Mombasa, with enough in the cart for free pickup
+	order = &domain.Order{ShippingAddress: domain.ShippingAddress{County: "Mombasa"}, Items: []domain.OrderItem{{ProductID: 1, Quantity: 2, Price: kes(600000)}}}
+	quotes, err = service.QuoteShipping(context.Background(), order)
+	assert.NoError(t, err)
+	if assert.Len(t, quotes, 2) {
+		assert.True(t, quotes[0].Free)
+		assert.Equal(t, kes(0), quotes[0].Cost)
+		assert.Equal(t, "Courier", quotes[1].Name)
+	}
+}
+
+func TestOrderService_CreateOrder_Shipping(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, WeightGrams: 1200}, nil)
+	mockShippingRepo := newMockShippingRepository(shippingTestMethods()...)
+	mockShippingRepo.On("GetMethod", mock.Anything, uint(9)).Return(nil, domain.ErrNotFound)
+	service := newShippingTestOrderService(mockShippingRepo, mockOrderRepo)
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+
+	order := &domain.Order{CustomerID: 1, ShippingMethodID: uintPtr(2), Items: []domain.OrderItem{{ProductID: 1, Quantity: 2, Price: kes(300000)}}}
+	err := service.CreateOrder(ctx, order)
+	assert.NoError(t, err)
+	assert.Equal(t, "Courier", order.ShippingMethodName)
+	assert.Equal(t, kes(40000), order.ShippingCost)
+	assert.Equal(t, kes(340000), order.TotalPrice)
+
+	// No method while some are active, an inactive method and an unknown one
+	for _, methodID := range []*uint{nil, uintPtr(4), uintPtr(9)} {
+		order := &domain.Order{CustomerID: 1, ShippingMethodID: methodID, Items: []domain.OrderItem{{ProductID: 1, Quantity: 1, Price: kes(150000)}}}
+		err := service.CreateOrder(ctx, order)
+		assert.True(t, domain.IsValidationError(err), "got %v", err)
+	}
+	mockOrderRepo.AssertNumberOfCalls(t, "Create", 1)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+)
+
+// taxEngine taxes order lines at the rates in force at one time.
+type taxEngine struct {
+	rates        map[uint]domain.Rate
+	defaultClass *uint
+}
+
+func loadTaxEngine(ctx context.Context, repo ports.TaxRepository, at time.Time) (*taxEngine, error) {
+	rates, err := repo.RatesAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	engine := &taxEngine{rates: make(map[uint]domain.Rate, len(rates))}
+	for _, rate := range rates {
+		engine.rates[rate.TaxClassID] = rate.Percent
+	}
+	if len(engine.rates) == 0 {
+		return engine, nil
+	}
+
+	classes, err := repo.ListClasses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, class := range classes {
+		if class.IsDefault {
+			id := class.ID
+			engine.defaultClass = &id
+		}
+	}
+	return engine, nil
+}
+
+// active tells whether any class has a rate, without one nothing is taxed.
+func (e *taxEngine) active() bool {
+	return len(e.rates) > 0
+}
+
+// classOf is the product's own class, or else the class of its category or
+// the nearest ancestor that has one, or else the default class.
+func (e *taxEngine) classOf(product *domain.Product, tree *categoryTree) *uint {
+	if product.TaxClassID != nil {
+		return product.TaxClassID
+	}
+	for _, id := range tree.ancestors(product.CategoryID) {
+		if category := tree.byID[id]; category.TaxClassID != nil {
+			return category.TaxClassID
+		}
+	}
+	return e.defaultClass
+}
+
+// rate is the class's rate in force, 0 for no class or a class without a rate.
+func (e *taxEngine) rate(classID *uint) domain.Rate {
+	if classID == nil {
+		return "0"
+	}
+	if rate, ok := e.rates[*classID]; ok {
+		return rate
+	}
+	return "0"
+}
+
+// applyTax taxes each line on its price less its discount. With prices that
+// include tax the tax is only worked out, otherwise it is added to the total.
+func (s *orderService) applyTax(ctx context.Context, order *domain.Order, catalog *orderCatalog) error {
+	order.PricesIncludeTax = s.pricesIncludeTax
+	order.TaxTotal = domain.NewMoney(0, order.Currency)
+
+	engine, err := loadTaxEngine(ctx, s.taxRepo, s.now())
+	if err != nil {
+		return err
+	}
+	var tree *categoryTree
+	if engine.active() {
+		if tree, err = catalog.categories(ctx); err != nil {
+			return err
+		}
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.TaxClassID = nil
+		item.TaxRate = "0"
+		item.Tax = domain.NewMoney(0, order.Currency)
+		if !engine.active() {
+			continue
+		}
+
+		product, err := catalog.product(ctx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		item.TaxClassID = engine.classOf(product, tree)
+		item.TaxRate = engine.rate(item.TaxClassID)
+
+		taxable, err := item.Price.Sub(item.Discount)
+		if err != nil {
+			return err
+		}
+		item.Tax = item.TaxRate.TaxOn(taxable, order.PricesIncludeTax)
+		order.TaxTotal.Amount += item.Tax.Amount
+	}
+
+	if !order.PricesIncludeTax {
+		order.TotalPrice.Amount += order.TaxTotal.Amount
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type taxService struct {
+	taxRepo   ports.TaxRepository
+	auditRepo ports.AuditRepository
+	now       func() time.Time
+}
+
+func NewTaxService(taxRepo ports.TaxRepository, auditRepo ports.AuditRepository) ports.TaxService {
+	return &taxService{
+		taxRepo:   taxRepo,
+		auditRepo: auditRepo,
+		now:       time.Now,
+	}
+}
+
+func (s *taxService) CreateTaxClass(ctx context.Context, class *domain.TaxClass) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.CreateTaxClass")
+	defer span.End()
+
+	class.ID = 0
+	if err := validateTaxClass(class); err != nil {
+		return err
+	}
+
+	if err := s.taxRepo.CreateClass(ctx, class); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityTaxClass, class.ID, nil, class)
+	return nil
+}
+
+func (s *taxService) GetTaxClass(ctx context.Context, id uint) (*domain.TaxClass, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.GetTaxClass")
+	defer span.End()
+
+	class, err := s.taxRepo.GetClass(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	classes := []domain.TaxClass{*class}
+	if err := s.fillRates(ctx, classes); err != nil {
+		return nil, err
+	}
+	return &classes[0], nil
+}
+
+func (s *taxService) ListTaxClasses(ctx context.Context) ([]domain.TaxClass, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.ListTaxClasses")
+	defer span.End()
+
+	classes, err := s.taxRepo.ListClasses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if classes == nil {
+		classes = []domain.TaxClass{}
+	}
+	if err := s.fillRates(ctx, classes); err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+// fillRates sets the rate in force now on each class.
+func (s *taxService) fillRates(ctx context.Context, classes []domain.TaxClass) error {
+	rates, err := s.taxRepo.RatesAt(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	for i := range classes {
+		for j := range rates {
+			if rates[j].TaxClassID == classes[i].ID {
+				classes[i].Rate = &rates[j]
+			}
+		}
+	}
+	return nil
+}
+
+func (s *taxService) UpdateTaxClass(ctx context.Context, class *domain.TaxClass) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.UpdateTaxClass")
+	defer span.End()
+
+	before, err := s.taxRepo.GetClass(ctx, class.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateTaxClass(class); err != nil {
+		return err
+	}
+	class.CreatedAt = before.CreatedAt
+
+	if err := s.taxRepo.UpdateClass(ctx, class); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityTaxClass, class.ID, before, class)
+	return nil
+}
+
+func (s *taxService) DeleteTaxClass(ctx context.Context, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.DeleteTaxClass")
+	defer span.End()
+
+	before, err := s.taxRepo.GetClass(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.taxRepo.DeleteClass(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionDelete, domain.AuditEntityTaxClass, id, before, nil)
+	return nil
+}
+
+func (s *taxService) SetTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.SetTaxRate")
+	defer span.End()
+
+	rate.ID = 0
+	if _, err := s.taxRepo.GetClass(ctx, rate.TaxClassID); err != nil {
+		return err
+	}
+	percent, err := domain.ParseTaxPercent(string(rate.Percent))
+	if err != nil {
+		return err
+	}
+	rate.Percent = percent
+
+	// Orders placed at past rates keep the tax they were charged
+	now := s.now()
+	if rate.EffectiveFrom.IsZero() {
+		rate.EffectiveFrom = now
+	} else if rate.EffectiveFrom.Before(now) {
+		return domain.NewValidationError("effective_from must not be in the past")
+	}
+
+	if err := s.taxRepo.CreateRate(ctx, rate); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityTaxRate, rate.ID, nil, rate)
+	return nil
+}
+
+func (s *taxService) TaxRateHistory(ctx context.Context, classID uint) ([]domain.TaxRate, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.TaxRateHistory")
+	defer span.End()
+
+	if _, err := s.taxRepo.GetClass(ctx, classID); err != nil {
+		return nil, err
+	}
+	rates, err := s.taxRepo.RateHistory(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if rates == nil {
+		rates = []domain.TaxRate{}
+	}
+	return rates, nil
+}
+
+func (s *taxService) AssignCategoryTaxClass(ctx context.Context, categoryID uint, classID *uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.AssignCategoryTaxClass")
+	defer span.End()
+
+	if err := s.checkClass(ctx, classID); err != nil {
+		return err
+	}
+	if err := s.taxRepo.SetCategoryClass(ctx, categoryID, classID); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityCategory, categoryID, nil, map[string]*uint{"tax_class_id": classID})
+	return nil
+}
+
+func (s *taxService) AssignProductTaxClass(ctx context.Context, productID uint, classID *uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TaxService.AssignProductTaxClass")
+	defer span.End()
+
+	if err := s.checkClass(ctx, classID); err != nil {
+		return err
+	}
+	if err := s.taxRepo.SetProductClass(ctx, productID, classID); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityProduct, productID, nil, map[string]*uint{"tax_class_id": classID})
+	return nil
+}
+
+// checkClass reports a class that doesn't exist as bad input rather than as
+// the category or product not being found.
+func (s *taxService) checkClass(ctx context.Context, classID *uint) error {
+	if classID == nil {
+		return nil
+	}
+	_, err := s.taxRepo.GetClass(ctx, *classID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.NewValidationError("tax class %d does not exist", *classID)
+	}
+	return err
+}
+
+func validateTaxClass(class *domain.TaxClass) error {
+	class.Name = strings.TrimSpace(class.Name)
+	if class.Name == "" {
+		return domain.NewValidationError("name is required")
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTaxRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxRepository) CreateClass(ctx context.Context, class *domain.TaxClass) error {
+	args := m.Called(ctx, class)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) GetClass(ctx context.Context, id uint) (*domain.TaxClass, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TaxClass), args.Error(1)
+}
+
+func (m *MockTaxRepository) ListClasses(ctx context.Context) ([]domain.TaxClass, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.TaxClass), args.Error(1)
+}
+
+func (m *MockTaxRepository) UpdateClass(ctx context.Context, class *domain.TaxClass) error {
+	args := m.Called(ctx, class)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) DeleteClass(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) CreateRate(ctx context.Context, rate *domain.TaxRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) RatesAt(ctx context.Context, at time.Time) ([]domain.TaxRate, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]domain.TaxRate), args.Error(1)
+}
+
+func (m *MockTaxRepository) RateHistory(ctx context.Context, classID uint) ([]domain.TaxRate, error) {
+	args := m.Called(ctx, classID)
+	return args.Get(0).([]domain.TaxRate), args.Error(1)
+}
+
+func (m *MockTaxRepository) SetCategoryClass(ctx context.Context, categoryID uint, classID *uint) error {
+	args := m.Called(ctx, categoryID, classID)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) SetProductClass(ctx context.Context, productID uint, classID *uint) error {
+	args := m.Called(ctx, productID, classID)
+	return args.Error(0)
+}
+
+// newMockTaxRepository has the given rates in force, none taxes nothing.
+func newMockTaxRepository(rates ...domain.TaxRate) *MockTaxRepository {
+	m := new(MockTaxRepository)
+	m.On("RatesAt", mock.Anything, mock.Anything).Return(rates, nil).Maybe()
+	return m
+}
+
+// newTaxTestOrderService taxes at 16% on the default Standard class and 0% on
+// Zero rated, which Phones and so Android inherit.
+func newTaxTestOrderService(discountRepo *MockDiscountRepository, orderRepo *MockOrderRepository, pricesIncludeTax bool) *orderService {
+	taxRepo := newMockTaxRepository(
+		domain.TaxRate{TaxClassID: 1, Percent: "16"},
+		domain.TaxRate{TaxClassID: 2, Percent: "0"},
+	)
+	taxRepo.On("ListClasses", mock.Anything).Return([]domain.TaxClass{
+		{ID: 1, Name: "Standard", IsDefault: true},
+		{ID: 2, Name: "Zero rated"},
+	}, nil)
+
+	categories := exportTestCategories()
+	categories[1].TaxClassID = uintPtr(2)
+	categoryRepo := new(MockCategoryRepository)
+	categoryRepo.On("List", mock.Anything).Return(categories, nil)
+
+	service := newDiscountTestOrderService(discountRepo, orderRepo)
+	service.taxRepo = taxRepo
+	service.categoryRepo = categoryRepo
+	service.pricesIncludeTax = pricesIncludeTax
+	return service
+}
+
+func TestParseTaxPercent(t *testing.T) {
+	for value, want := range map[string]domain.Rate{"16": "16", "12.5": "12.5", " 0 ": "0", "100": "100"} {
+		got, err := domain.ParseTaxPercent(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"", "abc", "-1", "16.", "100.0001", "1.23456"} {
+		_, err := domain.ParseTaxPercent(value)
+		assert.True(t, domain.IsValidationError(err), "%q: got %v", value, err)
+	}
+}
+
+func TestRate_TaxOn(t *testing.T) {
+	assert.Equal(t, kes(16000), domain.Rate("16").TaxOn(kes(116000), true))
+	assert.Equal(t, kes(16000), domain.Rate("16").TaxOn(kes(100000), false))
+	// 999 * 16 / 116 is 137.79
+	assert.Equal(t, kes(138), domain.Rate("16").TaxOn(kes(999), true))
+	assert.Equal(t, kes(0), domain.Rate("0").TaxOn(kes(100000), false))
+}
+
+func TestTaxService_SetTaxRate(t *testing.T) {
+	mockTaxRepo := new(MockTaxRepository)
+	service := NewTaxService(mockTaxRepo, newMockAuditRepository()).(*taxService)
+	service.now = func() time.Time { return rateTestNow }
+
+	mockTaxRepo.On("GetClass", mock.Anything, uint(1)).Return(&domain.TaxClass{ID: 1, Name: "Standard"}, nil)
+	mockTaxRepo.On("GetClass", mock.Anything, uint(2)).Return(nil, domain.ErrNotFound)
+	mockTaxRepo.On("CreateRate", mock.Anything, mock.Anything).Return(nil)
+
+	rate := &domain.TaxRate{TaxClassID: 1, Percent: " 16.00 "}
+	err := service.SetTaxRate(context.Background(), rate)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Rate("16"), rate.Percent)
+	assert.Equal(t, rateTestNow, rate.EffectiveFrom)
+
+	err = service.SetTaxRate(context.Background(), &domain.TaxRate{TaxClassID: 1, Percent: "16", EffectiveFrom: rateTestNow.Add(-time.Hour)})
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	err = service.SetTaxRate(context.Background(), &domain.TaxRate{TaxClassID: 1, Percent: "116"})
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	err = service.SetTaxRate(context.Background(), &domain.TaxRate{TaxClassID: 2, Percent: "16"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockTaxRepo.AssertNumberOfCalls(t, "CreateRate", 1)
+}
+
+func TestTaxService_AssignProductTaxClass(t *testing.T) {
+	mockTaxRepo := new(MockTaxRepository)
+	service := NewTaxService(mockTaxRepo, newMockAuditRepository())
+
+	mockTaxRepo.On("GetClass", mock.Anything, uint(2)).Return(nil, domain.ErrNotFound)
+	mockTaxRepo.On("SetProductClass", mock.Anything, uint(5), (*uint)(nil)).Return(nil)
+
+	err := service.AssignProductTaxClass(context.Background(), 5, uintPtr(2))
+	assert.True(t, domain.IsValidationError(err), "got %v", err)
+
+	err = service.AssignProductTaxClass(context.Background(), 5, nil)
+	assert.NoError(t, err)
+	mockTaxRepo.AssertNumberOfCalls(t, "SetProductClass", 1)
+}
+
+func TestOrderService_CreateOrder_TaxClasses(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	// Android inherits Zero rated from Phones, Clothing gets the default
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, CategoryID: 3}, nil)
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(2)).Return(&domain.Product{ID: 2, CategoryID: 4}, nil)
+	mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(3)).Return(&domain.Product{ID: 3, CategoryID: 3, TaxClassID: uintPtr(1)}, nil)
+	service := newTaxTestOrderService(newMockDiscountRepository(), mockOrderRepo, true)
+
+	ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+	order := &domain.Order{CustomerID: 1, Items: []domain.OrderItem{
+		{ProductID: 1, Quantity: 1, Price: kes(100000)},
+		{ProductID: 2, Quantity: 1, Price: kes(116000)},
+		{ProductID: 3, Quantity: 2, Price: kes(116000)},
+	}}
+	err := service.CreateOrder(ctx, order)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint(2), *order.Items[0].TaxClassID)
+	assert.Equal(t, domain.Rate("0"), order.Items[0].TaxRate)
+	assert.Equal(t, kes(0), order.Items[0].Tax)
+	assert.Equal(t, uint(1), *order.Items[1].TaxClassID)
+	assert.Equal(t, kes(16000), order.Items[1].Tax)
+	// The product's own class wins over its category's
+	assert.Equal(t, uint(1), *order.Items[2].TaxClassID)
+	assert.Equal(t, domain.Rate("16"), order.Items[2].TaxRate)
+	assert.Equal(t, kes(16000), order.Items[2].Tax)
+
+	// Prices include tax, so the total doesn't change
+	assert.True(t, order.PricesIncludeTax)
+	assert.Equal(t, kes(32000), order.TaxTotal)
+	assert.Equal(t, kes(332000), order.TotalPrice)
+}
+
+func TestOrderService_CreateOrder_TaxOnDiscountedPrice(t *testing.T) {
+	tenOff := domain.Discount{ID: 1, Name: "Ten off", Type: domain.DiscountPercentage, PercentOff: 10, Active: true}
+
+	for _, tc := range []struct {
+		name             string
+		pricesIncludeTax bool
+		tax              domain.Money
+		total            domain.Money
+	}{
+		// 90,000 * 16 / 116 is 12,413.79
+		{name: "inclusive", pricesIncludeTax: true, tax: kes(12414), total: kes(90000)},
+		{name: "exclusive", pricesIncludeTax: false, tax: kes(14400), total: kes(104400)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			mockOrderRepo.On("GetOrderProduct", mock.Anything, uint(2)).Return(&domain.Product{ID: 2, CategoryID: 4}, nil)
+			service := newTaxTestOrderService(newMockDiscountRepository(tenOff), mockOrderRepo, tc.pricesIncludeTax)
+
+			ctx := context.WithValue(context.Background(), "email", "jane@example.com")
+			order := &domain.Order{CustomerID: 1, Items: []domain.OrderItem{{ProductID: 2, Quantity: 1, Price: kes(100000)}}}
+			err := service.CreateOrder(ctx, order)
+			assert.NoError(t, err)
+
+			assert.Equal(t, kes(10000), order.Items[0].Discount)
+			assert.Equal(t, tc.tax, order.Items[0].Tax)
+			assert.Equal(t, tc.tax, order.TaxTotal)
+			assert.Equal(t, tc.total, order.TotalPrice)
+		})
+	}
+}
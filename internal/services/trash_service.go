@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+var (
+	ErrProductCategoryDeleted = domain.NewValidationError("the product's category is deleted, restore the category first")
+	ErrParentCategoryDeleted  = domain.NewValidationError("the parent category is deleted, restore it first")
+)
+
+type trashService struct {
+	productRepo  ports.ProductRepository
+	categoryRepo ports.CategoryRepository
+	auditRepo    ports.AuditRepository
+	retention    time.Duration
+	now          func() time.Time
+}
+
+// NewTrashService manages soft deleted products and categories. Items are
+// kept for the retention period, 30 days when it is not set, before a purge
+// removes them.
+func NewTrashService(productRepo ports.ProductRepository, categoryRepo ports.CategoryRepository, auditRepo ports.AuditRepository, retention time.Duration) ports.TrashService {
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+	return &trashService{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		auditRepo:    auditRepo,
+		retention:    retention,
+		now:          time.Now,
+	}
+}
+
+func (s *trashService) ListTrash(ctx context.Context) (*domain.Trash, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TrashService.ListTrash")
+	defer span.End()
+
+	products, err := s.productRepo.ListDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.ListDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trash := &domain.Trash{
+		Products:      products,
+		Categories:    categories,
+		RetentionDays: int(s.retention / (24 * time.Hour)),
+	}
+	if trash.Products == nil {
+		trash.Products = []domain.Product{}
+	}
+	if trash.Categories == nil {
+		trash.Categories = []domain.Category{}
+	}
+	return trash, nil
+}
+
+func (s *trashService) RestoreProduct(ctx context.Context, id uint) (*domain.Product, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TrashService.RestoreProduct")
+	defer span.End()
+
+	before, err := s.productRepo.GetDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.categoryRepo.Get(ctx, before.CategoryID); errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrProductCategoryDeleted
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := s.productRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionRestore, domain.AuditEntityProduct, id, before, product)
+	return product, nil
+}
+
+func (s *trashService) RestoreCategory(ctx context.Context, id uint) (*domain.Category, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TrashService.RestoreCategory")
+	defer span.End()
+
+	before, err := s.categoryRepo.GetDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if before.ParentCategoryID != nil {
+		if _, err := s.categoryRepo.Get(ctx, *before.ParentCategoryID); errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrParentCategoryDeleted
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.categoryRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	category, err := s.categoryRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionRestore, domain.AuditEntityCategory, id, before, category)
+	return category, nil
+}
+
+// PurgeTrash removes everything deleted longer ago than the retention period.
+// Products go first, since they can be all that keeps a category around.
+func (s *trashService) PurgeTrash(ctx context.Context) (*domain.TrashPurgeSummary, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TrashService.PurgeTrash")
+	defer span.End()
+
+	summary := &domain.TrashPurgeSummary{DeletedBefore: s.now().Add(-s.retention)}
+
+	var err error
+	summary.ProductsPurged, err = s.productRepo.PurgeDeleted(ctx, summary.DeletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	summary.CategoriesPurged, err = s.categoryRepo.PurgeDeleted(ctx, summary.DeletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
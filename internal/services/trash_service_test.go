@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTrashService_RestoreProduct(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewTrashService(mockProductRepo, mockCategoryRepo, newMockAuditRepository(), 0)
+
+	deleted := &domain.Product{ID: 7, Name: "Phone", CategoryID: 2}
+	restored := &domain.Product{ID: 7, Name: "Phone", CategoryID: 2}
+	mockProductRepo.On("GetDeleted", mock.Anything, uint(7)).Return(deleted, nil)
+	mockCategoryRepo.On("Get", mock.Anything, uint(2)).Return(&domain.Category{ID: 2}, nil)
+	mockProductRepo.On("Restore", mock.Anything, uint(7)).Return(nil)
+	mockProductRepo.On("Get", mock.Anything, uint(7)).Return(restored, nil)
+
+	product, err := service.RestoreProduct(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, restored, product)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestTrashService_RestoreProduct_CategoryDeleted(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewTrashService(mockProductRepo, mockCategoryRepo, newMockAuditRepository(), 0)
+
+	mockProductRepo.On("GetDeleted", mock.Anything, uint(7)).Return(&domain.Product{ID: 7, CategoryID: 2}, nil)
+	mockCategoryRepo.On("Get", mock.Anything, uint(2)).Return(nil, domain.ErrNotFound)
+
+	_, err := service.RestoreProduct(context.Background(), 7)
+	assert.ErrorIs(t, err, ErrProductCategoryDeleted)
+	assert.True(t, domain.IsValidationError(err))
+	mockProductRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
+
+func TestTrashService_RestoreCategory_ParentDeleted(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewTrashService(new(MockProductRepository), mockCategoryRepo, newMockAuditRepository(), 0)
+
+	parentID := uint(1)
+	mockCategoryRepo.On("GetDeleted", mock.Anything, uint(2)).Return(&domain.Category{ID: 2, ParentCategoryID: &parentID}, nil)
+	mockCategoryRepo.On("Get", mock.Anything, uint(1)).Return(nil, domain.ErrNotFound)
+
+	_, err := service.RestoreCategory(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrParentCategoryDeleted)
+	mockCategoryRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
+
+func TestTrashService_PurgeTrash_UsesRetention(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewTrashService(mockProductRepo, mockCategoryRepo, newMockAuditRepository(), 7*24*time.Hour).(*trashService)
+
+	now := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	cutoff := time.Date(2024, 5, 3, 9, 0, 0, 0, time.UTC)
+	mockProductRepo.On("PurgeDeleted", mock.Anything, cutoff).Return(int64(3), nil)
+	mockCategoryRepo.On("PurgeDeleted", mock.Anything, cutoff).Return(int64(1), nil)
+
+	summary, err := service.PurgeTrash(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, cutoff, summary.DeletedBefore)
+	assert.Equal(t, int64(3), summary.ProductsPurged)
+	assert.Equal(t, int64(1), summary.CategoriesPurged)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/ports"
+	"go.opentelemetry.io/otel"
+)
+
+type productVariantService struct {
+	productRepo ports.ProductRepository
+	variantRepo ports.ProductVariantRepository
+	auditRepo   ports.AuditRepository
+}
+
+func NewProductVariantService(productRepo ports.ProductRepository, variantRepo ports.ProductVariantRepository, auditRepo ports.AuditRepository) ports.ProductVariantService {
+	return &productVariantService{
+		productRepo: productRepo,
+		variantRepo: variantRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+func (s *productVariantService) ListVariants(ctx context.Context, productID uint) (*domain.ProductVariants, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantService.ListVariants")
+	defer span.End()
+
+	if _, err := s.productRepo.Get(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	options, err := s.variantRepo.ListOptions(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.variantRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &domain.ProductVariants{Options: options, Variants: variants}
+	if list.Options == nil {
+		list.Options = []domain.ProductOption{}
+	}
+	if list.Variants == nil {
+		list.Variants = []domain.ProductVariant{}
+	}
+	return list, nil
+}
+
+func (s *productVariantService) GetVariant(ctx context.Context, productID, id uint) (*domain.ProductVariant, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantService.GetVariant")
+	defer span.End()
+
+	variant, err := s.variantRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if variant.ProductID != productID {
+		return nil, domain.ErrNotFound
+	}
+	return variant, nil
+}
+
+func (s *productVariantService) CreateVariant(ctx context.Context, productID uint, variant *domain.ProductVariant) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantService.CreateVariant")
+	defer span.End()
+
+	product, err := s.productRepo.Get(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	variant.ID = 0
+	variant.ProductID = productID
+	if err := s.validateVariant(ctx, product, variant); err != nil {
+		return err
+	}
+
+	if err := s.variantRepo.Create(ctx, variant); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionCreate, domain.AuditEntityVariant, variant.ID, nil, variant)
+	return nil
+}
+
+func (s *productVariantService) UpdateVariant(ctx context.Context, productID uint, variant *domain.ProductVariant) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantService.UpdateVariant")
+	defer span.End()
+
+	before, err := s.GetVariant(ctx, productID, variant.ID)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepo.Get(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	variant.ProductID = productID
+	variant.CreatedAt = before.CreatedAt
+	if err := s.validateVariant(ctx, product, variant); err != nil {
+		return err
+	}
+
+	if err := s.variantRepo.Update(ctx, variant); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionUpdate, domain.AuditEntityVariant, variant.ID, before, variant)
+	return nil
+}
+
+func (s *productVariantService) DeleteVariant(ctx context.Context, productID, id uint) error {
+	ctx, span := otel.Tracer("").Start(ctx, "ProductVariantService.DeleteVariant")
+	defer span.End()
+
+	before, err := s.GetVariant(ctx, productID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.variantRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.auditRepo, domain.AuditActionDelete, domain.AuditEntityVariant, id, before, nil)
+	return nil
+}
+
+// validateVariant checks the variant picks one value for each of the
+// product's options and that no other variant already has the same values.
+// Names and values that match existing ones apart from case are spelled the
+// stored way, and the options are put in the product's order. A price
+// without a currency is in the product's currency.
+func (s *productVariantService) validateVariant(ctx context.Context, product *domain.Product, variant *domain.ProductVariant) error {
+	variant.SKU = strings.TrimSpace(variant.SKU)
+	if variant.Price != nil {
+		if variant.Price.Currency == "" {
+			variant.Price.Currency = product.Price.Currency
+		}
+		if err := normalizePrice(variant.Price); err != nil {
+			return err
+		}
+		if variant.Price.Currency != product.Price.Currency {
+			return domain.NewValidationError("price must be in the product's currency %s", product.Price.Currency)
+		}
+	}
+	if variant.Stock < 0 {
+		return domain.NewValidationError("stock must not be negative")
+	}
+	if len(variant.Options) == 0 {
+		return domain.NewValidationError("a variant needs at least one option, like Size: M")
+	}
+
+	options, err := s.variantRepo.ListOptions(ctx, variant.ProductID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]domain.ProductOption, len(options))
+	for _, option := range options {
+		existing[strings.ToLower(option.Name)] = option
+	}
+
+	picked := make(map[string]domain.VariantOption, len(variant.Options))
+	for _, selected := range variant.Options {
+		selected.Name = strings.TrimSpace(selected.Name)
+		selected.Value = strings.TrimSpace(selected.Value)
+		if selected.Name == "" || selected.Value == "" {
+			return domain.NewValidationError("option names and values must not be empty")
+		}
+		key := strings.ToLower(selected.Name)
+		if _, ok := picked[key]; ok {
+			return domain.NewValidationError("option %q is given more than once", selected.Name)
+		}
+		if option, ok := existing[key]; ok {
+			selected.Name = option.Name
+			for _, value := range option.Values {
+				if strings.EqualFold(value.Value, selected.Value) {
+					selected.Value = value.Value
+				}
+			}
+		} else if len(options) > 0 {
+			return domain.NewValidationError("the product has no option %q", selected.Name)
+		}
+		picked[key] = selected
+	}
+
+	// Existing options keep their order, new ones follow in the order given
+	normalized := make([]domain.VariantOption, 0, len(picked))
+	for _, option := range options {
+		selected, ok := picked[strings.ToLower(option.Name)]
+		if !ok {
+			return domain.NewValidationError("option %q is missing", option.Name)
+		}
+		normalized = append(normalized, selected)
+	}
+	if len(options) == 0 {
+		for _, selected := range variant.Options {
+			normalized = append(normalized, picked[strings.ToLower(strings.TrimSpace(selected.Name))])
+		}
+	}
+	variant.Options = normalized
+
+	siblings, err := s.variantRepo.ListByProduct(ctx, variant.ProductID)
+	if err != nil {
+		return err
+	}
+	key := variantKey(variant.Options)
+	for _, sibling := range siblings {
+		if sibling.ID != variant.ID && variantKey(sibling.Options) == key {
+			return domain.NewValidationError("variant %d already has %s", sibling.ID, key)
+		}
+	}
+	return nil
+}
+
+// variantKey describes the options as "Size: M, Colour: Red".
+func variantKey(options []domain.VariantOption) string {
+	parts := make([]string, len(options))
+	for i, option := range options {
+		parts[i] = option.Name + ": " + option.Value
+	}
+	return strings.Join(parts, ", ")
+}
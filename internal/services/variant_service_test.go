@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductVariantRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductVariantRepository) Create(ctx context.Context, variant *domain.ProductVariant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
+
+func (m *MockProductVariantRepository) Get(ctx context.Context, id uint) (*domain.ProductVariant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) ListByProduct(ctx context.Context, productID uint) ([]domain.ProductVariant, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).([]domain.ProductVariant), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) ListOptions(ctx context.Context, productID uint) ([]domain.ProductOption, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).([]domain.ProductOption), args.Error(1)
+}
+
+func (m *MockProductVariantRepository) Update(ctx context.Context, variant *domain.ProductVariant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
+
+func (m *MockProductVariantRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func shirtOptions() []domain.ProductOption {
+	return []domain.ProductOption{
+		{ID: 1, ProductID: 1, Name: "Size", Values: []domain.ProductOptionValue{{ID: 1, OptionID: 1, Value: "M"}}},
+		{ID: 2, ProductID: 1, Name: "Colour", Values: []domain.ProductOptionValue{{ID: 2, OptionID: 2, Value: "Red"}}},
+	}
+}
+
+func TestProductVariantService_CreateVariant_NormalizesOptions(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockVariantRepo := new(MockProductVariantRepository)
+	service := NewProductVariantService(mockProductRepo, mockVariantRepo, newMockAuditRepository())
+
+	mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1, Price: kes(1200)}, nil)
+	mockVariantRepo.On("ListOptions", mock.Anything, uint(1)).Return(shirtOptions(), nil)
+	mockVariantRepo.On("ListByProduct", mock.Anything, uint(1)).Return([]domain.ProductVariant{
+		{ID: 4, ProductID: 1, Options: []domain.VariantOption{{Name: "Size", Value: "M"}, {Name: "Colour", Value: "Red"}}},
+	}, nil)
+	mockVariantRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ProductVariant")).Return(nil)
+
+	variant := &domain.ProductVariant{
+		SKU:     " TS-M-BLU ",
+		Stock:   5,
+		Options: []domain.VariantOption{{Name: "colour", Value: "Blue"}, {Name: "SIZE", Value: "m"}},
+	}
+	err := service.CreateVariant(context.Background(), 1, variant)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), variant.ProductID)
+	assert.Equal(t, "TS-M-BLU", variant.SKU)
+	assert.Equal(t, []domain.VariantOption{{Name: "Size", Value: "M"}, {Name: "Colour", Value: "Blue"}}, variant.Options)
+	assert.Equal(t, kes(1200), variant.EffectivePrice(kes(1200)))
+	mockVariantRepo.AssertExpectations(t)
+}
+
+func TestProductVariantService_CreateVariant_RejectsBadOptions(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	mockVariantRepo := new(MockProductVariantRepository)
+	service := NewProductVariantService(mockProductRepo, mockVariantRepo, newMockAuditRepository())
+
+	mockProductRepo.On("Get", mock.Anything, uint(1)).Return(&domain.Product{ID: 1}, nil)
+	mockVariantRepo.On("ListOptions", mock.Anything, uint(1)).Return(shirtOptions(), nil)
+	mockVariantRepo.On("ListByProduct", mock.Anything, uint(1)).Return([]domain.ProductVariant{
+		{ID: 4, ProductID: 1, Options: []domain.VariantOption{{Name: "Size", Value: "M"}, {Name: "Colour", Value: "Red"}}},
+	}, nil)
+
+	tests := map[string][]domain.VariantOption{
+		"missing option":   {{Name: "Size", Value: "L"}},
+		"unknown option":   {{Name: "Size", Value: "L"}, {Name: "Colour", Value: "Red"}, {Name: "Fit", Value: "Slim"}},
+		"repeated option":  {{Name: "Size", Value: "L"}, {Name: "size", Value: "XL"}},
+		"same as variant":  {{Name: "Size", Value: "m"}, {Name: "Colour", Value: "red"}},
+		"empty value":      {{Name: "Size", Value: " "}, {Name: "Colour", Value: "Red"}},
+		"no options given": {},
+	}
+	for name, options := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := service.CreateVariant(context.Background(), 1, &domain.ProductVariant{Options: options})
+			assert.True(t, domain.IsValidationError(err), err)
+		})
+	}
+	mockVariantRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductVariantService_GetVariant_OtherProduct(t *testing.T) {
+	mockVariantRepo := new(MockProductVariantRepository)
+	service := NewProductVariantService(new(MockProductRepository), mockVariantRepo, newMockAuditRepository())
+
+	mockVariantRepo.On("Get", mock.Anything, uint(4)).Return(&domain.ProductVariant{ID: 4, ProductID: 2}, nil)
+
+	_, err := service.GetVariant(context.Background(), 1, 4)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+)
+
+type ActorType string
+
+const (
+	ActorCustomer ActorType = "customer"
+	ActorAPIKey   ActorType = "api_key"
+	ActorSystem   ActorType = "system"
+)
+
+// Actor identifies who is performing a request, either a logged in customer or an API key.
+type Actor struct {
+	Type   ActorType `json:"type"`
+	ID     uint      `json:"id"`
+	Email  string    `json:"email,omitempty"`
+	Name   string    `json:"name,omitempty"`
+	Scopes []string  `json:"scopes,omitempty"`
+	Admin  bool      `json:"admin"`
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) (*Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(*Actor)
+	return actor, ok && actor != nil
+}
+
+// customerScopes are what every customer session may do. Changing the
+// catalogue is left to admins.
+var customerScopes = []string{domain.ScopeProductsRead, domain.ScopeOrdersRead, domain.ScopeOrdersWrite}
+
+// HasScope reports whether the actor may use the given scope. Admins may use
+// every scope, API keys those they were granted and customers customerScopes.
+func (a *Actor) HasScope(scope string) bool {
+	if a.Admin {
+		return true
+	}
+	granted := customerScopes
+	switch a.Type {
+	case ActorAPIKey:
+		granted = a.Scopes
+	case ActorSystem:
+		return true
+	}
+	for _, s := range granted {
+		if s == scope || s == domain.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the actor the way it is written to logs, e.g. "api_key:3".
+func (a *Actor) String() string {
+	if a.Type == ActorCustomer && a.Email != "" {
+		return fmt.Sprintf("%s:%s", a.Type, a.Email)
+	}
+	return fmt.Sprintf("%s:%d", a.Type, a.ID)
+}
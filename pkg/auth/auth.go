@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"strings"
+
 	"github.com/sean-miningah/sil-backend-assessment/pkg/config"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -9,6 +11,7 @@ import (
 type AuthConfig struct {
 	GoogleOAuth *oauth2.Config
 	JWTSecret   []byte
+	AdminEmails []string
 }
 
 func NewAuthConfig(cfg *config.Config) *AuthConfig {
@@ -23,6 +26,26 @@ func NewAuthConfig(cfg *config.Config) *AuthConfig {
 			},
 			Endpoint: google.Endpoint,
 		},
-		JWTSecret: []byte(cfg.JWTSecret),
+		JWTSecret:   []byte(cfg.JWTSecret),
+		AdminEmails: splitList(cfg.AdminEmails),
+	}
+}
+
+func (a *AuthConfig) IsAdminEmail(email string) bool {
+	for _, admin := range a.AdminEmails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"github.com/sean-miningah/sil-backend-assessment/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		actor  *auth.Actor
+		method string
+		scope  string
+		want   int
+	}{
+		{name: "anonymous", method: http.MethodPost, scope: domain.ScopeProductsWrite, want: http.StatusUnauthorized},
+		{name: "customer writing products", actor: &auth.Actor{Type: auth.ActorCustomer, ID: 1}, method: http.MethodPost, scope: domain.ScopeProductsWrite, want: http.StatusForbidden},
+		{name: "customer reading products", actor: &auth.Actor{Type: auth.ActorCustomer, ID: 1}, method: http.MethodGet, scope: domain.ScopeProductsRead, want: http.StatusOK},
+		{name: "customer placing an order", actor: &auth.Actor{Type: auth.ActorCustomer, ID: 1}, method: http.MethodPost, scope: domain.ScopeOrdersWrite, want: http.StatusOK},
+		{name: "admin customer writing products", actor: &auth.Actor{Type: auth.ActorCustomer, ID: 1, Admin: true}, method: http.MethodPost, scope: domain.ScopeProductsWrite, want: http.StatusOK},
+		{name: "key with the scope", actor: &auth.Actor{Type: auth.ActorAPIKey, ID: 2, Scopes: []string{domain.ScopeProductsWrite}}, method: http.MethodPost, scope: domain.ScopeProductsWrite, want: http.StatusOK},
+		{name: "key without the scope", actor: &auth.Actor{Type: auth.ActorAPIKey, ID: 2, Scopes: []string{domain.ScopeProductsRead}}, method: http.MethodPost, scope: domain.ScopeProductsWrite, want: http.StatusForbidden},
+		{name: "admin key", actor: &auth.Actor{Type: auth.ActorAPIKey, ID: 2, Scopes: []string{domain.ScopeAdmin}, Admin: true}, method: http.MethodPost, scope: domain.ScopeProductsWrite, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.actor != nil {
+					c.Request = c.Request.WithContext(auth.WithActor(c.Request.Context(), tt.actor))
+				}
+			})
+			router.Handle(tt.method, "/products", RequireScope(tt.scope), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/products", nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
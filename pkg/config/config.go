@@ -23,6 +23,45 @@ type Config struct {
 	GoogleClientSecret string `mapstructure:"CLIENT_SECRET"`
 	JWTSecret          string `mapstructure:"JWT_SECRET"`
 	GoogleRedirectURL  string `mapstructure:"REDIRECT_URL"`
+	AdminEmails        string `mapstructure:"ADMIN_EMAILS"`
+
+	// Days deleted products and categories stay in the trash, 30 when unset
+	TrashRetentionDays int `mapstructure:"TRASH_RETENTION_DAYS"`
+
+	// Blob storage for uploads, "local" (the default) or "s3"
+	StorageDriver    string `mapstructure:"STORAGE_DRIVER"`
+	StorageLocalDir  string `mapstructure:"STORAGE_LOCAL_DIR"`
+	StoragePublicURL string `mapstructure:"STORAGE_PUBLIC_URL"`
+	S3Endpoint       string `mapstructure:"S3_ENDPOINT"`
+	S3Region         string `mapstructure:"S3_REGION"`
+	S3Bucket         string `mapstructure:"S3_BUCKET"`
+	S3AccessKey      string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey      string `mapstructure:"S3_SECRET_KEY"`
+	S3PathStyle      bool   `mapstructure:"S3_PATH_STYLE"`
+	// Largest product image accepted in bytes, 5 MiB when unset
+	ImageMaxBytes int64 `mapstructure:"IMAGE_MAX_BYTES"`
+
+	// Whether catalog prices include tax, "inclusive" (the default) or "exclusive"
+	TaxMode string `mapstructure:"TAX_MODE"`
+
+	// Payment provider, "mpesa" or, in development only, "fake" (settles in process)
+	PaymentGateway string `mapstructure:"PAYMENT_GATEWAY"`
+	// Public address of POST /api/v1/payments/callback and the secret added to it
+	PaymentCallbackURL   string `mapstructure:"PAYMENT_CALLBACK_URL"`
+	PaymentCallbackToken string `mapstructure:"PAYMENT_CALLBACK_TOKEN"`
+	// Seconds a customer has to answer a payment request, 180 when unset
+	PaymentTimeoutSeconds int `mapstructure:"PAYMENT_TIMEOUT_SECONDS"`
+	// M-Pesa Daraja, the sandbox unless MPESA_BASE_URL is set
+	MpesaBaseURL         string `mapstructure:"MPESA_BASE_URL"`
+	MpesaConsumerKey     string `mapstructure:"MPESA_CONSUMER_KEY"`
+	MpesaConsumerSecret  string `mapstructure:"MPESA_CONSUMER_SECRET"`
+	MpesaShortCode       string `mapstructure:"MPESA_SHORT_CODE"`
+	MpesaPasskey         string `mapstructure:"MPESA_PASSKEY"`
+	MpesaTransactionType string `mapstructure:"MPESA_TRANSACTION_TYPE"`
+	// B2C credentials M-Pesa refunds are paid with
+	MpesaInitiatorName      string `mapstructure:"MPESA_INITIATOR_NAME"`
+	MpesaSecurityCredential string `mapstructure:"MPESA_SECURITY_CREDENTIAL"`
+	MpesaB2CShortCode       string `mapstructure:"MPESA_B2C_SHORT_CODE"`
 
 	// AT API
 	ATAPIKey             string `mapstructure:"ATAPI_KEY"`
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sean-miningah/sil-backend-assessment/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+// moneyColumns are the columns that held prices as floats before prices were
+// stored as domain.Money, mapped to the prefix of the columns that replace them.
+var moneyColumns = []struct{ table, column string }{
+	{"products", "price"},
+	{"product_variants", "price"},
+	{"product_prices", "price"},
+	{"order_items", "price"},
+	{"orders", "total_price"},
+	{"orders", "total_amount"},
+}
+
+// migrateMoney converts each float column, where it is still there, to minor
+// units of the default currency. ROUND on numeric rounds halves away from
+// zero, as domain.Money does. Every statement is safe to run on each start.
+func migrateMoney(db *gorm.DB) error {
+	exponent, _ := domain.CurrencyExponent(domain.DefaultCurrency)
+	factor := int64(math.Pow10(exponent))
+
+	for _, c := range moneyColumns {
+		err := db.Exec(fmt.Sprintf(`DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = '%[1]s' AND column_name = '%[2]s'
+			) THEN
+				UPDATE %[1]s SET %[2]s_amount = ROUND(%[2]s::numeric * %[3]d), %[2]s_currency = '%[4]s'
+				WHERE %[2]s IS NOT NULL;
+				ALTER TABLE %[1]s DROP COLUMN %[2]s;
+			END IF;
+		END
+		$$`, c.table, c.column, factor, domain.DefaultCurrency)).Error
+		if err != nil {
+			return fmt.Errorf("failed to convert %s.%s to money: %w", c.table, c.column, err)
+		}
+	}
+
+	// The price index went with the float column
+	err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_price_amount ON products (price_amount)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to index product prices: %w", err)
+	}
+	return nil
+}
@@ -60,9 +60,38 @@ func autoMigrate(db *gorm.DB) error {
 	models := []interface{}{
 		&domain.Category{},
 		&domain.Product{},
+		&domain.ProductOption{},
+		&domain.ProductOptionValue{},
+		&domain.ProductVariant{},
+		&domain.ProductImage{},
 		&domain.Customer{},
 		&domain.OrderItem{},
 		&domain.Order{},
+		&domain.APIKey{},
+		&domain.PhoneVerification{},
+		&domain.Address{},
+		&domain.NotificationLog{},
+		&domain.ErasureRequest{},
+		&domain.AuditEntry{},
+		&domain.CategoryAttribute{},
+		&domain.ProductPrice{},
+		&domain.ExchangeRate{},
+		&domain.Cart{},
+		&domain.CartItem{},
+		&domain.Discount{},
+		&domain.OrderAdjustment{},
+		&domain.TaxClass{},
+		&domain.TaxRate{},
+		&domain.ShippingMethod{},
+		&domain.PaymentIntent{},
+		&domain.OrderRefund{},
+		&domain.OrderRefundLine{},
+		&domain.OrderAmendment{},
+		&domain.OrderAmendmentLine{},
+		&domain.OrderReturn{},
+		&domain.OrderReturnLine{},
+		&domain.ReturnPhoto{},
+		&domain.StoreCredit{},
 	}
 
 	// Run auto-migration for each model
@@ -72,7 +101,72 @@ func autoMigrate(db *gorm.DB) error {
 		}
 	}
 
-	return nil
+	// gorm can't declare an operator class, jsonb_path_ops keeps the index
+	// small and serves the @> and @@ operators attribute filters use
+	err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_attributes ON products USING gin (attributes jsonb_path_ops)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to index product attributes: %w", err)
+	}
+
+	if err := migrateMoney(db); err != nil {
+		return err
+	}
+
+	// Products from before price history start it with their current price
+	err = db.Exec(`INSERT INTO product_prices (product_id, price_amount, price_currency, effective_from, created_at)
+		SELECT id, price_amount, price_currency, created_at, now() FROM products
+		WHERE NOT EXISTS (SELECT 1 FROM product_prices WHERE product_prices.product_id = products.id)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to start price history: %w", err)
+	}
+
+	// Orders from before exchange rates were charged in their prices' currency
+	err = db.Exec(`UPDATE orders SET currency = total_price_currency, exchange_rate = 1
+		WHERE (currency IS NULL OR currency = '') AND total_price_currency <> ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to set order currencies: %w", err)
+	}
+
+	// Orders from before discounts were not discounted
+	err = db.Exec(`UPDATE orders SET subtotal_amount = total_price_amount, subtotal_currency = total_price_currency,
+			discount_total_amount = 0, discount_total_currency = total_price_currency
+		WHERE (subtotal_currency IS NULL OR subtotal_currency = '') AND total_price_currency <> ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to set order subtotals: %w", err)
+	}
+	err = db.Exec(`UPDATE order_items SET discount_amount = 0, discount_currency = price_currency
+		WHERE (discount_currency IS NULL OR discount_currency = '') AND price_currency <> ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to set order item discounts: %w", err)
+	}
+
+	// Orders from before tax were not taxed
+	err = db.Exec(`UPDATE orders SET tax_total_amount = 0, tax_total_currency = total_price_currency
+		WHERE (tax_total_currency IS NULL OR tax_total_currency = '') AND total_price_currency <> ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to set order tax totals: %w", err)
+	}
+	err = db.Exec(`UPDATE order_items SET tax_amount = 0, tax_currency = price_currency, tax_rate = 0
+		WHERE (tax_currency IS NULL OR tax_currency = '') AND price_currency <> ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to set order item tax: %w", err)
+	}
+
+	// Orders from before shipping methods weren't charged for shipping
+	err = db.Exec(`UPDATE orders SET shipping_cost_amount = 0, shipping_cost_currency = total_price_currency
+		WHERE (shipping_cost_currency IS NULL OR shipping_cost_currency = '') AND total_price_currency <> ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to set order shipping costs: %w", err)
+	}
+
+	// Orders from before refunds haven't had anything refunded
+	err = db.Exec(`UPDATE orders SET refunded_total_amount = 0, refunded_total_currency = total_price_currency
+		WHERE (refunded_total_currency IS NULL OR refunded_total_currency = '') AND total_price_currency <> ''`).Error
+	if err != nil {
+		return fmt.Errorf("failed to set order refunded totals: %w", err)
+	}
+
+	return migrateSearch(db)
 }
 
 // Utility function to check database health
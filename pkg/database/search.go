@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// searchMigrations set up product full-text search. Category names live in
+// another table, so a trigger copies each product's category path into
+// search_categories and the generated search_vector is built from that and
+// the product's name and description. Every statement is safe to run on each start.
+var searchMigrations = []string{
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_categories text NOT NULL DEFAULT ''`,
+
+	`CREATE OR REPLACE FUNCTION category_path_names(bigint) RETURNS text AS $$
+		WITH RECURSIVE path AS (
+			SELECT id, name, parent_category_id, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.parent_category_id, path.depth + 1
+			FROM categories c JOIN path ON c.id = path.parent_category_id
+			WHERE path.depth < 32
+		)
+		SELECT coalesce(string_agg(name, ' ' ORDER BY depth DESC), '') FROM path
+	$$ LANGUAGE sql STABLE`,
+
+	`CREATE OR REPLACE FUNCTION products_set_search_categories() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_categories := category_path_names(NEW.category_id);
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+
+	`DROP TRIGGER IF EXISTS products_search_categories ON products`,
+	`CREATE TRIGGER products_search_categories
+		BEFORE INSERT OR UPDATE OF category_id ON products
+		FOR EACH ROW EXECUTE FUNCTION products_set_search_categories()`,
+
+	// Renaming or moving a category changes the path of every product below it
+	`CREATE OR REPLACE FUNCTION categories_refresh_product_search() RETURNS trigger AS $$
+	BEGIN
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = NEW.id
+			UNION
+			SELECT c.id FROM categories c JOIN subtree ON c.parent_category_id = subtree.id
+		)
+		UPDATE products SET search_categories = category_path_names(category_id)
+		WHERE category_id IN (SELECT id FROM subtree);
+		RETURN NULL;
+	END
+	$$ LANGUAGE plpgsql`,
+
+	`DROP TRIGGER IF EXISTS categories_product_search ON categories`,
+	`CREATE TRIGGER categories_product_search
+		AFTER UPDATE OF name, parent_category_id ON categories
+		FOR EACH ROW EXECUTE FUNCTION categories_refresh_product_search()`,
+
+	// Backfill rows written before the trigger existed
+	`UPDATE products SET search_categories = category_path_names(category_id) WHERE search_categories = ''`,
+
+	// A generated column's expression cannot be altered, so a vector built
+	// before descriptions were indexed is dropped and added again below
+	`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'products' AND column_name = 'search_vector'
+				AND generation_expression NOT LIKE '%description%'
+		) THEN
+			ALTER TABLE products DROP COLUMN search_vector;
+		END IF;
+	END
+	$$`,
+
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+		setweight(to_tsvector('english', coalesce(search_categories, '')), 'C')
+	) STORED`,
+
+	`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING gin (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops)`,
+}
+
+func migrateSearch(db *gorm.DB) error {
+	for _, statement := range searchMigrations {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate product search: %w", err)
+		}
+	}
+	return nil
+}